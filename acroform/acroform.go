@@ -0,0 +1,426 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package acroform
+
+import (
+	"errors"
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/AletheiaWareLLC/pdfgo/font"
+	"github.com/AletheiaWareLLC/pdfgo/graphics"
+)
+
+// Field flags
+const (
+	// Flags of all fields
+	FLAG_READ_ONLY = 1 << 0
+	FLAG_REQUIRED  = 1 << 1
+	FLAG_NO_EXPORT = 1 << 2
+	// Flags of text fields
+	FLAG_MULTILINE          = 1 << 12
+	FLAG_PASSWORD           = 1 << 13
+	FLAG_FILE_SELECT        = 1 << 20
+	FLAG_DO_NOT_SPELL_CHECK = 1 << 22
+	FLAG_DO_NOT_SCROLL      = 1 << 23
+	FLAG_COMB               = 1 << 24
+	// Flags of button fields
+	FLAG_NO_TOGGLE_TO_OFF = 1 << 14
+	FLAG_RADIO            = 1 << 15
+	FLAG_PUSHBUTTON       = 1 << 16
+	FLAG_RADIOS_IN_UNISON = 1 << 25
+	// Flags of choice fields
+	FLAG_COMBO                = 1 << 17
+	FLAG_EDIT                 = 1 << 18
+	FLAG_SORT                 = 1 << 19
+	FLAG_MULTI_SELECT         = 1 << 21
+	FLAG_COMMIT_ON_SEL_CHANGE = 1 << 26
+)
+
+// ANNOTATION_PRINT is the annotation flag which prints the widgets of fields
+const ANNOTATION_PRINT = 1 << 2
+
+// DEFAULT_FONT is the name of the font added to every form, which fields use unless another is given
+const DEFAULT_FONT = "Helv"
+
+// Form builds the interactive form of a document.
+type Form struct {
+	p        *pdfgo.PDF
+	AcroForm *pdfgo.DictionaryObject
+	Fields   *pdfgo.ArrayObject
+	// Fonts holds the fonts of the default resources, which are used by the default appearances of fields
+	Fonts *pdfgo.DictionaryObject
+	// fonts holds the fonts added with AddFont, by name, which encode and measure the text they show
+	fonts map[string]font.Font
+}
+
+// NewForm returns the interactive form of the given document, adding one to the catalog if there isn't one.
+func NewForm(p *pdfgo.PDF) *Form {
+	f := &Form{
+		p: p,
+	}
+	var ok bool
+	if f.AcroForm, ok = pdfgo.Resolve(p.Catalog.GetEntry("AcroForm")).(*pdfgo.DictionaryObject); !ok {
+		f.AcroForm = p.NewDictionaryObject()
+		p.Catalog.SetNameObjectEntry("AcroForm", pdfgo.NewObjectReference(f.AcroForm))
+	}
+	if f.Fields, ok = pdfgo.Resolve(f.AcroForm.GetEntry("Fields")).(*pdfgo.ArrayObject); !ok {
+		f.Fields = &pdfgo.ArrayObject{}
+		f.AcroForm.SetNameObjectEntry("Fields", f.Fields)
+	}
+	resources, ok := pdfgo.Resolve(f.AcroForm.GetEntry("DR")).(*pdfgo.DictionaryObject)
+	if !ok {
+		resources = pdfgo.NewDictionary()
+		f.AcroForm.SetNameObjectEntry("DR", resources)
+	}
+	if f.Fonts, ok = pdfgo.Resolve(resources.GetEntry("Font")).(*pdfgo.DictionaryObject); !ok {
+		f.Fonts = pdfgo.NewDictionary()
+		resources.SetNameObjectEntry("Font", f.Fonts)
+	}
+	if !f.Fonts.HasEntry(DEFAULT_FONT) {
+		helvetica := p.NewDictionaryObject()
+		helvetica.AddNameNameEntry("Type", "Font")
+		helvetica.AddNameNameEntry("Subtype", "Type1")
+		helvetica.AddNameNameEntry("BaseFont", "Helvetica")
+		helvetica.AddNameNameEntry("Encoding", "WinAnsiEncoding")
+		f.Fonts.AddNameObjectEntry(DEFAULT_FONT, pdfgo.NewObjectReference(helvetica))
+	}
+	if !f.AcroForm.HasEntry("DA") {
+		f.AcroForm.AddNameObjectEntry("DA", &pdfgo.StringObject{
+			String: DefaultAppearance(DEFAULT_FONT, 0, nil),
+		})
+	}
+	return f
+}
+
+// AddFont adds the given font to the default resources of the form, so fields can show text in it.
+// Appearances show text in the font with its own encoding, if it has one, and measure text with its own metrics.
+func (f *Form) AddFont(name string, typeface font.Font) {
+	if f.fonts == nil {
+		f.fonts = make(map[string]font.Font)
+	}
+	f.fonts[name] = typeface
+	f.Fonts.SetNameObjectEntry(name, typeface.GetReference())
+}
+
+// Widget is the annotation which shows a field on a page.
+type Widget struct {
+	Page *pdfgo.DictionaryObject
+	graphics.Rectangle
+	BorderColour     []float64
+	BackgroundColour []float64
+	BorderWidth      float64
+}
+
+// TextField is a field holding text, on one line or several.
+// Multi-line, password and comb fields are made with FLAG_MULTILINE, FLAG_PASSWORD and FLAG_COMB.
+type TextField struct {
+	Widget
+	Name       string
+	Value      string
+	FontID     string
+	FontSize   float64
+	FontColour []float64
+	Align      graphics.Alignment
+	// MaxLength limits the number of characters, and is required by comb fields
+	MaxLength int
+	Flags     int
+}
+
+// AddTextField adds the given text field to the form.
+func (f *Form) AddTextField(t *TextField) (*pdfgo.DictionaryObject, error) {
+	if t.Flags&FLAG_COMB != 0 && t.MaxLength <= 0 {
+		return nil, errors.New("Comb field requires a maximum length")
+	}
+	d, err := f.newField(&t.Widget, "Tx", t.Name, t.Flags)
+	if err != nil {
+		return nil, err
+	}
+	d.AddNameObjectEntry("DA", &pdfgo.StringObject{
+		String: DefaultAppearance(t.FontID, t.FontSize, t.FontColour),
+	})
+	d.AddNameObjectEntry("Q", &pdfgo.NumberObject{
+		Number: float64(quadding(t.Align)),
+	})
+	if t.MaxLength > 0 {
+		d.AddNameObjectEntry("MaxLen", &pdfgo.NumberObject{
+			Number: float64(t.MaxLength),
+		})
+	}
+	if t.Value != "" {
+		d.AddNameObjectEntry("V", pdfgo.NewTextString(t.Value))
+	}
+	return d, f.UpdateAppearance(d)
+}
+
+// CheckBox is a field which is either on or off.
+type CheckBox struct {
+	Widget
+	Name string
+	// ExportValue names the on state, and defaults to Yes
+	ExportValue string
+	Checked     bool
+	// Colour of the check mark, defaulting to black
+	Colour []float64
+	Flags  int
+}
+
+// AddCheckBox adds the given check box to the form.
+func (f *Form) AddCheckBox(c *CheckBox) (*pdfgo.DictionaryObject, error) {
+	d, err := f.newField(&c.Widget, "Btn", c.Name, c.Flags&^(FLAG_RADIO|FLAG_PUSHBUTTON))
+	if err != nil {
+		return nil, err
+	}
+	on := c.ExportValue
+	if on == "" {
+		on = "Yes"
+	}
+	value := "Off"
+	if c.Checked {
+		value = on
+	}
+	d.AddNameObjectEntry("V", &pdfgo.NameObject{
+		Name: value,
+	})
+	d.AddNameObjectEntry("DA", &pdfgo.StringObject{
+		String: DefaultAppearance(DEFAULT_FONT, 0, c.Colour),
+	})
+	mk := characteristics(d)
+	mk.SetNameObjectEntry("CA", &pdfgo.StringObject{
+		// The check mark of ZapfDingbats, which viewers use when they generate appearances
+		String: "4",
+	})
+	return d, f.buttonAppearance(d, c.Flags, on)
+}
+
+// RadioGroup is a set of buttons of which at most one is on.
+type RadioGroup struct {
+	Name string
+	// Value is the export value of the button which is on, if any
+	Value   string
+	Buttons []*RadioButton
+	// Colour of the selection mark, defaulting to black
+	Colour []float64
+	Flags  int
+}
+
+// RadioButton is one of the buttons of a group, which names its on state with an export value.
+type RadioButton struct {
+	Widget
+	ExportValue string
+}
+
+// AddRadioGroup adds the given radio group to the form, returning the parent field of the buttons.
+func (f *Form) AddRadioGroup(r *RadioGroup) (*pdfgo.DictionaryObject, error) {
+	flags := r.Flags | FLAG_RADIO | FLAG_NO_TOGGLE_TO_OFF
+	d := f.p.NewDictionaryObject()
+	d.AddNameNameEntry("FT", "Btn")
+	d.AddNameObjectEntry("T", pdfgo.NewTextString(r.Name))
+	d.AddNameObjectEntry("Ff", &pdfgo.NumberObject{
+		Number: float64(flags),
+	})
+	value := r.Value
+	if value == "" {
+		value = "Off"
+	}
+	d.AddNameObjectEntry("V", &pdfgo.NameObject{
+		Name: value,
+	})
+	d.AddNameObjectEntry("DA", &pdfgo.StringObject{
+		String: DefaultAppearance(DEFAULT_FONT, 0, r.Colour),
+	})
+	kids := &pdfgo.ArrayObject{}
+	d.AddNameObjectEntry("Kids", kids)
+	for _, b := range r.Buttons {
+		if b.ExportValue == "" || b.ExportValue == "Off" {
+			return nil, errors.New("Radio button requires an export value")
+		}
+		w, err := f.newWidget(&b.Widget)
+		if err != nil {
+			return nil, err
+		}
+		w.AddNameObjectEntry("Parent", pdfgo.NewObjectReference(d))
+		mk := characteristics(w)
+		mk.SetNameObjectEntry("CA", &pdfgo.StringObject{
+			// The bullet of ZapfDingbats, which viewers use when they generate appearances
+			String: "l",
+		})
+		kids.Array = append(kids.Array, pdfgo.NewObjectReference(w))
+		if err := f.buttonAppearance(w, flags, b.ExportValue); err != nil {
+			return nil, err
+		}
+	}
+	f.Fields.Array = append(f.Fields.Array, pdfgo.NewObjectReference(d))
+	return d, nil
+}
+
+// Option is an item of a choice field, which shows the label and exports the value.
+// The label defaults to the value.
+type Option struct {
+	Value, Label string
+}
+
+// ChoiceField is a list box, or a combo box with FLAG_COMBO.
+type ChoiceField struct {
+	Widget
+	Name       string
+	Options    []*Option
+	Values     []string
+	FontID     string
+	FontSize   float64
+	FontColour []float64
+	Flags      int
+}
+
+// AddChoiceField adds the given choice field to the form.
+func (f *Form) AddChoiceField(c *ChoiceField) (*pdfgo.DictionaryObject, error) {
+	if len(c.Values) > 1 && c.Flags&FLAG_MULTI_SELECT == 0 {
+		return nil, errors.New("Choice field has multiple values but doesn't allow multiple selection")
+	}
+	d, err := f.newField(&c.Widget, "Ch", c.Name, c.Flags)
+	if err != nil {
+		return nil, err
+	}
+	d.AddNameObjectEntry("DA", &pdfgo.StringObject{
+		String: DefaultAppearance(c.FontID, c.FontSize, c.FontColour),
+	})
+	options := &pdfgo.ArrayObject{}
+	for _, o := range c.Options {
+		if o.Label == "" || o.Label == o.Value {
+			options.Array = append(options.Array, pdfgo.NewTextString(o.Value))
+		} else {
+			options.Array = append(options.Array, &pdfgo.ArrayObject{
+				Array: []pdfgo.Object{
+					pdfgo.NewTextString(o.Value),
+					pdfgo.NewTextString(o.Label),
+				},
+			})
+		}
+	}
+	d.AddNameObjectEntry("Opt", options)
+	switch len(c.Values) {
+	case 0:
+	case 1:
+		d.AddNameObjectEntry("V", pdfgo.NewTextString(c.Values[0]))
+	default:
+		values := &pdfgo.ArrayObject{}
+		for _, v := range c.Values {
+			values.Array = append(values.Array, pdfgo.NewTextString(v))
+		}
+		d.AddNameObjectEntry("V", values)
+	}
+	return d, f.UpdateAppearance(d)
+}
+
+// PushButton is a button which performs an action when clicked.
+type PushButton struct {
+	Widget
+	Name       string
+	Caption    string
+	FontID     string
+	FontSize   float64
+	FontColour []float64
+	// Action is performed when the button is released, if not nil
+	Action pdfgo.Action
+	Flags  int
+}
+
+// AddPushButton adds the given push button to the form.
+func (f *Form) AddPushButton(b *PushButton) (*pdfgo.DictionaryObject, error) {
+	d, err := f.newField(&b.Widget, "Btn", b.Name, (b.Flags|FLAG_PUSHBUTTON)&^FLAG_RADIO)
+	if err != nil {
+		return nil, err
+	}
+	d.AddNameObjectEntry("DA", &pdfgo.StringObject{
+		String: DefaultAppearance(b.FontID, b.FontSize, b.FontColour),
+	})
+	characteristics(d).SetNameObjectEntry("CA", pdfgo.NewTextString(b.Caption))
+	if b.Action != nil {
+		action, err := pdfgo.NewActionDictionary(b.Action)
+		if err != nil {
+			return nil, err
+		}
+		d.AddNameObjectEntry("A", action)
+	}
+	return d, f.UpdateAppearance(d)
+}
+
+// newField adds a field with a single widget, whose dictionaries are merged, to the form.
+func (f *Form) newField(w *Widget, fieldType, name string, flags int) (*pdfgo.DictionaryObject, error) {
+	d, err := f.newWidget(w)
+	if err != nil {
+		return nil, err
+	}
+	d.AddNameNameEntry("FT", fieldType)
+	d.AddNameObjectEntry("T", pdfgo.NewTextString(name))
+	if flags != 0 {
+		d.AddNameObjectEntry("Ff", &pdfgo.NumberObject{
+			Number: float64(flags),
+		})
+	}
+	f.Fields.Array = append(f.Fields.Array, pdfgo.NewObjectReference(d))
+	return d, nil
+}
+
+// newWidget adds a widget annotation to the page of the given widget.
+func (f *Form) newWidget(w *Widget) (*pdfgo.DictionaryObject, error) {
+	if w.Page == nil {
+		return nil, errors.New("Widget requires a page")
+	}
+	d := f.p.NewDictionaryObject()
+	d.AddNameNameEntry("Type", "Annot")
+	d.AddNameNameEntry("Subtype", "Widget")
+	d.AddNameObjectEntry("Rect", pdfgo.NewRectangleArray(w.Left, w.Bottom, w.Right, w.Top))
+	d.AddNameObjectEntry("F", &pdfgo.NumberObject{
+		Number: ANNOTATION_PRINT,
+	})
+	mk := pdfgo.NewDictionary()
+	if w.BorderColour != nil {
+		mk.AddNameObjectEntry("BC", pdfgo.NewNumberArray(w.BorderColour))
+	}
+	if w.BackgroundColour != nil {
+		mk.AddNameObjectEntry("BG", pdfgo.NewNumberArray(w.BackgroundColour))
+	}
+	d.AddNameObjectEntry("MK", mk)
+	bs := pdfgo.NewDictionary()
+	bs.AddNameNameEntry("Type", "Border")
+	bs.AddNameObjectEntry("W", &pdfgo.NumberObject{
+		Number: w.BorderWidth,
+	})
+	bs.AddNameNameEntry("S", "S")
+	d.AddNameObjectEntry("BS", bs)
+	f.p.AddPageAnnotation(w.Page, d)
+	return d, nil
+}
+
+// characteristics returns the appearance characteristics dictionary of the given widget, adding one if absent.
+func characteristics(widget *pdfgo.DictionaryObject) *pdfgo.DictionaryObject {
+	mk, ok := pdfgo.Resolve(widget.GetEntry("MK")).(*pdfgo.DictionaryObject)
+	if !ok {
+		mk = pdfgo.NewDictionary()
+		widget.SetNameObjectEntry("MK", mk)
+	}
+	return mk
+}
+
+func quadding(a graphics.Alignment) int {
+	switch a {
+	case graphics.Center, graphics.JustifiedCenter:
+		return 1
+	case graphics.Right, graphics.JustifiedRight:
+		return 2
+	}
+	return 0
+}
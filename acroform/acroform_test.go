@@ -0,0 +1,380 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package acroform_test
+
+import (
+	"bytes"
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/AletheiaWareLLC/pdfgo/acroform"
+	"github.com/AletheiaWareLLC/pdfgo/content"
+	"github.com/AletheiaWareLLC/pdfgo/font"
+	"github.com/AletheiaWareLLC/pdfgo/graphics"
+	"github.com/AletheiaWareLLC/pdfgo/render"
+	"github.com/golang/freetype/truetype"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/image/font/gofont/goregular"
+	"image/color"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newForm(t *testing.T) (*pdfgo.PDF, *pdfgo.DictionaryObject, *acroform.Form) {
+	t.Helper()
+	p := pdfgo.NewPDF()
+	p.AddPage(200, 200, nil, nil)
+	return p, p.GetPages()[0], acroform.NewForm(p)
+}
+
+func widget(page *pdfgo.DictionaryObject, left, bottom, right, top float64) acroform.Widget {
+	return acroform.Widget{
+		Page: page,
+		Rectangle: graphics.Rectangle{
+			Left:   left,
+			Bottom: bottom,
+			Right:  right,
+			Top:    top,
+		},
+		BorderColour: []float64{0},
+		BorderWidth:  1,
+	}
+}
+
+// normal returns the operations of the normal appearance of the given widget, in the given state if it has several.
+func normal(t *testing.T, widget *pdfgo.DictionaryObject, state string) []*content.Operation {
+	t.Helper()
+	ap := pdfgo.Resolve(widget.GetEntry("AP")).(*pdfgo.DictionaryObject)
+	n := pdfgo.Resolve(ap.GetEntry("N"))
+	if d, ok := n.(*pdfgo.DictionaryObject); ok {
+		n = pdfgo.Resolve(d.GetEntry(state))
+	}
+	operations, err := content.ParseStream(n.(*pdfgo.StreamObject))
+	assert.Nil(t, err)
+	return operations
+}
+
+func shown(operations []*content.Operation) []string {
+	var texts []string
+	for _, o := range operations {
+		if o.Operator == "Tj" {
+			texts = append(texts, string(o.Operands[0].(*pdfgo.StringObject).Bytes()))
+		}
+	}
+	return texts
+}
+
+func TestNewForm(t *testing.T) {
+	p, _, form := newForm(t)
+	assert.Equal(t, form.AcroForm, pdfgo.Resolve(p.Catalog.GetEntry("AcroForm")))
+	assert.True(t, form.Fonts.HasEntry(acroform.DEFAULT_FONT))
+	assert.Equal(t, "/Helv 0 Tf 0 g", form.AcroForm.GetEntry("DA").(*pdfgo.StringObject).String)
+	// The existing form is returned
+	assert.Equal(t, form.AcroForm, acroform.NewForm(p).AcroForm)
+}
+
+func TestAddTextField(t *testing.T) {
+	p, page, form := newForm(t)
+	field, err := form.AddTextField(&acroform.TextField{
+		Widget:   widget(page, 10, 10, 110, 30),
+		Name:     "name",
+		Value:    "Café (Ltd)",
+		FontSize: 12,
+		Align:    graphics.Right,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "Tx", field.GetEntry("FT").(*pdfgo.NameObject).Name)
+	assert.Equal(t, "Café (Ltd)", acroform.TextValue(field.GetEntry("V")))
+	assert.Equal(t, "/Helv 12 Tf 0 g", field.GetEntry("DA").(*pdfgo.StringObject).String)
+	assert.Equal(t, 2., field.GetEntry("Q").(*pdfgo.NumberObject).Number)
+	annots := pdfgo.Resolve(page.GetEntry("Annots")).(*pdfgo.ArrayObject)
+	assert.Equal(t, field, pdfgo.Resolve(annots.Array[0]))
+	assert.Equal(t, field, pdfgo.Resolve(form.Fields.Array[0]))
+
+	operations := normal(t, field, "")
+	// Text is encoded with WinAnsiEncoding
+	assert.Equal(t, []string{"Caf\xe9 (Ltd)"}, shown(operations))
+	var marked bool
+	for _, o := range operations {
+		if o.Operator == "BMC" && o.Name(0) == "Tx" {
+			marked = true
+		}
+	}
+	assert.True(t, marked)
+
+	var buffer bytes.Buffer
+	assert.Nil(t, p.Write(&buffer))
+	parsed, err := pdfgo.ReadPDF(buffer.Bytes())
+	assert.Nil(t, err)
+	acroForm := pdfgo.Resolve(parsed.Catalog.GetEntry("AcroForm")).(*pdfgo.DictionaryObject)
+	fields := pdfgo.Resolve(acroForm.GetEntry("Fields")).(*pdfgo.ArrayObject)
+	assert.Equal(t, 1, len(fields.Array))
+}
+
+func TestAddTextField_comb(t *testing.T) {
+	_, page, form := newForm(t)
+	_, err := form.AddTextField(&acroform.TextField{
+		Widget: widget(page, 10, 10, 110, 30),
+		Name:   "pin",
+		Flags:  acroform.FLAG_COMB,
+	})
+	assert.NotNil(t, err)
+	field, err := form.AddTextField(&acroform.TextField{
+		Widget:    widget(page, 10, 10, 110, 30),
+		Name:      "pin",
+		Value:     "123456",
+		MaxLength: 4,
+		Flags:     acroform.FLAG_COMB,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"1", "2", "3", "4"}, shown(normal(t, field, "")))
+}
+
+func TestAddTextField_multiline(t *testing.T) {
+	_, page, form := newForm(t)
+	field, err := form.AddTextField(&acroform.TextField{
+		Widget:   widget(page, 10, 10, 110, 110),
+		Name:     "address",
+		Value:    "1 Long Street Name\nSome Town",
+		FontSize: 12,
+		Flags:    acroform.FLAG_MULTILINE,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"1 Long Street", "Name", "Some Town"}, shown(normal(t, field, "")))
+}
+
+func TestAddTextField_password(t *testing.T) {
+	_, page, form := newForm(t)
+	field, err := form.AddTextField(&acroform.TextField{
+		Widget: widget(page, 10, 10, 110, 30),
+		Name:   "password",
+		Value:  "secret",
+		Flags:  acroform.FLAG_PASSWORD,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"******"}, shown(normal(t, field, "")))
+}
+
+func TestAddCheckBox(t *testing.T) {
+	p, page, form := newForm(t)
+	field, err := form.AddCheckBox(&acroform.CheckBox{
+		Widget:  widget(page, 10, 10, 30, 30),
+		Name:    "agree",
+		Checked: true,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "Yes", field.GetEntry("V").(*pdfgo.NameObject).Name)
+	assert.Equal(t, "Yes", field.GetEntry("AS").(*pdfgo.NameObject).Name)
+	assert.Equal(t, "Yes", acroform.OnState(field))
+
+	// Draw the on appearance on the page to check the mark is painted
+	ap := pdfgo.Resolve(field.GetEntry("AP")).(*pdfgo.DictionaryObject)
+	states := pdfgo.Resolve(ap.GetEntry("N")).(*pdfgo.DictionaryObject)
+	xs := p.NewDictionaryObject()
+	xs.AddNameObjectEntry("On", states.GetEntry("Yes"))
+	xs.AddNameObjectEntry("Off", states.GetEntry("Off"))
+	resources := p.NewDictionaryObject()
+	resources.AddNameObjectEntry("XObject", xs)
+	contents := p.NewStreamObject()
+	contents.Data = []byte("q 1 0 0 1 10 10 cm /On Do Q q 1 0 0 1 50 10 cm /Off Do Q")
+	page.SetNameObjectEntry("Resources", resources)
+	page.SetNameObjectEntry("Contents", pdfgo.NewObjectReference(contents))
+	img, err := render.Render(p, 0, 72)
+	assert.Nil(t, err)
+	// The stroke of the check mark passes through the middle of the box
+	assert.True(t, img.RGBAAt(10+11, 200-10-11).R < 64)
+	assert.Equal(t, color.RGBA{255, 255, 255, 255}, img.RGBAAt(50+11, 200-10-11))
+}
+
+func TestAddRadioGroup(t *testing.T) {
+	_, page, form := newForm(t)
+	field, err := form.AddRadioGroup(&acroform.RadioGroup{
+		Name:  "size",
+		Value: "Large",
+		Buttons: []*acroform.RadioButton{
+			{Widget: widget(page, 10, 10, 30, 30), ExportValue: "Small"},
+			{Widget: widget(page, 40, 10, 60, 30), ExportValue: "Large"},
+		},
+	})
+	assert.Nil(t, err)
+	flags := int(field.GetEntry("Ff").(*pdfgo.NumberObject).Number)
+	assert.NotZero(t, flags&acroform.FLAG_RADIO)
+	kids := field.GetEntry("Kids").(*pdfgo.ArrayObject)
+	assert.Equal(t, 2, len(kids.Array))
+	small := pdfgo.Resolve(kids.Array[0]).(*pdfgo.DictionaryObject)
+	large := pdfgo.Resolve(kids.Array[1]).(*pdfgo.DictionaryObject)
+	assert.Equal(t, "Off", small.GetEntry("AS").(*pdfgo.NameObject).Name)
+	assert.Equal(t, "Large", large.GetEntry("AS").(*pdfgo.NameObject).Name)
+	assert.Equal(t, "Small", acroform.OnState(small))
+	assert.Equal(t, 2, len(pdfgo.Resolve(page.GetEntry("Annots")).(*pdfgo.ArrayObject).Array))
+	assert.Equal(t, 1, len(form.Fields.Array))
+
+	_, err = form.AddRadioGroup(&acroform.RadioGroup{
+		Name: "invalid",
+		Buttons: []*acroform.RadioButton{
+			{Widget: widget(page, 10, 10, 30, 30)},
+		},
+	})
+	assert.NotNil(t, err)
+}
+
+func TestAddChoiceField(t *testing.T) {
+	_, page, form := newForm(t)
+	options := []*acroform.Option{
+		{Value: "R", Label: "Red"},
+		{Value: "G", Label: "Green"},
+		{Value: "B"},
+	}
+	combo, err := form.AddChoiceField(&acroform.ChoiceField{
+		Widget:  widget(page, 10, 10, 110, 30),
+		Name:    "colour",
+		Options: options,
+		Values:  []string{"G"},
+		Flags:   acroform.FLAG_COMBO,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Green"}, shown(normal(t, combo, "")))
+	assert.Equal(t, []string{"G"}, acroform.ChoiceValues(combo))
+	assert.Equal(t, "B", acroform.Options(combo)[2].Label)
+
+	list, err := form.AddChoiceField(&acroform.ChoiceField{
+		Widget:   widget(page, 10, 40, 110, 100),
+		Name:     "colours",
+		Options:  options,
+		Values:   []string{"R", "B"},
+		FontSize: 10,
+		Flags:    acroform.FLAG_MULTI_SELECT,
+	})
+	assert.Nil(t, err)
+	operations := normal(t, list, "")
+	assert.Equal(t, []string{"Red", "Green", "B"}, shown(operations))
+	var highlights int
+	for _, o := range operations {
+		if o.Operator == "rg" && o.Number(0) == 0.6 {
+			highlights++
+		}
+	}
+	assert.Equal(t, 2, highlights)
+
+	_, err = form.AddChoiceField(&acroform.ChoiceField{
+		Widget:  widget(page, 10, 40, 110, 100),
+		Name:    "single",
+		Options: options,
+		Values:  []string{"R", "B"},
+	})
+	assert.NotNil(t, err)
+}
+
+func TestAddPushButton(t *testing.T) {
+	_, page, form := newForm(t)
+	field, err := form.AddPushButton(&acroform.PushButton{
+		Widget:  widget(page, 10, 10, 110, 30),
+		Name:    "reset",
+		Caption: "Reset",
+		Action: &pdfgo.ResetFormAction{
+			Fields: []string{"name"},
+		},
+	})
+	assert.Nil(t, err)
+	action := field.GetEntry("A").(*pdfgo.DictionaryObject)
+	assert.Equal(t, "ResetForm", action.GetEntry("S").(*pdfgo.NameObject).Name)
+	assert.Equal(t, "name", action.GetEntry("Fields").(*pdfgo.ArrayObject).Array[0].(*pdfgo.StringObject).Text())
+	assert.Equal(t, []string{"Reset"}, shown(normal(t, field, "")))
+	flags := int(field.GetEntry("Ff").(*pdfgo.NumberObject).Number)
+	assert.NotZero(t, flags&acroform.FLAG_PUSHBUTTON)
+}
+
+// goRegular writes the Go Regular font to a temporary file, returning its path.
+func goRegular(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "font")
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	file := filepath.Join(dir, "Go-Regular.ttf")
+	assert.Nil(t, ioutil.WriteFile(file, goregular.TTF, 0600))
+	return file
+}
+
+func TestAddFont_type0(t *testing.T) {
+	p, page, form := newForm(t)
+	f, err := font.NewType0Font(p, goRegular(t))
+	assert.Nil(t, err)
+	form.AddFont("Go", f)
+	field, err := form.AddTextField(&acroform.TextField{
+		Widget:   widget(page, 10, 10, 110, 30),
+		Name:     "greeting",
+		Value:    "Привет",
+		FontID:   "Go",
+		FontSize: 12,
+	})
+	assert.Nil(t, err)
+
+	// Text is shown with the two byte glyph indices of the font
+	ttf, err := truetype.Parse(goregular.TTF)
+	assert.Nil(t, err)
+	var expected []byte
+	for _, r := range "Привет" {
+		i := ttf.Index(r)
+		assert.NotZero(t, i)
+		expected = append(expected, byte(i>>8), byte(i))
+	}
+	assert.Equal(t, []string{string(expected)}, shown(normal(t, field, "")))
+}
+
+func TestAddFont_subset(t *testing.T) {
+	p, page, form := newForm(t)
+	f, err := font.NewTrueTypeFont(p, goRegular(t))
+	assert.Nil(t, err)
+	f.Subset = true
+	form.AddFont("Go", f)
+	field, err := form.AddTextField(&acroform.TextField{
+		Widget:   widget(page, 10, 10, 110, 30),
+		Name:     "greeting",
+		Value:    "Hello",
+		FontID:   "Go",
+		FontSize: 12,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Hello"}, shown(normal(t, field, "")))
+	var buffer bytes.Buffer
+	assert.Nil(t, p.Write(&buffer))
+
+	// The subset holds the glyphs of the value, and maps their codes to its characters
+	d := f.GetReference().Object.(*pdfgo.DictionaryObject)
+	descriptor := pdfgo.Resolve(d.GetEntry("FontDescriptor")).(*pdfgo.DictionaryObject)
+	file := pdfgo.Resolve(descriptor.GetEntry("FontFile2")).(*pdfgo.StreamObject)
+	subset, err := truetype.Parse(file.Data)
+	assert.Nil(t, err)
+	for _, c := range "Helo" {
+		assert.NotZero(t, subset.Index(c), string(c))
+	}
+	assert.Zero(t, subset.Index('x'))
+	toUnicode, err := pdfgo.Resolve(d.GetEntry("ToUnicode")).(*pdfgo.StreamObject).Decode()
+	assert.Nil(t, err)
+	for _, m := range []string{"<48> <0048>", "<65> <0065>", "<6C> <006C>", "<6F> <006F>"} {
+		assert.Contains(t, string(toUnicode), m)
+	}
+}
+
+func TestDefaultAppearance(t *testing.T) {
+	assert.Equal(t, "/Helv 0 Tf 0 g", acroform.DefaultAppearance("", 0, nil))
+	assert.Equal(t, "/F1 10.5 Tf 1 0 0 rg", acroform.DefaultAppearance("F1", 10.5, []float64{1, 0, 0}))
+	assert.True(t, strings.HasSuffix(acroform.DefaultAppearance("F1", 8, []float64{0, 0, 0, 1}), " k"))
+}
@@ -0,0 +1,602 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package acroform
+
+import (
+	"fmt"
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/AletheiaWareLLC/pdfgo/content"
+	"github.com/AletheiaWareLLC/pdfgo/font"
+	"github.com/AletheiaWareLLC/pdfgo/graphics"
+	"math"
+	"strings"
+)
+
+const (
+	// TEXT_PADDING separates text from the border of a field
+	TEXT_PADDING = 2
+	// DEFAULT_FONT_SIZE is used by multi-line and list fields with an automatic font size
+	DEFAULT_FONT_SIZE = 12
+	// MINIMUM_FONT_SIZE limits the shrinking of text to fit a field with an automatic font size
+	MINIMUM_FONT_SIZE = 4
+)
+
+// SELECTION_COLOUR is the background of the selected options of list boxes
+var SELECTION_COLOUR = []float64{0.6, 0.75, 0.85}
+
+// DefaultAppearance returns a default appearance string, which sets the font, font size and colour of the text of a field.
+// A font size of zero sizes the text automatically, and a nil colour is black.
+func DefaultAppearance(fontID string, fontSize float64, colour []float64) string {
+	if fontID == "" {
+		fontID = DEFAULT_FONT
+	}
+	writer := graphics.NewContentWriter()
+	writer.SetFont(fontID, fontSize)
+	if colour == nil {
+		colour = []float64{0}
+	}
+	writer.SetFillColour(colour)
+	data, err := writer.Bytes()
+	if err != nil {
+		return "/" + pdfgo.EscapeName(fontID) + " 0 Tf 0 g"
+	}
+	return strings.ReplaceAll(string(data), "\n", " ")
+}
+
+// appearance holds the text settings of a default appearance string
+type appearance struct {
+	font    string
+	size    float64
+	colour  []float64
+	fonts   *pdfgo.DictionaryObject
+	decoder *font.Decoder
+	// added is the font given to AddFont, if any
+	added font.Font
+}
+
+// codes returns the codes showing the given text in the font, replacing characters the font can't show with question marks.
+func (a *appearance) codes(text string) []byte {
+	if e, ok := a.added.(font.Encoder); ok {
+		return e.Encode([]rune(text))
+	}
+	var data []byte
+	for _, r := range text {
+		code, ok := a.decoder.Encode(r)
+		if !ok {
+			code, _ = a.decoder.Encode('?')
+		}
+		data = append(data, code...)
+	}
+	return data
+}
+
+// encode returns the codes showing the given text in the font, escaped for a content stream.
+func (a *appearance) encode(text string) string {
+	return pdfgo.EscapeString(string(a.codes(text)))
+}
+
+// measure returns the width of the given text in the font, at the given size.
+func (a *appearance) measure(text string, size float64) float64 {
+	if a.added != nil {
+		return a.added.MeasureText([]rune(text), size)
+	}
+	var width float64
+	for _, c := range a.decoder.Decode(a.codes(text)) {
+		width += c.Width
+	}
+	return width * size
+}
+
+// resources returns the resources of appearance streams which show text.
+func (a *appearance) resources() *pdfgo.DictionaryObject {
+	r := pdfgo.NewDictionary()
+	r.AddNameObjectEntry("Font", a.fonts)
+	return r
+}
+
+// UpdateAppearance regenerates the normal appearance of the given widget annotation from the value and default appearance of its field.
+// Text and choice fields, check boxes, radio buttons and push buttons are supported.
+func (f *Form) UpdateAppearance(widget *pdfgo.DictionaryObject) error {
+	flags := int(pdfgo.NumberValue(Inherited(widget, "Ff")))
+	switch name(Inherited(widget, "FT")) {
+	case "Tx":
+		return f.textAppearance(widget, flags)
+	case "Btn":
+		if flags&FLAG_PUSHBUTTON != 0 {
+			return f.pushButtonAppearance(widget)
+		}
+		return f.buttonAppearance(widget, flags, OnState(widget))
+	case "Ch":
+		return f.choiceAppearance(widget, flags)
+	}
+	return fmt.Errorf("Unsupported Field Type: %s", name(Inherited(widget, "FT")))
+}
+
+// Inherited returns the value of the given key in the field or widget, or in its nearest ancestor.
+func Inherited(field *pdfgo.DictionaryObject, key string) pdfgo.Object {
+	visited := make(map[*pdfgo.DictionaryObject]bool)
+	for field != nil && !visited[field] {
+		visited[field] = true
+		if v := field.GetEntry(key); v != nil {
+			return pdfgo.Resolve(v)
+		}
+		field, _ = pdfgo.Resolve(field.GetEntry("Parent")).(*pdfgo.DictionaryObject)
+	}
+	return nil
+}
+
+// OnState returns the name of the appearance state of the given check box or radio button widget when it is on.
+func OnState(widget *pdfgo.DictionaryObject) string {
+	if ap, ok := pdfgo.Resolve(widget.GetEntry("AP")).(*pdfgo.DictionaryObject); ok {
+		if n, ok := pdfgo.Resolve(ap.GetEntry("N")).(*pdfgo.DictionaryObject); ok {
+			for _, k := range n.Keys {
+				if k.Name != "Off" {
+					return k.Name
+				}
+			}
+		}
+	}
+	return "Yes"
+}
+
+// defaultAppearance returns the text settings of the field of the given widget, or of the form.
+func (f *Form) defaultAppearance(widget *pdfgo.DictionaryObject) *appearance {
+	a := &appearance{
+		font:  DEFAULT_FONT,
+		fonts: f.Fonts,
+	}
+	da, ok := Inherited(widget, "DA").(*pdfgo.StringObject)
+	if !ok {
+		da, _ = pdfgo.Resolve(f.AcroForm.GetEntry("DA")).(*pdfgo.StringObject)
+	}
+	if da != nil {
+		operations, _ := content.Parse(da.Bytes())
+		for _, o := range operations {
+			switch o.Operator {
+			case "Tf":
+				a.font = o.Name(0)
+				a.size = o.Number(1)
+			case "g", "rg", "k":
+				a.colour = nil
+				for i := range o.Operands {
+					a.colour = append(a.colour, o.Number(i))
+				}
+			}
+		}
+	}
+	dictionary, ok := pdfgo.Resolve(f.Fonts.GetEntry(a.font)).(*pdfgo.DictionaryObject)
+	if !ok {
+		dictionary = pdfgo.NewDictionary()
+	}
+	a.decoder = font.NewDecoder(dictionary)
+	a.added = f.fonts[a.font]
+	return a
+}
+
+// quadding returns the alignment of the text of the field of the given widget, or of the form.
+func (f *Form) quadding(widget *pdfgo.DictionaryObject) int {
+	if q, ok := Inherited(widget, "Q").(*pdfgo.NumberObject); ok {
+		return int(q.Number)
+	}
+	return int(pdfgo.NumberValue(f.AcroForm.GetEntry("Q")))
+}
+
+// widgetBox returns the width and height of the given widget.
+func widgetBox(widget *pdfgo.DictionaryObject) (float64, float64) {
+	var r [4]float64
+	if a, ok := pdfgo.Resolve(widget.GetEntry("Rect")).(*pdfgo.ArrayObject); ok && len(a.Array) == 4 {
+		for i, o := range a.Array {
+			r[i] = pdfgo.NumberValue(o)
+		}
+	}
+	return math.Abs(r[2] - r[0]), math.Abs(r[3] - r[1])
+}
+
+// drawBorder paints the background and border of the given widget, as a rectangle or a circle, returning the width of the border.
+func drawBorder(writer *graphics.ContentWriter, widget *pdfgo.DictionaryObject, width, height float64, circle bool) float64 {
+	var background, border []float64
+	if mk, ok := pdfgo.Resolve(widget.GetEntry("MK")).(*pdfgo.DictionaryObject); ok {
+		background, _ = pdfgo.Numbers(mk.GetEntry("BG"))
+		border, _ = pdfgo.Numbers(mk.GetEntry("BC"))
+	}
+	borderWidth := 1.
+	style := "S"
+	var dashes []float64
+	if bs, ok := pdfgo.Resolve(widget.GetEntry("BS")).(*pdfgo.DictionaryObject); ok {
+		if w, ok := pdfgo.Resolve(bs.GetEntry("W")).(*pdfgo.NumberObject); ok {
+			borderWidth = w.Number
+		}
+		if s := name(bs.GetEntry("S")); s != "" {
+			style = s
+		}
+		dashes, _ = pdfgo.Numbers(bs.GetEntry("D"))
+	}
+	if len(border) == 0 {
+		borderWidth = 0
+	}
+	shape := func(inset float64) {
+		if circle {
+			drawCircle(writer, width/2, height/2, math.Min(width, height)/2-inset)
+		} else {
+			writer.Rectangle(inset, inset, width-2*inset, height-2*inset)
+		}
+	}
+	if len(background) > 0 {
+		writer.SaveState()
+		writer.SetFillColour(background)
+		shape(0)
+		writer.Fill()
+		writer.RestoreState()
+	}
+	if borderWidth > 0 {
+		writer.SaveState()
+		writer.SetStrokeColour(border)
+		writer.SetLineWidth(borderWidth)
+		switch style {
+		case "D":
+			if len(dashes) == 0 {
+				dashes = []float64{3}
+			}
+			writer.SetDash(dashes, 0)
+			shape(borderWidth / 2)
+		case "U":
+			writer.MoveTo(0, borderWidth/2)
+			writer.LineTo(width, borderWidth/2)
+		default:
+			shape(borderWidth / 2)
+		}
+		writer.Stroke()
+		writer.RestoreState()
+	}
+	return borderWidth
+}
+
+// drawCircle adds a circle to the current path.
+func drawCircle(writer *graphics.ContentWriter, x, y, radius float64) {
+	k := radius * graphics.KAPPA
+	writer.MoveTo(x+radius, y)
+	writer.CurveTo(x+radius, y+k, x+k, y+radius, x, y+radius)
+	writer.CurveTo(x-k, y+radius, x-radius, y+k, x-radius, y)
+	writer.CurveTo(x-radius, y-k, x-k, y-radius, x, y-radius)
+	writer.CurveTo(x+k, y-radius, x+radius, y-k, x+radius, y)
+	writer.ClosePath()
+}
+
+// setAppearance sets the normal appearance of the given widget to a form XObject of the given content.
+func (f *Form) setAppearance(widget *pdfgo.DictionaryObject, width, height float64, writer *graphics.ContentWriter, resources pdfgo.Object) error {
+	data, err := writer.Bytes()
+	if err != nil {
+		return err
+	}
+	ap := pdfgo.NewDictionary()
+	ap.AddNameObjectEntry("N", f.p.AddForm(0, 0, width, height, nil, resources, data))
+	widget.SetNameObjectEntry("AP", ap)
+	return nil
+}
+
+func (f *Form) textAppearance(widget *pdfgo.DictionaryObject, flags int) error {
+	width, height := widgetBox(widget)
+	a := f.defaultAppearance(widget)
+	value := TextValue(Inherited(widget, "V"))
+	if flags&FLAG_PASSWORD != 0 {
+		value = strings.Repeat("*", len([]rune(value)))
+	}
+	maxLength := int(pdfgo.NumberValue(Inherited(widget, "MaxLen")))
+	if maxLength > 0 && len([]rune(value)) > maxLength {
+		value = string([]rune(value)[:maxLength])
+	}
+
+	writer := graphics.NewContentWriter()
+	border := drawBorder(writer, widget, width, height, false)
+	writer.BeginMarkedContent("Tx", "")
+	if value != "" {
+		padding := border + TEXT_PADDING
+		writer.SaveState()
+		writer.Rectangle(border, border, width-2*border, height-2*border)
+		writer.Clip()
+		writer.EndPath()
+		writer.BeginText()
+		if a.colour != nil {
+			writer.SetFillColour(a.colour)
+		}
+		switch {
+		case flags&FLAG_MULTILINE != 0:
+			size := a.size
+			if size == 0 {
+				size = DEFAULT_FONT_SIZE
+			}
+			writer.SetFont(a.font, size)
+			lines := graphics.Wrap(value, width-2*padding, func(line string) float64 {
+				return a.measure(line, size)
+			})
+			y := height - padding - size*graphics.ASCENT
+			for _, line := range lines {
+				writer.SetTextMatrix(1, 0, 0, 1, align(f.quadding(widget), a.measure(line, size), width, padding), y)
+				writer.ShowText(a.encode(line))
+				y -= size
+			}
+		case flags&FLAG_COMB != 0 && maxLength > 0:
+			cell := width / float64(maxLength)
+			size := a.size
+			if size == 0 {
+				size = autoSize(a, value, width, height-2*padding, cell*0.8*float64(maxLength))
+			}
+			writer.SetFont(a.font, size)
+			for i, r := range []rune(value) {
+				c := string(r)
+				writer.SetTextMatrix(1, 0, 0, 1, float64(i)*cell+(cell-a.measure(c, size))/2, baseline(height, size))
+				writer.ShowText(a.encode(c))
+			}
+		default:
+			size := a.size
+			if size == 0 {
+				size = autoSize(a, value, width, height-2*padding, width-2*padding)
+			}
+			writer.SetFont(a.font, size)
+			writer.SetTextMatrix(1, 0, 0, 1, align(f.quadding(widget), a.measure(value, size), width, padding), baseline(height, size))
+			writer.ShowText(a.encode(value))
+		}
+		writer.EndText()
+		writer.RestoreState()
+	}
+	writer.EndMarkedContent()
+	return f.setAppearance(widget, width, height, writer, a.resources())
+}
+
+// buttonAppearance sets the on and off appearances of the given check box or radio button widget, selecting the one which matches the value of its field.
+func (f *Form) buttonAppearance(widget *pdfgo.DictionaryObject, flags int, on string) error {
+	width, height := widgetBox(widget)
+	a := f.defaultAppearance(widget)
+	colour := a.colour
+	if colour == nil {
+		colour = []float64{0}
+	}
+	radio := flags&FLAG_RADIO != 0
+
+	states := pdfgo.NewDictionary()
+	for _, state := range []string{on, "Off"} {
+		writer := graphics.NewContentWriter()
+		border := drawBorder(writer, widget, width, height, radio)
+		if state == on {
+			size := math.Min(width, height) - 2*border
+			writer.SaveState()
+			if radio {
+				writer.SetFillColour(colour)
+				drawCircle(writer, width/2, height/2, size/4)
+				writer.Fill()
+			} else {
+				writer.SetStrokeColour(colour)
+				writer.SetLineWidth(size / 8)
+				writer.SetLineCap(graphics.RoundCap)
+				writer.SetLineJoin(graphics.RoundJoin)
+				x := (width - size) / 2
+				y := (height - size) / 2
+				writer.MoveTo(x+size*0.2, y+size*0.5)
+				writer.LineTo(x+size*0.4, y+size*0.25)
+				writer.LineTo(x+size*0.8, y+size*0.75)
+				writer.Stroke()
+			}
+			writer.RestoreState()
+		}
+		data, err := writer.Bytes()
+		if err != nil {
+			return err
+		}
+		states.AddNameObjectEntry(state, f.p.AddForm(0, 0, width, height, nil, nil, data))
+	}
+	ap := pdfgo.NewDictionary()
+	ap.AddNameObjectEntry("N", states)
+	widget.SetNameObjectEntry("AP", ap)
+	state := "Off"
+	if name(Inherited(widget, "V")) == on {
+		state = on
+	}
+	widget.SetNameNameEntry("AS", state)
+	return nil
+}
+
+func (f *Form) choiceAppearance(widget *pdfgo.DictionaryObject, flags int) error {
+	width, height := widgetBox(widget)
+	a := f.defaultAppearance(widget)
+	options := Options(widget)
+	values := ChoiceValues(widget)
+	selected := make(map[string]bool)
+	for _, v := range values {
+		selected[v] = true
+	}
+
+	writer := graphics.NewContentWriter()
+	border := drawBorder(writer, widget, width, height, false)
+	padding := border + TEXT_PADDING
+	writer.BeginMarkedContent("Tx", "")
+	writer.SaveState()
+	writer.Rectangle(border, border, width-2*border, height-2*border)
+	writer.Clip()
+	writer.EndPath()
+	if flags&FLAG_COMBO != 0 {
+		// Combo boxes show the label of the value
+		var text string
+		if len(values) > 0 {
+			text = values[0]
+			for _, o := range options {
+				if o.Value == text {
+					text = o.Label
+				}
+			}
+		}
+		if text != "" {
+			size := a.size
+			if size == 0 {
+				size = autoSize(a, text, width, height-2*padding, width-2*padding)
+			}
+			writer.BeginText()
+			if a.colour != nil {
+				writer.SetFillColour(a.colour)
+			}
+			writer.SetFont(a.font, size)
+			writer.SetTextMatrix(1, 0, 0, 1, align(f.quadding(widget), a.measure(text, size), width, padding), baseline(height, size))
+			writer.ShowText(a.encode(text))
+			writer.EndText()
+		}
+	} else {
+		// List boxes show the options from the top index, highlighting those selected
+		size := a.size
+		if size == 0 {
+			size = DEFAULT_FONT_SIZE
+		}
+		top := int(pdfgo.NumberValue(widget.GetEntry("TI")))
+		if top < 0 || top >= len(options) {
+			top = 0
+		}
+		y := height - border
+		for _, o := range options[top:] {
+			if y < border {
+				break
+			}
+			if selected[o.Value] {
+				writer.SaveState()
+				writer.SetFillColour(SELECTION_COLOUR)
+				writer.Rectangle(border, y-size, width-2*border, size)
+				writer.Fill()
+				writer.RestoreState()
+			}
+			writer.BeginText()
+			if a.colour != nil {
+				writer.SetFillColour(a.colour)
+			}
+			writer.SetFont(a.font, size)
+			writer.SetTextMatrix(1, 0, 0, 1, align(f.quadding(widget), a.measure(o.Label, size), width, padding), y-size+baseline(size, size))
+			writer.ShowText(a.encode(o.Label))
+			writer.EndText()
+			y -= size
+		}
+	}
+	writer.RestoreState()
+	writer.EndMarkedContent()
+	return f.setAppearance(widget, width, height, writer, a.resources())
+}
+
+func (f *Form) pushButtonAppearance(widget *pdfgo.DictionaryObject) error {
+	width, height := widgetBox(widget)
+	a := f.defaultAppearance(widget)
+	var caption string
+	if mk, ok := pdfgo.Resolve(widget.GetEntry("MK")).(*pdfgo.DictionaryObject); ok {
+		caption = TextValue(mk.GetEntry("CA"))
+	}
+	writer := graphics.NewContentWriter()
+	border := drawBorder(writer, widget, width, height, false)
+	if caption != "" {
+		padding := border + TEXT_PADDING
+		size := a.size
+		if size == 0 {
+			size = autoSize(a, caption, width, height-2*padding, width-2*padding)
+		}
+		writer.BeginText()
+		if a.colour != nil {
+			writer.SetFillColour(a.colour)
+		}
+		writer.SetFont(a.font, size)
+		writer.SetTextMatrix(1, 0, 0, 1, (width-a.measure(caption, size))/2, baseline(height, size))
+		writer.ShowText(a.encode(caption))
+		writer.EndText()
+	}
+	return f.setAppearance(widget, width, height, writer, a.resources())
+}
+
+// autoSize returns the largest font size which fits the text in the given height and width.
+func autoSize(a *appearance, text string, width, height, maxWidth float64) float64 {
+	size := height / (graphics.ASCENT - graphics.DESCENT)
+	if w := a.measure(text, size); w > maxWidth && w > 0 {
+		size *= maxWidth / w
+	}
+	return math.Max(size, MINIMUM_FONT_SIZE)
+}
+
+// baseline returns the baseline which centres text of the given size vertically.
+func baseline(height, size float64) float64 {
+	return (height - size*(graphics.ASCENT+graphics.DESCENT)) / 2
+}
+
+// align returns the horizontal position of text of the given width for the given quadding.
+func align(quadding int, textWidth, width, padding float64) float64 {
+	switch quadding {
+	case 1:
+		return (width - textWidth) / 2
+	case 2:
+		return width - padding - textWidth
+	}
+	return padding
+}
+
+// TextValue returns the text of the given text string or stream, or an empty string.
+func TextValue(o pdfgo.Object) string {
+	switch v := pdfgo.Resolve(o).(type) {
+	case *pdfgo.StringObject:
+		return v.Text()
+	case *pdfgo.StreamObject:
+		if data, err := v.Decode(); err == nil {
+			return pdfgo.DecodeText(data)
+		}
+	}
+	return ""
+}
+
+// Options returns the options of the choice field of the given widget.
+func Options(widget *pdfgo.DictionaryObject) []*Option {
+	var options []*Option
+	if a, ok := Inherited(widget, "Opt").(*pdfgo.ArrayObject); ok {
+		for _, o := range a.Array {
+			switch v := pdfgo.Resolve(o).(type) {
+			case *pdfgo.StringObject:
+				options = append(options, &Option{
+					Value: v.Text(),
+					Label: v.Text(),
+				})
+			case *pdfgo.ArrayObject:
+				if len(v.Array) == 2 {
+					options = append(options, &Option{
+						Value: TextValue(v.Array[0]),
+						Label: TextValue(v.Array[1]),
+					})
+				}
+			}
+		}
+	}
+	return options
+}
+
+// ChoiceValues returns the selected values of the choice field of the given widget.
+func ChoiceValues(widget *pdfgo.DictionaryObject) []string {
+	switch v := Inherited(widget, "V").(type) {
+	case *pdfgo.StringObject:
+		return []string{v.Text()}
+	case *pdfgo.ArrayObject:
+		var values []string
+		for _, o := range v.Array {
+			values = append(values, TextValue(o))
+		}
+		return values
+	}
+	return nil
+}
+
+func name(o pdfgo.Object) string {
+	if n, ok := pdfgo.Resolve(o).(*pdfgo.NameObject); ok {
+		return n.Name
+	}
+	return ""
+}
@@ -0,0 +1,352 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package acroform
+
+import (
+	"errors"
+	"fmt"
+	"github.com/AletheiaWareLLC/pdfgo"
+	"sort"
+	"strings"
+)
+
+// Kind distinguishes the types of field, including the variants of buttons and choices.
+type Kind int
+
+const (
+	UnknownKind Kind = iota
+	TextKind
+	CheckBoxKind
+	RadioGroupKind
+	PushButtonKind
+	ComboBoxKind
+	ListBoxKind
+	SignatureKind
+)
+
+func (k Kind) String() string {
+	switch k {
+	case TextKind:
+		return "Text"
+	case CheckBoxKind:
+		return "CheckBox"
+	case RadioGroupKind:
+		return "RadioGroup"
+	case PushButtonKind:
+		return "PushButton"
+	case ComboBoxKind:
+		return "ComboBox"
+	case ListBoxKind:
+		return "ListBox"
+	case SignatureKind:
+		return "Signature"
+	}
+	return "Unknown"
+}
+
+// Field is a terminal field of a form, whose value is shown by one or more widgets.
+type Field struct {
+	// Name is the fully qualified name of the field
+	Name       string
+	Kind       Kind
+	Flags      int
+	Dictionary *pdfgo.DictionaryObject
+	Widgets    []*pdfgo.DictionaryObject
+}
+
+// Value returns the value of the field, or the first value of a list box with several selected.
+// Check boxes and radio groups have the name of the state which is on, or Off.
+func (f *Field) Value() string {
+	if v := f.Values(); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// Values returns the values of the field.
+func (f *Field) Values() []string {
+	switch f.Kind {
+	case CheckBoxKind, RadioGroupKind:
+		if v := name(Inherited(f.Dictionary, "V")); v != "" {
+			return []string{v}
+		}
+		return []string{"Off"}
+	case ComboBoxKind, ListBoxKind:
+		return ChoiceValues(f.Dictionary)
+	}
+	if v := TextValue(Inherited(f.Dictionary, "V")); v != "" {
+		return []string{v}
+	}
+	return nil
+}
+
+// Options returns the options of a choice field.
+func (f *Field) Options() []*Option {
+	return Options(f.Dictionary)
+}
+
+// States returns the names of the on states of the widgets of a check box or radio group.
+func (f *Field) States() []string {
+	var states []string
+	for _, w := range f.Widgets {
+		states = append(states, OnState(w))
+	}
+	return states
+}
+
+// GetForm returns the interactive form of the given document, without changing it.
+func GetForm(p *pdfgo.PDF) (*Form, error) {
+	a, ok := pdfgo.Resolve(p.Catalog.GetEntry("AcroForm")).(*pdfgo.DictionaryObject)
+	if !ok {
+		return nil, errors.New("Document has no form")
+	}
+	f := &Form{
+		p:        p,
+		AcroForm: a,
+	}
+	if f.Fields, ok = pdfgo.Resolve(a.GetEntry("Fields")).(*pdfgo.ArrayObject); !ok {
+		f.Fields = &pdfgo.ArrayObject{}
+	}
+	if resources, ok := pdfgo.Resolve(a.GetEntry("DR")).(*pdfgo.DictionaryObject); ok {
+		f.Fonts, _ = pdfgo.Resolve(resources.GetEntry("Font")).(*pdfgo.DictionaryObject)
+	}
+	if f.Fonts == nil {
+		f.Fonts = pdfgo.NewDictionary()
+	}
+	return f, nil
+}
+
+// GetFields returns the terminal fields of the form, in order.
+func (f *Form) GetFields() []*Field {
+	var fields []*Field
+	visited := make(map[*pdfgo.DictionaryObject]bool)
+	var walk func(kids *pdfgo.ArrayObject, prefix string)
+	walk = func(kids *pdfgo.ArrayObject, prefix string) {
+		for _, k := range kids.Array {
+			d, ok := pdfgo.Resolve(k).(*pdfgo.DictionaryObject)
+			if !ok || visited[d] {
+				continue
+			}
+			visited[d] = true
+			qualified := prefix
+			if t, ok := pdfgo.Resolve(d.GetEntry("T")).(*pdfgo.StringObject); ok {
+				if qualified != "" {
+					qualified += "."
+				}
+				qualified += t.Text()
+			}
+			field := &Field{
+				Name:       qualified,
+				Dictionary: d,
+			}
+			if isWidget(d) {
+				field.Widgets = append(field.Widgets, d)
+			}
+			children := &pdfgo.ArrayObject{}
+			if kids, ok := pdfgo.Resolve(d.GetEntry("Kids")).(*pdfgo.ArrayObject); ok {
+				for _, k := range kids.Array {
+					kid, ok := pdfgo.Resolve(k).(*pdfgo.DictionaryObject)
+					if !ok {
+						continue
+					}
+					// Kids without names are the widgets of the field
+					if kid.HasEntry("T") {
+						children.Array = append(children.Array, k)
+					} else {
+						field.Widgets = append(field.Widgets, kid)
+					}
+				}
+			}
+			if len(children.Array) > 0 {
+				walk(children, qualified)
+				continue
+			}
+			field.Flags = int(pdfgo.NumberValue(Inherited(d, "Ff")))
+			field.Kind = kind(name(Inherited(d, "FT")), field.Flags)
+			fields = append(fields, field)
+		}
+	}
+	walk(f.Fields, "")
+	return fields
+}
+
+// GetField returns the terminal field with the given fully qualified name, or nil.
+func (f *Form) GetField(name string) *Field {
+	for _, field := range f.GetFields() {
+		if field.Name == name {
+			return field
+		}
+	}
+	return nil
+}
+
+// SetValue sets the value of the given field and regenerates the appearances of its widgets, returning the objects which were changed.
+// Check boxes and radio groups take the name of the state to turn on, or Off, and choice fields take the value of one of their options, unless editable.
+func (f *Form) SetValue(field *Field, value string) ([]pdfgo.Object, error) {
+	switch field.Kind {
+	case TextKind:
+		if max := int(pdfgo.NumberValue(Inherited(field.Dictionary, "MaxLen"))); max > 0 && len([]rune(value)) > max {
+			return nil, fmt.Errorf("Value exceeds maximum length of %d", max)
+		}
+		field.Dictionary.SetNameObjectEntry("V", pdfgo.NewTextString(value))
+	case CheckBoxKind, RadioGroupKind:
+		if value != "Off" {
+			found := false
+			for _, s := range field.States() {
+				if s == value {
+					found = true
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("Invalid State: %s; expected one of %s or Off", value, strings.Join(field.States(), ", "))
+			}
+		}
+		field.Dictionary.SetNameObjectEntry("V", &pdfgo.NameObject{
+			Name: value,
+		})
+	case ComboBoxKind, ListBoxKind:
+		return f.SetValues(field, []string{value})
+	default:
+		return nil, fmt.Errorf("Cannot set value of %s field", field.Kind)
+	}
+	return f.updateWidgets(field)
+}
+
+// SetChecked turns the given check box on or off.
+func (f *Form) SetChecked(field *Field, checked bool) ([]pdfgo.Object, error) {
+	if field.Kind != CheckBoxKind {
+		return nil, fmt.Errorf("Cannot check %s field", field.Kind)
+	}
+	value := "Off"
+	if checked {
+		if states := field.States(); len(states) > 0 {
+			value = states[0]
+		}
+	}
+	return f.SetValue(field, value)
+}
+
+// SetValues sets the selected values of the given choice field.
+// Several values can only be selected in list boxes with FLAG_MULTI_SELECT.
+func (f *Form) SetValues(field *Field, values []string) ([]pdfgo.Object, error) {
+	if field.Kind != ComboBoxKind && field.Kind != ListBoxKind {
+		return nil, fmt.Errorf("Cannot select values of %s field", field.Kind)
+	}
+	if len(values) > 1 && field.Flags&FLAG_MULTI_SELECT == 0 {
+		return nil, errors.New("Field doesn't allow multiple selection")
+	}
+	options := field.Options()
+	var indices []int
+	for _, v := range values {
+		index := -1
+		for i, o := range options {
+			if o.Value == v {
+				index = i
+			}
+		}
+		if index < 0 && field.Flags&FLAG_EDIT == 0 {
+			return nil, fmt.Errorf("Invalid Option: %s", v)
+		}
+		if index >= 0 {
+			indices = append(indices, index)
+		}
+	}
+	switch len(values) {
+	case 0:
+		field.Dictionary.RemoveEntry("V")
+	case 1:
+		field.Dictionary.SetNameObjectEntry("V", pdfgo.NewTextString(values[0]))
+	default:
+		a := &pdfgo.ArrayObject{}
+		for _, v := range values {
+			a.Array = append(a.Array, pdfgo.NewTextString(v))
+		}
+		field.Dictionary.SetNameObjectEntry("V", a)
+	}
+	// Indices of the selected options disambiguate options with the same value
+	field.Dictionary.RemoveEntry("I")
+	if len(indices) > 0 && field.Kind == ListBoxKind {
+		sort.Ints(indices)
+		field.Dictionary.AddNameObjectEntry("I", pdfgo.NewNumberArray(intsToFloats(indices)))
+	}
+	return f.updateWidgets(field)
+}
+
+// updateWidgets regenerates the appearances of the widgets of the given field, returning the objects which were changed.
+func (f *Form) updateWidgets(field *Field) ([]pdfgo.Object, error) {
+	changes := &changes{}
+	changes.add(field.Dictionary)
+	for _, w := range field.Widgets {
+		if err := f.UpdateAppearance(w); err != nil {
+			return nil, err
+		}
+		changes.add(w)
+	}
+	return changes.objects, nil
+}
+
+func kind(fieldType string, flags int) Kind {
+	switch fieldType {
+	case "Tx":
+		return TextKind
+	case "Btn":
+		switch {
+		case flags&FLAG_PUSHBUTTON != 0:
+			return PushButtonKind
+		case flags&FLAG_RADIO != 0:
+			return RadioGroupKind
+		}
+		return CheckBoxKind
+	case "Ch":
+		if flags&FLAG_COMBO != 0 {
+			return ComboBoxKind
+		}
+		return ListBoxKind
+	case "Sig":
+		return SignatureKind
+	}
+	return UnknownKind
+}
+
+func isWidget(d *pdfgo.DictionaryObject) bool {
+	return name(d.GetEntry("Subtype")) == "Widget"
+}
+
+// changes collects the indirect objects which were changed, once each.
+type changes struct {
+	objects []pdfgo.Object
+}
+
+func (c *changes) add(o pdfgo.Object) {
+	if o.GetName() == 0 {
+		return
+	}
+	for _, e := range c.objects {
+		if e == o {
+			return
+		}
+	}
+	c.objects = append(c.objects, o)
+}
+
+func intsToFloats(ints []int) []float64 {
+	var floats []float64
+	for _, i := range ints {
+		floats = append(floats, float64(i))
+	}
+	return floats
+}
@@ -0,0 +1,200 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package acroform_test
+
+import (
+	"bytes"
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/AletheiaWareLLC/pdfgo/acroform"
+	"github.com/AletheiaWareLLC/pdfgo/render"
+	"github.com/stretchr/testify/assert"
+	"image/color"
+	"testing"
+)
+
+// parsedForm writes a document with a form and reads it back.
+func parsedForm(t *testing.T) ([]byte, *pdfgo.PDF, *acroform.Form) {
+	t.Helper()
+	p, page, form := newForm(t)
+	_, err := form.AddTextField(&acroform.TextField{
+		Widget:    widget(page, 10, 170, 110, 190),
+		Name:      "name",
+		Value:     "Alice",
+		FontSize:  12,
+		MaxLength: 10,
+	})
+	assert.Nil(t, err)
+	_, err = form.AddCheckBox(&acroform.CheckBox{
+		Widget: widget(page, 10, 140, 30, 160),
+		Name:   "agree",
+	})
+	assert.Nil(t, err)
+	_, err = form.AddRadioGroup(&acroform.RadioGroup{
+		Name:  "size",
+		Value: "Small",
+		Buttons: []*acroform.RadioButton{
+			{Widget: widget(page, 10, 110, 30, 130), ExportValue: "Small"},
+			{Widget: widget(page, 40, 110, 60, 130), ExportValue: "Large"},
+		},
+	})
+	assert.Nil(t, err)
+	_, err = form.AddChoiceField(&acroform.ChoiceField{
+		Widget: widget(page, 10, 40, 110, 100),
+		Name:   "colours",
+		Options: []*acroform.Option{
+			{Value: "R", Label: "Red"},
+			{Value: "G", Label: "Green"},
+			{Value: "B", Label: "Blue"},
+		},
+		Values:   []string{"R"},
+		FontSize: 10,
+		Flags:    acroform.FLAG_MULTI_SELECT,
+	})
+	assert.Nil(t, err)
+	// Nest a field under a non-terminal field
+	parent := p.NewDictionaryObject()
+	parent.AddNameObjectEntry("T", pdfgo.NewTextString("address"))
+	child, err := form.AddTextField(&acroform.TextField{
+		Widget: widget(page, 120, 170, 190, 190),
+		Name:   "city",
+	})
+	assert.Nil(t, err)
+	form.Fields.Array = form.Fields.Array[:len(form.Fields.Array)-1]
+	form.Fields.Array = append(form.Fields.Array, pdfgo.NewObjectReference(parent))
+	parent.AddNameObjectEntry("Kids", &pdfgo.ArrayObject{
+		Array: []pdfgo.Object{pdfgo.NewObjectReference(child)},
+	})
+	child.AddNameObjectEntry("Parent", pdfgo.NewObjectReference(parent))
+
+	var buffer bytes.Buffer
+	assert.Nil(t, p.Write(&buffer))
+	original := buffer.Bytes()
+	parsed, err := pdfgo.ReadPDF(original)
+	assert.Nil(t, err)
+	f, err := acroform.GetForm(parsed)
+	assert.Nil(t, err)
+	return original, parsed, f
+}
+
+func TestGetFields(t *testing.T) {
+	_, _, form := parsedForm(t)
+	fields := form.GetFields()
+	var names []string
+	var kinds []acroform.Kind
+	var values []string
+	for _, f := range fields {
+		names = append(names, f.Name)
+		kinds = append(kinds, f.Kind)
+		values = append(values, f.Value())
+	}
+	assert.Equal(t, []string{"name", "agree", "size", "colours", "address.city"}, names)
+	assert.Equal(t, []acroform.Kind{acroform.TextKind, acroform.CheckBoxKind, acroform.RadioGroupKind, acroform.ListBoxKind, acroform.TextKind}, kinds)
+	assert.Equal(t, []string{"Alice", "Off", "Small", "R", ""}, values)
+	assert.Equal(t, []string{"Small", "Large"}, fields[2].States())
+	assert.Equal(t, 3, len(fields[3].Options()))
+	assert.Equal(t, fields[4], form.GetField("address.city"))
+	assert.Nil(t, form.GetField("city"))
+
+	_, err := acroform.GetForm(pdfgo.NewPDF())
+	assert.NotNil(t, err)
+}
+
+func TestSetValue(t *testing.T) {
+	_, _, form := parsedForm(t)
+
+	name := form.GetField("name")
+	changed, err := form.SetValue(name, "Bob")
+	assert.Nil(t, err)
+	assert.Equal(t, []pdfgo.Object{name.Dictionary}, changed)
+	assert.Equal(t, "Bob", name.Value())
+	assert.Equal(t, []string{"Bob"}, shown(normal(t, name.Dictionary, "")))
+	_, err = form.SetValue(name, "Bartholomew Jr")
+	assert.NotNil(t, err)
+
+	agree := form.GetField("agree")
+	_, err = form.SetChecked(agree, true)
+	assert.Nil(t, err)
+	assert.Equal(t, "Yes", agree.Value())
+	assert.Equal(t, "Yes", agree.Dictionary.GetEntry("AS").(*pdfgo.NameObject).Name)
+	_, err = form.SetValue(agree, "Maybe")
+	assert.NotNil(t, err)
+
+	size := form.GetField("size")
+	changed, err = form.SetValue(size, "Large")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(changed))
+	assert.Equal(t, "Off", size.Widgets[0].GetEntry("AS").(*pdfgo.NameObject).Name)
+	assert.Equal(t, "Large", size.Widgets[1].GetEntry("AS").(*pdfgo.NameObject).Name)
+
+	colours := form.GetField("colours")
+	_, err = form.SetValues(colours, []string{"B", "G"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"B", "G"}, colours.Values())
+	assert.Equal(t, []float64{1, 2}, indices(colours.Dictionary))
+	_, err = form.SetValue(colours, "Y")
+	assert.NotNil(t, err)
+}
+
+func indices(field *pdfgo.DictionaryObject) []float64 {
+	var result []float64
+	for _, o := range field.GetEntry("I").(*pdfgo.ArrayObject).Array {
+		result = append(result, o.(*pdfgo.NumberObject).Number)
+	}
+	return result
+}
+
+func TestFlatten(t *testing.T) {
+	original, p, form := parsedForm(t)
+	_, err := form.SetChecked(form.GetField("agree"), true)
+	assert.Nil(t, err)
+	changed, err := form.Flatten()
+	assert.Nil(t, err)
+
+	var buffer bytes.Buffer
+	assert.Nil(t, p.WriteUpdate(&buffer, original, changed))
+	result, err := pdfgo.ReadPDF(buffer.Bytes())
+	assert.Nil(t, err)
+	assert.False(t, result.Catalog.HasEntry("AcroForm"))
+	page := result.GetPages()[0]
+	assert.False(t, page.HasEntry("Annots"))
+
+	img, err := render.RenderPage(page, 72)
+	assert.Nil(t, err)
+	// The check mark of the check box and the border of the text field are drawn in the page content
+	assert.True(t, img.RGBAAt(10+11, 200-140-11).R < 64)
+	assert.True(t, img.RGBAAt(60, 200-171).R < 128)
+	assert.Equal(t, color.RGBA{255, 255, 255, 255}, img.RGBAAt(150, 150))
+}
+
+func TestFlatten_field(t *testing.T) {
+	_, p, form := parsedForm(t)
+	changed, err := form.Flatten(form.GetField("address.city"), form.GetField("size"))
+	assert.Nil(t, err)
+	assert.NotEmpty(t, changed)
+	for _, o := range changed {
+		assert.NotZero(t, o.GetName())
+	}
+	var names []string
+	for _, f := range form.GetFields() {
+		names = append(names, f.Name)
+	}
+	// The parent left without kids is removed too
+	assert.Equal(t, []string{"name", "agree", "colours"}, names)
+	assert.True(t, p.Catalog.HasEntry("AcroForm"))
+	annots := pdfgo.Resolve(p.GetPages()[0].GetEntry("Annots")).(*pdfgo.ArrayObject)
+	assert.Equal(t, 3, len(annots.Array))
+}
@@ -0,0 +1,116 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package acroform
+
+import (
+	"github.com/AletheiaWareLLC/pdfgo"
+)
+
+// Flatten draws the appearances of the widgets of the given fields into the content of their pages and removes the fields from the form, so they can no longer be edited.
+// All fields are flattened if none are given, in which case the form is removed from the document.
+// The objects which were changed are returned so they can be written as an incremental update.
+func (f *Form) Flatten(fields ...*Field) ([]pdfgo.Object, error) {
+	all := len(fields) == 0
+	if all {
+		fields = f.GetFields()
+	}
+	changes := &changes{}
+
+	// Map widgets to the pages which show them, preferring the page annotations over the P entry of the widget
+	pages := f.p.GetPages()
+	owners := make(map[*pdfgo.DictionaryObject]*pdfgo.DictionaryObject)
+	for _, page := range pages {
+		if annots, ok := pdfgo.Resolve(page.GetEntry("Annots")).(*pdfgo.ArrayObject); ok {
+			for _, a := range annots.Array {
+				if d, ok := pdfgo.Resolve(a).(*pdfgo.DictionaryObject); ok {
+					owners[d] = page
+				}
+			}
+		}
+	}
+
+	widgets := make(map[*pdfgo.DictionaryObject][]*pdfgo.DictionaryObject)
+	var order []*pdfgo.DictionaryObject
+	for _, field := range fields {
+		for _, w := range field.Widgets {
+			page, ok := owners[w]
+			if !ok {
+				if page, ok = pdfgo.Resolve(w.GetEntry("P")).(*pdfgo.DictionaryObject); !ok {
+					continue
+				}
+			}
+			if !w.HasEntry("AP") && field.Kind != SignatureKind {
+				if err := f.UpdateAppearance(w); err != nil {
+					return nil, err
+				}
+			}
+			if _, ok := widgets[page]; !ok {
+				order = append(order, page)
+			}
+			widgets[page] = append(widgets[page], w)
+		}
+	}
+	for _, page := range order {
+		if err := f.p.FlattenAnnotations(page, widgets[page]...); err != nil {
+			return nil, err
+		}
+		changes.add(page)
+	}
+
+	// Remove the fields from the form
+	if all {
+		f.p.Catalog.RemoveEntry("AcroForm")
+		changes.add(f.p.Catalog)
+		f.Fields.Array = nil
+		return changes.objects, nil
+	}
+	for _, field := range fields {
+		f.remove(field.Dictionary, changes)
+	}
+	return changes.objects, nil
+}
+
+// remove removes the given field from its parent, and removes any parent left without kids.
+func (f *Form) remove(field *pdfgo.DictionaryObject, changes *changes) {
+	parent, ok := pdfgo.Resolve(field.GetEntry("Parent")).(*pdfgo.DictionaryObject)
+	if !ok {
+		f.Fields.Array = without(f.Fields.Array, field)
+		changes.add(f.Fields)
+		changes.add(f.AcroForm)
+		return
+	}
+	kids, ok := pdfgo.Resolve(parent.GetEntry("Kids")).(*pdfgo.ArrayObject)
+	if !ok {
+		return
+	}
+	kids.Array = without(kids.Array, field)
+	changes.add(kids)
+	changes.add(parent)
+	if len(kids.Array) == 0 {
+		f.remove(parent, changes)
+	}
+}
+
+func without(array []pdfgo.Object, d *pdfgo.DictionaryObject) []pdfgo.Object {
+	var result []pdfgo.Object
+	for _, o := range array {
+		if pdfgo.Resolve(o) != d {
+			result = append(result, o)
+		}
+	}
+	return result
+}
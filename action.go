@@ -0,0 +1,451 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pdfgo
+
+import (
+	"errors"
+)
+
+// Views of destinations, fitting the page or an area of it to the window
+const (
+	FIT_XYZ             = "XYZ"
+	FIT_PAGE            = "Fit"
+	FIT_WIDTH           = "FitH"
+	FIT_HEIGHT          = "FitV"
+	FIT_RECTANGLE       = "FitR"
+	FIT_BOUNDING_BOX    = "FitB"
+	FIT_BOUNDING_WIDTH  = "FitBH"
+	FIT_BOUNDING_HEIGHT = "FitBV"
+)
+
+// Triggers of additional actions of the document
+const (
+	TRIGGER_WILL_CLOSE = "WC"
+	TRIGGER_WILL_SAVE  = "WS"
+	TRIGGER_DID_SAVE   = "DS"
+	TRIGGER_WILL_PRINT = "WP"
+	TRIGGER_DID_PRINT  = "DP"
+)
+
+// Triggers of additional actions of pages
+const (
+	TRIGGER_PAGE_OPEN  = "O"
+	TRIGGER_PAGE_CLOSE = "C"
+)
+
+// Triggers of additional actions of annotations
+const (
+	TRIGGER_ENTER     = "E"
+	TRIGGER_EXIT      = "X"
+	TRIGGER_DOWN      = "D"
+	TRIGGER_UP        = "U"
+	TRIGGER_FOCUS     = "Fo"
+	TRIGGER_BLUR      = "Bl"
+	TRIGGER_OPEN      = "PO"
+	TRIGGER_CLOSE     = "PC"
+	TRIGGER_VISIBLE   = "PV"
+	TRIGGER_INVISIBLE = "PI"
+)
+
+// Triggers of additional actions of form fields
+const (
+	TRIGGER_KEYSTROKE = "K"
+	TRIGGER_FORMAT    = "F"
+	TRIGGER_VALIDATE  = "V"
+	TRIGGER_CALCULATE = "C"
+)
+
+// Flags of SubmitForm actions
+const (
+	SUBMIT_EXCLUDE                 = 1 << 0
+	SUBMIT_INCLUDE_NO_VALUE_FIELDS = 1 << 1
+	SUBMIT_EXPORT_FORMAT           = 1 << 2
+	SUBMIT_GET_METHOD              = 1 << 3
+	SUBMIT_COORDINATES             = 1 << 4
+	SUBMIT_XFDF                    = 1 << 5
+	SUBMIT_INCLUDE_APPEND_SAVES    = 1 << 6
+	SUBMIT_INCLUDE_ANNOTATIONS     = 1 << 7
+	SUBMIT_PDF                     = 1 << 8
+	SUBMIT_CANONICAL_FORMAT        = 1 << 9
+	SUBMIT_EXCL_NON_USER_ANNOTS    = 1 << 10
+	SUBMIT_EXCL_F_KEY              = 1 << 11
+	SUBMIT_EMBED_FORM              = 1 << 13
+)
+
+// Action is performed by the viewer when a link, outline item or form field is activated, or when the document or a page is opened.
+type Action interface {
+	// GetType returns the type of the action, such as URI
+	GetType() string
+	// SetEntries adds the entries particular to the type of the action to the given action dictionary
+	SetEntries(d *DictionaryObject) error
+}
+
+// ActionChain performs its actions in order, linking them with Next entries.
+type ActionChain []Action
+
+func (c ActionChain) GetType() string {
+	if len(c) == 0 {
+		return ""
+	}
+	return c[0].GetType()
+}
+
+func (c ActionChain) SetEntries(d *DictionaryObject) error {
+	if len(c) == 0 {
+		return errors.New("Empty action chain")
+	}
+	if err := c[0].SetEntries(d); err != nil {
+		return err
+	}
+	switch len(c) {
+	case 1:
+	case 2:
+		next, err := NewActionDictionary(c[1])
+		if err != nil {
+			return err
+		}
+		d.SetNameObjectEntry("Next", next)
+	default:
+		next := &ArrayObject{}
+		for _, a := range c[1:] {
+			n, err := NewActionDictionary(a)
+			if err != nil {
+				return err
+			}
+			next.Array = append(next.Array, n)
+		}
+		d.SetNameObjectEntry("Next", next)
+	}
+	return nil
+}
+
+// NewActionDictionary returns the action dictionary of the given action.
+func NewActionDictionary(a Action) (*DictionaryObject, error) {
+	if a == nil {
+		return nil, errors.New("Missing action")
+	}
+	d := &DictionaryObject{
+		Dictionary: make(map[*NameObject]Object),
+	}
+	d.AddNameNameEntry("Type", "Action")
+	d.AddNameNameEntry("S", a.GetType())
+	if err := a.SetEntries(d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Destination is a view of a page in this document, or another.
+type Destination struct {
+	// Page is the page in this document
+	Page *DictionaryObject
+	// PageIndex is the zero-based index of the page in another document
+	PageIndex int
+	// Name refers to a named destination instead of a page, if not empty
+	Name string
+	// Fit is how the page is fitted to the window, and defaults to FIT_PAGE
+	Fit string
+	// Left, Bottom, Right and Top are the coordinates of the area shown, as required by the fit
+	Left, Bottom, Right, Top float64
+	// Zoom is the magnification of FIT_XYZ, where 0 retains the current magnification
+	Zoom float64
+}
+
+// Object returns the destination as a name string or an explicit destination array, referring to the page if it is in this document.
+func (d *Destination) Object(remote bool) (Object, error) {
+	if d.Name != "" {
+		return NewTextString(d.Name), nil
+	}
+	array := &ArrayObject{}
+	if remote {
+		array.Array = append(array.Array, &NumberObject{
+			Number: float64(d.PageIndex),
+		})
+	} else if d.Page != nil {
+		array.Array = append(array.Array, NewObjectReference(d.Page))
+	} else {
+		return nil, errors.New("Destination requires a page")
+	}
+	fit := d.Fit
+	if fit == "" {
+		fit = FIT_PAGE
+	}
+	array.Array = append(array.Array, &NameObject{
+		Name: fit,
+	})
+	var parameters []float64
+	switch fit {
+	case FIT_XYZ:
+		parameters = []float64{d.Left, d.Top, d.Zoom}
+	case FIT_PAGE, FIT_BOUNDING_BOX:
+	case FIT_WIDTH, FIT_BOUNDING_WIDTH:
+		parameters = []float64{d.Top}
+	case FIT_HEIGHT, FIT_BOUNDING_HEIGHT:
+		parameters = []float64{d.Left}
+	case FIT_RECTANGLE:
+		parameters = []float64{d.Left, d.Bottom, d.Right, d.Top}
+	default:
+		return nil, errors.New("Unrecognized destination fit: " + fit)
+	}
+	for _, p := range parameters {
+		array.Array = append(array.Array, &NumberObject{
+			Number: p,
+		})
+	}
+	return array, nil
+}
+
+// SetNamedDestinations sets the destinations which GoTo actions and links can refer to by name, replacing any the document had.
+func (p *PDF) SetNamedDestinations(destinations map[string]*Destination) error {
+	entries := make(map[string]Object)
+	for n, d := range destinations {
+		if d.Name != "" {
+			return errors.New("Named destination refers to another name: " + n)
+		}
+		dest, err := d.Object(false)
+		if err != nil {
+			return err
+		}
+		entries[n] = dest
+	}
+	names, ok := Resolve(p.Catalog.GetEntry("Names")).(*DictionaryObject)
+	if !ok {
+		names = p.NewDictionaryObject()
+		p.Catalog.SetNameObjectEntry("Names", NewObjectReference(names))
+	}
+	names.SetNameObjectEntry("Dests", NewObjectReference(p.NewNameTree(entries)))
+	return nil
+}
+
+// URIAction resolves a uniform resource identifier, such as a web page.
+type URIAction struct {
+	URI string
+	// IsMap appends the coordinates of the mouse to the URI
+	IsMap bool
+}
+
+func (a *URIAction) GetType() string {
+	return "URI"
+}
+
+func (a *URIAction) SetEntries(d *DictionaryObject) error {
+	d.SetNameObjectEntry("URI", &StringObject{
+		String: a.URI,
+	})
+	if a.IsMap {
+		d.SetNameObjectEntry("IsMap", &BooleanObject{
+			Boolean: true,
+		})
+	}
+	return nil
+}
+
+// GoToAction goes to a destination in this document.
+type GoToAction struct {
+	Destination Destination
+}
+
+func (a *GoToAction) GetType() string {
+	return "GoTo"
+}
+
+func (a *GoToAction) SetEntries(d *DictionaryObject) error {
+	dest, err := a.Destination.Object(false)
+	if err != nil {
+		return err
+	}
+	d.SetNameObjectEntry("D", dest)
+	return nil
+}
+
+// GoToRAction goes to a destination in another document.
+type GoToRAction struct {
+	File        string
+	Destination Destination
+	// NewWindow opens the document in a new window
+	NewWindow bool
+}
+
+func (a *GoToRAction) GetType() string {
+	return "GoToR"
+}
+
+func (a *GoToRAction) SetEntries(d *DictionaryObject) error {
+	if a.File == "" {
+		return errors.New("GoToR action requires a file")
+	}
+	d.SetNameObjectEntry("F", NewTextString(a.File))
+	dest, err := a.Destination.Object(true)
+	if err != nil {
+		return err
+	}
+	d.SetNameObjectEntry("D", dest)
+	if a.NewWindow {
+		d.SetNameObjectEntry("NewWindow", &BooleanObject{
+			Boolean: true,
+		})
+	}
+	return nil
+}
+
+// LaunchAction opens a file, or launches an application.
+type LaunchAction struct {
+	File string
+	// NewWindow opens a document in a new window
+	NewWindow bool
+}
+
+func (a *LaunchAction) GetType() string {
+	return "Launch"
+}
+
+func (a *LaunchAction) SetEntries(d *DictionaryObject) error {
+	if a.File == "" {
+		return errors.New("Launch action requires a file")
+	}
+	d.SetNameObjectEntry("F", NewTextString(a.File))
+	if a.NewWindow {
+		d.SetNameObjectEntry("NewWindow", &BooleanObject{
+			Boolean: true,
+		})
+	}
+	return nil
+}
+
+// NamedAction performs an action of the viewer, such as NextPage, PrevPage, FirstPage or LastPage.
+type NamedAction struct {
+	Name string
+}
+
+func (a *NamedAction) GetType() string {
+	return "Named"
+}
+
+func (a *NamedAction) SetEntries(d *DictionaryObject) error {
+	if a.Name == "" {
+		return errors.New("Named action requires a name")
+	}
+	d.SetNameNameEntry("N", a.Name)
+	return nil
+}
+
+// SubmitFormAction sends the values of form fields to a URL.
+type SubmitFormAction struct {
+	URL string
+	// Fields are the fully qualified names of the fields submitted, or excluded with SUBMIT_EXCLUDE, or all fields if empty
+	Fields []string
+	Flags  int
+}
+
+func (a *SubmitFormAction) GetType() string {
+	return "SubmitForm"
+}
+
+func (a *SubmitFormAction) SetEntries(d *DictionaryObject) error {
+	if a.URL == "" {
+		return errors.New("SubmitForm action requires a URL")
+	}
+	spec := &DictionaryObject{
+		Dictionary: make(map[*NameObject]Object),
+	}
+	spec.AddNameNameEntry("FS", "URL")
+	spec.AddNameObjectEntry("F", &StringObject{
+		String: a.URL,
+	})
+	d.SetNameObjectEntry("F", spec)
+	setFields(d, a.Fields)
+	if a.Flags != 0 {
+		d.SetNameObjectEntry("Flags", &NumberObject{
+			Number: float64(a.Flags),
+		})
+	}
+	return nil
+}
+
+// ResetFormAction resets form fields to their default values.
+type ResetFormAction struct {
+	// Fields are the fully qualified names of the fields reset, or excluded if Exclude is true, or all fields if empty
+	Fields  []string
+	Exclude bool
+}
+
+func (a *ResetFormAction) GetType() string {
+	return "ResetForm"
+}
+
+func (a *ResetFormAction) SetEntries(d *DictionaryObject) error {
+	setFields(d, a.Fields)
+	if a.Exclude {
+		d.SetNameObjectEntry("Flags", &NumberObject{
+			Number: SUBMIT_EXCLUDE,
+		})
+	}
+	return nil
+}
+
+// JavaScriptAction runs a script.
+type JavaScriptAction struct {
+	Script string
+}
+
+func (a *JavaScriptAction) GetType() string {
+	return "JavaScript"
+}
+
+func (a *JavaScriptAction) SetEntries(d *DictionaryObject) error {
+	d.SetNameObjectEntry("JS", NewTextString(a.Script))
+	return nil
+}
+
+// setFields adds the names of the given fields to the given action dictionary, if there are any.
+func setFields(d *DictionaryObject, fields []string) {
+	if len(fields) == 0 {
+		return
+	}
+	array := &ArrayObject{}
+	for _, f := range fields {
+		array.Array = append(array.Array, NewTextString(f))
+	}
+	d.SetNameObjectEntry("Fields", array)
+}
+
+// SetOpenAction sets the action performed when the document is opened.
+func (p *PDF) SetOpenAction(a Action) error {
+	d, err := NewActionDictionary(a)
+	if err != nil {
+		return err
+	}
+	p.Catalog.SetNameObjectEntry("OpenAction", d)
+	return nil
+}
+
+// SetAdditionalAction sets the action performed by the given trigger of the catalog, a page, an annotation or a form field.
+func SetAdditionalAction(d *DictionaryObject, trigger string, a Action) error {
+	action, err := NewActionDictionary(a)
+	if err != nil {
+		return err
+	}
+	aa, ok := Resolve(d.GetEntry("AA")).(*DictionaryObject)
+	if !ok {
+		aa = &DictionaryObject{
+			Dictionary: make(map[*NameObject]Object),
+		}
+		d.SetNameObjectEntry("AA", aa)
+	}
+	aa.SetNameObjectEntry(trigger, action)
+	return nil
+}
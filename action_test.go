@@ -0,0 +1,182 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pdfgo_test
+
+import (
+	"bytes"
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func write(t *testing.T, o pdfgo.Object) string {
+	t.Helper()
+	var buffer bytes.Buffer
+	_, err := o.Write(&buffer)
+	assert.Nil(t, err)
+	return buffer.String()
+}
+
+func TestNewActionDictionary(t *testing.T) {
+	p := pdfgo.NewPDF()
+	p.AddPage(200, 200, nil, nil)
+	page := p.GetPages()[0]
+	for name, test := range map[string]struct {
+		action   pdfgo.Action
+		expected string
+	}{
+		"URI": {
+			&pdfgo.URIAction{URI: "https://example.com"},
+			"<</Type /Action /S /URI /URI (https://example.com)>>",
+		},
+		"GoTo": {
+			&pdfgo.GoToAction{Destination: pdfgo.Destination{Page: page, Fit: pdfgo.FIT_XYZ, Left: 10, Top: 190}},
+			"<</Type /Action /S /GoTo /D [3 0 R /XYZ 10 190 0]>>",
+		},
+		"GoToNamed": {
+			&pdfgo.GoToAction{Destination: pdfgo.Destination{Name: "chapter1"}},
+			"<</Type /Action /S /GoTo /D (chapter1)>>",
+		},
+		"GoToR": {
+			&pdfgo.GoToRAction{File: "other.pdf", Destination: pdfgo.Destination{PageIndex: 2, Fit: pdfgo.FIT_WIDTH, Top: 100}, NewWindow: true},
+			"<</Type /Action /S /GoToR /F (other.pdf) /D [2 /FitH 100] /NewWindow true>>",
+		},
+		"Launch": {
+			&pdfgo.LaunchAction{File: "readme.txt"},
+			"<</Type /Action /S /Launch /F (readme.txt)>>",
+		},
+		"Named": {
+			&pdfgo.NamedAction{Name: "NextPage"},
+			"<</Type /Action /S /Named /N /NextPage>>",
+		},
+		"SubmitForm": {
+			&pdfgo.SubmitFormAction{URL: "https://example.com/submit", Fields: []string{"name"}, Flags: pdfgo.SUBMIT_XFDF},
+			"<</Type /Action /S /SubmitForm /F <</FS /URL /F (https://example.com/submit)>> /Fields [(name)] /Flags 32>>",
+		},
+		"ResetForm": {
+			&pdfgo.ResetFormAction{Fields: []string{"name"}, Exclude: true},
+			"<</Type /Action /S /ResetForm /Fields [(name)] /Flags 1>>",
+		},
+		"JavaScript": {
+			&pdfgo.JavaScriptAction{Script: "app.alert('Hi');"},
+			"<</Type /Action /S /JavaScript /JS (app.alert\\('Hi'\\);)>>",
+		},
+		"Chain": {
+			pdfgo.ActionChain{
+				&pdfgo.NamedAction{Name: "FirstPage"},
+				&pdfgo.URIAction{URI: "a"},
+				pdfgo.ActionChain{&pdfgo.URIAction{URI: "b"}, &pdfgo.URIAction{URI: "c"}},
+			},
+			"<</Type /Action /S /Named /N /FirstPage /Next [<</Type /Action /S /URI /URI (a)>> <</Type /Action /S /URI /URI (b) /Next <</Type /Action /S /URI /URI (c)>>>>]>>",
+		},
+	} {
+		d, err := pdfgo.NewActionDictionary(test.action)
+		assert.Nil(t, err, name)
+		assert.Equal(t, test.expected, write(t, d), name)
+	}
+
+	for name, action := range map[string]pdfgo.Action{
+		"Nil":         nil,
+		"EmptyChain":  pdfgo.ActionChain{},
+		"NoPage":      &pdfgo.GoToAction{},
+		"InvalidFit":  &pdfgo.GoToAction{Destination: pdfgo.Destination{Page: page, Fit: "Fill"}},
+		"NoFile":      &pdfgo.LaunchAction{},
+		"NoURL":       &pdfgo.SubmitFormAction{},
+		"InvalidNext": pdfgo.ActionChain{&pdfgo.URIAction{}, &pdfgo.NamedAction{}},
+	} {
+		_, err := pdfgo.NewActionDictionary(action)
+		assert.NotNil(t, err, name)
+	}
+}
+
+func TestSetAdditionalAction(t *testing.T) {
+	p := pdfgo.NewPDF()
+	p.AddPage(200, 200, nil, nil)
+	page := p.GetPages()[0]
+	assert.Nil(t, p.SetOpenAction(&pdfgo.GoToAction{Destination: pdfgo.Destination{Page: page}}))
+	assert.Nil(t, pdfgo.SetAdditionalAction(p.Catalog, pdfgo.TRIGGER_WILL_PRINT, &pdfgo.JavaScriptAction{Script: "print"}))
+	assert.Nil(t, pdfgo.SetAdditionalAction(page, pdfgo.TRIGGER_PAGE_OPEN, &pdfgo.NamedAction{Name: "LastPage"}))
+	assert.NotNil(t, pdfgo.SetAdditionalAction(page, pdfgo.TRIGGER_PAGE_CLOSE, &pdfgo.NamedAction{}))
+
+	var buffer bytes.Buffer
+	assert.Nil(t, p.Write(&buffer))
+	result, err := pdfgo.ReadPDF(buffer.Bytes())
+	assert.Nil(t, err)
+	open := pdfgo.Resolve(result.Catalog.GetEntry("OpenAction")).(*pdfgo.DictionaryObject)
+	dest := open.GetEntry("D").(*pdfgo.ArrayObject)
+	assert.Equal(t, result.GetPages()[0], pdfgo.Resolve(dest.Array[0]))
+	aa := result.Catalog.GetEntry("AA").(*pdfgo.DictionaryObject)
+	assert.Equal(t, "print", aa.GetEntry("WP").(*pdfgo.DictionaryObject).GetEntry("JS").(*pdfgo.StringObject).Text())
+	aa = result.GetPages()[0].GetEntry("AA").(*pdfgo.DictionaryObject)
+	assert.True(t, aa.HasEntry("O"))
+	assert.False(t, aa.HasEntry("C"))
+}
+
+func TestSetOutline(t *testing.T) {
+	p := pdfgo.NewPDF()
+	p.AddPage(200, 200, nil, nil)
+	p.AddPage(200, 200, nil, nil)
+	pages := p.GetPages()
+	goTo := func(page *pdfgo.DictionaryObject) pdfgo.Action {
+		return &pdfgo.GoToAction{Destination: pdfgo.Destination{Page: page}}
+	}
+	assert.NotNil(t, p.SetOutline([]*pdfgo.OutlineItem{{}}))
+	assert.Nil(t, p.SetOutline([]*pdfgo.OutlineItem{
+		{
+			Title:  "Chapter 1",
+			Action: goTo(pages[0]),
+			Open:   true,
+			Style:  pdfgo.OUTLINE_BOLD,
+			Children: []*pdfgo.OutlineItem{
+				{Title: "Section 1.1", Action: goTo(pages[0])},
+				{
+					Title:  "Section 1.2",
+					Action: goTo(pages[1]),
+					Children: []*pdfgo.OutlineItem{
+						{Title: "Section 1.2.1", Action: goTo(pages[1])},
+					},
+				},
+			},
+		},
+		{
+			Title:  "Website",
+			Action: &pdfgo.URIAction{URI: "https://example.com"},
+			Colour: []float64{0, 0, 1},
+		},
+	}))
+
+	var buffer bytes.Buffer
+	assert.Nil(t, p.Write(&buffer))
+	result, err := pdfgo.ReadPDF(buffer.Bytes())
+	assert.Nil(t, err)
+	outlines := pdfgo.Resolve(result.Catalog.GetEntry("Outlines")).(*pdfgo.DictionaryObject)
+	// Chapter 1, its two sections, and Website are visible
+	assert.Equal(t, 4.0, outlines.GetEntry("Count").(*pdfgo.NumberObject).Number)
+	first := pdfgo.Resolve(outlines.GetEntry("First")).(*pdfgo.DictionaryObject)
+	last := pdfgo.Resolve(outlines.GetEntry("Last")).(*pdfgo.DictionaryObject)
+	assert.Equal(t, "Chapter 1", first.GetEntry("Title").(*pdfgo.StringObject).Text())
+	assert.Equal(t, "Website", last.GetEntry("Title").(*pdfgo.StringObject).Text())
+	assert.Equal(t, last, pdfgo.Resolve(first.GetEntry("Next")))
+	assert.Equal(t, first, pdfgo.Resolve(last.GetEntry("Prev")))
+	assert.Equal(t, outlines, pdfgo.Resolve(first.GetEntry("Parent")))
+	assert.Equal(t, 2.0, first.GetEntry("Count").(*pdfgo.NumberObject).Number)
+	section := pdfgo.Resolve(first.GetEntry("Last")).(*pdfgo.DictionaryObject)
+	assert.Equal(t, "Section 1.2", section.GetEntry("Title").(*pdfgo.StringObject).Text())
+	// Closed items count their hidden descendants negatively
+	assert.Equal(t, -1.0, section.GetEntry("Count").(*pdfgo.NumberObject).Number)
+	assert.False(t, last.HasEntry("Count"))
+}
@@ -75,13 +75,9 @@ func (h *Hyperlink) GetBorder() *ArrayObject {
 }
 
 func (h *Hyperlink) GetAction() *DictionaryObject {
-	a := &DictionaryObject{
-		Dictionary: make(map[*NameObject]Object),
-	}
-	a.AddNameNameEntry("Type", "Action")
-	a.AddNameNameEntry("S", "URI")
-	a.AddNameObjectEntry("URI", &StringObject{
-		String: h.URI,
+	// URI actions have no entries which can be invalid
+	a, _ := NewActionDictionary(&URIAction{
+		URI: h.URI,
 	})
 	return a
 }
@@ -89,3 +85,21 @@ func (h *Hyperlink) GetAction() *DictionaryObject {
 func (h *Hyperlink) GetDestination() *DictionaryObject {
 	return nil
 }
+
+// AddPageAnnotation adds the given annotation dictionary to the given page only.
+// A page sharing the annotations of the document is given its own copy of them, so annotations added later with AddAnnotation won't appear on it.
+func (p *PDF) AddPageAnnotation(page, annotation *DictionaryObject) {
+	annotation.SetNameObjectEntry("P", NewObjectReference(page))
+	reference := NewObjectReference(annotation)
+	annots, ok := Resolve(page.GetEntry("Annots")).(*ArrayObject)
+	if ok && annots != p.Annotations {
+		annots.Array = append(annots.Array, reference)
+		return
+	}
+	a := &ArrayObject{}
+	if ok {
+		a.Array = append(a.Array, annots.Array...)
+	}
+	a.Array = append(a.Array, reference)
+	page.SetNameObjectEntry("Annots", a)
+}
@@ -0,0 +1,384 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package annotation_test
+
+import (
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/AletheiaWareLLC/pdfgo/annotation"
+	"github.com/AletheiaWareLLC/pdfgo/content"
+	"github.com/AletheiaWareLLC/pdfgo/graphics"
+	"github.com/AletheiaWareLLC/pdfgo/render"
+	"github.com/stretchr/testify/assert"
+	"image"
+	"image/color"
+	"testing"
+	"time"
+)
+
+func newPage(t *testing.T) (*pdfgo.PDF, *pdfgo.DictionaryObject) {
+	t.Helper()
+	p := pdfgo.NewPDF()
+	p.AddPage(200, 200, nil, nil)
+	return p, p.GetPages()[0]
+}
+
+// draw renders the page with the normal appearance of the given annotation drawn on it.
+func draw(t *testing.T, page, annot *pdfgo.DictionaryObject) *image.RGBA {
+	t.Helper()
+	ap := pdfgo.Resolve(annot.GetEntry("AP")).(*pdfgo.DictionaryObject)
+	xs := &pdfgo.DictionaryObject{
+		Dictionary: make(map[*pdfgo.NameObject]pdfgo.Object),
+	}
+	xs.AddNameObjectEntry("A", ap.GetEntry("N"))
+	resources := &pdfgo.DictionaryObject{
+		Dictionary: make(map[*pdfgo.NameObject]pdfgo.Object),
+	}
+	resources.AddNameObjectEntry("XObject", xs)
+	page.SetNameObjectEntry("Resources", resources)
+	page.SetNameObjectEntry("Contents", &pdfgo.StreamObject{
+		DictionaryObject: pdfgo.DictionaryObject{
+			Dictionary: make(map[*pdfgo.NameObject]pdfgo.Object),
+		},
+		Data: []byte("/A Do"),
+	})
+	img, err := render.RenderPage(page, 72)
+	assert.Nil(t, err)
+	return img
+}
+
+// at returns the colour of the rendered page at the given point in page coordinates.
+func at(img *image.RGBA, x, y int) color.RGBA {
+	return img.RGBAAt(x, 200-y)
+}
+
+func operators(t *testing.T, annot *pdfgo.DictionaryObject) []string {
+	t.Helper()
+	ap := pdfgo.Resolve(annot.GetEntry("AP")).(*pdfgo.DictionaryObject)
+	operations, err := content.ParseStream(pdfgo.Resolve(ap.GetEntry("N")).(*pdfgo.StreamObject))
+	assert.Nil(t, err)
+	var result []string
+	for _, o := range operations {
+		result = append(result, o.Operator)
+	}
+	return result
+}
+
+func numbers(o pdfgo.Object) []float64 {
+	var result []float64
+	for _, n := range pdfgo.Resolve(o).(*pdfgo.ArrayObject).Array {
+		result = append(result, n.(*pdfgo.NumberObject).Number)
+	}
+	return result
+}
+
+var white = color.RGBA{255, 255, 255, 255}
+
+func TestMarkup(t *testing.T) {
+	p, page := newPage(t)
+	markup := annotation.NewMarkup(page, annotation.RED)
+	markup.Author = "Zoë"
+	markup.Contents = "Check this"
+	markup.Opacity = 0.5
+	markup.Date = time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	d, err := (&annotation.Square{
+		Markup:    markup,
+		Rectangle: graphics.Rectangle{Left: 10, Bottom: 10, Right: 50, Top: 30},
+	}).Add(p)
+	assert.Nil(t, err)
+	assert.Equal(t, "Square", d.GetEntry("Subtype").(*pdfgo.NameObject).Name)
+	assert.Equal(t, "Zoë", d.GetEntry("T").(*pdfgo.StringObject).Text())
+	assert.Equal(t, "Check this", d.GetEntry("Contents").(*pdfgo.StringObject).Text())
+	assert.Equal(t, "D:20210304050607Z", d.GetEntry("CreationDate").(*pdfgo.StringObject).String)
+	assert.Equal(t, 0.5, d.GetEntry("CA").(*pdfgo.NumberObject).Number)
+	assert.Equal(t, []float64{1, 0, 0}, numbers(d.GetEntry("C")))
+	assert.Equal(t, page, pdfgo.Resolve(d.GetEntry("P")))
+	annots := pdfgo.Resolve(page.GetEntry("Annots")).(*pdfgo.ArrayObject)
+	assert.Equal(t, d, pdfgo.Resolve(annots.Array[0]))
+	// Pages added later don't share the annotation
+	assert.Equal(t, 0, len(p.Annotations.Array))
+
+	markup.Opacity = 2
+	_, err = (&annotation.Square{Markup: markup}).Add(p)
+	assert.NotNil(t, err)
+	_, err = (&annotation.Square{}).Add(p)
+	assert.NotNil(t, err)
+}
+
+func TestText(t *testing.T) {
+	p, page := newPage(t)
+	d, err := (&annotation.Text{
+		Markup: annotation.NewMarkup(page, nil),
+		X:      10,
+		Y:      150,
+		Open:   true,
+	}).Add(p)
+	assert.Nil(t, err)
+	assert.Equal(t, []float64{10, 150, 30, 170}, numbers(d.GetEntry("Rect")))
+	assert.Equal(t, "Note", d.GetEntry("Name").(*pdfgo.NameObject).Name)
+	popup := pdfgo.Resolve(d.GetEntry("Popup")).(*pdfgo.DictionaryObject)
+	assert.Equal(t, "Popup", popup.GetEntry("Subtype").(*pdfgo.NameObject).Name)
+	assert.Equal(t, d, pdfgo.Resolve(popup.GetEntry("Parent")))
+	assert.True(t, popup.GetEntry("Open").(*pdfgo.BooleanObject).Boolean)
+	assert.Equal(t, 2, len(pdfgo.Resolve(page.GetEntry("Annots")).(*pdfgo.ArrayObject).Array))
+
+	img := draw(t, page, d)
+	assert.Equal(t, color.RGBA{255, 255, 0, 255}, at(img, 13, 158))
+}
+
+func TestTextMarkup(t *testing.T) {
+	p, page := newPage(t)
+	areas := []*graphics.Rectangle{
+		{Left: 10, Bottom: 100, Right: 150, Top: 116},
+		{Left: 10, Bottom: 80, Right: 60, Top: 96},
+	}
+	highlight, err := (&annotation.TextMarkup{
+		Markup: annotation.NewMarkup(page, nil),
+		Type:   annotation.Highlight,
+		Areas:  areas,
+	}).Add(p)
+	assert.Nil(t, err)
+	assert.Equal(t, "Highlight", highlight.GetEntry("Subtype").(*pdfgo.NameObject).Name)
+	assert.Equal(t, []float64{10, 116, 150, 116, 10, 100, 150, 100, 10, 96, 60, 96, 10, 80, 60, 80}, numbers(highlight.GetEntry("QuadPoints")))
+	assert.Equal(t, []float64{10, 80, 150, 116}, numbers(highlight.GetEntry("Rect")))
+	img := draw(t, page, highlight)
+	assert.Equal(t, color.RGBA{255, 255, 0, 255}, at(img, 100, 108))
+	assert.Equal(t, white, at(img, 100, 88))
+
+	for _, kind := range []annotation.TextMarkupType{annotation.Underline, annotation.StrikeOut, annotation.Squiggly} {
+		d, err := (&annotation.TextMarkup{
+			Markup: annotation.NewMarkup(page, annotation.RED),
+			Type:   kind,
+			Areas:  areas,
+		}).Add(p)
+		assert.Nil(t, err)
+		assert.Equal(t, kind.String(), d.GetEntry("Subtype").(*pdfgo.NameObject).Name)
+		assert.Contains(t, operators(t, d), "S")
+	}
+	img = draw(t, page, pdfgo.Resolve(pdfgo.Resolve(page.GetEntry("Annots")).(*pdfgo.ArrayObject).Array[2]).(*pdfgo.DictionaryObject))
+	// The strike out line crosses the text
+	assert.True(t, at(img, 100, 107).G < 64)
+
+	_, err = (&annotation.TextMarkup{
+		Markup: annotation.NewMarkup(page, nil),
+	}).Add(p)
+	assert.NotNil(t, err)
+}
+
+func TestFreeText(t *testing.T) {
+	p, page := newPage(t)
+	markup := annotation.NewMarkup(page, []float64{0.8})
+	markup.Contents = "The quick brown fox jumps over the lazy dog"
+	d, err := (&annotation.FreeText{
+		Markup:     markup,
+		Rectangle:  graphics.Rectangle{Left: 10, Bottom: 100, Right: 110, Top: 180},
+		FontSize:   10,
+		FontColour: annotation.RED,
+		Align:      graphics.Center,
+	}).Add(p)
+	assert.Nil(t, err)
+	assert.Equal(t, "/F1 10 Tf 1 0 0 rg", d.GetEntry("DA").(*pdfgo.StringObject).String)
+	assert.Equal(t, 1., d.GetEntry("Q").(*pdfgo.NumberObject).Number)
+	var lines int
+	for _, o := range operators(t, d) {
+		if o == "Tj" {
+			lines++
+		}
+	}
+	assert.True(t, lines > 1)
+	img := draw(t, page, d)
+	// The background is filled and the border is drawn in the colour of the text
+	assert.Equal(t, color.RGBA{204, 204, 204, 255}, at(img, 105, 105))
+	assert.Equal(t, color.RGBA{255, 0, 0, 255}, at(img, 10, 140))
+
+	_, err = (&annotation.FreeText{
+		Markup:   markup,
+		FontName: "Comic Sans",
+	}).Add(p)
+	assert.NotNil(t, err)
+}
+
+func TestCircle(t *testing.T) {
+	p, page := newPage(t)
+	d, err := (&annotation.Circle{
+		Markup:         annotation.NewMarkup(page, annotation.BLACK),
+		Rectangle:      graphics.Rectangle{Left: 20, Bottom: 20, Right: 100, Top: 60},
+		InteriorColour: []float64{0, 0, 1},
+	}).Add(p)
+	assert.Nil(t, err)
+	assert.Equal(t, []float64{0, 0, 1}, numbers(d.GetEntry("IC")))
+	img := draw(t, page, d)
+	assert.Equal(t, color.RGBA{0, 0, 255, 255}, at(img, 60, 40))
+	// The corners of the bounds are outside the ellipse
+	assert.Equal(t, white, at(img, 22, 58))
+}
+
+func TestLine(t *testing.T) {
+	p, page := newPage(t)
+	markup := annotation.NewMarkup(page, annotation.RED)
+	markup.BorderWidth = 2
+	d, err := (&annotation.Line{
+		Markup: markup,
+		X1:     20,
+		Y1:     100,
+		X2:     180,
+		Y2:     100,
+		End:    annotation.ClosedArrowEnding,
+	}).Add(p)
+	assert.Nil(t, err)
+	assert.Equal(t, []float64{20, 100, 180, 100}, numbers(d.GetEntry("L")))
+	le := d.GetEntry("LE").(*pdfgo.ArrayObject)
+	assert.Equal(t, "None", le.Array[0].(*pdfgo.NameObject).Name)
+	assert.Equal(t, "ClosedArrow", le.Array[1].(*pdfgo.NameObject).Name)
+	// The rectangle includes the arrow head
+	assert.Equal(t, []float64{8, 88, 192, 112}, numbers(d.GetEntry("Rect")))
+	img := draw(t, page, d)
+	assert.Equal(t, color.RGBA{255, 0, 0, 255}, at(img, 100, 100))
+	// The side of the arrow head
+	assert.Equal(t, color.RGBA{255, 0, 0, 255}, at(img, 172, 104))
+
+	_, err = (&annotation.Line{Markup: markup}).Add(p)
+	assert.NotNil(t, err)
+}
+
+func TestPolygon(t *testing.T) {
+	p, page := newPage(t)
+	d, err := (&annotation.Polygon{
+		Markup:         annotation.NewMarkup(page, annotation.BLACK),
+		Vertices:       []float64{20, 20, 180, 20, 100, 180},
+		InteriorColour: []float64{0, 1, 0},
+	}).Add(p)
+	assert.Nil(t, err)
+	assert.Equal(t, 6, len(numbers(d.GetEntry("Vertices"))))
+	assert.False(t, d.HasEntry("LE"))
+	img := draw(t, page, d)
+	assert.Equal(t, color.RGBA{0, 255, 0, 255}, at(img, 100, 60))
+	assert.Equal(t, white, at(img, 30, 170))
+
+	polyline, err := (&annotation.PolyLine{
+		Markup:   annotation.NewMarkup(page, annotation.BLACK),
+		Vertices: []float64{20, 20, 180, 20, 100, 180},
+		Start:    annotation.CircleEnding,
+	}).Add(p)
+	assert.Nil(t, err)
+	assert.Equal(t, "Circle", polyline.GetEntry("LE").(*pdfgo.ArrayObject).Array[0].(*pdfgo.NameObject).Name)
+	assert.Equal(t, "PolyLine", polyline.GetEntry("Subtype").(*pdfgo.NameObject).Name)
+
+	_, err = (&annotation.Polygon{
+		Markup:   annotation.NewMarkup(page, nil),
+		Vertices: []float64{20, 20, 180, 20},
+	}).Add(p)
+	assert.NotNil(t, err)
+}
+
+func TestInk(t *testing.T) {
+	p, page := newPage(t)
+	markup := annotation.NewMarkup(page, []float64{0, 0, 1})
+	markup.BorderWidth = 4
+	d, err := (&annotation.Ink{
+		Markup: markup,
+		Paths: [][]float64{
+			{20, 20, 60, 60, 100, 20},
+			{150, 150},
+		},
+	}).Add(p)
+	assert.Nil(t, err)
+	list := d.GetEntry("InkList").(*pdfgo.ArrayObject)
+	assert.Equal(t, 2, len(list.Array))
+	assert.Equal(t, []float64{16, 16, 154, 154}, numbers(d.GetEntry("Rect")))
+	img := draw(t, page, d)
+	assert.Equal(t, color.RGBA{0, 0, 255, 255}, at(img, 40, 40))
+
+	_, err = (&annotation.Ink{Markup: markup}).Add(p)
+	assert.NotNil(t, err)
+}
+
+func TestStamp(t *testing.T) {
+	p, page := newPage(t)
+	markup := annotation.NewMarkup(page, nil)
+	markup.BorderWidth = 3
+	d, err := (&annotation.Stamp{
+		Markup:    markup,
+		Rectangle: graphics.Rectangle{Left: 20, Bottom: 100, Right: 180, Top: 150},
+		Name:      "NotApproved",
+	}).Add(p)
+	assert.Nil(t, err)
+	assert.Equal(t, "NotApproved", d.GetEntry("Name").(*pdfgo.NameObject).Name)
+	assert.Contains(t, operators(t, d), "Tj")
+	img := draw(t, page, d)
+	assert.Equal(t, color.RGBA{255, 0, 0, 255}, at(img, 100, 149))
+
+	_, err = (&annotation.Stamp{Markup: markup}).Add(p)
+	assert.NotNil(t, err)
+}
+
+func TestFileAttachment(t *testing.T) {
+	p, page := newPage(t)
+	d, err := (&annotation.FileAttachment{
+		Markup: annotation.NewMarkup(page, nil),
+		X:      20,
+		Y:      20,
+		File: &pdfgo.Attachment{
+			Name:     "report.csv",
+			MimeType: "text/csv",
+			Data:     []byte("a,b\n"),
+		},
+	}).Add(p)
+	assert.Nil(t, err)
+	assert.Equal(t, "Paperclip", d.GetEntry("Name").(*pdfgo.NameObject).Name)
+	assert.Equal(t, "report.csv", d.GetEntry("Contents").(*pdfgo.StringObject).Text())
+	assert.Contains(t, operators(t, d), "S")
+
+	attachments, err := pdfgo.PageAttachments(page)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(attachments))
+	assert.Equal(t, "a,b\n", string(attachments[0].Data))
+	// Attachments of annotations aren't in the name tree
+	attachments, err = p.GetAttachments()
+	assert.Nil(t, err)
+	assert.Empty(t, attachments)
+
+	_, err = (&annotation.FileAttachment{
+		Markup: annotation.NewMarkup(page, nil),
+	}).Add(p)
+	assert.NotNil(t, err)
+}
+
+func TestLink(t *testing.T) {
+	p, page := newPage(t)
+	d, err := (&annotation.Link{
+		Page:      page,
+		Rectangle: graphics.Rectangle{Left: 10, Bottom: 10, Right: 100, Top: 30},
+		Action: pdfgo.ActionChain{
+			&pdfgo.GoToAction{Destination: pdfgo.Destination{Page: page}},
+			&pdfgo.URIAction{URI: "https://example.com"},
+		},
+		Highlight: annotation.HIGHLIGHT_OUTLINE,
+	}).Add(p)
+	assert.Nil(t, err)
+	assert.Equal(t, "O", d.GetEntry("H").(*pdfgo.NameObject).Name)
+	a := d.GetEntry("A").(*pdfgo.DictionaryObject)
+	assert.Equal(t, "GoTo", a.GetEntry("S").(*pdfgo.NameObject).Name)
+	next := a.GetEntry("Next").(*pdfgo.DictionaryObject)
+	assert.Equal(t, "URI", next.GetEntry("S").(*pdfgo.NameObject).Name)
+	annots := page.GetEntry("Annots").(*pdfgo.ArrayObject)
+	assert.Equal(t, d, pdfgo.Resolve(annots.Array[0]))
+
+	_, err = (&annotation.Link{
+		Page: page,
+	}).Add(p)
+	assert.NotNil(t, err)
+}
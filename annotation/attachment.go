@@ -0,0 +1,85 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package annotation
+
+import (
+	"errors"
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/AletheiaWareLLC/pdfgo/graphics"
+)
+
+// FileAttachment is a file attached to a point on the page, shown as an icon.
+type FileAttachment struct {
+	Markup
+	// X and Y are the bottom left corner of the icon
+	X, Y float64
+	// Icon names the icon, such as Graph, PushPin, Tag, or Paperclip, which is the default
+	Icon string
+	File *pdfgo.Attachment
+}
+
+// Add adds the file attachment annotation to the page, embedding the file in the document.
+func (f *FileAttachment) Add(p *pdfgo.PDF) (*pdfgo.DictionaryObject, error) {
+	if f.File == nil {
+		return nil, errors.New("File attachment requires a file")
+	}
+	spec, err := p.NewFileSpecification(f.File)
+	if err != nil {
+		return nil, err
+	}
+	rect := &graphics.Rectangle{
+		Left:   f.X,
+		Bottom: f.Y,
+		Right:  f.X + ICON_SIZE,
+		Top:    f.Y + ICON_SIZE,
+	}
+	colour := f.colour(BLACK)
+	// The contents describe the attachment to readers which can't show it
+	markup := f.Markup
+	if markup.Contents == "" {
+		markup.Contents = f.File.Name
+	}
+	d, err := markup.newAnnotation(p, "FileAttachment", rect, colour)
+	if err != nil {
+		return nil, err
+	}
+	icon := f.Icon
+	if icon == "" {
+		icon = "Paperclip"
+	}
+	d.AddNameNameEntry("Name", icon)
+	d.AddNameObjectEntry("FS", pdfgo.NewObjectReference(spec))
+
+	// Draw a paperclip of three nested loops
+	writer := graphics.NewContentWriter()
+	writer.SetStrokeColour(colour)
+	writer.SetLineWidth(1.5)
+	writer.SetLineCap(graphics.RoundCap)
+	writer.SetLineJoin(graphics.RoundJoin)
+	x, y := rect.Left+ICON_SIZE/2, rect.Bottom
+	writer.MoveTo(x+2, y+6)
+	writer.LineTo(x+2, y+16)
+	writer.CurveTo(x+2, y+19, x-2, y+19, x-2, y+16)
+	writer.LineTo(x-2, y+4)
+	writer.CurveTo(x-2, y, x+4, y, x+4, y+4)
+	writer.LineTo(x+4, y+14)
+	writer.Stroke()
+	if err := setAppearance(p, d, rect, writer, nil); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
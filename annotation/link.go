@@ -0,0 +1,73 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package annotation
+
+import (
+	"errors"
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/AletheiaWareLLC/pdfgo/graphics"
+)
+
+// Highlighting modes of links when they are clicked
+const (
+	HIGHLIGHT_NONE    = "N"
+	HIGHLIGHT_INVERT  = "I"
+	HIGHLIGHT_OUTLINE = "O"
+	HIGHLIGHT_PUSH    = "P"
+)
+
+// Link is an invisible area of a page which performs an action when clicked.
+type Link struct {
+	Page *pdfgo.DictionaryObject
+	graphics.Rectangle
+	Action pdfgo.Action
+	// Highlight is how the area is shown while clicked, and defaults to HIGHLIGHT_INVERT
+	Highlight string
+	// Layer makes the link active only when the layer is shown, if not nil
+	Layer *pdfgo.Layer
+}
+
+// Add adds the link to its page.
+func (l *Link) Add(p *pdfgo.PDF) (*pdfgo.DictionaryObject, error) {
+	if l.Page == nil {
+		return nil, errors.New("Annotation requires a page")
+	}
+	if l.Layer != nil && l.Layer.Group == nil {
+		return nil, errors.New("Layer not added: " + l.Layer.Name)
+	}
+	action, err := pdfgo.NewActionDictionary(l.Action)
+	if err != nil {
+		return nil, err
+	}
+	d := p.NewDictionaryObject()
+	d.AddNameNameEntry("Type", "Annot")
+	d.AddNameNameEntry("Subtype", "Link")
+	d.AddNameObjectEntry("Rect", pdfgo.NewRectangleArray(l.Left, l.Bottom, l.Right, l.Top))
+	d.AddNameObjectEntry("F", &pdfgo.NumberObject{
+		Number: FLAG_PRINT,
+	})
+	d.AddNameObjectEntry("Border", pdfgo.NewNumberArray([]float64{0, 0, 0}))
+	if l.Highlight != "" && l.Highlight != HIGHLIGHT_INVERT {
+		d.AddNameNameEntry("H", l.Highlight)
+	}
+	d.AddNameObjectEntry("A", action)
+	if l.Layer != nil {
+		d.AddNameObjectEntry("OC", pdfgo.NewObjectReference(l.Layer.Group))
+	}
+	p.AddPageAnnotation(l.Page, d)
+	return d, nil
+}
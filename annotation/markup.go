@@ -0,0 +1,183 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package annotation
+
+import (
+	"errors"
+	"fmt"
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/AletheiaWareLLC/pdfgo/graphics"
+	"math"
+	"time"
+)
+
+// FLAG_PRINT is the annotation flag which prints the annotation with the page
+const FLAG_PRINT = 1 << 2
+
+var (
+	BLACK  = []float64{0}
+	RED    = []float64{1, 0, 0}
+	YELLOW = []float64{1, 1, 0}
+)
+
+// Markup holds the properties shared by markup annotations.
+type Markup struct {
+	Page *pdfgo.DictionaryObject
+	// Colour is used to draw the annotation, defaulting to black, or yellow for highlights
+	Colour []float64
+	// Opacity ranges from 0 (transparent) to 1 (opaque)
+	Opacity float64
+	// Author is shown as the title of the pop-up window of the annotation
+	Author   string
+	Contents string
+	// Date is when the annotation was created, if not zero
+	Date        time.Time
+	BorderWidth float64
+	// Dash draws borders and lines dashed if not empty
+	Dash []float64
+	// Layer shows the annotation only when the layer is shown, if not nil
+	Layer *pdfgo.Layer
+}
+
+// NewMarkup returns the markup properties for an opaque annotation on the given page, drawn in the given colour with lines 1 point wide, created now.
+func NewMarkup(page *pdfgo.DictionaryObject, colour []float64) Markup {
+	return Markup{
+		Page:        page,
+		Colour:      colour,
+		Opacity:     1,
+		Date:        time.Now(),
+		BorderWidth: 1,
+	}
+}
+
+// newAnnotation adds an annotation of the given type with the markup properties to the page, covering the given rectangle.
+func (m *Markup) newAnnotation(p *pdfgo.PDF, subtype string, rect *graphics.Rectangle, colour []float64) (*pdfgo.DictionaryObject, error) {
+	if m.Page == nil {
+		return nil, errors.New("Annotation requires a page")
+	}
+	if m.Opacity < 0 || m.Opacity > 1 {
+		return nil, fmt.Errorf("Invalid Opacity: %g", m.Opacity)
+	}
+	if m.BorderWidth < 0 {
+		return nil, fmt.Errorf("Invalid Border Width: %g", m.BorderWidth)
+	}
+	if m.Layer != nil && m.Layer.Group == nil {
+		return nil, errors.New("Layer not added: " + m.Layer.Name)
+	}
+	d := p.NewDictionaryObject()
+	d.AddNameNameEntry("Type", "Annot")
+	d.AddNameNameEntry("Subtype", subtype)
+	d.AddNameObjectEntry("Rect", pdfgo.NewRectangleArray(rect.Left, rect.Bottom, rect.Right, rect.Top))
+	d.AddNameObjectEntry("F", &pdfgo.NumberObject{
+		Number: FLAG_PRINT,
+	})
+	if m.Contents != "" {
+		d.AddNameObjectEntry("Contents", pdfgo.NewTextString(m.Contents))
+	}
+	if m.Author != "" {
+		d.AddNameObjectEntry("T", pdfgo.NewTextString(m.Author))
+	}
+	if !m.Date.IsZero() {
+		d.AddNameObjectEntry("M", pdfgo.NewDateString(m.Date))
+		d.AddNameObjectEntry("CreationDate", pdfgo.NewDateString(m.Date))
+	}
+	if colour != nil {
+		d.AddNameObjectEntry("C", pdfgo.NewNumberArray(colour))
+	}
+	if m.Opacity != 1 {
+		d.AddNameObjectEntry("CA", &pdfgo.NumberObject{
+			Number: m.Opacity,
+		})
+	}
+	if m.Layer != nil {
+		d.AddNameObjectEntry("OC", pdfgo.NewObjectReference(m.Layer.Group))
+	}
+	p.AddPageAnnotation(m.Page, d)
+	return d, nil
+}
+
+// border adds the border style of the markup to the given annotation.
+func (m *Markup) border(d *pdfgo.DictionaryObject) {
+	bs := pdfgo.NewDictionary()
+	bs.AddNameNameEntry("Type", "Border")
+	bs.AddNameObjectEntry("W", &pdfgo.NumberObject{
+		Number: m.BorderWidth,
+	})
+	if len(m.Dash) > 0 {
+		bs.AddNameNameEntry("S", "D")
+		bs.AddNameObjectEntry("D", pdfgo.NewNumberArray(m.Dash))
+	} else {
+		bs.AddNameNameEntry("S", "S")
+	}
+	d.AddNameObjectEntry("BS", bs)
+}
+
+// colour returns the colour of the markup, or the given default.
+func (m *Markup) colour(fallback []float64) []float64 {
+	if m.Colour != nil {
+		return m.Colour
+	}
+	return fallback
+}
+
+// setStroke sets the colour, width and dash of lines drawn by the given writer.
+func (m *Markup) setStroke(writer *graphics.ContentWriter, colour []float64) {
+	writer.SetStrokeColour(colour)
+	writer.SetLineWidth(m.BorderWidth)
+	if len(m.Dash) > 0 {
+		writer.SetDash(m.Dash, 0)
+	}
+}
+
+// setAppearance sets the normal appearance of the given annotation to the content of the writer.
+// The bounding box of the appearance is the rectangle of the annotation, so the content is drawn in the coordinates of the page.
+func setAppearance(p *pdfgo.PDF, d *pdfgo.DictionaryObject, rect *graphics.Rectangle, writer *graphics.ContentWriter, resources pdfgo.Object) error {
+	data, err := writer.Bytes()
+	if err != nil {
+		return err
+	}
+	ap := pdfgo.NewDictionary()
+	ap.AddNameObjectEntry("N", p.AddForm(rect.Left, rect.Bottom, rect.Right, rect.Top, nil, resources, data))
+	d.SetNameObjectEntry("AP", ap)
+	return nil
+}
+
+// bounds returns the rectangle enclosing the given points, given as pairs of coordinates, expanded by the given margin.
+func bounds(points []float64, margin float64) *graphics.Rectangle {
+	r := graphics.NegativeRectangle()
+	for i := 0; i+1 < len(points); i += 2 {
+		r.Left = math.Min(r.Left, points[i])
+		r.Right = math.Max(r.Right, points[i])
+		r.Bottom = math.Min(r.Bottom, points[i+1])
+		r.Top = math.Max(r.Top, points[i+1])
+	}
+	r.Left -= margin
+	r.Bottom -= margin
+	r.Right += margin
+	r.Top += margin
+	return r
+}
+
+// normalize returns the given rectangle with its left below its right and its bottom below its top.
+func normalize(r graphics.Rectangle) *graphics.Rectangle {
+	return &graphics.Rectangle{
+		Left:   math.Min(r.Left, r.Right),
+		Bottom: math.Min(r.Bottom, r.Top),
+		Right:  math.Max(r.Left, r.Right),
+		Top:    math.Max(r.Bottom, r.Top),
+	}
+}
@@ -0,0 +1,327 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package annotation
+
+import (
+	"errors"
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/AletheiaWareLLC/pdfgo/graphics"
+	"math"
+)
+
+// LineEnding is the shape drawn at the end of a line.
+type LineEnding int
+
+const (
+	NoEnding LineEnding = iota
+	SquareEnding
+	CircleEnding
+	OpenArrowEnding
+	ClosedArrowEnding
+)
+
+func (e LineEnding) String() string {
+	switch e {
+	case SquareEnding:
+		return "Square"
+	case CircleEnding:
+		return "Circle"
+	case OpenArrowEnding:
+		return "OpenArrow"
+	case ClosedArrowEnding:
+		return "ClosedArrow"
+	}
+	return "None"
+}
+
+// Square is a rectangle drawn within its bounds, filled with the interior colour if not nil.
+type Square struct {
+	Markup
+	graphics.Rectangle
+	InteriorColour []float64
+}
+
+// Add adds the square annotation to the page.
+func (s *Square) Add(p *pdfgo.PDF) (*pdfgo.DictionaryObject, error) {
+	return s.addShape(p, "Square", normalize(s.Rectangle), s.InteriorColour)
+}
+
+// Circle is an ellipse drawn within its bounds, filled with the interior colour if not nil.
+type Circle struct {
+	Markup
+	graphics.Rectangle
+	InteriorColour []float64
+}
+
+// Add adds the circle annotation to the page.
+func (c *Circle) Add(p *pdfgo.PDF) (*pdfgo.DictionaryObject, error) {
+	return c.addShape(p, "Circle", normalize(c.Rectangle), c.InteriorColour)
+}
+
+func (m *Markup) addShape(p *pdfgo.PDF, subtype string, rect *graphics.Rectangle, interior []float64) (*pdfgo.DictionaryObject, error) {
+	colour := m.colour(BLACK)
+	d, err := m.newAnnotation(p, subtype, rect, colour)
+	if err != nil {
+		return nil, err
+	}
+	m.border(d)
+	if interior != nil {
+		d.AddNameObjectEntry("IC", pdfgo.NewNumberArray(interior))
+	}
+	writer := graphics.NewContentWriter()
+	m.setStroke(writer, colour)
+	if interior != nil {
+		writer.SetFillColour(interior)
+	}
+	// The border is drawn inside the bounds
+	inset := m.BorderWidth / 2
+	left, bottom, right, top := rect.Left+inset, rect.Bottom+inset, rect.Right-inset, rect.Top-inset
+	if subtype == "Circle" {
+		x, y := (left+right)/2, (bottom+top)/2
+		rx, ry := (right-left)/2, (top-bottom)/2
+		writer.MoveTo(x+rx, y)
+		writer.CurveTo(x+rx, y+ry*graphics.KAPPA, x+rx*graphics.KAPPA, y+ry, x, y+ry)
+		writer.CurveTo(x-rx*graphics.KAPPA, y+ry, x-rx, y+ry*graphics.KAPPA, x-rx, y)
+		writer.CurveTo(x-rx, y-ry*graphics.KAPPA, x-rx*graphics.KAPPA, y-ry, x, y-ry)
+		writer.CurveTo(x+rx*graphics.KAPPA, y-ry, x+rx, y-ry*graphics.KAPPA, x+rx, y)
+		writer.ClosePath()
+	} else {
+		writer.Rectangle(left, bottom, right-left, top-bottom)
+	}
+	paint(writer, interior, m.BorderWidth)
+	if err := setAppearance(p, d, rect, writer, nil); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Line is a straight line between two points, with optional shapes at either end.
+type Line struct {
+	Markup
+	X1, Y1, X2, Y2 float64
+	Start, End     LineEnding
+	// InteriorColour fills closed line endings if not nil
+	InteriorColour []float64
+}
+
+// Add adds the line annotation to the page.
+func (l *Line) Add(p *pdfgo.PDF) (*pdfgo.DictionaryObject, error) {
+	points := []float64{l.X1, l.Y1, l.X2, l.Y2}
+	if l.X1 == l.X2 && l.Y1 == l.Y2 {
+		return nil, errors.New("Line requires two distinct points")
+	}
+	return l.addPath(p, "Line", points, false, l.Start, l.End, l.InteriorColour)
+}
+
+// Polygon is a closed shape of straight sides, filled with the interior colour if not nil.
+type Polygon struct {
+	Markup
+	// Vertices holds the coordinates of the corners, in pairs
+	Vertices       []float64
+	InteriorColour []float64
+}
+
+// Add adds the polygon annotation to the page.
+func (g *Polygon) Add(p *pdfgo.PDF) (*pdfgo.DictionaryObject, error) {
+	if len(g.Vertices) < 6 || len(g.Vertices)%2 != 0 {
+		return nil, errors.New("Polygon requires at least three vertices")
+	}
+	return g.addPath(p, "Polygon", g.Vertices, true, NoEnding, NoEnding, g.InteriorColour)
+}
+
+// PolyLine is an open path of straight lines, with optional shapes at either end.
+type PolyLine struct {
+	Markup
+	// Vertices holds the coordinates of the points, in pairs
+	Vertices   []float64
+	Start, End LineEnding
+	// InteriorColour fills closed line endings if not nil
+	InteriorColour []float64
+}
+
+// Add adds the polyline annotation to the page.
+func (l *PolyLine) Add(p *pdfgo.PDF) (*pdfgo.DictionaryObject, error) {
+	if len(l.Vertices) < 4 || len(l.Vertices)%2 != 0 {
+		return nil, errors.New("PolyLine requires at least two vertices")
+	}
+	return l.addPath(p, "PolyLine", l.Vertices, false, l.Start, l.End, l.InteriorColour)
+}
+
+func (m *Markup) addPath(p *pdfgo.PDF, subtype string, points []float64, closed bool, start, end LineEnding, interior []float64) (*pdfgo.DictionaryObject, error) {
+	size := endingSize(m.BorderWidth)
+	margin := m.BorderWidth
+	if start != NoEnding || end != NoEnding {
+		margin += size
+	}
+	rect := bounds(points, margin)
+	colour := m.colour(BLACK)
+	d, err := m.newAnnotation(p, subtype, rect, colour)
+	if err != nil {
+		return nil, err
+	}
+	m.border(d)
+	if subtype == "Line" {
+		d.AddNameObjectEntry("L", pdfgo.NewNumberArray(points))
+	} else {
+		d.AddNameObjectEntry("Vertices", pdfgo.NewNumberArray(points))
+	}
+	if !closed {
+		d.AddNameObjectEntry("LE", &pdfgo.ArrayObject{
+			Array: []pdfgo.Object{
+				&pdfgo.NameObject{Name: start.String()},
+				&pdfgo.NameObject{Name: end.String()},
+			},
+		})
+	}
+	if interior != nil {
+		d.AddNameObjectEntry("IC", pdfgo.NewNumberArray(interior))
+	}
+
+	writer := graphics.NewContentWriter()
+	m.setStroke(writer, colour)
+	if interior != nil {
+		writer.SetFillColour(interior)
+	}
+	writer.SetLineJoin(graphics.RoundJoin)
+	writer.MoveTo(points[0], points[1])
+	for i := 2; i+1 < len(points); i += 2 {
+		writer.LineTo(points[i], points[i+1])
+	}
+	if closed {
+		writer.ClosePath()
+		paint(writer, interior, m.BorderWidth)
+	} else {
+		writer.Stroke()
+		// Endings are drawn solid
+		if len(m.Dash) > 0 {
+			writer.SetDash(nil, 0)
+		}
+		n := len(points)
+		drawEnding(writer, start, points[0], points[1], points[0]-points[2], points[1]-points[3], size, interior, m.BorderWidth)
+		drawEnding(writer, end, points[n-2], points[n-1], points[n-2]-points[n-4], points[n-1]-points[n-3], size, interior, m.BorderWidth)
+	}
+	if err := setAppearance(p, d, rect, writer, nil); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// endingSize returns the size of the line endings of lines of the given width.
+func endingSize(width float64) float64 {
+	return 5 * math.Max(width, 1)
+}
+
+// drawEnding draws the given line ending at the given point, for a line heading in the given direction.
+func drawEnding(writer *graphics.ContentWriter, ending LineEnding, x, y, dx, dy, size float64, interior []float64, width float64) {
+	length := math.Hypot(dx, dy)
+	if length == 0 {
+		return
+	}
+	dx, dy = dx/length, dy/length
+	switch ending {
+	case SquareEnding:
+		writer.Rectangle(x-size/2, y-size/2, size, size)
+		paint(writer, interior, width)
+	case CircleEnding:
+		r := size / 2
+		writer.MoveTo(x+r, y)
+		writer.CurveTo(x+r, y+r*graphics.KAPPA, x+r*graphics.KAPPA, y+r, x, y+r)
+		writer.CurveTo(x-r*graphics.KAPPA, y+r, x-r, y+r*graphics.KAPPA, x-r, y)
+		writer.CurveTo(x-r, y-r*graphics.KAPPA, x-r*graphics.KAPPA, y-r, x, y-r)
+		writer.CurveTo(x+r*graphics.KAPPA, y-r, x+r, y-r*graphics.KAPPA, x+r, y)
+		writer.ClosePath()
+		paint(writer, interior, width)
+	case OpenArrowEnding, ClosedArrowEnding:
+		// The sides of the arrow head are 30 degrees either side of the line
+		sin, cos := math.Sincos(math.Pi / 6)
+		x1, y1 := x-size*(dx*cos-dy*sin), y-size*(dx*sin+dy*cos)
+		x2, y2 := x-size*(dx*cos+dy*sin), y-size*(-dx*sin+dy*cos)
+		writer.MoveTo(x1, y1)
+		writer.LineTo(x, y)
+		writer.LineTo(x2, y2)
+		if ending == ClosedArrowEnding {
+			writer.ClosePath()
+			paint(writer, interior, width)
+		} else {
+			writer.Stroke()
+		}
+	}
+}
+
+// paint fills the current path if the interior colour, which is set before the path, is not nil, and strokes it if the border has a width.
+func paint(writer *graphics.ContentWriter, interior []float64, width float64) {
+	switch {
+	case interior != nil && width > 0:
+		writer.FillStroke()
+	case interior != nil:
+		writer.Fill()
+	case width > 0:
+		writer.Stroke()
+	default:
+		writer.EndPath()
+	}
+}
+
+// Ink is a freehand drawing of one or more paths.
+type Ink struct {
+	Markup
+	// Paths holds the coordinates of the points of each path, in pairs
+	Paths [][]float64
+}
+
+// Add adds the ink annotation to the page.
+func (i *Ink) Add(p *pdfgo.PDF) (*pdfgo.DictionaryObject, error) {
+	var points []float64
+	list := &pdfgo.ArrayObject{}
+	for _, path := range i.Paths {
+		if len(path) < 2 || len(path)%2 != 0 {
+			return nil, errors.New("Ink path requires pairs of coordinates")
+		}
+		points = append(points, path...)
+		list.Array = append(list.Array, pdfgo.NewNumberArray(path))
+	}
+	if len(points) == 0 {
+		return nil, errors.New("Ink requires a path")
+	}
+	rect := bounds(points, i.BorderWidth)
+	colour := i.colour(BLACK)
+	d, err := i.newAnnotation(p, "Ink", rect, colour)
+	if err != nil {
+		return nil, err
+	}
+	i.border(d)
+	d.AddNameObjectEntry("InkList", list)
+	writer := graphics.NewContentWriter()
+	i.setStroke(writer, colour)
+	writer.SetLineCap(graphics.RoundCap)
+	writer.SetLineJoin(graphics.RoundJoin)
+	for _, path := range i.Paths {
+		writer.MoveTo(path[0], path[1])
+		if len(path) == 2 {
+			// A single point is drawn as a dot by the round cap
+			writer.LineTo(path[0], path[1])
+		}
+		for j := 2; j+1 < len(path); j += 2 {
+			writer.LineTo(path[j], path[j+1])
+		}
+	}
+	writer.Stroke()
+	if err := setAppearance(p, d, rect, writer, nil); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
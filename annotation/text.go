@@ -0,0 +1,446 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package annotation
+
+import (
+	"errors"
+	"fmt"
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/AletheiaWareLLC/pdfgo/font"
+	"github.com/AletheiaWareLLC/pdfgo/graphics"
+	"math"
+	"strings"
+	"unicode"
+)
+
+const (
+	// ICON_SIZE is the width and height of the icons of text annotations
+	ICON_SIZE = 20
+	// POPUP_WIDTH and POPUP_HEIGHT are the default size of pop-up windows
+	POPUP_WIDTH  = 180
+	POPUP_HEIGHT = 120
+	// TEXT_PADDING separates text from the border of free text and stamp annotations
+	TEXT_PADDING = 4
+	// DEFAULT_FONT is the standard font used by free text annotations if none is given
+	DEFAULT_FONT = "Helvetica"
+	// DEFAULT_FONT_SIZE is used by free text annotations if no size is given
+	DEFAULT_FONT_SIZE = 12
+)
+
+// Text is a note shown as an icon, whose contents are shown in a pop-up window.
+type Text struct {
+	Markup
+	// X and Y are the bottom left corner of the icon
+	X, Y float64
+	// Icon names the icon, such as Comment, Help, Key, or Note, which is the default
+	Icon string
+	// Open shows the pop-up window initially
+	Open bool
+	// Popup is the area of the pop-up window, which is beside the icon if nil
+	Popup *graphics.Rectangle
+}
+
+// Add adds the text annotation and its pop-up to the page.
+func (t *Text) Add(p *pdfgo.PDF) (*pdfgo.DictionaryObject, error) {
+	rect := &graphics.Rectangle{
+		Left:   t.X,
+		Bottom: t.Y,
+		Right:  t.X + ICON_SIZE,
+		Top:    t.Y + ICON_SIZE,
+	}
+	colour := t.colour(YELLOW)
+	d, err := t.newAnnotation(p, "Text", rect, colour)
+	if err != nil {
+		return nil, err
+	}
+	icon := t.Icon
+	if icon == "" {
+		icon = "Note"
+	}
+	d.AddNameNameEntry("Name", icon)
+	d.AddNameObjectEntry("Open", &pdfgo.BooleanObject{
+		Boolean: t.Open,
+	})
+
+	// Draw a sheet of paper with a folded corner and lines of writing
+	fold := ICON_SIZE / 4.
+	writer := graphics.NewContentWriter()
+	writer.SetFillColour(colour)
+	writer.SetStrokeGray(0)
+	writer.SetLineWidth(0.5)
+	writer.SetLineJoin(graphics.RoundJoin)
+	writer.MoveTo(rect.Left+1, rect.Bottom+1)
+	writer.LineTo(rect.Right-1, rect.Bottom+1)
+	writer.LineTo(rect.Right-1, rect.Top-1-fold)
+	writer.LineTo(rect.Right-1-fold, rect.Top-1)
+	writer.LineTo(rect.Left+1, rect.Top-1)
+	writer.ClosePath()
+	writer.FillStroke()
+	writer.MoveTo(rect.Right-1-fold, rect.Top-1)
+	writer.LineTo(rect.Right-1-fold, rect.Top-1-fold)
+	writer.LineTo(rect.Right-1, rect.Top-1-fold)
+	writer.Stroke()
+	for i := 1; i <= 3; i++ {
+		y := rect.Bottom + 1 + float64(i)*(ICON_SIZE-2-fold)/4
+		writer.MoveTo(rect.Left+4, y)
+		writer.LineTo(rect.Right-4, y)
+	}
+	writer.Stroke()
+	if err := setAppearance(p, d, rect, writer, nil); err != nil {
+		return nil, err
+	}
+
+	popup := t.Popup
+	if popup == nil {
+		popup = &graphics.Rectangle{
+			Left:   rect.Right,
+			Bottom: rect.Top - POPUP_HEIGHT,
+			Right:  rect.Right + POPUP_WIDTH,
+			Top:    rect.Top,
+		}
+	}
+	window := p.NewDictionaryObject()
+	window.AddNameNameEntry("Type", "Annot")
+	window.AddNameNameEntry("Subtype", "Popup")
+	window.AddNameObjectEntry("Rect", pdfgo.NewRectangleArray(popup.Left, popup.Bottom, popup.Right, popup.Top))
+	window.AddNameObjectEntry("Parent", pdfgo.NewObjectReference(d))
+	window.AddNameObjectEntry("Open", &pdfgo.BooleanObject{
+		Boolean: t.Open,
+	})
+	p.AddPageAnnotation(t.Page, window)
+	d.AddNameObjectEntry("Popup", pdfgo.NewObjectReference(window))
+	return d, nil
+}
+
+// TextMarkupType distinguishes the ways of marking up text.
+type TextMarkupType int
+
+const (
+	Highlight TextMarkupType = iota
+	Underline
+	StrikeOut
+	Squiggly
+)
+
+func (t TextMarkupType) String() string {
+	switch t {
+	case Underline:
+		return "Underline"
+	case StrikeOut:
+		return "StrikeOut"
+	case Squiggly:
+		return "Squiggly"
+	}
+	return "Highlight"
+}
+
+// TextMarkup highlights, underlines, strikes out, or underlines with a squiggle the text in the given areas, such as the lines of a sentence.
+type TextMarkup struct {
+	Markup
+	Type  TextMarkupType
+	Areas []*graphics.Rectangle
+}
+
+// Add adds the text markup annotation to the page.
+func (t *TextMarkup) Add(p *pdfgo.PDF) (*pdfgo.DictionaryObject, error) {
+	if len(t.Areas) == 0 {
+		return nil, errors.New("Text markup requires an area")
+	}
+	rect := graphics.NegativeRectangle()
+	quads := &pdfgo.ArrayObject{}
+	for _, a := range t.Areas {
+		a = normalize(*a)
+		rect = rect.Max(a)
+		// Quadrilaterals are given by their top left, top right, bottom left, and bottom right corners
+		quads.Array = append(quads.Array, pdfgo.NewNumberArray([]float64{a.Left, a.Top, a.Right, a.Top, a.Left, a.Bottom, a.Right, a.Bottom}).Array...)
+	}
+	fallback := BLACK
+	if t.Type == Highlight {
+		fallback = YELLOW
+	}
+	colour := t.colour(fallback)
+	d, err := t.newAnnotation(p, t.Type.String(), rect, colour)
+	if err != nil {
+		return nil, err
+	}
+	d.AddNameObjectEntry("QuadPoints", quads)
+
+	writer := graphics.NewContentWriter()
+	var resources *pdfgo.DictionaryObject
+	switch t.Type {
+	case Highlight:
+		// Multiply the colour with the page so the text remains legible
+		state := pdfgo.NewDictionary()
+		state.AddNameNameEntry("Type", "ExtGState")
+		state.AddNameNameEntry("BM", "Multiply")
+		states := pdfgo.NewDictionary()
+		states.AddNameObjectEntry("GS1", state)
+		resources = pdfgo.NewDictionary()
+		resources.AddNameObjectEntry("ExtGState", states)
+		writer.SetGraphicsState("GS1")
+		writer.SetFillColour(colour)
+		for _, a := range t.Areas {
+			a = normalize(*a)
+			writer.Rectangle(a.Left, a.Bottom, a.DX(), a.DY())
+		}
+		writer.Fill()
+	default:
+		writer.SetStrokeColour(colour)
+		for _, a := range t.Areas {
+			a = normalize(*a)
+			width := math.Max(a.DY()/16, 0.5)
+			writer.SetLineWidth(width)
+			switch t.Type {
+			case Underline:
+				writer.MoveTo(a.Left, a.Bottom+width)
+				writer.LineTo(a.Right, a.Bottom+width)
+			case StrikeOut:
+				y := a.Bottom + a.DY()*0.4
+				writer.MoveTo(a.Left, y)
+				writer.LineTo(a.Right, y)
+			case Squiggly:
+				step := 2 * width
+				up := false
+				writer.MoveTo(a.Left, a.Bottom+width)
+				for x := a.Left + step; x < a.Right+step; x += step {
+					y := a.Bottom + width
+					if up {
+						y += 2 * width
+					}
+					writer.LineTo(math.Min(x, a.Right), y)
+					up = !up
+				}
+			}
+			writer.Stroke()
+		}
+	}
+	if err := setAppearance(p, d, rect, writer, resources); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// FreeText shows its contents directly on the page, in a box.
+// Colour fills the background of the box if not nil, and the border is drawn in the colour of the text.
+type FreeText struct {
+	Markup
+	graphics.Rectangle
+	// FontName is a standard font, or Helvetica if empty
+	FontName   string
+	FontSize   float64
+	FontColour []float64
+	Align      graphics.Alignment
+}
+
+// Add adds the free text annotation to the page.
+func (t *FreeText) Add(p *pdfgo.PDF) (*pdfgo.DictionaryObject, error) {
+	name := t.FontName
+	if name == "" {
+		name = DEFAULT_FONT
+	}
+	if !font.IsStandardFont(name) {
+		return nil, fmt.Errorf("Unsupported Font: %s", name)
+	}
+	size := t.FontSize
+	if size <= 0 {
+		size = DEFAULT_FONT_SIZE
+	}
+	textColour := t.FontColour
+	if textColour == nil {
+		textColour = BLACK
+	}
+	rect := normalize(t.Rectangle)
+	d, err := t.newAnnotation(p, "FreeText", rect, t.Colour)
+	if err != nil {
+		return nil, err
+	}
+	t.border(d)
+	writer := graphics.NewContentWriter()
+	writer.SetFont("F1", size)
+	writer.SetFillColour(textColour)
+	data, err := writer.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	d.AddNameObjectEntry("DA", &pdfgo.StringObject{
+		String: strings.ReplaceAll(string(data), "\n", " "),
+	})
+	quadding := 0
+	switch t.Align {
+	case graphics.Center, graphics.JustifiedCenter:
+		quadding = 1
+	case graphics.Right, graphics.JustifiedRight:
+		quadding = 2
+	}
+	d.AddNameObjectEntry("Q", &pdfgo.NumberObject{
+		Number: float64(quadding),
+	})
+
+	writer = graphics.NewContentWriter()
+	if t.Colour != nil {
+		writer.SetFillColour(t.Colour)
+		writer.Rectangle(rect.Left, rect.Bottom, rect.DX(), rect.DY())
+		writer.Fill()
+	}
+	if t.BorderWidth > 0 {
+		writer.SaveState()
+		t.setStroke(writer, textColour)
+		inset := t.BorderWidth / 2
+		writer.Rectangle(rect.Left+inset, rect.Bottom+inset, rect.DX()-t.BorderWidth, rect.DY()-t.BorderWidth)
+		writer.Stroke()
+		writer.RestoreState()
+	}
+	padding := t.BorderWidth + TEXT_PADDING
+	writer.SaveState()
+	writer.Rectangle(rect.Left+t.BorderWidth, rect.Bottom+t.BorderWidth, rect.DX()-2*t.BorderWidth, rect.DY()-2*t.BorderWidth)
+	writer.Clip()
+	writer.EndPath()
+	writer.BeginText()
+	writer.SetFont("F1", size)
+	writer.SetFillColour(textColour)
+	lines := graphics.Wrap(t.Contents, rect.DX()-2*padding, func(line string) float64 {
+		return measure(name, line, size)
+	})
+	y := rect.Top - padding - graphics.ASCENT*size
+	for _, line := range lines {
+		width := measure(name, line, size)
+		x := rect.Left + padding
+		switch quadding {
+		case 1:
+			x = rect.Left + (rect.DX()-width)/2
+		case 2:
+			x = rect.Right - padding - width
+		}
+		writer.SetTextMatrix(1, 0, 0, 1, x, y)
+		writer.ShowText(encode(line))
+		y -= size * (graphics.ASCENT - graphics.DESCENT)
+	}
+	writer.EndText()
+	writer.RestoreState()
+	if err := setAppearance(p, d, rect, writer, fontResources(name)); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Stamp is a rubber stamp, such as Approved or Draft, drawn as its name in capitals in a rounded box.
+type Stamp struct {
+	Markup
+	graphics.Rectangle
+	// Name is one of the standard names, such as Approved, AsIs, Confidential, Departmental, Draft, Experimental, Expired, Final, ForComment, ForPublicRelease, NotApproved, NotForPublicRelease, Sold, or TopSecret
+	Name string
+}
+
+// Add adds the stamp annotation to the page.
+func (s *Stamp) Add(p *pdfgo.PDF) (*pdfgo.DictionaryObject, error) {
+	if s.Name == "" {
+		return nil, errors.New("Stamp requires a name")
+	}
+	rect := normalize(s.Rectangle)
+	colour := s.colour(RED)
+	d, err := s.newAnnotation(p, "Stamp", rect, colour)
+	if err != nil {
+		return nil, err
+	}
+	d.AddNameNameEntry("Name", s.Name)
+
+	// Split the name into capitalised words
+	var label []rune
+	for i, r := range s.Name {
+		if i > 0 && unicode.IsUpper(r) {
+			label = append(label, ' ')
+		}
+		label = append(label, unicode.ToUpper(r))
+	}
+	text := string(label)
+
+	const name = "Helvetica-Bold"
+	width := rect.DX() - 2*(s.BorderWidth+TEXT_PADDING)
+	size := math.Max(rect.DY()*0.6, 1)
+	if w := measure(name, text, size); w > width && w > 0 {
+		size *= width / w
+	}
+	writer := graphics.NewContentWriter()
+	writer.SetFillColour(colour)
+	if s.BorderWidth > 0 {
+		s.setStroke(writer, colour)
+		inset := s.BorderWidth / 2
+		roundedRectangle(writer, rect.Left+inset, rect.Bottom+inset, rect.Right-inset, rect.Top-inset, math.Min(rect.DX(), rect.DY())/6)
+		writer.Stroke()
+	}
+	writer.BeginText()
+	writer.SetFont("F1", size)
+	writer.SetTextMatrix(1, 0, 0, 1, rect.Left+(rect.DX()-measure(name, text, size))/2, rect.Bottom+(rect.DY()-size*(graphics.ASCENT+graphics.DESCENT))/2)
+	writer.ShowText(encode(text))
+	writer.EndText()
+	if err := setAppearance(p, d, rect, writer, fontResources(name)); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// roundedRectangle adds a rectangle with corners rounded to the given radius to the path.
+func roundedRectangle(writer *graphics.ContentWriter, left, bottom, right, top, radius float64) {
+	k := radius * (1 - graphics.KAPPA)
+	writer.MoveTo(left+radius, bottom)
+	writer.LineTo(right-radius, bottom)
+	writer.CurveTo(right-k, bottom, right, bottom+k, right, bottom+radius)
+	writer.LineTo(right, top-radius)
+	writer.CurveTo(right, top-k, right-k, top, right-radius, top)
+	writer.LineTo(left+radius, top)
+	writer.CurveTo(left+k, top, left, top-k, left, top-radius)
+	writer.LineTo(left, bottom+radius)
+	writer.CurveTo(left, bottom+k, left+k, bottom, left+radius, bottom)
+	writer.ClosePath()
+}
+
+// fontResources returns resources holding the named standard font as F1, with WinAnsiEncoding.
+func fontResources(name string) *pdfgo.DictionaryObject {
+	f := pdfgo.NewDictionary()
+	f.AddNameNameEntry("Type", "Font")
+	f.AddNameNameEntry("Subtype", "Type1")
+	f.AddNameNameEntry("BaseFont", name)
+	f.AddNameNameEntry("Encoding", "WinAnsiEncoding")
+	fonts := pdfgo.NewDictionary()
+	fonts.AddNameObjectEntry("F1", f)
+	resources := pdfgo.NewDictionary()
+	resources.AddNameObjectEntry("Font", fonts)
+	return resources
+}
+
+// encode returns the given text in WinAnsiEncoding, escaped for a content stream, replacing characters which can't be encoded with question marks.
+func encode(text string) string {
+	encoding := font.GetEncoding("WinAnsiEncoding")
+	var codes []byte
+	for _, r := range text {
+		c, ok := encoding.Encode(r)
+		if !ok {
+			c = '?'
+		}
+		codes = append(codes, c)
+	}
+	return pdfgo.EscapeString(string(codes))
+}
+
+// measure returns the width of the given text in the named standard font, at the given size.
+func measure(name, text string, size float64) float64 {
+	var width float64
+	for _, r := range text {
+		width += font.StandardFontWidth(name, r)
+	}
+	return width * size / 1000
+}
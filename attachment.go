@@ -0,0 +1,272 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pdfgo
+
+import (
+	"bytes"
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Relationships of associated files to the document, as given by AFRelationship
+const (
+	AF_SOURCE      = "Source"
+	AF_DATA        = "Data"
+	AF_ALTERNATIVE = "Alternative"
+	AF_SUPPLEMENT  = "Supplement"
+	AF_UNSPECIFIED = "Unspecified"
+)
+
+// Attachment is a file embedded in a document.
+type Attachment struct {
+	// Name is the file name of the attachment
+	Name        string
+	Description string
+	// MimeType is the media type of the data, such as text/csv
+	MimeType string
+	Data     []byte
+	// Created and Modified are omitted if zero
+	Created  time.Time
+	Modified time.Time
+	// Relationship associates the file with the document if not empty, such as AF_SOURCE or AF_DATA
+	Relationship string
+}
+
+// NewFileSpecification adds the given attachment to the document as an embedded file stream, and returns a file specification of it.
+func (p *PDF) NewFileSpecification(a *Attachment) (*DictionaryObject, error) {
+	if a.Name == "" {
+		return nil, errors.New("Attachment requires a name")
+	}
+	data, err := FlateEncode(a.Data)
+	if err != nil {
+		return nil, err
+	}
+	s := p.NewStreamObject()
+	s.Data = data
+	s.AddNameNameEntry("Type", "EmbeddedFile")
+	if a.MimeType != "" {
+		s.AddNameNameEntry("Subtype", a.MimeType)
+	}
+	s.AddNameNameEntry("Filter", "FlateDecode")
+	params := &DictionaryObject{
+		Dictionary: make(map[*NameObject]Object),
+	}
+	params.AddNameObjectEntry("Size", &NumberObject{
+		Number: float64(len(a.Data)),
+	})
+	sum := md5.Sum(a.Data)
+	params.AddNameObjectEntry("CheckSum", &StringObject{
+		String: EscapeString(string(sum[:])),
+	})
+	if !a.Created.IsZero() {
+		params.AddNameObjectEntry("CreationDate", NewDateString(a.Created))
+	}
+	if !a.Modified.IsZero() {
+		params.AddNameObjectEntry("ModDate", NewDateString(a.Modified))
+	}
+	s.AddNameObjectEntry("Params", params)
+
+	spec := p.NewDictionaryObject()
+	spec.AddNameNameEntry("Type", "Filespec")
+	// F is limited to bytes, so holds an ASCII approximation of the name, which is given in full by UF
+	var ascii []byte
+	for _, r := range a.Name {
+		if r < 0x20 || r >= 0x7F {
+			r = '_'
+		}
+		ascii = append(ascii, byte(r))
+	}
+	spec.AddNameObjectEntry("F", &StringObject{
+		String: EscapeString(string(ascii)),
+	})
+	spec.AddNameObjectEntry("UF", NewTextString(a.Name))
+	if a.Description != "" {
+		spec.AddNameObjectEntry("Desc", NewTextString(a.Description))
+	}
+	ef := &DictionaryObject{
+		Dictionary: make(map[*NameObject]Object),
+	}
+	ef.AddNameObjectEntry("F", NewObjectReference(s))
+	ef.AddNameObjectEntry("UF", NewObjectReference(s))
+	spec.AddNameObjectEntry("EF", ef)
+	if a.Relationship != "" {
+		spec.AddNameNameEntry("AFRelationship", a.Relationship)
+	}
+	return spec, nil
+}
+
+// AddAttachment embeds the given attachment in the document, adding it to the EmbeddedFiles name tree, and to the associated files of the document if it has a relationship.
+// Attachments with the same name are given distinct keys in the name tree.
+// The objects which were changed are returned so they can be written as an incremental update.
+func (p *PDF) AddAttachment(a *Attachment) ([]Object, error) {
+	spec, err := p.NewFileSpecification(a)
+	if err != nil {
+		return nil, err
+	}
+	changed := []Object{p.Catalog}
+	names, ok := Resolve(p.Catalog.GetEntry("Names")).(*DictionaryObject)
+	if !ok {
+		names = p.NewDictionaryObject()
+		p.Catalog.SetNameObjectEntry("Names", NewObjectReference(names))
+	}
+	changed = append(changed, names)
+	tree, ok := Resolve(names.GetEntry("EmbeddedFiles")).(*DictionaryObject)
+	if !ok {
+		tree = p.NewDictionaryObject()
+		names.SetNameObjectEntry("EmbeddedFiles", NewObjectReference(tree))
+	}
+	changed = append(changed, tree)
+
+	entries := make(map[string]Object)
+	for _, e := range NameTreeEntries(tree) {
+		entries[e.Name] = e.Value
+	}
+	key := a.Name
+	for i := 2; entries[key] != nil; i++ {
+		key = a.Name + " (" + strconv.Itoa(i) + ")"
+	}
+	entries[key] = NewObjectReference(spec)
+	p.setNameTree(tree, entries)
+
+	if a.Relationship != "" {
+		af, ok := Resolve(p.Catalog.GetEntry("AF")).(*ArrayObject)
+		if !ok {
+			af = &ArrayObject{}
+			p.Catalog.SetNameObjectEntry("AF", af)
+		} else if af.GetName() > 0 {
+			changed = append(changed, af)
+		}
+		af.Array = append(af.Array, NewObjectReference(spec))
+	}
+	var result []Object
+	for _, o := range changed {
+		if o.GetName() > 0 {
+			result = append(result, o)
+		}
+	}
+	return result, nil
+}
+
+// GetAttachments returns the files embedded in the document through the EmbeddedFiles name tree, in order of their keys.
+// The attachments of FileAttachment annotations are returned by PageAttachments.
+func (p *PDF) GetAttachments() ([]*Attachment, error) {
+	names, ok := Resolve(p.Catalog.GetEntry("Names")).(*DictionaryObject)
+	if !ok {
+		return nil, nil
+	}
+	tree, ok := Resolve(names.GetEntry("EmbeddedFiles")).(*DictionaryObject)
+	if !ok {
+		return nil, nil
+	}
+	var attachments []*Attachment
+	for _, e := range NameTreeEntries(tree) {
+		a, err := ReadAttachment(e.Value)
+		if err != nil {
+			return nil, fmt.Errorf("Attachment %s: %s", e.Name, err)
+		}
+		attachments = append(attachments, a)
+	}
+	return attachments, nil
+}
+
+// PageAttachments returns the files attached to the given page by FileAttachment annotations.
+func PageAttachments(page *DictionaryObject) ([]*Attachment, error) {
+	annots, ok := Resolve(page.GetEntry("Annots")).(*ArrayObject)
+	if !ok {
+		return nil, nil
+	}
+	var attachments []*Attachment
+	for _, o := range annots.Array {
+		d, ok := Resolve(o).(*DictionaryObject)
+		if !ok {
+			continue
+		}
+		if t, ok := Resolve(d.GetEntry("Subtype")).(*NameObject); !ok || t.Name != "FileAttachment" {
+			continue
+		}
+		a, err := ReadAttachment(d.GetEntry("FS"))
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+	return attachments, nil
+}
+
+// ReadAttachment extracts the embedded file of the given file specification, verifying its checksum if it has one.
+func ReadAttachment(spec Object) (*Attachment, error) {
+	d, ok := Resolve(spec).(*DictionaryObject)
+	if !ok {
+		return nil, errors.New("Invalid File Specification")
+	}
+	a := &Attachment{}
+	for _, key := range []string{"UF", "F"} {
+		if s, ok := Resolve(d.GetEntry(key)).(*StringObject); ok {
+			a.Name = s.Text()
+			break
+		}
+	}
+	if s, ok := Resolve(d.GetEntry("Desc")).(*StringObject); ok {
+		a.Description = s.Text()
+	}
+	if r, ok := Resolve(d.GetEntry("AFRelationship")).(*NameObject); ok {
+		a.Relationship = r.Name
+	}
+	ef, ok := Resolve(d.GetEntry("EF")).(*DictionaryObject)
+	if !ok {
+		return nil, errors.New("File Specification has no embedded file")
+	}
+	var stream *StreamObject
+	for _, key := range []string{"UF", "F"} {
+		if stream, ok = Resolve(ef.GetEntry(key)).(*StreamObject); ok {
+			break
+		}
+	}
+	if stream == nil {
+		return nil, errors.New("File Specification has no embedded file")
+	}
+	data, err := stream.Decode()
+	if err != nil {
+		return nil, err
+	}
+	a.Data = data
+	if t, ok := Resolve(stream.GetEntry("Subtype")).(*NameObject); ok {
+		a.MimeType = t.Name
+	}
+	if params, ok := Resolve(stream.GetEntry("Params")).(*DictionaryObject); ok {
+		if s, ok := Resolve(params.GetEntry("CheckSum")).(*StringObject); ok {
+			sum := md5.Sum(data)
+			if !bytes.Equal(sum[:], s.Bytes()) {
+				return nil, fmt.Errorf("Checksum mismatch: %s", a.Name)
+			}
+		}
+		for key, t := range map[string]*time.Time{
+			"CreationDate": &a.Created,
+			"ModDate":      &a.Modified,
+		} {
+			if s, ok := Resolve(params.GetEntry(key)).(*StringObject); ok {
+				if date, err := ParseDate(string(s.Bytes())); err == nil {
+					*t = date
+				}
+			}
+		}
+	}
+	return a, nil
+}
@@ -0,0 +1,103 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pdfgo_test
+
+import (
+	"bytes"
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestAddAttachment(t *testing.T) {
+	created := time.Date(2021, 2, 3, 4, 5, 6, 0, time.UTC)
+	source := pdfgo.NewPDF()
+	source.AddPage(200, 200, nil, nil)
+	_, err := source.AddAttachment(&pdfgo.Attachment{
+		Name:         "data.csv",
+		Description:  "Source data",
+		MimeType:     "text/csv",
+		Data:         []byte("a,b\n1,2\n"),
+		Created:      created,
+		Modified:     created,
+		Relationship: pdfgo.AF_SOURCE,
+	})
+	assert.Nil(t, err)
+	_, err = source.AddAttachment(&pdfgo.Attachment{
+		Name: "données.xml",
+		Data: []byte("<a/>"),
+	})
+	assert.Nil(t, err)
+	_, err = source.AddAttachment(&pdfgo.Attachment{})
+	assert.NotNil(t, err)
+	af := source.Catalog.GetEntry("AF").(*pdfgo.ArrayObject)
+	assert.Equal(t, 1, len(af.Array))
+
+	var buffer bytes.Buffer
+	assert.Nil(t, source.Write(&buffer))
+	original := append([]byte{}, buffer.Bytes()...)
+	p, err := pdfgo.ReadPDF(original)
+	assert.Nil(t, err)
+	attachments, err := p.GetAttachments()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(attachments))
+	csv := attachments[0]
+	assert.Equal(t, "data.csv", csv.Name)
+	assert.Equal(t, "Source data", csv.Description)
+	assert.Equal(t, "text/csv", csv.MimeType)
+	assert.Equal(t, "a,b\n1,2\n", string(csv.Data))
+	assert.True(t, created.Equal(csv.Created))
+	assert.True(t, created.Equal(csv.Modified))
+	assert.Equal(t, pdfgo.AF_SOURCE, csv.Relationship)
+	assert.Equal(t, "données.xml", attachments[1].Name)
+
+	// Attachments can be added in an incremental update, with distinct keys for the same name
+	changed, err := p.AddAttachment(&pdfgo.Attachment{
+		Name: "data.csv",
+		Data: []byte("c,d\n"),
+	})
+	assert.Nil(t, err)
+	buffer.Reset()
+	assert.Nil(t, p.WriteUpdate(&buffer, original, changed))
+	result, err := pdfgo.ReadPDF(buffer.Bytes())
+	assert.Nil(t, err)
+	attachments, err = result.GetAttachments()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(attachments))
+	assert.Equal(t, "c,d\n", string(attachments[1].Data))
+	names := pdfgo.Resolve(pdfgo.Resolve(result.Catalog.GetEntry("Names")).(*pdfgo.DictionaryObject).GetEntry("EmbeddedFiles")).(*pdfgo.DictionaryObject).GetEntry("Names").(*pdfgo.ArrayObject)
+	assert.Equal(t, "data.csv (2)", names.Array[2].(*pdfgo.StringObject).Text())
+}
+
+func TestReadAttachment_checksum(t *testing.T) {
+	p := pdfgo.NewPDF()
+	spec, err := p.NewFileSpecification(&pdfgo.Attachment{
+		Name: "data.txt",
+		Data: []byte("original"),
+	})
+	assert.Nil(t, err)
+	a, err := pdfgo.ReadAttachment(spec)
+	assert.Nil(t, err)
+	assert.Equal(t, "original", string(a.Data))
+
+	stream := pdfgo.Resolve(spec.GetEntry("EF").(*pdfgo.DictionaryObject).GetEntry("F")).(*pdfgo.StreamObject)
+	stream.RemoveEntry("Filter")
+	stream.Data = []byte("tampered")
+	_, err = pdfgo.ReadAttachment(spec)
+	assert.NotNil(t, err)
+}
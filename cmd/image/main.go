@@ -1,7 +1,6 @@
 package main
 
 import (
-	"bytes"
 	"github.com/AletheiaWareLLC/pdfgo"
 	"github.com/AletheiaWareLLC/pdfgo/graphics"
 	"io/ioutil"
@@ -13,7 +12,7 @@ import (
 var jpeg = flag.String("jpeg", "", "the jpeg to include")
 
 func main() {
-	flag.ParseFlags()
+	flag.Parse()
 
 	p := pdfgo.NewPDF()
 
@@ -22,15 +21,15 @@ func main() {
 	xs := p.NewDictionaryObject()
 	resources.AddNameObjectEntry("XObject", pdfgo.NewObjectReference(xs))
 
-	data, err := ioutil.ReadFile(*jpeg)
+	image, err := ioutil.ReadFile(*jpeg)
 	if err != nil {
 		log.Fatal(err)
 	}
-	ir, w, h, err := p.AddImage("image/jpg", data)
+	ir, w, h, err := p.AddImage("image/jpg", image)
 	if err != nil {
 		log.Fatal(err)
 	}
-	id := "/img"
+	id := "img"
 	xs.AddNameObjectEntry(id, ir)
 
 	// Create Contents
@@ -49,12 +48,16 @@ func main() {
 		log.Fatal(err)
 	}
 
-	var buffer bytes.Buffer
-	if err := box.Write(p, &buffer); err != nil {
+	cw := graphics.NewContentWriter()
+	if err := box.Write(p, cw); err != nil {
+		log.Fatal(err)
+	}
+	data, err := cw.Bytes()
+	if err != nil {
 		log.Fatal(err)
 	}
 	contents := p.NewStreamObject()
-	contents.Data = buffer.Bytes()
+	contents.Data = data
 
 	// Create Page
 	width := 400.0
@@ -0,0 +1,245 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/AletheiaWareLLC/pdfgo/acroform"
+	"github.com/AletheiaWareLLC/pdfgo/stamp"
+	"io/ioutil"
+	"log"
+	"mime"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	var err error
+	switch os.Args[1] {
+	case "flatten":
+		err = flattenCommand(os.Args[2:])
+	case "stamp":
+		err = stampCommand(os.Args[2:])
+	default:
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: pdfgo <command> [flags] <input> <output>")
+	fmt.Fprintln(os.Stderr, "Commands:")
+	fmt.Fprintln(os.Stderr, "  flatten - draw annotations and form fields into the content of their pages")
+	fmt.Fprintln(os.Stderr, "  stamp - overlay or underlay text, an image, or a page on existing pages")
+}
+
+func stampCommand(args []string) error {
+	flags := flag.NewFlagSet("stamp", flag.ExitOnError)
+	text := flags.String("text", "", "Text of the stamp")
+	fontName := flags.String("font", "Helvetica-Bold", "Standard font of the text")
+	size := flags.Float64("size", 72, "Font size of the text")
+	colour := flags.String("colour", "1,0,0", "Comma separated gray, RGB, or CMYK components of the text")
+	image := flags.String("image", "", "JPEG, PNG, or GIF file of the stamp")
+	page := flags.String("page", "", "PDF file containing the page of the stamp")
+	pageNumber := flags.Int("page-number", 1, "Number of the page of the stamp, counting from one")
+	pages := flags.String("pages", "", "Pages to stamp, such as 1,3-5; defaults to every page")
+	x := flags.Float64("x", 0.5, "Horizontal position of the centre of the stamp, as a fraction of the page width")
+	y := flags.Float64("y", 0.5, "Vertical position of the centre of the stamp, as a fraction of the page height")
+	rotation := flags.Float64("rotation", 0, "Anticlockwise rotation of the stamp in degrees")
+	opacity := flags.Float64("opacity", 1, "Opacity of the stamp, from 0 to 1")
+	scale := flags.Float64("scale", 1, "Scale of the stamp")
+	underlay := flags.Bool("underlay", false, "Draw the stamp under the existing content")
+	incremental := flags.Bool("incremental", false, "Append the changes to the input as an incremental update")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: pdfgo stamp [flags] <input> <output>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	input, p, err := readDocument(flags.Arg(0), *incremental)
+	if err != nil {
+		return err
+	}
+
+	var s *stamp.Stamp
+	switch {
+	case *text != "":
+		c, err := parseNumbers(*colour)
+		if err != nil {
+			return err
+		}
+		s, err = stamp.NewTextStamp(p, *text, *fontName, *size, c)
+		if err != nil {
+			return err
+		}
+	case *image != "":
+		data, err := ioutil.ReadFile(*image)
+		if err != nil {
+			return err
+		}
+		s, err = stamp.NewImageStamp(p, mime.TypeByExtension(filepath.Ext(*image)), data, 0, 0)
+		if err != nil {
+			return err
+		}
+	case *page != "":
+		data, err := ioutil.ReadFile(*page)
+		if err != nil {
+			return err
+		}
+		source, err := pdfgo.ReadPDF(data)
+		if err != nil {
+			return err
+		}
+		sources := source.GetPages()
+		if *pageNumber < 1 || *pageNumber > len(sources) {
+			return fmt.Errorf("Page Number Out of Range: %d", *pageNumber)
+		}
+		s, err = stamp.NewPageStamp(p, sources[*pageNumber-1])
+		if err != nil {
+			return err
+		}
+	default:
+		return errors.New("One of -text, -image, or -page is required")
+	}
+	s.X = *x
+	s.Y = *y
+	s.Rotation = *rotation
+	s.Opacity = *opacity
+	s.Scale = *scale
+	if *underlay {
+		s.Layer = stamp.Underlay
+	}
+
+	indices, err := stamp.ParsePageRanges(*pages, len(p.GetPages()))
+	if err != nil {
+		return err
+	}
+	changed, err := s.Apply(p, indices...)
+	if err != nil {
+		return err
+	}
+	return writeDocument(flags.Arg(1), p, input, changed, *incremental)
+}
+
+func flattenCommand(args []string) error {
+	flags := flag.NewFlagSet("flatten", flag.ExitOnError)
+	pages := flags.String("pages", "", "Pages to flatten the annotations of, such as 1,3-5; defaults to every page")
+	forms := flags.Bool("forms", true, "Flatten the form fields of the document")
+	incremental := flags.Bool("incremental", false, "Append the changes to the input as an incremental update")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: pdfgo flatten [flags] <input> <output>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	input, p, err := readDocument(flags.Arg(0), *incremental)
+	if err != nil {
+		return err
+	}
+	var changed []pdfgo.Object
+	if *forms {
+		if form, err := acroform.GetForm(p); err == nil {
+			c, err := form.Flatten()
+			if err != nil {
+				return err
+			}
+			changed = append(changed, c...)
+		}
+	}
+	all := p.GetPages()
+	indices, err := stamp.ParsePageRanges(*pages, len(all))
+	if err != nil {
+		return err
+	}
+	for _, i := range indices {
+		if err := p.FlattenAnnotations(all[i]); err != nil {
+			return err
+		}
+		changed = append(changed, all[i])
+	}
+	return writeDocument(flags.Arg(1), p, input, changed, *incremental)
+}
+
+// readDocument reads and, if necessary, repairs the given document, which can't then be updated incrementally.
+func readDocument(path string, incremental bool) ([]byte, *pdfgo.PDF, error) {
+	input, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	p, report, err := pdfgo.RepairPDF(input)
+	if err != nil {
+		return nil, nil, err
+	}
+	if report.Repaired() {
+		for _, m := range report.Messages {
+			log.Println("Repaired:", m)
+		}
+		if incremental {
+			return nil, nil, errors.New("Cannot update a document which needed repair")
+		}
+	}
+	return input, p, nil
+}
+
+// writeDocument writes the given document, or appends the changed objects to the input as an incremental update.
+func writeDocument(path string, p *pdfgo.PDF, input []byte, changed []pdfgo.Object, incremental bool) error {
+	var buffer bytes.Buffer
+	var err error
+	if incremental {
+		err = p.WriteUpdate(&buffer, input, changed)
+	} else {
+		err = p.Write(&buffer)
+	}
+	if err != nil {
+		return err
+	}
+	log.Println("Writing:", path)
+	return ioutil.WriteFile(path, buffer.Bytes(), 0644)
+}
+
+func parseNumbers(s string) ([]float64, error) {
+	var numbers []float64
+	for _, n := range strings.Split(s, ",") {
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid Number: %s", n)
+		}
+		numbers = append(numbers, f)
+	}
+	return numbers, nil
+}
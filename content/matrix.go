@@ -0,0 +1,56 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package content
+
+// Matrix is an affine transformation [a b c d e f], as used by the cm and Tm operators.
+type Matrix [6]float64
+
+// IdentityMatrix returns the matrix which leaves points unchanged.
+func IdentityMatrix() Matrix {
+	return Matrix{1, 0, 0, 1, 0, 0}
+}
+
+// TranslationMatrix returns the matrix which moves points by the given offset.
+func TranslationMatrix(x, y float64) Matrix {
+	return Matrix{1, 0, 0, 1, x, y}
+}
+
+// Multiply returns the matrix which applies m followed by n.
+func (m Matrix) Multiply(n Matrix) Matrix {
+	return Matrix{
+		m[0]*n[0] + m[1]*n[2],
+		m[0]*n[1] + m[1]*n[3],
+		m[2]*n[0] + m[3]*n[2],
+		m[2]*n[1] + m[3]*n[3],
+		m[4]*n[0] + m[5]*n[2] + n[4],
+		m[4]*n[1] + m[5]*n[3] + n[5],
+	}
+}
+
+// Transform returns the given point transformed by the matrix.
+func (m Matrix) Transform(x, y float64) (float64, float64) {
+	return m[0]*x + m[2]*y + m[4], m[1]*x + m[3]*y + m[5]
+}
+
+// MatrixOperands returns the matrix formed by the first six operands of the given operation.
+func MatrixOperands(o *Operation) Matrix {
+	var m Matrix
+	for i := range m {
+		m[i] = o.Number(i)
+	}
+	return m
+}
@@ -0,0 +1,151 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package content
+
+import (
+	"github.com/AletheiaWareLLC/pdfgo"
+	"io"
+)
+
+// Operation is a single operator in a content stream together with its operands.
+type Operation struct {
+	Operator string
+	Operands []pdfgo.Object
+	// ImageDictionary and ImageData hold the inline image of a BI operation
+	ImageDictionary *pdfgo.DictionaryObject
+	ImageData       []byte
+}
+
+func NewOperation(operator string, operands ...pdfgo.Object) *Operation {
+	return &Operation{
+		Operator: operator,
+		Operands: operands,
+	}
+}
+
+// Number returns the operand at the given index as a number, or zero if it is not a number.
+func (o *Operation) Number(index int) float64 {
+	if index < len(o.Operands) {
+		if n, ok := o.Operands[index].(*pdfgo.NumberObject); ok {
+			return n.Number
+		}
+	}
+	return 0
+}
+
+// Name returns the operand at the given index as a name, or an empty string if it is not a name.
+func (o *Operation) Name(index int) string {
+	if index < len(o.Operands) {
+		if n, ok := o.Operands[index].(*pdfgo.NameObject); ok {
+			return n.Name
+		}
+	}
+	return ""
+}
+
+func (o *Operation) Write(out io.Writer) (int, error) {
+	var count int
+	for _, operand := range o.Operands {
+		n, err := operand.Write(out)
+		if err != nil {
+			return 0, err
+		}
+		count += n
+		n, err = pdfgo.WriteS(out, " ")
+		if err != nil {
+			return 0, err
+		}
+		count += n
+	}
+	n, err := pdfgo.WriteS(out, o.Operator)
+	if err != nil {
+		return 0, err
+	}
+	count += n
+	if o.Operator == "BI" {
+		n, err = o.writeInlineImage(out)
+		if err != nil {
+			return 0, err
+		}
+		count += n
+	}
+	return count, nil
+}
+
+func (o *Operation) writeInlineImage(out io.Writer) (int, error) {
+	var count int
+	if o.ImageDictionary != nil {
+		for _, k := range o.ImageDictionary.Keys {
+			n, err := pdfgo.WriteS(out, " ")
+			if err != nil {
+				return 0, err
+			}
+			count += n
+			n, err = k.Write(out)
+			if err != nil {
+				return 0, err
+			}
+			count += n
+			n, err = pdfgo.WriteS(out, " ")
+			if err != nil {
+				return 0, err
+			}
+			count += n
+			n, err = o.ImageDictionary.Dictionary[k].Write(out)
+			if err != nil {
+				return 0, err
+			}
+			count += n
+		}
+	}
+	n, err := pdfgo.WriteS(out, " ID ")
+	if err != nil {
+		return 0, err
+	}
+	count += n
+	n, err = out.Write(o.ImageData)
+	if err != nil {
+		return 0, err
+	}
+	count += n
+	n, err = pdfgo.WriteS(out, "\nEI")
+	if err != nil {
+		return 0, err
+	}
+	count += n
+	return count, nil
+}
+
+// Write serializes the given operations, one per line.
+func Write(out io.Writer, operations []*Operation) (int, error) {
+	var count int
+	for i, o := range operations {
+		if i > 0 {
+			n, err := pdfgo.WriteS(out, "\n")
+			if err != nil {
+				return 0, err
+			}
+			count += n
+		}
+		n, err := o.Write(out)
+		if err != nil {
+			return 0, err
+		}
+		count += n
+	}
+	return count, nil
+}
@@ -0,0 +1,189 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package content
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/AletheiaWareLLC/pdfgo"
+)
+
+// Parse tokenizes a content stream into a sequence of operations.
+func Parse(data []byte) ([]*Operation, error) {
+	p := pdfgo.NewParser(data)
+	var operations []*Operation
+	var operands []pdfgo.Object
+	for {
+		t, err := p.Peek()
+		if err != nil {
+			return nil, err
+		}
+		switch t.Kind {
+		case pdfgo.TokenEOF:
+			if len(operands) > 0 {
+				return nil, fmt.Errorf("Operands without operator at end of content: %d", len(operands))
+			}
+			return operations, nil
+		case pdfgo.TokenKeyword:
+			switch t.Value {
+			case "true", "false", "null":
+			default:
+				p.Next()
+				o := &Operation{
+					Operator: t.Value,
+					Operands: operands,
+				}
+				if t.Value == "BI" {
+					if err := parseInlineImage(p, o); err != nil {
+						return nil, err
+					}
+				}
+				operations = append(operations, o)
+				operands = nil
+				continue
+			}
+		}
+		o, err := p.ParseObject()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, o)
+	}
+}
+
+// ParseStream decodes the given stream and parses its content.
+func ParseStream(s *pdfgo.StreamObject) ([]*Operation, error) {
+	data, err := s.Decode()
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+func parseInlineImage(p *pdfgo.Parser, o *Operation) error {
+	o.ImageDictionary = &pdfgo.DictionaryObject{
+		Dictionary: make(map[*pdfgo.NameObject]pdfgo.Object),
+	}
+	for {
+		t, err := p.Next()
+		if err != nil {
+			return err
+		}
+		if t.Kind == pdfgo.TokenKeyword && t.Value == "ID" {
+			break
+		}
+		if t.Kind != pdfgo.TokenName {
+			return fmt.Errorf("Expected inline image key at %d, got '%s'", t.Offset, t.Value)
+		}
+		v, err := p.ParseObject()
+		if err != nil {
+			return err
+		}
+		o.ImageDictionary.AddNameObjectEntry(t.Value, v)
+	}
+	// A single whitespace character separates ID from the data
+	start := p.Offset + 1
+	if start > len(p.Data) {
+		return fmt.Errorf("Missing inline image data at %d", p.Offset)
+	}
+	end := -1
+	if length := inlineImageLength(o.ImageDictionary); length >= 0 && start+length <= len(p.Data) {
+		l := pdfgo.NewLexer(p.Data)
+		l.Offset = start + length
+		if t, err := l.Next(); err == nil && t.Kind == pdfgo.TokenKeyword && t.Value == "EI" {
+			end = start + length
+		}
+	}
+	if end < 0 {
+		// Search for EI surrounded by whitespace
+		for i := start; ; i++ {
+			j := bytes.Index(p.Data[i:], []byte("EI"))
+			if j < 0 {
+				return fmt.Errorf("Unterminated inline image at %d", start)
+			}
+			i += j
+			after := i + 2
+			if i > start && pdfgo.IsWhitespace(p.Data[i-1]) && (after >= len(p.Data) || pdfgo.IsWhitespace(p.Data[after]) || pdfgo.IsDelimiter(p.Data[after])) {
+				end = i - 1
+				break
+			}
+		}
+	}
+	o.ImageData = p.Data[start:end]
+	p.Offset = end
+	t, err := p.Next()
+	if err != nil {
+		return err
+	}
+	if t.Kind != pdfgo.TokenKeyword || t.Value != "EI" {
+		return fmt.Errorf("Expected EI at %d", t.Offset)
+	}
+	return nil
+}
+
+// inlineImageLength returns the length of unfiltered inline image data, or -1 if it cannot be determined.
+func inlineImageLength(d *pdfgo.DictionaryObject) int {
+	entry := func(short, long string) pdfgo.Object {
+		if v := d.GetEntry(short); v != nil {
+			return v
+		}
+		return d.GetEntry(long)
+	}
+	if entry("F", "Filter") != nil {
+		return -1
+	}
+	number := func(short, long string) int {
+		if n, ok := entry(short, long).(*pdfgo.NumberObject); ok {
+			return int(n.Number)
+		}
+		return -1
+	}
+	width := number("W", "Width")
+	height := number("H", "Height")
+	bpc := number("BPC", "BitsPerComponent")
+	colors := 1
+	if m, ok := entry("IM", "ImageMask").(*pdfgo.BooleanObject); ok && m.Boolean {
+		bpc = 1
+	} else {
+		switch cs := entry("CS", "ColorSpace").(type) {
+		case *pdfgo.NameObject:
+			switch cs.Name {
+			case "G", "DeviceGray", "I", "Indexed":
+			case "RGB", "DeviceRGB":
+				colors = 3
+			case "CMYK", "DeviceCMYK":
+				colors = 4
+			default:
+				return -1
+			}
+		case *pdfgo.ArrayObject:
+			// Indexed color spaces have one component
+			if len(cs.Array) == 0 {
+				return -1
+			}
+			if n, ok := cs.Array[0].(*pdfgo.NameObject); !ok || (n.Name != "I" && n.Name != "Indexed") {
+				return -1
+			}
+		default:
+			return -1
+		}
+	}
+	if width < 0 || height < 0 || bpc < 0 {
+		return -1
+	}
+	return height * ((width*colors*bpc + 7) / 8)
+}
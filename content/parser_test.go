@@ -0,0 +1,64 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package content_test
+
+import (
+	"bytes"
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/AletheiaWareLLC/pdfgo/content"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	operations, err := content.Parse([]byte("q\nBT\n/F1 32 Tf\n1 0 0 1 50 518 Tm\n[(Hello) -250 (World\\))] TJ\nET\nQ"))
+	assert.Nil(t, err)
+	assert.Equal(t, 7, len(operations))
+	assert.Equal(t, "Tf", operations[2].Operator)
+	assert.Equal(t, "F1", operations[2].Name(0))
+	assert.Equal(t, 32., operations[2].Number(1))
+	assert.Equal(t, "TJ", operations[4].Operator)
+	array := operations[4].Operands[0].(*pdfgo.ArrayObject)
+	assert.Equal(t, 3, len(array.Array))
+	assert.Equal(t, "World)", string(array.Array[2].(*pdfgo.StringObject).Bytes()))
+}
+
+func TestParse_inlineImage(t *testing.T) {
+	given := "q 2 0 0 2 0 0 cm\nBI /W 2 /H 2 /CS /G /BPC 8 ID \x00EI\xff\nEI\nQ"
+	operations, err := content.Parse([]byte(given))
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(operations))
+	image := operations[2]
+	assert.Equal(t, "BI", image.Operator)
+	assert.Equal(t, []byte("\x00EI\xff"), image.ImageData)
+	assert.Equal(t, "Q", operations[3].Operator)
+}
+
+func TestWrite(t *testing.T) {
+	given := "q\n0 0 1 rg\n50 50 300 500 re\nf\nBI /W 1 /H 1 /CS /G /BPC 8 ID \x80\nEI\n/P <</MCID 0>> BDC\nEMC\nQ"
+	operations, err := content.Parse([]byte(given))
+	assert.Nil(t, err)
+	var buffer bytes.Buffer
+	_, err = content.Write(&buffer, operations)
+	assert.Nil(t, err)
+	assert.Equal(t, given, buffer.String())
+}
+
+func TestParse_missingOperator(t *testing.T) {
+	_, err := content.Parse([]byte("1 0 0"))
+	assert.NotNil(t, err)
+}
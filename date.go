@@ -0,0 +1,88 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pdfgo
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// NewDateString returns a direct string holding the given time in the date format of PDF, such as D:20210131235959+01'00'.
+func NewDateString(t time.Time) *StringObject {
+	date := t.Format("D:20060102150405")
+	_, offset := t.Zone()
+	switch {
+	case offset == 0:
+		date += "Z"
+	default:
+		sign := '+'
+		if offset < 0 {
+			sign = '-'
+			offset = -offset
+		}
+		date += fmt.Sprintf("%c%02d'%02d'", sign, offset/3600, offset%3600/60)
+	}
+	return &StringObject{
+		String: date,
+	}
+}
+
+// ParseDate parses a date in the format of PDF, in which every field after the year is optional.
+func ParseDate(date string) (time.Time, error) {
+	s := strings.TrimPrefix(date, "D:")
+	digits := 0
+	for digits < len(s) && digits < 14 && s[digits] >= '0' && s[digits] <= '9' {
+		digits++
+	}
+	if digits < 4 || digits%2 != 0 {
+		return time.Time{}, fmt.Errorf("Invalid Date: %s", date)
+	}
+	// Fill the omitted fields with their defaults
+	full := s[:digits] + "0101000000"[digits-4:]
+	location := time.UTC
+	zone := strings.ReplaceAll(s[digits:], "'", "")
+	switch {
+	case zone == "" || zone == "Z" || zone == "Z00" || zone == "Z0000":
+	case len(zone) == 3 || len(zone) == 5:
+		var hours, minutes int
+		if _, err := fmt.Sscanf(zone[1:3], "%02d", &hours); err != nil {
+			return time.Time{}, fmt.Errorf("Invalid Date: %s", date)
+		}
+		if len(zone) == 5 {
+			if _, err := fmt.Sscanf(zone[3:5], "%02d", &minutes); err != nil {
+				return time.Time{}, fmt.Errorf("Invalid Date: %s", date)
+			}
+		}
+		offset := hours*3600 + minutes*60
+		switch zone[0] {
+		case '+':
+		case '-':
+			offset = -offset
+		default:
+			return time.Time{}, fmt.Errorf("Invalid Date: %s", date)
+		}
+		location = time.FixedZone("", offset)
+	default:
+		return time.Time{}, fmt.Errorf("Invalid Date: %s", date)
+	}
+	t, err := time.ParseInLocation("20060102150405", full, location)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Invalid Date: %s", date)
+	}
+	return t, nil
+}
@@ -0,0 +1,49 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pdfgo_test
+
+import (
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestNewDateString(t *testing.T) {
+	utc := time.Date(2021, 1, 31, 23, 59, 58, 0, time.UTC)
+	assert.Equal(t, "D:20210131235958Z", pdfgo.NewDateString(utc).String)
+	local := time.Date(2021, 6, 1, 8, 0, 0, 0, time.FixedZone("", -(5*3600+30*60)))
+	assert.Equal(t, "D:20210601080000-05'30'", pdfgo.NewDateString(local).String)
+}
+
+func TestParseDate(t *testing.T) {
+	for date, expected := range map[string]time.Time{
+		"D:20210131235958Z":       time.Date(2021, 1, 31, 23, 59, 58, 0, time.UTC),
+		"D:20210601080000-05'30'": time.Date(2021, 6, 1, 13, 30, 0, 0, time.UTC),
+		"D:20210601080000+01'00":  time.Date(2021, 6, 1, 7, 0, 0, 0, time.UTC),
+		"D:2021":                  time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+		"202106":                  time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC),
+	} {
+		actual, err := pdfgo.ParseDate(date)
+		assert.Nil(t, err, date)
+		assert.True(t, expected.Equal(actual), date)
+	}
+	for _, date := range []string{"", "D:", "D:202", "D:20211301", "D:2021+5"} {
+		_, err := pdfgo.ParseDate(date)
+		assert.NotNil(t, err, date)
+	}
+}
@@ -16,7 +16,10 @@
 
 package pdfgo
 
-import "io"
+import (
+	"io"
+	"strconv"
+)
 
 type DictionaryObject struct {
 	Metadata
@@ -24,6 +27,13 @@ type DictionaryObject struct {
 	Dictionary map[*NameObject]Object
 }
 
+// NewDictionary returns an empty direct dictionary.
+func NewDictionary() *DictionaryObject {
+	return &DictionaryObject{
+		Dictionary: make(map[*NameObject]Object),
+	}
+}
+
 func (o *DictionaryObject) AddNameNameEntry(key, value string) {
 	o.AddNameObjectEntry(key, &NameObject{Name: value})
 }
@@ -37,6 +47,73 @@ func (o *DictionaryObject) AddObjectObjectEntry(key *NameObject, value Object) {
 	o.Dictionary[key] = value
 }
 
+// GetEntry returns the value of the entry with the given key, or nil if absent.
+func (o *DictionaryObject) GetEntry(key string) Object {
+	for _, k := range o.Keys {
+		if k.Name == key {
+			return o.Dictionary[k]
+		}
+	}
+	return nil
+}
+
+// HasEntry returns true if the dictionary has an entry with the given key.
+func (o *DictionaryObject) HasEntry(key string) bool {
+	return o.GetEntry(key) != nil
+}
+
+// SetNameNameEntry replaces the value of the entry with the given key, adding it if absent.
+func (o *DictionaryObject) SetNameNameEntry(key, value string) {
+	o.SetNameObjectEntry(key, &NameObject{Name: value})
+}
+
+// SetNameObjectEntry replaces the value of the entry with the given key, adding it if absent.
+func (o *DictionaryObject) SetNameObjectEntry(key string, value Object) {
+	for _, k := range o.Keys {
+		if k.Name == key {
+			o.Dictionary[k] = value
+			return
+		}
+	}
+	o.AddNameObjectEntry(key, value)
+}
+
+// RemoveEntry removes the entry with the given key, if present.
+func (o *DictionaryObject) RemoveEntry(key string) {
+	for i, k := range o.Keys {
+		if k.Name == key {
+			o.Keys = append(o.Keys[:i:i], o.Keys[i+1:]...)
+			delete(o.Dictionary, k)
+			return
+		}
+	}
+}
+
+// Copy returns a direct dictionary with the same entries, whose values are shared with this dictionary.
+// Copying a nil dictionary returns an empty dictionary.
+func (o *DictionaryObject) Copy() *DictionaryObject {
+	c := &DictionaryObject{
+		Dictionary: make(map[*NameObject]Object),
+	}
+	if o == nil {
+		return c
+	}
+	for _, k := range o.Keys {
+		c.AddObjectObjectEntry(k, o.Dictionary[k])
+	}
+	return c
+}
+
+// UniqueName returns the first name made of the given prefix and a number which isn't a key of the given dictionary, such as a resource name which doesn't clash with existing resources.
+func UniqueName(d *DictionaryObject, prefix string) string {
+	for i := 1; ; i++ {
+		name := prefix + strconv.Itoa(i)
+		if !d.HasEntry(name) {
+			return name
+		}
+	}
+}
+
 func (o *DictionaryObject) Write(out io.Writer) (int, error) {
 	var count int
 	n, err := WriteS(out, "<<")
@@ -0,0 +1,287 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package facturx
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/AletheiaWareLLC/pdfgo/pdfa"
+	"io"
+	"strings"
+	"time"
+)
+
+// Conformance levels of Factur-X invoices, as written in XMP metadata
+const (
+	MINIMUM   = "MINIMUM"
+	BASIC_WL  = "BASIC WL"
+	BASIC     = "BASIC"
+	EN16931   = "EN 16931"
+	EXTENDED  = "EXTENDED"
+	XRECHNUNG = "XRECHNUNG"
+)
+
+const (
+	FILE_NAME           = "factur-x.xml"
+	XRECHNUNG_FILE_NAME = "xrechnung.xml"
+	MIME_TYPE           = "text/xml"
+	DOCUMENT_TYPE       = "INVOICE"
+	VERSION             = "1.0"
+)
+
+const (
+	NAMESPACE_INVOICE = "urn:un:unece:uncefact:data:standard:CrossIndustryInvoice:100"
+	NAMESPACE_FACTURX = "urn:factur-x:pdfa:CrossIndustryDocument:invoice:1p0#"
+	PREFIX_FACTURX    = "fx"
+)
+
+// Guidelines maps the guideline identifiers of the invoice document context to conformance levels
+var Guidelines = map[string]string{
+	"urn:factur-x.eu:1p0:minimum":                                            MINIMUM,
+	"urn:factur-x.eu:1p0:basicwl":                                            BASIC_WL,
+	"urn:cen.eu:en16931:2017#compliant#urn:factur-x.eu:1p0:basic":            BASIC,
+	"urn:cen.eu:en16931:2017":                                                EN16931,
+	"urn:cen.eu:en16931:2017#conformant#urn:factur-x.eu:1p0:extended":        EXTENDED,
+	"urn:cen.eu:en16931:2017#compliant#urn:xoev-de:kosit:standard:xrechnung": XRECHNUNG,
+}
+
+// DetectLevel returns the conformance level of the given Cross Industry Invoice, read from its guideline identifier.
+func DetectLevel(invoice []byte) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(invoice))
+	var path []string
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if len(path) == 0 && (t.Name.Space != NAMESPACE_INVOICE || t.Name.Local != "CrossIndustryInvoice") {
+				return "", fmt.Errorf("Not a Cross Industry Invoice: %s", t.Name.Local)
+			}
+			path = append(path, t.Name.Local)
+		case xml.EndElement:
+			path = path[:len(path)-1]
+		case xml.CharData:
+			if len(path) == 4 && path[1] == "ExchangedDocumentContext" && path[2] == "GuidelineSpecifiedDocumentContextParameter" && path[3] == "ID" {
+				id := strings.TrimSpace(string(t))
+				if level, ok := Guidelines[id]; ok {
+					return level, nil
+				}
+				// XRechnung identifiers end with the version of the standard
+				for guideline, level := range Guidelines {
+					if level == XRECHNUNG && strings.HasPrefix(id, guideline) {
+						return level, nil
+					}
+				}
+				return "", fmt.Errorf("Unrecognized guideline: %s", id)
+			}
+		}
+	}
+	return "", errors.New("Invoice has no guideline")
+}
+
+// Invoice is an electronic invoice, and the metadata of the document which carries it.
+type Invoice struct {
+	// XML is the Cross Industry Invoice
+	XML []byte
+	// Level is the conformance level, and is detected from the XML if empty
+	Level    string
+	Title    string
+	Author   string
+	Subject  string
+	Keywords string
+	Creator  string
+	Producer string
+	// Date is when the invoice was created, and defaults to now
+	Date time.Time
+}
+
+// FileName returns the name under which the invoice is embedded.
+func (i *Invoice) FileName() string {
+	if i.Level == XRECHNUNG {
+		return XRECHNUNG_FILE_NAME
+	}
+	return FILE_NAME
+}
+
+// Embed makes the given document a Factur-X invoice; it embeds the XML as an alternative representation of the document, and prepares the document to conform to PDF/A-3b with the Factur-X extension schema.
+// The objects which were changed are returned so they can be written as an incremental update.
+func Embed(p *pdfgo.PDF, invoice *Invoice) ([]pdfgo.Object, error) {
+	if invoice.Level == "" {
+		level, err := DetectLevel(invoice.XML)
+		if err != nil {
+			return nil, err
+		}
+		invoice.Level = level
+	}
+	if invoice.Date.IsZero() {
+		invoice.Date = time.Now()
+	}
+	changed, err := p.AddAttachment(&pdfgo.Attachment{
+		Name:         invoice.FileName(),
+		Description:  "Factur-X Invoice",
+		MimeType:     MIME_TYPE,
+		Data:         invoice.XML,
+		Created:      invoice.Date,
+		Modified:     invoice.Date,
+		Relationship: pdfgo.AF_ALTERNATIVE,
+	})
+	if err != nil {
+		return nil, err
+	}
+	c, err := pdfa.Conform(p, &pdfa.Metadata{
+		Title:      invoice.Title,
+		Author:     invoice.Author,
+		Subject:    invoice.Subject,
+		Keywords:   invoice.Keywords,
+		Creator:    invoice.Creator,
+		Producer:   invoice.Producer,
+		Created:    invoice.Date,
+		Modified:   invoice.Date,
+		Extensions: []*pdfa.Extension{Extension(invoice)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(changed, c...), nil
+}
+
+// Extension returns the Factur-X extension schema holding the properties of the given invoice.
+func Extension(invoice *Invoice) *pdfa.Extension {
+	return &pdfa.Extension{
+		Schema:    "Factur-X PDFA Extension Schema",
+		Namespace: NAMESPACE_FACTURX,
+		Prefix:    PREFIX_FACTURX,
+		Properties: []*pdfa.Property{
+			{
+				Name:        "DocumentFileName",
+				ValueType:   "Text",
+				Category:    "external",
+				Description: "Name of the embedded XML invoice file",
+				Value:       invoice.FileName(),
+			},
+			{
+				Name:        "DocumentType",
+				ValueType:   "Text",
+				Category:    "external",
+				Description: "INVOICE",
+				Value:       DOCUMENT_TYPE,
+			},
+			{
+				Name:        "Version",
+				ValueType:   "Text",
+				Category:    "external",
+				Description: "The actual version of the Factur-X XML schema",
+				Value:       VERSION,
+			},
+			{
+				Name:        "ConformanceLevel",
+				ValueType:   "Text",
+				Category:    "external",
+				Description: "The conformance level of the embedded Factur-X data",
+				Value:       invoice.Level,
+			},
+		},
+	}
+}
+
+// Validate checks the given file is a Factur-X invoice; it must conform to PDF/A-3b, embed the invoice as an alternative representation of the document, and describe it in its XMP metadata.
+// An error is returned only if the file can't be parsed at all.
+func Validate(data []byte) (*pdfa.Report, error) {
+	report, err := pdfa.Validate(data)
+	if err != nil {
+		return nil, err
+	}
+	p := report.Document
+	if p == nil {
+		return report, nil
+	}
+
+	var properties pdfa.Properties
+	if s, ok := pdfgo.Resolve(p.Catalog.GetEntry("Metadata")).(*pdfgo.StreamObject); ok {
+		properties, _ = pdfa.ParseXMP(s.Data)
+	}
+	values := make(map[string]string)
+	for _, key := range []string{"DocumentFileName", "DocumentType", "Version", "ConformanceLevel"} {
+		value, ok := properties.Get(NAMESPACE_FACTURX, key)
+		if !ok {
+			report.Add("XMP metadata has no Factur-X %s", key)
+		}
+		values[key] = value
+	}
+	if v := values["DocumentType"]; v != "" && v != DOCUMENT_TYPE {
+		report.Add("XMP metadata has an unrecognized Factur-X DocumentType: %s", v)
+	}
+
+	name := values["DocumentFileName"]
+	if name == "" {
+		return report, nil
+	}
+	attachments, err := p.GetAttachments()
+	if err != nil {
+		return report, nil
+	}
+	var invoice *pdfgo.Attachment
+	for _, a := range attachments {
+		if a.Name == name {
+			invoice = a
+		}
+	}
+	if invoice == nil {
+		report.Add("Invoice %s isn't embedded", name)
+		return report, nil
+	}
+	if invoice.MimeType != MIME_TYPE {
+		report.Add("Invoice %s has media type %s instead of %s", name, invoice.MimeType, MIME_TYPE)
+	}
+	switch invoice.Relationship {
+	case pdfgo.AF_ALTERNATIVE, pdfgo.AF_DATA, pdfgo.AF_SOURCE:
+	default:
+		report.Add("Invoice %s has relationship %s instead of %s", name, invoice.Relationship, pdfgo.AF_ALTERNATIVE)
+	}
+	if !associated(p, name) {
+		report.Add("Invoice %s isn't associated with the document", name)
+	}
+	level, err := DetectLevel(invoice.Data)
+	if err != nil {
+		report.Add("Invoice %s can't be read: %s", name, err)
+	} else if l := values["ConformanceLevel"]; l != "" && !strings.EqualFold(l, level) {
+		report.Add("XMP metadata has Factur-X ConformanceLevel %s but invoice is %s", l, level)
+	}
+	return report, nil
+}
+
+// associated returns true if the catalog's associated files includes the file with the given name.
+func associated(p *pdfgo.PDF, name string) bool {
+	af, ok := pdfgo.Resolve(p.Catalog.GetEntry("AF")).(*pdfgo.ArrayObject)
+	if !ok {
+		return false
+	}
+	for _, o := range af.Array {
+		if a, err := pdfgo.ReadAttachment(o); err == nil && a.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package facturx_test
+
+import (
+	"bytes"
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/AletheiaWareLLC/pdfgo/facturx"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func invoice(guideline string) []byte {
+	return []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<rsm:CrossIndustryInvoice xmlns:rsm="urn:un:unece:uncefact:data:standard:CrossIndustryInvoice:100" xmlns:ram="urn:un:unece:uncefact:data:standard:ReusableAggregateBusinessInformationEntity:100">
+  <rsm:ExchangedDocumentContext>
+    <ram:GuidelineSpecifiedDocumentContextParameter>
+      <ram:ID>` + guideline + `</ram:ID>
+    </ram:GuidelineSpecifiedDocumentContextParameter>
+  </rsm:ExchangedDocumentContext>
+  <rsm:ExchangedDocument>
+    <ram:ID>INV-1</ram:ID>
+    <ram:TypeCode>380</ram:TypeCode>
+  </rsm:ExchangedDocument>
+</rsm:CrossIndustryInvoice>
+`)
+}
+
+func TestDetectLevel(t *testing.T) {
+	for guideline, expected := range map[string]string{
+		"urn:factur-x.eu:1p0:minimum":                                                facturx.MINIMUM,
+		"urn:factur-x.eu:1p0:basicwl":                                                facturx.BASIC_WL,
+		"urn:cen.eu:en16931:2017#compliant#urn:factur-x.eu:1p0:basic":                facturx.BASIC,
+		"urn:cen.eu:en16931:2017":                                                    facturx.EN16931,
+		"urn:cen.eu:en16931:2017#conformant#urn:factur-x.eu:1p0:extended":            facturx.EXTENDED,
+		"urn:cen.eu:en16931:2017#compliant#urn:xoev-de:kosit:standard:xrechnung_2.0": facturx.XRECHNUNG,
+	} {
+		level, err := facturx.DetectLevel(invoice(guideline))
+		assert.Nil(t, err, guideline)
+		assert.Equal(t, expected, level, guideline)
+	}
+	_, err := facturx.DetectLevel(invoice("urn:example"))
+	assert.Equal(t, "Unrecognized guideline: urn:example", err.Error())
+	_, err = facturx.DetectLevel([]byte("<Invoice/>"))
+	assert.Equal(t, "Not a Cross Industry Invoice: Invoice", err.Error())
+}
+
+func document(t *testing.T, attach bool, level string) []byte {
+	t.Helper()
+	p := pdfgo.NewPDF()
+	content := p.NewStreamObject()
+	content.Data = []byte("0 0 1 rg 10 10 180 180 re f\n")
+	p.AddPage(200, 200, nil, pdfgo.NewObjectReference(content))
+	i := &facturx.Invoice{
+		XML:      invoice("urn:cen.eu:en16931:2017"),
+		Level:    level,
+		Title:    "Invoice INV-1",
+		Author:   "Seller",
+		Producer: "pdfgo",
+		Date:     time.Date(2021, 2, 3, 4, 5, 6, 0, time.UTC),
+	}
+	if attach {
+		_, err := facturx.Embed(p, i)
+		assert.Nil(t, err)
+	}
+	var buffer bytes.Buffer
+	assert.Nil(t, p.Write(&buffer))
+	return buffer.Bytes()
+}
+
+func TestEmbed(t *testing.T) {
+	data := document(t, true, "")
+	report, err := facturx.Validate(data)
+	assert.Nil(t, err)
+	assert.Equal(t, []string(nil), report.Violations)
+	assert.True(t, report.Valid())
+
+	p, err := pdfgo.ReadPDF(data)
+	assert.Nil(t, err)
+	attachments, err := p.GetAttachments()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(attachments))
+	assert.Equal(t, facturx.FILE_NAME, attachments[0].Name)
+	assert.Equal(t, "text/xml", attachments[0].MimeType)
+	assert.Equal(t, pdfgo.AF_ALTERNATIVE, attachments[0].Relationship)
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("WrongLevel", func(t *testing.T) {
+		report, err := facturx.Validate(document(t, true, facturx.BASIC))
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"XMP metadata has Factur-X ConformanceLevel BASIC but invoice is EN 16931"}, report.Violations)
+	})
+	t.Run("NotEmbedded", func(t *testing.T) {
+		report, err := facturx.Validate(document(t, false, ""))
+		assert.Nil(t, err)
+		assert.False(t, report.Valid())
+		assert.Contains(t, report.Violations, "XMP metadata has no Factur-X DocumentFileName")
+		assert.Contains(t, report.Violations, "Catalog has no XMP metadata")
+	})
+}
@@ -0,0 +1,333 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pdfgo
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/ascii85"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+)
+
+// Filters returns the names of the filters applied to the stream data, in the order they must be decoded.
+func (o *StreamObject) Filters() []string {
+	var filters []string
+	switch f := Resolve(o.GetEntry("Filter")).(type) {
+	case *NameObject:
+		filters = append(filters, f.Name)
+	case *ArrayObject:
+		for _, e := range f.Array {
+			if n, ok := Resolve(e).(*NameObject); ok {
+				filters = append(filters, n.Name)
+			}
+		}
+	}
+	return filters
+}
+
+func (o *StreamObject) decodeParameters(index int) *DictionaryObject {
+	switch p := Resolve(o.GetEntry("DecodeParms")).(type) {
+	case *DictionaryObject:
+		if index == 0 {
+			return p
+		}
+	case *ArrayObject:
+		if index < len(p.Array) {
+			if d, ok := Resolve(p.Array[index]).(*DictionaryObject); ok {
+				return d
+			}
+		}
+	}
+	return nil
+}
+
+// Decode returns the stream data with its filters removed.
+// Image codecs (DCTDecode, JPXDecode, CCITTFaxDecode, JBIG2Decode) are left in place so the data is returned still encoded by them.
+func (o *StreamObject) Decode() ([]byte, error) {
+	data := o.Data
+	for i, f := range o.Filters() {
+		var err error
+		switch f {
+		case "FlateDecode", "Fl":
+			data, err = flateDecode(data, o.decodeParameters(i))
+		case "LZWDecode", "LZW":
+			earlyChange := 1
+			if p := o.decodeParameters(i); p != nil {
+				if e, ok := Resolve(p.GetEntry("EarlyChange")).(*NumberObject); ok {
+					earlyChange = int(e.Number)
+				}
+			}
+			data, err = lzwDecode(data, earlyChange)
+			if err == nil {
+				data, err = unpredict(data, o.decodeParameters(i))
+			}
+		case "ASCIIHexDecode", "AHx":
+			data, err = asciiHexDecode(data)
+		case "ASCII85Decode", "A85":
+			data, err = ascii85Decode(data)
+		case "RunLengthDecode", "RL":
+			data, err = runLengthDecode(data)
+		case "DCTDecode", "DCT", "JPXDecode", "CCITTFaxDecode", "CCF", "JBIG2Decode":
+			return data, nil
+		default:
+			return nil, fmt.Errorf("Unsupported Filter: %s", f)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+func flateDecode(data []byte, parameters *DictionaryObject) ([]byte, error) {
+	r, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	decoded, err := ioutil.ReadAll(r)
+	if err != nil && len(decoded) == 0 {
+		return nil, err
+	}
+	// Data is often truncated or missing its checksum, so keep whatever was decoded
+	return unpredict(decoded, parameters)
+}
+
+// FlateEncode returns the data compressed for the FlateDecode filter.
+func FlateEncode(data []byte) ([]byte, error) {
+	var buffer bytes.Buffer
+	w := zlib.NewWriter(&buffer)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func unpredict(data []byte, parameters *DictionaryObject) ([]byte, error) {
+	if parameters == nil {
+		return data, nil
+	}
+	number := func(key string, fallback int) int {
+		if n, ok := Resolve(parameters.GetEntry(key)).(*NumberObject); ok {
+			return int(n.Number)
+		}
+		return fallback
+	}
+	predictor := number("Predictor", 1)
+	if predictor < 2 {
+		return data, nil
+	}
+	colors := number("Colors", 1)
+	bpc := number("BitsPerComponent", 8)
+	columns := number("Columns", 1)
+	bpp := (colors*bpc + 7) / 8
+	rowLength := (colors*bpc*columns + 7) / 8
+	if predictor == 2 {
+		// TIFF Predictor 2, only 8 bits per component is supported
+		if bpc != 8 {
+			return nil, fmt.Errorf("Unsupported TIFF Predictor Bits Per Component: %d", bpc)
+		}
+		for row := 0; row+rowLength <= len(data); row += rowLength {
+			for i := bpp; i < rowLength; i++ {
+				data[row+i] += data[row+i-bpp]
+			}
+		}
+		return data, nil
+	}
+	// PNG Predictors, each row is prefixed with its filter type
+	var output []byte
+	previous := make([]byte, rowLength)
+	for row := 0; row < len(data); row += rowLength + 1 {
+		end := row + rowLength + 1
+		if end > len(data) {
+			end = len(data)
+		}
+		current := make([]byte, rowLength)
+		copy(current, data[row+1:end])
+		switch data[row] {
+		case 0:
+		case 1:
+			for i := bpp; i < rowLength; i++ {
+				current[i] += current[i-bpp]
+			}
+		case 2:
+			for i := 0; i < rowLength; i++ {
+				current[i] += previous[i]
+			}
+		case 3:
+			for i := 0; i < rowLength; i++ {
+				var left byte
+				if i >= bpp {
+					left = current[i-bpp]
+				}
+				current[i] += byte((int(left) + int(previous[i])) / 2)
+			}
+		case 4:
+			for i := 0; i < rowLength; i++ {
+				var left, upperLeft byte
+				if i >= bpp {
+					left = current[i-bpp]
+					upperLeft = previous[i-bpp]
+				}
+				current[i] += paeth(left, previous[i], upperLeft)
+			}
+		default:
+			return nil, fmt.Errorf("Unsupported PNG Predictor: %d", data[row])
+		}
+		output = append(output, current...)
+		previous = current
+	}
+	return output, nil
+}
+
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa := abs(p - int(a))
+	pb := abs(p - int(b))
+	pc := abs(p - int(c))
+	if pa <= pb && pa <= pc {
+		return a
+	}
+	if pb <= pc {
+		return b
+	}
+	return c
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
+
+func lzwDecode(data []byte, earlyChange int) ([]byte, error) {
+	var (
+		output    []byte
+		table     [][]byte
+		previous  []byte
+		bits      uint = 9
+		buffer    uint32
+		available uint
+	)
+	reset := func() {
+		table = make([][]byte, 258, 4096)
+		for i := 0; i < 256; i++ {
+			table[i] = []byte{byte(i)}
+		}
+		bits = 9
+		previous = nil
+	}
+	reset()
+	for _, b := range data {
+		buffer = buffer<<8 | uint32(b)
+		available += 8
+		for available >= bits {
+			code := int(buffer >> (available - bits) & (1<<bits - 1))
+			available -= bits
+			switch {
+			case code == 256:
+				reset()
+				continue
+			case code == 257:
+				return output, nil
+			}
+			var entry []byte
+			if code < len(table) {
+				entry = table[code]
+			} else if code == len(table) && previous != nil {
+				entry = append(append([]byte{}, previous...), previous[0])
+			} else {
+				return nil, fmt.Errorf("Invalid LZW Code: %d", code)
+			}
+			output = append(output, entry...)
+			if previous != nil && len(table) < 4096 {
+				table = append(table, append(append([]byte{}, previous...), entry[0]))
+			}
+			previous = entry
+			if next := len(table) + earlyChange; next >= 1<<bits && bits < 12 {
+				bits++
+			}
+		}
+	}
+	return output, nil
+}
+
+func asciiHexDecode(data []byte) ([]byte, error) {
+	var digits []byte
+	for _, c := range data {
+		if c == '>' {
+			break
+		}
+		if !IsWhitespace(c) {
+			digits = append(digits, c)
+		}
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	decoded := make([]byte, len(digits)/2)
+	if _, err := hex.Decode(decoded, digits); err != nil {
+		return nil, err
+	}
+	return decoded, nil
+}
+
+func ascii85Decode(data []byte) ([]byte, error) {
+	data = bytes.TrimSpace(data)
+	data = bytes.TrimPrefix(data, []byte("<~"))
+	if i := bytes.Index(data, []byte("~>")); i >= 0 {
+		data = data[:i]
+	}
+	decoded := make([]byte, len(data)*4+4)
+	n, _, err := ascii85.Decode(decoded, data, true)
+	if err != nil {
+		return nil, err
+	}
+	return decoded[:n], nil
+}
+
+func runLengthDecode(data []byte) ([]byte, error) {
+	var output []byte
+	for i := 0; i < len(data); {
+		length := int(data[i])
+		i++
+		switch {
+		case length == 128:
+			return output, nil
+		case length < 128:
+			end := i + length + 1
+			if end > len(data) {
+				end = len(data)
+			}
+			output = append(output, data[i:end]...)
+			i = end
+		default:
+			if i >= len(data) {
+				return output, nil
+			}
+			output = append(output, bytes.Repeat(data[i:i+1], 257-length)...)
+			i++
+		}
+	}
+	return output, nil
+}
@@ -0,0 +1,217 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pdfgo
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+const (
+	// ANNOTATION_HIDDEN is the annotation flag which neither shows nor prints an annotation
+	ANNOTATION_HIDDEN = 1 << 1
+)
+
+// AnnotationAppearance returns the normal appearance stream of the given annotation in its current state, or nil if it has none.
+func AnnotationAppearance(annotation *DictionaryObject) *StreamObject {
+	ap, ok := Resolve(annotation.GetEntry("AP")).(*DictionaryObject)
+	if !ok {
+		return nil
+	}
+	switch n := Resolve(ap.GetEntry("N")).(type) {
+	case *StreamObject:
+		return n
+	case *DictionaryObject:
+		if state, ok := Resolve(annotation.GetEntry("AS")).(*NameObject); ok {
+			s, _ := Resolve(n.GetEntry(state.Name)).(*StreamObject)
+			return s
+		}
+	}
+	return nil
+}
+
+// FlattenAnnotations draws the normal appearances of the given annotations of the page into its content, as form XObjects filling their rectangles, and removes the annotations from the page.
+// If no annotations are given, every annotation of the page with an appearance is flattened, except the widgets of form fields, which should be flattened with their form.
+// Hidden annotations and annotations without an appearance are removed without being drawn, as are the pop-ups of flattened annotations.
+func (p *PDF) FlattenAnnotations(page *DictionaryObject, annotations ...*DictionaryObject) error {
+	annots, _ := Resolve(page.GetEntry("Annots")).(*ArrayObject)
+	if len(annotations) == 0 && annots != nil {
+		for _, a := range annots.Array {
+			d, ok := Resolve(a).(*DictionaryObject)
+			if !ok || AnnotationAppearance(d) == nil {
+				continue
+			}
+			if t, ok := Resolve(d.GetEntry("Subtype")).(*NameObject); ok && t.Name == "Widget" {
+				continue
+			}
+			annotations = append(annotations, d)
+		}
+	}
+	if len(annotations) == 0 {
+		return nil
+	}
+
+	// Copy the resources so that pages sharing them are not affected
+	resources := PageResources(page).Copy()
+	xs := &DictionaryObject{
+		Dictionary: make(map[*NameObject]Object),
+	}
+	if x, ok := Resolve(resources.GetEntry("XObject")).(*DictionaryObject); ok {
+		xs = x.Copy()
+	}
+	properties := &DictionaryObject{
+		Dictionary: make(map[*NameObject]Object),
+	}
+	if x, ok := Resolve(resources.GetEntry("Properties")).(*DictionaryObject); ok {
+		properties = x.Copy()
+	}
+	states := &DictionaryObject{
+		Dictionary: make(map[*NameObject]Object),
+	}
+	if x, ok := Resolve(resources.GetEntry("ExtGState")).(*DictionaryObject); ok {
+		states = x.Copy()
+	}
+	opacities := make(map[float64]string)
+	var buffer bytes.Buffer
+	flattened := make(map[*DictionaryObject]bool)
+	for _, a := range annotations {
+		flattened[a] = true
+		if f, ok := Resolve(a.GetEntry("F")).(*NumberObject); ok && int(f.Number)&ANNOTATION_HIDDEN != 0 {
+			continue
+		}
+		form := AnnotationAppearance(a)
+		if form == nil {
+			continue
+		}
+		if form.GetName() == 0 {
+			p.add(form)
+		}
+		matrix, err := appearanceMatrix(a, form)
+		if err != nil {
+			return err
+		}
+		if matrix == nil {
+			continue
+		}
+		name := UniqueName(xs, "Flat")
+		xs.AddNameObjectEntry(name, NewObjectReference(form))
+		// Annotations in a layer remain in the layer as optional content
+		oc, layered := Resolve(a.GetEntry("OC")).(*DictionaryObject)
+		if layered {
+			buffer.WriteString("/OC /")
+			buffer.WriteString(EscapeName(propertiesName(properties, oc)))
+			buffer.WriteString(" BDC ")
+		}
+		buffer.WriteString("q")
+		// The constant opacity of the annotation applies to its whole appearance
+		if ca, ok := Resolve(a.GetEntry("CA")).(*NumberObject); ok && ca.Number < 1 {
+			state, ok := opacities[ca.Number]
+			if !ok {
+				gs := &DictionaryObject{
+					Dictionary: make(map[*NameObject]Object),
+				}
+				gs.AddNameNameEntry("Type", "ExtGState")
+				gs.AddNameObjectEntry("CA", &NumberObject{Number: ca.Number})
+				gs.AddNameObjectEntry("ca", &NumberObject{Number: ca.Number})
+				state = UniqueName(states, "GS")
+				states.AddNameObjectEntry(state, gs)
+				opacities[ca.Number] = state
+			}
+			buffer.WriteString(" /")
+			buffer.WriteString(EscapeName(state))
+			buffer.WriteString(" gs")
+		}
+		for _, m := range matrix {
+			buffer.WriteString(" ")
+			buffer.WriteString(strconv.FormatFloat(m, 'f', -1, 64))
+		}
+		buffer.WriteString(" cm /")
+		buffer.WriteString(EscapeName(name))
+		buffer.WriteString(" Do Q")
+		if layered {
+			buffer.WriteString(" EMC")
+		}
+		buffer.WriteString("\n")
+	}
+
+	if annots != nil {
+		remaining := &ArrayObject{}
+		for _, a := range annots.Array {
+			d, ok := Resolve(a).(*DictionaryObject)
+			if ok && flattened[d] {
+				continue
+			}
+			if ok {
+				if parent, ok := Resolve(d.GetEntry("Parent")).(*DictionaryObject); ok && flattened[parent] {
+					continue
+				}
+			}
+			remaining.Array = append(remaining.Array, a)
+		}
+		if len(remaining.Array) == 0 {
+			page.RemoveEntry("Annots")
+		} else {
+			page.SetNameObjectEntry("Annots", remaining)
+		}
+	}
+	if buffer.Len() == 0 {
+		return nil
+	}
+	resources.SetNameObjectEntry("XObject", xs)
+	if len(properties.Keys) > 0 {
+		resources.SetNameObjectEntry("Properties", properties)
+	}
+	if len(opacities) > 0 {
+		resources.SetNameObjectEntry("ExtGState", states)
+	}
+	page.SetNameObjectEntry("Resources", resources)
+	return p.AddPageContent(page, buffer.Bytes())
+}
+
+// appearanceMatrix returns the matrix which maps the bounding box of the given appearance stream, transformed by its matrix, onto the rectangle of the annotation, or nil if either is empty.
+func appearanceMatrix(annotation *DictionaryObject, form *StreamObject) ([]float64, error) {
+	rect, ok := Numbers(annotation.GetEntry("Rect"))
+	if !ok || len(rect) != 4 {
+		return nil, fmt.Errorf("Invalid Annotation Rectangle: %v", annotation.GetEntry("Rect"))
+	}
+	box, ok := Numbers(form.GetEntry("BBox"))
+	if !ok || len(box) != 4 {
+		return nil, fmt.Errorf("Invalid Appearance Bounding Box: %v", form.GetEntry("BBox"))
+	}
+	matrix, ok := Numbers(form.GetEntry("Matrix"))
+	if !ok || len(matrix) != 6 {
+		matrix = []float64{1, 0, 0, 1, 0, 0}
+	}
+	x0, y0 := math.Inf(1), math.Inf(1)
+	x1, y1 := math.Inf(-1), math.Inf(-1)
+	for _, c := range [][2]float64{{box[0], box[1]}, {box[0], box[3]}, {box[2], box[1]}, {box[2], box[3]}} {
+		x := matrix[0]*c[0] + matrix[2]*c[1] + matrix[4]
+		y := matrix[1]*c[0] + matrix[3]*c[1] + matrix[5]
+		x0, y0 = math.Min(x0, x), math.Min(y0, y)
+		x1, y1 = math.Max(x1, x), math.Max(y1, y)
+	}
+	left, bottom := math.Min(rect[0], rect[2]), math.Min(rect[1], rect[3])
+	right, top := math.Max(rect[0], rect[2]), math.Max(rect[1], rect[3])
+	if x1 <= x0 || y1 <= y0 || right <= left || top <= bottom {
+		return nil, nil
+	}
+	sx := (right - left) / (x1 - x0)
+	sy := (top - bottom) / (y1 - y0)
+	return []float64{sx, 0, 0, sy, left - sx*x0, bottom - sy*y0}, nil
+}
@@ -0,0 +1,152 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pdfgo_test
+
+import (
+	"bytes"
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/AletheiaWareLLC/pdfgo/annotation"
+	"github.com/AletheiaWareLLC/pdfgo/graphics"
+	"github.com/AletheiaWareLLC/pdfgo/render"
+	"github.com/stretchr/testify/assert"
+	"image/color"
+	"testing"
+)
+
+func TestFlattenAnnotations(t *testing.T) {
+	p := pdfgo.NewPDF()
+	p.AddAnnotation(pdfgo.NewHyperlink(0, 0, 10, 10, "https://example.com"))
+	p.AddPage(200, 200, nil, nil)
+	p.AddPage(200, 200, nil, nil)
+	pages := p.GetPages()
+	page := pages[0]
+
+	// An appearance drawn in a 10 by 10 box, scaled and translated to fill the rectangle
+	square := p.AddForm(0, 0, 10, 10, []float64{2, 0, 0, 2, 5, 5}, nil, []byte("1 0 0 rg 0 0 10 10 re f"))
+	a := p.NewDictionaryObject()
+	a.AddNameNameEntry("Type", "Annot")
+	a.AddNameNameEntry("Subtype", "Square")
+	a.AddNameObjectEntry("Rect", pdfgo.NewRectangleArray(100, 100, 150, 150))
+	ap := p.NewDictionaryObject()
+	ap.AddNameObjectEntry("N", square)
+	a.AddNameObjectEntry("AP", pdfgo.NewObjectReference(ap))
+	p.AddPageAnnotation(page, a)
+	popup := p.NewDictionaryObject()
+	popup.AddNameNameEntry("Subtype", "Popup")
+	popup.AddNameObjectEntry("Parent", pdfgo.NewObjectReference(a))
+	p.AddPageAnnotation(page, popup)
+	markup := annotation.NewMarkup(page, []float64{0, 0, 1})
+	markup.BorderWidth = 4
+	_, err := (&annotation.Ink{
+		Markup: markup,
+		Paths:  [][]float64{{10, 10, 50, 10}},
+	}).Add(p)
+	assert.Nil(t, err)
+	widget := p.NewDictionaryObject()
+	widget.AddNameNameEntry("Subtype", "Widget")
+	widget.AddNameObjectEntry("Rect", pdfgo.NewRectangleArray(0, 0, 10, 10))
+	widget.AddNameObjectEntry("AP", pdfgo.NewObjectReference(ap))
+	p.AddPageAnnotation(page, widget)
+
+	assert.Nil(t, p.FlattenAnnotations(page))
+	// The link has no appearance, and the widget belongs to a form
+	annots := pdfgo.Resolve(page.GetEntry("Annots")).(*pdfgo.ArrayObject)
+	assert.Equal(t, 2, len(annots.Array))
+	assert.Equal(t, widget, pdfgo.Resolve(annots.Array[1]))
+	// Other pages keep the shared annotations
+	assert.Equal(t, p.Annotations, pages[1].GetEntry("Annots"))
+	assert.Equal(t, 1, len(p.Annotations.Array))
+
+	data, err := pdfgo.PageContents(page)
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), "q 2.5 0 0 2.5 87.5 87.5 cm /Flat1 Do Q")
+	img, err := render.RenderPage(page, 72)
+	assert.Nil(t, err)
+	assert.Equal(t, color.RGBA{255, 0, 0, 255}, img.RGBAAt(125, 200-125))
+	assert.Equal(t, color.RGBA{0, 0, 255, 255}, img.RGBAAt(30, 200-10))
+	assert.Equal(t, color.RGBA{255, 255, 255, 255}, img.RGBAAt(5, 200-5))
+}
+
+func TestFlattenAnnotations_opacity(t *testing.T) {
+	p := pdfgo.NewPDF()
+	p.AddPage(200, 200, nil, nil)
+	page := p.GetPages()[0]
+	markup := annotation.NewMarkup(page, []float64{1, 0, 0})
+	markup.Opacity = 0.5
+	_, err := (&annotation.TextMarkup{
+		Markup: markup,
+		Type:   annotation.Highlight,
+		Areas:  []*graphics.Rectangle{{Left: 10, Bottom: 10, Right: 110, Top: 30}},
+	}).Add(p)
+	assert.Nil(t, err)
+
+	assert.Nil(t, p.FlattenAnnotations(page))
+	assert.Nil(t, page.GetEntry("Annots"))
+	data, err := pdfgo.PageContents(page)
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), "q /GS1 gs ")
+	states := pdfgo.Resolve(pdfgo.PageResources(page).GetEntry("ExtGState")).(*pdfgo.DictionaryObject)
+	state := pdfgo.Resolve(states.GetEntry("GS1")).(*pdfgo.DictionaryObject)
+	assert.Equal(t, 0.5, state.GetEntry("CA").(*pdfgo.NumberObject).Number)
+	assert.Equal(t, 0.5, state.GetEntry("ca").(*pdfgo.NumberObject).Number)
+	// Half of the red highlight is blended with the white page
+	img, err := render.RenderPage(page, 72)
+	assert.Nil(t, err)
+	c := img.RGBAAt(60, 200-20)
+	assert.Equal(t, uint8(255), c.R)
+	assert.InDelta(t, 128, int(c.G), 2)
+	assert.InDelta(t, 128, int(c.B), 2)
+}
+
+func TestFlattenAnnotations_parsed(t *testing.T) {
+	source := pdfgo.NewPDF()
+	resources := source.NewDictionaryObject()
+	resources.AddNameObjectEntry("XObject", &pdfgo.DictionaryObject{
+		Dictionary: make(map[*pdfgo.NameObject]pdfgo.Object),
+	})
+	source.AddPage(200, 200, pdfgo.NewObjectReference(resources), nil)
+	page := source.GetPages()[0]
+	page.RemoveEntry("Annots")
+	_, err := (&annotation.Square{
+		Markup:         annotation.NewMarkup(page, annotation.RED),
+		Rectangle:      graphics.Rectangle{Left: 20, Bottom: 20, Right: 60, Top: 60},
+		InteriorColour: annotation.RED,
+	}).Add(source)
+	assert.Nil(t, err)
+	var buffer bytes.Buffer
+	assert.Nil(t, source.Write(&buffer))
+	original := append([]byte{}, buffer.Bytes()...)
+
+	p, err := pdfgo.ReadPDF(original)
+	assert.Nil(t, err)
+	page = p.GetPages()[0]
+	assert.Nil(t, p.FlattenAnnotations(page))
+	assert.False(t, page.HasEntry("Annots"))
+	buffer.Reset()
+	assert.Nil(t, p.WriteUpdate(&buffer, original, []pdfgo.Object{page}))
+
+	result, err := pdfgo.ReadPDF(buffer.Bytes())
+	assert.Nil(t, err)
+	page = result.GetPages()[0]
+	assert.False(t, page.HasEntry("Annots"))
+	img, err := render.RenderPage(page, 72)
+	assert.Nil(t, err)
+	assert.Equal(t, color.RGBA{255, 0, 0, 255}, img.RGBAAt(40, 200-40))
+	// The shared resources are not changed
+	xs := pdfgo.Resolve(result.Objects[resources.GetName()-1].(*pdfgo.DictionaryObject).GetEntry("XObject")).(*pdfgo.DictionaryObject)
+	assert.Equal(t, 0, len(xs.Keys))
+}
@@ -0,0 +1,398 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package font
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/AletheiaWareLLC/pdfgo"
+	"sort"
+	"unicode/utf16"
+)
+
+// Entries per block of a CMap, which PostScript limits to 100
+const CMAP_BLOCK_SIZE = 100
+
+type CodeSpaceRange struct {
+	Low, High []byte
+}
+
+type unicodeRange struct {
+	Low, High int
+	Length    int
+	// Either Start is incremented across the range, or each code has its own entry in Array
+	Start []rune
+	Array [][]rune
+}
+
+type cidRange struct {
+	Low, High int
+	Length    int
+	CID       int
+}
+
+// CMap maps character codes to Unicode, as in a ToUnicode stream, or to CIDs, as in the Encoding of a composite font.
+type CMap struct {
+	Name          string
+	CodeSpaces    []*CodeSpaceRange
+	unicodes      map[string][]rune
+	unicodeRanges []*unicodeRange
+	cids          map[string]int
+	cidRanges     []*cidRange
+}
+
+func NewCMap(name string) *CMap {
+	return &CMap{
+		Name:     name,
+		unicodes: make(map[string][]rune),
+		cids:     make(map[string]int),
+	}
+}
+
+// ParseCMap parses the PostScript CMap in the given data.
+func ParseCMap(data []byte) (*CMap, error) {
+	c := NewCMap("")
+	p := pdfgo.NewParser(data)
+	var operands []pdfgo.Object
+	for {
+		t, err := p.Peek()
+		if err != nil {
+			return nil, err
+		}
+		if t.Kind == pdfgo.TokenEOF {
+			break
+		}
+		if t.Kind == pdfgo.TokenKeyword {
+			p.Next()
+			switch t.Value {
+			case "def":
+				if len(operands) >= 2 {
+					if k, ok := operands[len(operands)-2].(*pdfgo.NameObject); ok && k.Name == "CMapName" {
+						if v, ok := operands[len(operands)-1].(*pdfgo.NameObject); ok {
+							c.Name = v.Name
+						}
+					}
+				}
+			case "endcodespacerange":
+				for i := 0; i+1 < len(operands); i += 2 {
+					low, ok1 := operands[i].(*pdfgo.StringObject)
+					high, ok2 := operands[i+1].(*pdfgo.StringObject)
+					if ok1 && ok2 {
+						c.CodeSpaces = append(c.CodeSpaces, &CodeSpaceRange{
+							Low:  low.Bytes(),
+							High: high.Bytes(),
+						})
+					}
+				}
+			case "endbfchar":
+				for i := 0; i+1 < len(operands); i += 2 {
+					code, ok := operands[i].(*pdfgo.StringObject)
+					if !ok {
+						continue
+					}
+					switch v := operands[i+1].(type) {
+					case *pdfgo.StringObject:
+						c.unicodes[string(code.Bytes())] = decodeUTF16(v.Bytes())
+					case *pdfgo.NameObject:
+						c.unicodes[string(code.Bytes())] = GlyphRunes(v.Name)
+					}
+				}
+			case "endbfrange":
+				for i := 0; i+2 < len(operands); i += 3 {
+					low, ok1 := operands[i].(*pdfgo.StringObject)
+					high, ok2 := operands[i+1].(*pdfgo.StringObject)
+					if !ok1 || !ok2 {
+						continue
+					}
+					r := &unicodeRange{
+						Low:    codeValue(low.Bytes()),
+						High:   codeValue(high.Bytes()),
+						Length: len(low.Bytes()),
+					}
+					switch v := operands[i+2].(type) {
+					case *pdfgo.StringObject:
+						r.Start = decodeUTF16(v.Bytes())
+					case *pdfgo.ArrayObject:
+						for _, e := range v.Array {
+							if s, ok := e.(*pdfgo.StringObject); ok {
+								r.Array = append(r.Array, decodeUTF16(s.Bytes()))
+							} else {
+								r.Array = append(r.Array, nil)
+							}
+						}
+					}
+					c.unicodeRanges = append(c.unicodeRanges, r)
+				}
+			case "endcidchar":
+				for i := 0; i+1 < len(operands); i += 2 {
+					code, ok1 := operands[i].(*pdfgo.StringObject)
+					cid, ok2 := operands[i+1].(*pdfgo.NumberObject)
+					if ok1 && ok2 {
+						c.cids[string(code.Bytes())] = int(cid.Number)
+					}
+				}
+			case "endcidrange":
+				for i := 0; i+2 < len(operands); i += 3 {
+					low, ok1 := operands[i].(*pdfgo.StringObject)
+					high, ok2 := operands[i+1].(*pdfgo.StringObject)
+					cid, ok3 := operands[i+2].(*pdfgo.NumberObject)
+					if ok1 && ok2 && ok3 {
+						c.cidRanges = append(c.cidRanges, &cidRange{
+							Low:    codeValue(low.Bytes()),
+							High:   codeValue(high.Bytes()),
+							Length: len(low.Bytes()),
+							CID:    int(cid.Number),
+						})
+					}
+				}
+			}
+			operands = nil
+			continue
+		}
+		o, err := p.ParseObject()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, o)
+	}
+	if len(c.CodeSpaces) == 0 && len(c.unicodes) == 0 && len(c.unicodeRanges) == 0 && len(c.cids) == 0 && len(c.cidRanges) == 0 {
+		return nil, errors.New("Empty CMap")
+	}
+	return c, nil
+}
+
+// CodeLength returns the number of bytes in the code at the start of the given data.
+func (c *CMap) CodeLength(data []byte) int {
+	for length := 1; length <= 4 && length <= len(data); length++ {
+		for _, r := range c.CodeSpaces {
+			if len(r.Low) != length {
+				continue
+			}
+			match := true
+			for i := 0; i < length; i++ {
+				if data[i] < r.Low[i] || data[i] > r.High[i] {
+					match = false
+					break
+				}
+			}
+			if match {
+				return length
+			}
+		}
+	}
+	if len(c.CodeSpaces) > 0 {
+		// Use the shortest code space length for codes that don't match any range
+		shortest := len(c.CodeSpaces[0].Low)
+		for _, r := range c.CodeSpaces {
+			if len(r.Low) < shortest {
+				shortest = len(r.Low)
+			}
+		}
+		if shortest > 0 && shortest <= len(data) {
+			return shortest
+		}
+	}
+	return 1
+}
+
+// Unicode returns the characters the given code maps to.
+func (c *CMap) Unicode(code []byte) ([]rune, bool) {
+	if r, ok := c.unicodes[string(code)]; ok {
+		return r, true
+	}
+	v := codeValue(code)
+	for _, r := range c.unicodeRanges {
+		if r.Length != len(code) || v < r.Low || v > r.High {
+			continue
+		}
+		if r.Array != nil {
+			if i := v - r.Low; i < len(r.Array) && r.Array[i] != nil {
+				return r.Array[i], true
+			}
+			continue
+		}
+		if len(r.Start) == 0 {
+			continue
+		}
+		runes := append([]rune{}, r.Start...)
+		runes[len(runes)-1] += rune(v - r.Low)
+		return runes, true
+	}
+	return nil, false
+}
+
+// CID returns the CID the given code maps to.
+func (c *CMap) CID(code []byte) (int, bool) {
+	if cid, ok := c.cids[string(code)]; ok {
+		return cid, true
+	}
+	v := codeValue(code)
+	for _, r := range c.cidRanges {
+		if r.Length == len(code) && v >= r.Low && v <= r.High {
+			return r.CID + v - r.Low, true
+		}
+	}
+	return 0, false
+}
+
+// Code returns the code which maps to the given character, if any.
+func (c *CMap) Code(r rune) ([]byte, bool) {
+	// Codes are searched in order, so the same code is chosen each time where several map to the character
+	for _, code := range c.codes() {
+		if runes := c.unicodes[code]; len(runes) == 1 && runes[0] == r {
+			return []byte(code), true
+		}
+	}
+	for _, u := range c.unicodeRanges {
+		for v := u.Low; v <= u.High; v++ {
+			var runes []rune
+			if u.Array != nil {
+				if i := v - u.Low; i < len(u.Array) {
+					runes = u.Array[i]
+				}
+			} else if len(u.Start) == 1 {
+				runes = []rune{u.Start[0] + rune(v-u.Low)}
+			}
+			if len(runes) == 1 && runes[0] == r {
+				code := make([]byte, u.Length)
+				for i := range code {
+					code[i] = byte(v >> uint(8*(u.Length-1-i)))
+				}
+				return code, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// AddUnicode maps the given code to the given characters.
+func (c *CMap) AddUnicode(code []byte, runes []rune) {
+	c.unicodes[string(code)] = runes
+}
+
+// AddCodeSpace adds the range of valid codes from low to high.
+func (c *CMap) AddCodeSpace(low, high []byte) {
+	c.CodeSpaces = append(c.CodeSpaces, &CodeSpaceRange{
+		Low:  low,
+		High: high,
+	})
+}
+
+// Bytes returns the CMap as a ToUnicode CMap program, mapping each code to the UTF-16 encoding of its characters.
+func (c *CMap) Bytes() []byte {
+	name := c.Name
+	if name == "" {
+		name = "Adobe-Identity-UCS"
+	}
+	var b bytes.Buffer
+	b.WriteString("/CIDInit /ProcSet findresource begin\n")
+	b.WriteString("12 dict begin\n")
+	b.WriteString("begincmap\n")
+	b.WriteString("/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n")
+	fmt.Fprintf(&b, "/CMapName /%s def\n", pdfgo.EscapeName(name))
+	b.WriteString("/CMapType 2 def\n")
+	fmt.Fprintf(&b, "%d begincodespacerange\n", len(c.CodeSpaces))
+	for _, r := range c.CodeSpaces {
+		fmt.Fprintf(&b, "<%X> <%X>\n", r.Low, r.High)
+	}
+	b.WriteString("endcodespacerange\n")
+	codes := c.codes()
+	for i := 0; i < len(codes); i += CMAP_BLOCK_SIZE {
+		block := codes[i:]
+		if len(block) > CMAP_BLOCK_SIZE {
+			block = block[:CMAP_BLOCK_SIZE]
+		}
+		fmt.Fprintf(&b, "%d beginbfchar\n", len(block))
+		for _, code := range block {
+			fmt.Fprintf(&b, "<%X> <%s>\n", code, encodeUTF16(c.unicodes[code]))
+		}
+		b.WriteString("endbfchar\n")
+	}
+	for i := 0; i < len(c.unicodeRanges); i += CMAP_BLOCK_SIZE {
+		block := c.unicodeRanges[i:]
+		if len(block) > CMAP_BLOCK_SIZE {
+			block = block[:CMAP_BLOCK_SIZE]
+		}
+		fmt.Fprintf(&b, "%d beginbfrange\n", len(block))
+		for _, r := range block {
+			fmt.Fprintf(&b, "<%0*X> <%0*X> ", 2*r.Length, r.Low, 2*r.Length, r.High)
+			if r.Array != nil {
+				b.WriteString("[")
+				for j, runes := range r.Array {
+					if j > 0 {
+						b.WriteString(" ")
+					}
+					fmt.Fprintf(&b, "<%s>", encodeUTF16(runes))
+				}
+				b.WriteString("]\n")
+			} else {
+				fmt.Fprintf(&b, "<%s>\n", encodeUTF16(r.Start))
+			}
+		}
+		b.WriteString("endbfrange\n")
+	}
+	b.WriteString("endcmap\n")
+	b.WriteString("CMapName currentdict /CMap defineresource pop\n")
+	b.WriteString("end\n")
+	b.WriteString("end\n")
+	return b.Bytes()
+}
+
+// codes returns the codes mapped to characters, shortest first, then in order of their values.
+func (c *CMap) codes() []string {
+	var codes []string
+	for code := range c.unicodes {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool {
+		if len(codes[i]) != len(codes[j]) {
+			return len(codes[i]) < len(codes[j])
+		}
+		return codes[i] < codes[j]
+	})
+	return codes
+}
+
+func codeValue(code []byte) int {
+	v := 0
+	for _, b := range code {
+		v = v<<8 | int(b)
+	}
+	return v
+}
+
+func decodeUTF16(data []byte) []rune {
+	if len(data)%2 == 1 {
+		// Single byte destinations are treated as a code unit
+		data = append([]byte{0}, data...)
+	}
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = uint16(data[2*i])<<8 | uint16(data[2*i+1])
+	}
+	return utf16.Decode(units)
+}
+
+// encodeUTF16 returns the hexadecimal UTF-16 encoding of the given characters.
+func encodeUTF16(runes []rune) string {
+	var b bytes.Buffer
+	for _, u := range utf16.Encode(runes) {
+		fmt.Fprintf(&b, "%04X", u)
+	}
+	return b.String()
+}
@@ -59,18 +59,57 @@ func NewCoreFont(p *pdfgo.PDF, name string) (*CoreFont, error) {
 	if metrics == nil {
 		return nil, errors.New("Could not get font: " + name)
 	}
+	if metrics.FontName == "" {
+		metrics.FontName = name
+	}
+	return NewCoreFontFromAFM(p, metrics), nil
+}
+
+// NewCoreFontFromAFM adds a font with the given metrics, which isn't embedded, with its widths and a descriptor of its metrics.
+func NewCoreFontFromAFM(p *pdfgo.PDF, metrics *AFM) *CoreFont {
+	name := metrics.FontName
+	d := AFMDescriptor(metrics)
 	font := p.NewDictionaryObject()
 	font.AddNameNameEntry("Type", "Font")
 	font.AddNameNameEntry("Subtype", "Type1")
 	font.AddNameNameEntry("BaseFont", name)
-	if name != "Symbol" && name != "ZapfDingbats" {
+	symbolic := d.Flags&FLAG_SYMBOLIC != 0
+	if !symbolic {
 		font.AddNameNameEntry("Encoding", "WinAnsiEncoding")
 	}
+	// Symbolic fonts use the codes of their built-in encoding, others those of WinAnsiEncoding
+	codes := make(map[int]float64)
+	for n, m := range metrics.Metrics {
+		if symbolic && m.Code >= 0 {
+			codes[m.Code] = m.WidthX[0]
+		}
+		if !symbolic {
+			for code, c := range WinAnsiEncoding {
+				if c == n {
+					codes[code] = m.WidthX[0]
+				}
+			}
+		}
+	}
+	var widths []pdfgo.Object
+	for code := 32; code < 256; code++ {
+		widths = append(widths, &pdfgo.NumberObject{
+			Number: codes[code],
+		})
+	}
+	font.AddNameObjectEntry("FirstChar", &pdfgo.NumberObject{
+		Number: 32,
+	})
+	font.AddNameObjectEntry("LastChar", &pdfgo.NumberObject{
+		Number: 255,
+	})
+	font.AddNameObjectEntry("Widths", pdfgo.NewObjectReference(p.NewArrayObject(widths)))
+	font.AddNameObjectEntry("FontDescriptor", pdfgo.NewObjectReference(d.Add(p)))
 	return &CoreFont{
 		Name:      name,
 		Metrics:   metrics,
 		Reference: pdfgo.NewObjectReference(font),
-	}, nil
+	}
 }
 
 func (f *CoreFont) GetReference() *pdfgo.ObjectReference {
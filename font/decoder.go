@@ -0,0 +1,330 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package font
+
+import (
+	"github.com/AletheiaWareLLC/pdfgo"
+	"log"
+)
+
+// Code is a single character code decoded from a string shown with a font.
+type Code struct {
+	Bytes []byte
+	// Code is the numeric value of Bytes
+	Code int
+	// CID is the character identifier of the code in a composite font
+	CID int
+	// Text holds the characters the code represents, if known
+	Text []rune
+	// Name is the glyph name of the code in the encoding of a simple font, if any
+	Name string
+	// Width is the horizontal displacement in text space for a font size of one
+	Width float64
+	// Space is true if word spacing applies to the code
+	Space bool
+}
+
+// Decoder maps the strings shown with a font dictionary to text and widths.
+type Decoder struct {
+	Name      string
+	Subtype   string
+	Composite bool
+	encoding  *Encoding
+	toUnicode *CMap
+	cmap      *CMap
+	// Widths of simple fonts
+	firstChar    int
+	widths       []float64
+	missingWidth float64
+	// Widths of composite fonts
+	cidWidths    map[int]float64
+	defaultWidth float64
+	// Scale converts glyph space to text space
+	scale float64
+}
+
+// NewDecoder creates a decoder for the given font dictionary.
+// Missing or malformed entries are tolerated, falling back to defaults.
+func NewDecoder(dictionary *pdfgo.DictionaryObject) *Decoder {
+	d := &Decoder{
+		scale:        0.001,
+		defaultWidth: 1000,
+	}
+	if n, ok := pdfgo.Resolve(dictionary.GetEntry("BaseFont")).(*pdfgo.NameObject); ok {
+		d.Name = n.Name
+	}
+	if n, ok := pdfgo.Resolve(dictionary.GetEntry("Subtype")).(*pdfgo.NameObject); ok {
+		d.Subtype = n.Name
+	}
+	if s, ok := pdfgo.Resolve(dictionary.GetEntry("ToUnicode")).(*pdfgo.StreamObject); ok {
+		if data, err := s.Decode(); err != nil {
+			log.Println("Font", d.Name, "ToUnicode:", err)
+		} else if c, err := ParseCMap(data); err != nil {
+			log.Println("Font", d.Name, "ToUnicode:", err)
+		} else {
+			d.toUnicode = c
+		}
+	}
+	if d.Subtype == "Type0" {
+		d.Composite = true
+		d.readComposite(dictionary)
+	} else {
+		d.readSimple(dictionary)
+	}
+	return d
+}
+
+func (d *Decoder) readSimple(dictionary *pdfgo.DictionaryObject) {
+	if d.Subtype == "Type3" {
+		if m, ok := pdfgo.Resolve(dictionary.GetEntry("FontMatrix")).(*pdfgo.ArrayObject); ok && len(m.Array) > 0 {
+			if n, ok := pdfgo.Resolve(m.Array[0]).(*pdfgo.NumberObject); ok {
+				d.scale = n.Number
+			}
+		}
+	}
+	switch e := pdfgo.Resolve(dictionary.GetEntry("Encoding")).(type) {
+	case *pdfgo.NameObject:
+		d.encoding = copyEncoding(GetEncoding(e.Name))
+	case *pdfgo.DictionaryObject:
+		if n, ok := pdfgo.Resolve(e.GetEntry("BaseEncoding")).(*pdfgo.NameObject); ok {
+			d.encoding = copyEncoding(GetEncoding(n.Name))
+		}
+		if d.encoding == nil {
+			d.encoding = copyEncoding(d.defaultEncoding())
+		}
+		if a, ok := pdfgo.Resolve(e.GetEntry("Differences")).(*pdfgo.ArrayObject); ok {
+			code := 0
+			for _, o := range a.Array {
+				switch v := pdfgo.Resolve(o).(type) {
+				case *pdfgo.NumberObject:
+					code = int(v.Number)
+				case *pdfgo.NameObject:
+					if code >= 0 && code < 256 {
+						d.encoding[code] = v.Name
+					}
+					code++
+				}
+			}
+		}
+	}
+	if d.encoding == nil {
+		d.encoding = d.defaultEncoding()
+	}
+	if n, ok := pdfgo.Resolve(dictionary.GetEntry("FirstChar")).(*pdfgo.NumberObject); ok {
+		d.firstChar = int(n.Number)
+	}
+	if a, ok := pdfgo.Resolve(dictionary.GetEntry("Widths")).(*pdfgo.ArrayObject); ok {
+		for _, o := range a.Array {
+			var w float64
+			if n, ok := pdfgo.Resolve(o).(*pdfgo.NumberObject); ok {
+				w = n.Number
+			}
+			d.widths = append(d.widths, w)
+		}
+	}
+	if fd, ok := pdfgo.Resolve(dictionary.GetEntry("FontDescriptor")).(*pdfgo.DictionaryObject); ok {
+		if n, ok := pdfgo.Resolve(fd.GetEntry("MissingWidth")).(*pdfgo.NumberObject); ok {
+			d.missingWidth = n.Number
+		}
+	}
+}
+
+func (d *Decoder) defaultEncoding() *Encoding {
+	switch StandardFontFamily(d.Name) {
+	case "Symbol", "ZapfDingbats":
+		// Symbolic fonts use their built-in encoding, which isn't known here
+		return nil
+	}
+	if d.Subtype == "TrueType" {
+		return &WinAnsiEncoding
+	}
+	return &StandardEncoding
+}
+
+func copyEncoding(e *Encoding) *Encoding {
+	if e == nil {
+		return nil
+	}
+	c := *e
+	return &c
+}
+
+func (d *Decoder) readComposite(dictionary *pdfgo.DictionaryObject) {
+	switch e := pdfgo.Resolve(dictionary.GetEntry("Encoding")).(type) {
+	case *pdfgo.NameObject:
+		if e.Name != "Identity-H" && e.Name != "Identity-V" {
+			log.Println("Font", d.Name, "unsupported CMap:", e.Name)
+		}
+	case *pdfgo.StreamObject:
+		if data, err := e.Decode(); err != nil {
+			log.Println("Font", d.Name, "Encoding:", err)
+		} else if c, err := ParseCMap(data); err != nil {
+			log.Println("Font", d.Name, "Encoding:", err)
+		} else {
+			d.cmap = c
+		}
+	}
+	d.cidWidths = make(map[int]float64)
+	descendants, ok := pdfgo.Resolve(dictionary.GetEntry("DescendantFonts")).(*pdfgo.ArrayObject)
+	if !ok || len(descendants.Array) == 0 {
+		return
+	}
+	descendant, ok := pdfgo.Resolve(descendants.Array[0]).(*pdfgo.DictionaryObject)
+	if !ok {
+		return
+	}
+	if n, ok := pdfgo.Resolve(descendant.GetEntry("DW")).(*pdfgo.NumberObject); ok {
+		d.defaultWidth = n.Number
+	}
+	w, ok := pdfgo.Resolve(descendant.GetEntry("W")).(*pdfgo.ArrayObject)
+	if !ok {
+		return
+	}
+	// W contains entries of the form "c [w1 w2 ... wn]" and "cfirst clast w"
+	for i := 0; i+1 < len(w.Array); {
+		first, ok := pdfgo.Resolve(w.Array[i]).(*pdfgo.NumberObject)
+		if !ok {
+			return
+		}
+		switch v := pdfgo.Resolve(w.Array[i+1]).(type) {
+		case *pdfgo.ArrayObject:
+			for j, o := range v.Array {
+				if n, ok := pdfgo.Resolve(o).(*pdfgo.NumberObject); ok {
+					d.cidWidths[int(first.Number)+j] = n.Number
+				}
+			}
+			i += 2
+		case *pdfgo.NumberObject:
+			if i+2 >= len(w.Array) {
+				return
+			}
+			n, ok := pdfgo.Resolve(w.Array[i+2]).(*pdfgo.NumberObject)
+			if !ok {
+				return
+			}
+			for c := int(first.Number); c <= int(v.Number); c++ {
+				d.cidWidths[c] = n.Number
+			}
+			i += 3
+		default:
+			return
+		}
+	}
+}
+
+// Encode returns the code which shows the given character in the font, if any.
+func (d *Decoder) Encode(r rune) ([]byte, bool) {
+	if d.toUnicode != nil {
+		if code, ok := d.toUnicode.Code(r); ok && (d.Composite || len(code) == 1) {
+			return code, true
+		}
+	}
+	switch {
+	case d.Composite:
+		return nil, false
+	case d.encoding != nil:
+		if c, ok := d.encoding.Encode(r); ok {
+			return []byte{c}, true
+		}
+		return nil, false
+	case r < 256:
+		// Without an encoding assume the code is the character
+		return []byte{byte(r)}, true
+	}
+	return nil, false
+}
+
+// Decode splits the given string into character codes.
+func (d *Decoder) Decode(data []byte) []*Code {
+	var codes []*Code
+	for len(data) > 0 {
+		length := 1
+		if d.Composite {
+			if d.cmap != nil {
+				length = d.cmap.CodeLength(data)
+			} else if len(data) >= 2 {
+				length = 2
+			}
+		}
+		b := data[:length]
+		data = data[length:]
+		c := &Code{
+			Bytes: b,
+			Code:  codeValue(b),
+			Space: length == 1 && b[0] == ' ',
+		}
+		if d.Composite {
+			d.decodeComposite(c)
+		} else {
+			d.decodeSimple(c)
+		}
+		codes = append(codes, c)
+	}
+	return codes
+}
+
+func (d *Decoder) decodeSimple(c *Code) {
+	var name string
+	if d.encoding != nil {
+		name = d.encoding[c.Code]
+		c.Name = name
+	}
+	if d.toUnicode != nil {
+		c.Text, _ = d.toUnicode.Unicode(c.Bytes)
+	}
+	if c.Text == nil && name != "" {
+		c.Text = GlyphRunes(name)
+	}
+	if c.Text == nil && c.Code >= 0x20 {
+		// Without an encoding assume the code is the character
+		c.Text = []rune{rune(c.Code)}
+	}
+	if i := c.Code - d.firstChar; d.widths != nil && i >= 0 && i < len(d.widths) {
+		c.Width = d.widths[i] * d.scale
+		return
+	}
+	if d.missingWidth != 0 || d.widths != nil {
+		c.Width = d.missingWidth * d.scale
+		return
+	}
+	var r rune = ' '
+	if len(c.Text) > 0 {
+		r = c.Text[0]
+	}
+	c.Width = StandardFontWidth(d.Name, r) * d.scale
+}
+
+func (d *Decoder) decodeComposite(c *Code) {
+	cid := c.Code
+	if d.cmap != nil {
+		if v, ok := d.cmap.CID(c.Bytes); ok {
+			cid = v
+		}
+	}
+	c.CID = cid
+	if d.toUnicode != nil {
+		c.Text, _ = d.toUnicode.Unicode(c.Bytes)
+	}
+	if c.Text == nil {
+		c.Text = []rune{'\uFFFD'}
+	}
+	if w, ok := d.cidWidths[cid]; ok {
+		c.Width = w * d.scale
+	} else {
+		c.Width = d.defaultWidth * d.scale
+	}
+}
@@ -0,0 +1,333 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package font
+
+import (
+	"encoding/binary"
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/golang/freetype/truetype"
+	xfont "golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+	"math"
+	"strings"
+)
+
+// Flags of a font descriptor
+const (
+	FLAG_FIXED_PITCH = 1 << 0
+	FLAG_SERIF       = 1 << 1
+	FLAG_SYMBOLIC    = 1 << 2
+	FLAG_SCRIPT      = 1 << 3
+	FLAG_NONSYMBOLIC = 1 << 5
+	FLAG_ITALIC      = 1 << 6
+	FLAG_ALL_CAP     = 1 << 16
+	FLAG_SMALL_CAP   = 1 << 17
+	FLAG_FORCE_BOLD  = 1 << 18
+)
+
+// Weight classes of fonts whose weight isn't known
+const (
+	WEIGHT_NORMAL = 400
+	WEIGHT_BOLD   = 700
+)
+
+// Descriptor holds the metrics and flags of a font, in glyph space of 1000 units per em.
+type Descriptor struct {
+	FontName     string
+	Flags        int
+	FontBBox     [4]float64
+	ItalicAngle  float64
+	Ascent       float64
+	Descent      float64
+	Leading      float64
+	CapHeight    float64
+	XHeight      float64
+	StemV        float64
+	StemH        float64
+	AvgWidth     float64
+	MaxWidth     float64
+	MissingWidth float64
+}
+
+// Add adds a font descriptor dictionary holding the metrics to the document, omitting optional metrics which are zero.
+func (d *Descriptor) Add(p *pdfgo.PDF) *pdfgo.DictionaryObject {
+	descriptor := p.NewDictionaryObject()
+	descriptor.AddNameNameEntry("Type", "FontDescriptor")
+	descriptor.AddNameNameEntry("FontName", d.FontName)
+	descriptor.AddNameObjectEntry("Flags", &pdfgo.NumberObject{
+		Number: float64(d.Flags),
+	})
+	var bbox []pdfgo.Object
+	for _, v := range d.FontBBox {
+		bbox = append(bbox, &pdfgo.NumberObject{
+			Number: v,
+		})
+	}
+	descriptor.AddNameObjectEntry("FontBBox", &pdfgo.ArrayObject{
+		Array: bbox,
+	})
+	for _, e := range []struct {
+		key      string
+		value    float64
+		optional bool
+	}{
+		{"ItalicAngle", d.ItalicAngle, false},
+		{"Ascent", d.Ascent, false},
+		{"Descent", d.Descent, false},
+		{"Leading", d.Leading, true},
+		{"CapHeight", d.CapHeight, false},
+		{"XHeight", d.XHeight, true},
+		{"StemV", d.StemV, false},
+		{"StemH", d.StemH, true},
+		{"AvgWidth", d.AvgWidth, true},
+		{"MaxWidth", d.MaxWidth, true},
+		{"MissingWidth", d.MissingWidth, true},
+	} {
+		if e.optional && e.value == 0 {
+			continue
+		}
+		descriptor.AddNameObjectEntry(e.key, &pdfgo.NumberObject{
+			Number: e.value,
+		})
+	}
+	return descriptor
+}
+
+// ReadTrueTypeDescriptor returns the metrics and flags of the given TrueType font, read from its head, hhea, OS/2 and post tables, falling back to measuring its glyphs where tables or values are missing.
+func ReadTrueTypeDescriptor(data []byte, f *truetype.Font) *Descriptor {
+	tables, _ := readTables(data)
+	units := float64(f.FUnitsPerEm())
+	scale := fixed.Int26_6(f.FUnitsPerEm())
+	glyphSpace := func(v float64) float64 {
+		return math.Round(v * 1000 / units)
+	}
+	d := &Descriptor{
+		FontName: postscriptName(f),
+	}
+
+	bounds := f.Bounds(scale)
+	d.FontBBox = [4]float64{
+		glyphSpace(float64(bounds.Min.X)),
+		glyphSpace(float64(bounds.Min.Y)),
+		glyphSpace(float64(bounds.Max.X)),
+		glyphSpace(float64(bounds.Max.Y)),
+	}
+	var macStyle uint16
+	if head := tables["head"]; len(head) >= 46 {
+		macStyle = binary.BigEndian.Uint16(head[44:])
+	}
+
+	// Vertical metrics come from the horizontal header, or else from the typographic metrics, or else from the bounding box
+	var ascent, descent, lineGap int16
+	if hhea := tables["hhea"]; len(hhea) >= 12 {
+		ascent = int16(binary.BigEndian.Uint16(hhea[4:]))
+		descent = int16(binary.BigEndian.Uint16(hhea[6:]))
+		lineGap = int16(binary.BigEndian.Uint16(hhea[8:]))
+		d.MaxWidth = glyphSpace(float64(binary.BigEndian.Uint16(hhea[10:])))
+	}
+	var (
+		weight                    = WEIGHT_NORMAL
+		family, fsSelection       int
+		panose                    [10]byte
+		avgWidth, xHeight, capHgt int16
+	)
+	if macStyle&1 != 0 {
+		weight = WEIGHT_BOLD
+	}
+	if os2 := tables["OS/2"]; len(os2) >= 78 {
+		avgWidth = int16(binary.BigEndian.Uint16(os2[2:]))
+		if w := int(binary.BigEndian.Uint16(os2[4:])); w > 0 {
+			weight = w
+		}
+		family = int(os2[30])
+		copy(panose[:], os2[32:42])
+		fsSelection = int(binary.BigEndian.Uint16(os2[62:]))
+		if ascent == 0 && descent == 0 {
+			ascent = int16(binary.BigEndian.Uint16(os2[68:]))
+			descent = int16(binary.BigEndian.Uint16(os2[70:]))
+			lineGap = int16(binary.BigEndian.Uint16(os2[72:]))
+		}
+		if version := binary.BigEndian.Uint16(os2); version >= 2 && len(os2) >= 90 {
+			xHeight = int16(binary.BigEndian.Uint16(os2[86:]))
+			capHgt = int16(binary.BigEndian.Uint16(os2[88:]))
+		}
+	}
+	if ascent == 0 && descent == 0 {
+		d.Ascent, d.Descent = d.FontBBox[3], d.FontBBox[1]
+	} else {
+		d.Ascent, d.Descent = glyphSpace(float64(ascent)), glyphSpace(float64(descent))
+	}
+	if lineGap > 0 {
+		d.Leading = d.Ascent - d.Descent + glyphSpace(float64(lineGap))
+	}
+	d.CapHeight = glyphSpace(float64(capHgt))
+	if d.CapHeight == 0 {
+		d.CapHeight = glyphHeight(f, 'H', glyphSpace)
+	}
+	if d.CapHeight == 0 {
+		d.CapHeight = d.Ascent
+	}
+	d.XHeight = glyphSpace(float64(xHeight))
+	if d.XHeight == 0 {
+		d.XHeight = glyphHeight(f, 'x', glyphSpace)
+	}
+	d.StemV = stemV(weight)
+
+	// Widths are averaged over all glyphs with a width, if the font doesn't give an average
+	d.AvgWidth = glyphSpace(float64(avgWidth))
+	count, _ := glyphCount(data)
+	if d.AvgWidth <= 0 {
+		var sum, n float64
+		for i := 0; i < count; i++ {
+			if w := float64(f.HMetric(scale, truetype.Index(i)).AdvanceWidth); w > 0 {
+				sum += w
+				n++
+			}
+		}
+		if n > 0 {
+			d.AvgWidth = glyphSpace(sum / n)
+		}
+	}
+	d.MissingWidth = glyphSpace(float64(f.HMetric(scale, 0).AdvanceWidth))
+
+	var fixedPitch bool
+	if post := tables["post"]; len(post) >= 16 {
+		d.ItalicAngle = math.Round(float64(int32(binary.BigEndian.Uint32(post[4:])))/65536*10) / 10
+		fixedPitch = binary.BigEndian.Uint32(post[12:]) != 0
+	}
+
+	// Flags come from the family class and PANOSE classification of the font
+	if fixedPitch || panose[3] == 9 {
+		d.Flags |= FLAG_FIXED_PITCH
+	}
+	switch {
+	case family >= 1 && family <= 5, family == 7:
+		d.Flags |= FLAG_SERIF
+	case family == 0 && panose[0] == 2 && panose[1] >= 2 && panose[1] <= 10:
+		d.Flags |= FLAG_SERIF
+	}
+	if family == 10 || panose[0] == 3 {
+		d.Flags |= FLAG_SCRIPT
+	}
+	if fsSelection&1 != 0 || macStyle&2 != 0 || d.ItalicAngle != 0 {
+		d.Flags |= FLAG_ITALIC
+	}
+	if family == 12 || panose[0] == 5 || hasSymbolCmap(tables["cmap"]) || f.Index('A') == 0 {
+		// Fonts of symbols, or without Latin characters, aren't described by a standard encoding
+		d.Flags |= FLAG_SYMBOLIC
+	} else {
+		d.Flags |= FLAG_NONSYMBOLIC
+	}
+	return d
+}
+
+// AFMDescriptor returns the metrics and flags of the font described by the given font metrics.
+func AFMDescriptor(a *AFM) *Descriptor {
+	d := &Descriptor{
+		FontName:  a.FontName,
+		FontBBox:  a.FontBBox,
+		Ascent:    a.Ascender,
+		Descent:   a.Descender,
+		CapHeight: a.CapHeight,
+		XHeight:   a.XHeight,
+		StemV:     a.StdVW,
+		StemH:     a.StdHW,
+	}
+	var fixedPitch bool
+	if direction := a.Directions[0]; direction != nil {
+		d.ItalicAngle = direction.ItalicAngle
+		fixedPitch = direction.IsFixedPitch
+	}
+	if d.Ascent == 0 && d.Descent == 0 {
+		d.Ascent, d.Descent = d.FontBBox[3], d.FontBBox[1]
+	}
+	if d.CapHeight == 0 {
+		d.CapHeight = d.Ascent
+	}
+	if d.StemV == 0 {
+		weight := WEIGHT_NORMAL
+		switch a.Weight {
+		case "Bold", "Black", "Heavy":
+			weight = WEIGHT_BOLD
+		}
+		d.StemV = stemV(weight)
+	}
+	var sum, n float64
+	for _, m := range a.Metrics {
+		if w := m.WidthX[0]; w > 0 {
+			sum += w
+			n++
+			if w > d.MaxWidth {
+				d.MaxWidth = w
+			}
+		}
+	}
+	if n > 0 {
+		d.AvgWidth = math.Round(sum / n)
+	}
+
+	family := StandardFontFamily(a.FontName)
+	if fixedPitch {
+		d.Flags |= FLAG_FIXED_PITCH
+	}
+	name := a.FamilyName + " " + a.FontName
+	if family == "Times" || family == "Courier" || (strings.Contains(name, "Serif") && !strings.Contains(name, "Sans")) {
+		d.Flags |= FLAG_SERIF
+	}
+	if d.ItalicAngle != 0 || strings.Contains(a.FontName, "Italic") || strings.Contains(a.FontName, "Oblique") {
+		d.Flags |= FLAG_ITALIC
+	}
+	if family == "Symbol" || family == "ZapfDingbats" || a.EncodingScheme == "FontSpecific" {
+		d.Flags |= FLAG_SYMBOLIC
+	} else {
+		d.Flags |= FLAG_NONSYMBOLIC
+	}
+	return d
+}
+
+// stemV estimates the thickness of vertical stems from the weight class of a font.
+func stemV(weight int) float64 {
+	return math.Round(50 + math.Pow(float64(weight)/65, 2))
+}
+
+// glyphHeight returns the top of the outline of the glyph of the given character, or zero if the font has no such glyph.
+func glyphHeight(f *truetype.Font, r rune, glyphSpace func(float64) float64) float64 {
+	index := f.Index(r)
+	if index == 0 {
+		return 0
+	}
+	var buffer truetype.GlyphBuf
+	if err := buffer.Load(f, fixed.Int26_6(f.FUnitsPerEm()), index, xfont.HintingNone); err != nil {
+		return 0
+	}
+	return glyphSpace(float64(buffer.Bounds.Max.Y))
+}
+
+// hasSymbolCmap returns true if the given character to glyph index mapping table has a subtable for the Windows Symbol encoding.
+func hasSymbolCmap(cmap []byte) bool {
+	if len(cmap) < 4 {
+		return false
+	}
+	count := int(binary.BigEndian.Uint16(cmap[2:]))
+	for i := 0; i < count && 4+8*i+4 <= len(cmap); i++ {
+		record := cmap[4+8*i:]
+		if binary.BigEndian.Uint16(record) == 3 && binary.BigEndian.Uint16(record[2:]) == 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,230 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package font_test
+
+import (
+	"encoding/binary"
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/AletheiaWareLLC/pdfgo/font"
+	"github.com/golang/freetype/truetype"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/image/font/gofont/goitalic"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/goregular"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func readDescriptor(t *testing.T, data []byte) *font.Descriptor {
+	t.Helper()
+	f, err := truetype.Parse(data)
+	assert.Nil(t, err)
+	return font.ReadTrueTypeDescriptor(data, f)
+}
+
+func TestReadTrueTypeDescriptor(t *testing.T) {
+	d := readDescriptor(t, goregular.TTF)
+	assert.Equal(t, "GoRegular", d.FontName)
+	assert.Equal(t, font.FLAG_NONSYMBOLIC, d.Flags)
+	assert.Equal(t, 0.0, d.ItalicAngle)
+	assert.Greater(t, d.Ascent, 700.0)
+	assert.Less(t, d.Ascent, 1100.0)
+	assert.Less(t, d.Descent, 0.0)
+	assert.Greater(t, d.CapHeight, 600.0)
+	assert.Less(t, d.CapHeight, d.Ascent)
+	assert.Greater(t, d.XHeight, 400.0)
+	assert.Less(t, d.XHeight, d.CapHeight)
+	assert.Equal(t, 88.0, d.StemV)
+	assert.Greater(t, d.AvgWidth, 300.0)
+	assert.Less(t, d.AvgWidth, 700.0)
+	assert.GreaterOrEqual(t, d.MaxWidth, d.AvgWidth)
+	assert.Less(t, d.FontBBox[1], 0.0)
+	assert.Greater(t, d.FontBBox[3], d.Ascent/2)
+
+	mono := readDescriptor(t, gomono.TTF)
+	assert.NotZero(t, mono.Flags&font.FLAG_FIXED_PITCH)
+	assert.Zero(t, mono.Flags&font.FLAG_ITALIC)
+
+	italic := readDescriptor(t, goitalic.TTF)
+	assert.NotZero(t, italic.Flags&font.FLAG_ITALIC)
+	assert.Less(t, italic.ItalicAngle, 0.0)
+	assert.Zero(t, italic.Flags&font.FLAG_FIXED_PITCH)
+}
+
+func TestTrueTypeFont_Descriptor(t *testing.T) {
+	p := pdfgo.NewPDF()
+	f, err := font.NewTrueTypeFont(p, goRegular(t))
+	assert.Nil(t, err)
+	d := f.GetReference().Object.(*pdfgo.DictionaryObject)
+	descriptor := pdfgo.Resolve(d.GetEntry("FontDescriptor")).(*pdfgo.DictionaryObject)
+	for _, key := range []string{"FontName", "Flags", "FontBBox", "ItalicAngle", "Ascent", "Descent", "CapHeight", "StemV", "AvgWidth", "MaxWidth", "FontFile2"} {
+		assert.True(t, descriptor.HasEntry(key), key)
+	}
+	assert.Equal(t, float64(font.FLAG_NONSYMBOLIC), descriptor.GetEntry("Flags").(*pdfgo.NumberObject).Number)
+
+	// Widths are in glyph space for each code
+	decoder := font.NewDecoder(d)
+	codes := decoder.Decode(f.Encode([]rune("Aé")))
+	assert.Equal(t, 2, len(codes))
+	assert.InDelta(t, f.MeasureText([]rune("A"), 1), codes[0].Width, 0.001)
+	assert.InDelta(t, f.MeasureText([]rune("é"), 1), codes[1].Width, 0.001)
+	// Widths are rounded to the nearest unit of glyph space
+	text := []rune("The quick brown fox jumps over the lazy dog")
+	for i, c := range decoder.Decode(f.Encode(text)) {
+		assert.InDelta(t, f.MeasureText(text[i:i+1], 1), c.Width, 0.0005, string(text[i]))
+	}
+}
+
+const testAFM = `StartFontMetrics 4.1
+FontName Test-Italic
+FullName Test Italic
+FamilyName Test
+Weight Medium
+ItalicAngle -15.5
+IsFixedPitch false
+CharacterSet ExtendedRoman
+FontBBox -170 -220 1000 900
+EncodingScheme AdobeStandardEncoding
+CapHeight 650
+XHeight 440
+Ascender 680
+Descender -210
+StdHW 30
+StdVW 80
+StartCharMetrics 3
+C 32 ; WX 250 ; N space ; B 0 0 0 0 ;
+C 65 ; WX 600 ; N A ; B 0 0 600 650 ;
+C -1 ; WX 500 ; N eacute ; B 0 0 500 700 ;
+EndCharMetrics
+EndFontMetrics
+`
+
+func TestAFMDescriptor(t *testing.T) {
+	metrics, err := font.ReadAFM(strings.NewReader(testAFM))
+	assert.Nil(t, err)
+	d := font.AFMDescriptor(metrics)
+	assert.Equal(t, "Test-Italic", d.FontName)
+	assert.Equal(t, font.FLAG_NONSYMBOLIC|font.FLAG_ITALIC, d.Flags)
+	assert.Equal(t, -15.5, d.ItalicAngle)
+	assert.Equal(t, [4]float64{-170, -220, 1000, 900}, d.FontBBox)
+	assert.Equal(t, 680.0, d.Ascent)
+	assert.Equal(t, -210.0, d.Descent)
+	assert.Equal(t, 650.0, d.CapHeight)
+	assert.Equal(t, 440.0, d.XHeight)
+	assert.Equal(t, 80.0, d.StemV)
+	assert.Equal(t, 30.0, d.StemH)
+	assert.Equal(t, 450.0, d.AvgWidth)
+	assert.Equal(t, 600.0, d.MaxWidth)
+
+	metrics.FontName = "Courier"
+	metrics.Directions[0].IsFixedPitch = true
+	metrics.Directions[0].ItalicAngle = 0
+	assert.Equal(t, font.FLAG_NONSYMBOLIC|font.FLAG_SERIF|font.FLAG_FIXED_PITCH, font.AFMDescriptor(metrics).Flags)
+
+	metrics.FontName = "Symbol"
+	assert.NotZero(t, font.AFMDescriptor(metrics).Flags&font.FLAG_SYMBOLIC)
+}
+
+func TestNewCoreFontFromAFM(t *testing.T) {
+	metrics, err := font.ReadAFM(strings.NewReader(testAFM))
+	assert.Nil(t, err)
+	p := pdfgo.NewPDF()
+	f := font.NewCoreFontFromAFM(p, metrics)
+	d := f.GetReference().Object.(*pdfgo.DictionaryObject)
+	assert.Equal(t, "Test-Italic", d.GetEntry("BaseFont").(*pdfgo.NameObject).Name)
+	descriptor := pdfgo.Resolve(d.GetEntry("FontDescriptor")).(*pdfgo.DictionaryObject)
+	assert.Equal(t, 680.0, descriptor.GetEntry("Ascent").(*pdfgo.NumberObject).Number)
+	assert.False(t, descriptor.HasEntry("FontFile"))
+
+	// Widths are given for the codes of WinAnsiEncoding, including characters outside the built-in encoding
+	var widths []float64
+	for _, c := range font.NewDecoder(d).Decode([]byte("A\xE9 B")) {
+		widths = append(widths, c.Width*1000)
+	}
+	assert.Equal(t, []float64{600, 500, 250, 0}, widths)
+}
+
+// symbolicFont writes a copy of the Go Regular font classed as symbolic to a temporary file, returning its path.
+func symbolicFont(t *testing.T) string {
+	t.Helper()
+	data := append([]byte{}, goregular.TTF...)
+	count := int(binary.BigEndian.Uint16(data[4:]))
+	for i := 0; i < count; i++ {
+		record := data[12+16*i:]
+		if string(record[:4]) == "OS/2" {
+			// The family class of symbolic fonts is 12
+			data[int(binary.BigEndian.Uint32(record[8:]))+30] = 12
+		}
+	}
+	file := filepath.Join(filepath.Dir(goRegular(t)), "Symbolic.ttf")
+	assert.Nil(t, ioutil.WriteFile(file, data, 0600))
+	return file
+}
+
+// cmapEncodings returns the platform and encoding of each subtable of the character to glyph index mapping table of the given font.
+func cmapEncodings(t *testing.T, data []byte) [][2]int {
+	t.Helper()
+	var encodings [][2]int
+	count := int(binary.BigEndian.Uint16(data[4:]))
+	for i := 0; i < count; i++ {
+		record := data[12+16*i:]
+		if string(record[:4]) != "cmap" {
+			continue
+		}
+		cmap := data[binary.BigEndian.Uint32(record[8:]):]
+		for j := 0; j < int(binary.BigEndian.Uint16(cmap[2:])); j++ {
+			encodings = append(encodings, [2]int{int(binary.BigEndian.Uint16(cmap[4+8*j:])), int(binary.BigEndian.Uint16(cmap[6+8*j:]))})
+		}
+	}
+	return encodings
+}
+
+func TestTrueTypeFont_Symbolic(t *testing.T) {
+	p := pdfgo.NewPDF()
+	f, err := font.NewTrueTypeFont(p, symbolicFont(t))
+	assert.Nil(t, err)
+	f.Subset = true
+	d := f.GetReference().Object.(*pdfgo.DictionaryObject)
+	assert.False(t, d.HasEntry("Encoding"))
+	descriptor := pdfgo.Resolve(d.GetEntry("FontDescriptor")).(*pdfgo.DictionaryObject)
+	assert.Equal(t, float64(font.FLAG_SYMBOLIC), descriptor.GetEntry("Flags").(*pdfgo.NumberObject).Number)
+
+	// Codes are the low bytes of characters, including those of the private use area
+	assert.Equal(t, []byte("AB?"), f.Encode([]rune("A\uF042Ж")))
+	codes := font.NewDecoder(d).Decode([]byte("A"))
+	assert.InDelta(t, f.MeasureText([]rune("A"), 1), codes[0].Width, 0.001)
+
+	// The subset maps codes to glyphs through the private use area
+	assert.Nil(t, p.Write(ioutil.Discard))
+	_, data := embedded(t, descriptor)
+	assert.Equal(t, [][2]int{{3, 0}, {3, 1}, {3, 10}}, cmapEncodings(t, data))
+	original, err := truetype.Parse(goregular.TTF)
+	assert.Nil(t, err)
+	subset, err := truetype.Parse(data)
+	assert.Nil(t, err)
+	assert.Equal(t, original.Index('A'), subset.Index('A'))
+
+	// Without subsetting the whole font is embedded
+	p = pdfgo.NewPDF()
+	f, err = font.NewTrueTypeFont(p, symbolicFont(t))
+	assert.Nil(t, err)
+	assert.Nil(t, p.Write(ioutil.Discard))
+	_, data = embedded(t, pdfgo.Resolve(f.GetReference().Object.(*pdfgo.DictionaryObject).GetEntry("FontDescriptor")).(*pdfgo.DictionaryObject))
+	assert.Equal(t, len(goregular.TTF), len(data))
+}
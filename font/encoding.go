@@ -0,0 +1,289 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package font
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Encoding maps single byte character codes to glyph names.
+type Encoding [256]string
+
+var (
+	StandardEncoding Encoding
+	WinAnsiEncoding  Encoding
+	MacRomanEncoding Encoding
+)
+
+var (
+	glyphRunes = make(map[string]rune)
+	runeGlyphs = make(map[rune]string)
+)
+
+var asciiGlyphs = []string{
+	"space", "exclam", "quotedbl", "numbersign", "dollar", "percent", "ampersand", "quotesingle",
+	"parenleft", "parenright", "asterisk", "plus", "comma", "hyphen", "period", "slash",
+	"zero", "one", "two", "three", "four", "five", "six", "seven",
+	"eight", "nine", "colon", "semicolon", "less", "equal", "greater", "question",
+	"at", "A", "B", "C", "D", "E", "F", "G",
+	"H", "I", "J", "K", "L", "M", "N", "O",
+	"P", "Q", "R", "S", "T", "U", "V", "W",
+	"X", "Y", "Z", "bracketleft", "backslash", "bracketright", "asciicircum", "underscore",
+	"grave", "a", "b", "c", "d", "e", "f", "g",
+	"h", "i", "j", "k", "l", "m", "n", "o",
+	"p", "q", "r", "s", "t", "u", "v", "w",
+	"x", "y", "z", "braceleft", "bar", "braceright", "asciitilde",
+}
+
+var latin1Glyphs = []string{
+	"nbspace", "exclamdown", "cent", "sterling", "currency", "yen", "brokenbar", "section",
+	"dieresis", "copyright", "ordfeminine", "guillemotleft", "logicalnot", "sfthyphen", "registered", "macron",
+	"degree", "plusminus", "twosuperior", "threesuperior", "acute", "mu", "paragraph", "periodcentered",
+	"cedilla", "onesuperior", "ordmasculine", "guillemotright", "onequarter", "onehalf", "threequarters", "questiondown",
+	"Agrave", "Aacute", "Acircumflex", "Atilde", "Adieresis", "Aring", "AE", "Ccedilla",
+	"Egrave", "Eacute", "Ecircumflex", "Edieresis", "Igrave", "Iacute", "Icircumflex", "Idieresis",
+	"Eth", "Ntilde", "Ograve", "Oacute", "Ocircumflex", "Otilde", "Odieresis", "multiply",
+	"Oslash", "Ugrave", "Uacute", "Ucircumflex", "Udieresis", "Yacute", "Thorn", "germandbls",
+	"agrave", "aacute", "acircumflex", "atilde", "adieresis", "aring", "ae", "ccedilla",
+	"egrave", "eacute", "ecircumflex", "edieresis", "igrave", "iacute", "icircumflex", "idieresis",
+	"eth", "ntilde", "ograve", "oacute", "ocircumflex", "otilde", "odieresis", "divide",
+	"oslash", "ugrave", "uacute", "ucircumflex", "udieresis", "yacute", "thorn", "ydieresis",
+}
+
+var otherGlyphs = map[string]rune{
+	"Euro":           0x20AC,
+	"quotesinglbase": 0x201A,
+	"florin":         0x0192,
+	"quotedblbase":   0x201E,
+	"ellipsis":       0x2026,
+	"dagger":         0x2020,
+	"daggerdbl":      0x2021,
+	"circumflex":     0x02C6,
+	"perthousand":    0x2030,
+	"Scaron":         0x0160,
+	"guilsinglleft":  0x2039,
+	"OE":             0x0152,
+	"Zcaron":         0x017D,
+	"quoteleft":      0x2018,
+	"quoteright":     0x2019,
+	"quotedblleft":   0x201C,
+	"quotedblright":  0x201D,
+	"bullet":         0x2022,
+	"endash":         0x2013,
+	"emdash":         0x2014,
+	"tilde":          0x02DC,
+	"trademark":      0x2122,
+	"scaron":         0x0161,
+	"guilsinglright": 0x203A,
+	"oe":             0x0153,
+	"zcaron":         0x017E,
+	"Ydieresis":      0x0178,
+	"fraction":       0x2044,
+	"dotlessi":       0x0131,
+	"Lslash":         0x0141,
+	"lslash":         0x0142,
+	"breve":          0x02D8,
+	"dotaccent":      0x02D9,
+	"ring":           0x02DA,
+	"hungarumlaut":   0x02DD,
+	"ogonek":         0x02DB,
+	"caron":          0x02C7,
+	"ff":             0xFB00,
+	"fi":             0xFB01,
+	"fl":             0xFB02,
+	"ffi":            0xFB03,
+	"ffl":            0xFB04,
+	"minus":          0x2212,
+	"notequal":       0x2260,
+	"infinity":       0x221E,
+	"lessequal":      0x2264,
+	"greaterequal":   0x2265,
+	"partialdiff":    0x2202,
+	"summation":      0x2211,
+	"product":        0x220F,
+	"pi":             0x03C0,
+	"integral":       0x222B,
+	"Omega":          0x03A9,
+	"radical":        0x221A,
+	"approxequal":    0x2248,
+	"Delta":          0x2206,
+	"lozenge":        0x25CA,
+	"apple":          0xF8FF,
+}
+
+func init() {
+	for i, n := range asciiGlyphs {
+		addGlyph(n, rune(0x20+i))
+	}
+	for i, n := range latin1Glyphs {
+		addGlyph(n, rune(0xA0+i))
+	}
+	for n, r := range otherGlyphs {
+		addGlyph(n, r)
+	}
+	// Alias that must not replace the preferred name
+	glyphRunes["nonbreakingspace"] = 0xA0
+
+	// WinAnsiEncoding
+	for i, n := range asciiGlyphs {
+		WinAnsiEncoding[0x20+i] = n
+	}
+	for i, n := range latin1Glyphs {
+		WinAnsiEncoding[0xA0+i] = n
+	}
+	WinAnsiEncoding[0xA0] = "space"
+	WinAnsiEncoding[0xAD] = "hyphen"
+	for c, n := range map[int]string{
+		0x80: "Euro", 0x82: "quotesinglbase", 0x83: "florin", 0x84: "quotedblbase",
+		0x85: "ellipsis", 0x86: "dagger", 0x87: "daggerdbl", 0x88: "circumflex",
+		0x89: "perthousand", 0x8A: "Scaron", 0x8B: "guilsinglleft", 0x8C: "OE",
+		0x8E: "Zcaron", 0x91: "quoteleft", 0x92: "quoteright", 0x93: "quotedblleft",
+		0x94: "quotedblright", 0x95: "bullet", 0x96: "endash", 0x97: "emdash",
+		0x98: "tilde", 0x99: "trademark", 0x9A: "scaron", 0x9B: "guilsinglright",
+		0x9C: "oe", 0x9E: "zcaron", 0x9F: "Ydieresis",
+	} {
+		WinAnsiEncoding[c] = n
+	}
+
+	// StandardEncoding
+	for i, n := range asciiGlyphs {
+		StandardEncoding[0x20+i] = n
+	}
+	StandardEncoding[0x27] = "quoteright"
+	StandardEncoding[0x60] = "quoteleft"
+	for c, n := range map[int]string{
+		0xA1: "exclamdown", 0xA2: "cent", 0xA3: "sterling", 0xA4: "fraction",
+		0xA5: "yen", 0xA6: "florin", 0xA7: "section", 0xA8: "currency",
+		0xA9: "quotesingle", 0xAA: "quotedblleft", 0xAB: "guillemotleft", 0xAC: "guilsinglleft",
+		0xAD: "guilsinglright", 0xAE: "fi", 0xAF: "fl", 0xB1: "endash",
+		0xB2: "dagger", 0xB3: "daggerdbl", 0xB4: "periodcentered", 0xB6: "paragraph",
+		0xB7: "bullet", 0xB8: "quotesinglbase", 0xB9: "quotedblbase", 0xBA: "quotedblright",
+		0xBB: "guillemotright", 0xBC: "ellipsis", 0xBD: "perthousand", 0xBF: "questiondown",
+		0xC1: "grave", 0xC2: "acute", 0xC3: "circumflex", 0xC4: "tilde",
+		0xC5: "macron", 0xC6: "breve", 0xC7: "dotaccent", 0xC8: "dieresis",
+		0xCA: "ring", 0xCB: "cedilla", 0xCD: "hungarumlaut", 0xCE: "ogonek",
+		0xCF: "caron", 0xD0: "emdash", 0xE1: "AE", 0xE3: "ordfeminine",
+		0xE8: "Lslash", 0xE9: "Oslash", 0xEA: "OE", 0xEB: "ordmasculine",
+		0xF1: "ae", 0xF5: "dotlessi", 0xF8: "lslash", 0xF9: "oslash",
+		0xFA: "oe", 0xFB: "germandbls",
+	} {
+		StandardEncoding[c] = n
+	}
+
+	// MacRomanEncoding
+	for i, n := range asciiGlyphs {
+		MacRomanEncoding[0x20+i] = n
+	}
+	for i, r := range []rune{
+		0xC4, 0xC5, 0xC7, 0xC9, 0xD1, 0xD6, 0xDC, 0xE1, 0xE0, 0xE2, 0xE4, 0xE3, 0xE5, 0xE7, 0xE9, 0xE8,
+		0xEA, 0xEB, 0xED, 0xEC, 0xEE, 0xEF, 0xF1, 0xF3, 0xF2, 0xF4, 0xF6, 0xF5, 0xFA, 0xF9, 0xFB, 0xFC,
+		0x2020, 0xB0, 0xA2, 0xA3, 0xA7, 0x2022, 0xB6, 0xDF, 0xAE, 0xA9, 0x2122, 0xB4, 0xA8, 0x2260, 0xC6, 0xD8,
+		0x221E, 0xB1, 0x2264, 0x2265, 0xA5, 0xB5, 0x2202, 0x2211, 0x220F, 0x3C0, 0x222B, 0xAA, 0xBA, 0x3A9, 0xE6, 0xF8,
+		0xBF, 0xA1, 0xAC, 0x221A, 0x192, 0x2248, 0x2206, 0xAB, 0xBB, 0x2026, 0x20, 0xC0, 0xC3, 0xD5, 0x152, 0x153,
+		0x2013, 0x2014, 0x201C, 0x201D, 0x2018, 0x2019, 0xF7, 0x25CA, 0xFF, 0x178, 0x2044, 0xA4, 0x2039, 0x203A, 0xFB01, 0xFB02,
+		0x2021, 0xB7, 0x201A, 0x201E, 0x2030, 0xC2, 0xCA, 0xC1, 0xCB, 0xC8, 0xCD, 0xCE, 0xCF, 0xCC, 0xD3, 0xD4,
+		0xF8FF, 0xD2, 0xDA, 0xDB, 0xD9, 0x131, 0x2C6, 0x2DC, 0xAF, 0x2D8, 0x2D9, 0x2DA, 0xB8, 0x2DD, 0x2DB, 0x2C7,
+	} {
+		MacRomanEncoding[0x80+i] = GlyphName(r)
+	}
+	MacRomanEncoding[0xCA] = "space"
+}
+
+func addGlyph(name string, r rune) {
+	glyphRunes[name] = r
+	if _, ok := runeGlyphs[r]; !ok {
+		runeGlyphs[r] = name
+	}
+}
+
+// GetEncoding returns the named encoding, or nil if it is not one of the standard encodings.
+func GetEncoding(name string) *Encoding {
+	switch name {
+	case "StandardEncoding":
+		return &StandardEncoding
+	case "WinAnsiEncoding":
+		return &WinAnsiEncoding
+	case "MacRomanEncoding":
+		return &MacRomanEncoding
+	}
+	return nil
+}
+
+// Encode returns the code of the given character in the encoding.
+func (e *Encoding) Encode(r rune) (byte, bool) {
+	name := GlyphName(r)
+	for i, n := range e {
+		if n == name {
+			return byte(i), true
+		}
+	}
+	return 0, false
+}
+
+// GlyphRunes returns the characters represented by the given glyph name, following the Adobe Glyph List conventions.
+func GlyphRunes(name string) []rune {
+	if r, ok := glyphRunes[name]; ok {
+		return []rune{r}
+	}
+	// Ligatures and variants such as "f_f_i" and "a.sc"
+	if i := strings.IndexByte(name, '.'); i > 0 {
+		return GlyphRunes(name[:i])
+	}
+	if strings.Contains(name, "_") {
+		var runes []rune
+		for _, part := range strings.Split(name, "_") {
+			runes = append(runes, GlyphRunes(part)...)
+		}
+		return runes
+	}
+	if strings.HasPrefix(name, "uni") && len(name) >= 7 && (len(name)-3)%4 == 0 {
+		var runes []rune
+		for i := 3; i < len(name); i += 4 {
+			v, err := strconv.ParseUint(name[i:i+4], 16, 32)
+			if err != nil {
+				return nil
+			}
+			runes = append(runes, rune(v))
+		}
+		return runes
+	}
+	if strings.HasPrefix(name, "u") && len(name) >= 5 && len(name) <= 7 {
+		if v, err := strconv.ParseUint(name[1:], 16, 32); err == nil {
+			return []rune{rune(v)}
+		}
+	}
+	return nil
+}
+
+// GlyphName returns the glyph name of the given character.
+func GlyphName(r rune) string {
+	if n, ok := runeGlyphs[r]; ok {
+		return n
+	}
+	if r > 0xFFFF {
+		return "u" + strings.ToUpper(strconv.FormatInt(int64(r), 16))
+	}
+	return "uni" + strings.ToUpper(leftPad(strconv.FormatInt(int64(r), 16), 4))
+}
+
+func leftPad(s string, length int) string {
+	for len(s) < length {
+		s = "0" + s
+	}
+	return s
+}
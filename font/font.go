@@ -24,3 +24,9 @@ type Font interface {
 	GetReference() *pdfgo.ObjectReference
 	MeasureText(text []rune, fontSize float64) float64
 }
+
+// Encoder is implemented by fonts which show text with codes other than its characters.
+type Encoder interface {
+	// Encode returns the codes which show the given text
+	Encode(text []rune) []byte
+}
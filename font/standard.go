@@ -0,0 +1,89 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package font
+
+import "strings"
+
+// Widths of the printable ASCII characters in Helvetica and Times-Roman, in thousandths of an em
+var (
+	helveticaWidths = []float64{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	}
+	timesWidths = []float64{
+		250, 333, 408, 500, 500, 833, 778, 180, 333, 333, 500, 564, 250, 333, 250, 278,
+		500, 500, 500, 500, 500, 500, 500, 500, 500, 500, 278, 278, 564, 564, 564, 444,
+		921, 722, 667, 667, 722, 611, 556, 722, 722, 333, 389, 722, 611, 889, 722, 722,
+		556, 722, 667, 556, 611, 722, 722, 944, 722, 722, 611, 333, 278, 333, 469, 500,
+		333, 444, 500, 444, 500, 444, 333, 500, 500, 278, 278, 500, 278, 778, 500, 500,
+		500, 500, 333, 389, 278, 500, 500, 722, 500, 500, 444, 480, 200, 480, 541,
+	}
+)
+
+// IsStandardFont returns true if the given name is one of the 14 standard fonts, or one of their common aliases.
+func IsStandardFont(name string) bool {
+	switch StandardFontFamily(name) {
+	case "Courier", "Helvetica", "Times", "Symbol", "ZapfDingbats":
+		return true
+	}
+	return false
+}
+
+// StandardFontFamily returns the family of the standard font the given name refers to, or an empty string.
+func StandardFontFamily(name string) string {
+	// Remove any subset prefix
+	if len(name) > 7 && name[6] == '+' {
+		name = name[7:]
+	}
+	switch {
+	case strings.HasPrefix(name, "Courier"):
+		return "Courier"
+	case strings.HasPrefix(name, "Helvetica"), strings.HasPrefix(name, "Arial"):
+		return "Helvetica"
+	case strings.HasPrefix(name, "Times"):
+		return "Times"
+	case name == "Symbol":
+		return "Symbol"
+	case name == "ZapfDingbats":
+		return "ZapfDingbats"
+	}
+	return ""
+}
+
+// StandardFontWidth returns the approximate width, in thousandths of an em, of the given character in the named standard font.
+// Bold and italic variants are approximated by the regular widths.
+func StandardFontWidth(name string, r rune) float64 {
+	switch StandardFontFamily(name) {
+	case "Courier":
+		return 600
+	case "Helvetica":
+		if r >= 0x20 && r < 0x7F {
+			return helveticaWidths[r-0x20]
+		}
+		return 556
+	case "Times":
+		if r >= 0x20 && r < 0x7F {
+			return timesWidths[r-0x20]
+		}
+		return 500
+	}
+	return 500
+}
@@ -0,0 +1,395 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package font
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"sort"
+)
+
+// Tables kept in subsets, which are those needed to show glyphs, to map characters to glyphs, and to describe the font
+var subsetTables = map[string]bool{
+	"OS/2": true,
+	"cmap": true,
+	"cvt ": true,
+	"fpgm": true,
+	"glyf": true,
+	"head": true,
+	"hhea": true,
+	"hmtx": true,
+	"loca": true,
+	"maxp": true,
+	"name": true,
+	"prep": true,
+}
+
+// Flags of the components of composite glyphs
+const (
+	ARG_1_AND_2_ARE_WORDS    = 0x0001
+	WE_HAVE_A_SCALE          = 0x0008
+	MORE_COMPONENTS          = 0x0020
+	WE_HAVE_AN_X_AND_Y_SCALE = 0x0040
+	WE_HAVE_A_TWO_BY_TWO     = 0x0080
+)
+
+// subsetTrueType returns a TrueType font holding only the glyphs which show the given characters and symbols, the given glyphs, and the components of those glyphs.
+// Symbols are the characters of the private use area which symbolic fonts map codes to.
+// Glyphs keep their indices, so codes which are glyph indices still refer to the same glyphs, but unused glyphs are empty and the glyphs after the last used are removed.
+func subsetTrueType(data []byte, characters, symbols map[rune]uint16, glyphs []uint16) ([]byte, error) {
+	tables, err := readTables(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range []string{"head", "hhea", "hmtx", "loca", "glyf", "maxp"} {
+		if _, ok := tables[t]; !ok {
+			return nil, errors.New("TrueType Font has no " + t + " table")
+		}
+	}
+	head, hhea, hmtx, loca, glyf, maxp := tables["head"], tables["hhea"], tables["hmtx"], tables["loca"], tables["glyf"], tables["maxp"]
+	if len(head) < 54 || len(hhea) < 36 || len(maxp) < 6 {
+		return nil, errors.New("Invalid TrueType Font")
+	}
+	count := int(binary.BigEndian.Uint16(maxp[4:]))
+	long := binary.BigEndian.Uint16(head[50:]) != 0
+	offset := func(i int) int {
+		if long {
+			if 4*i+4 > len(loca) {
+				return -1
+			}
+			return int(binary.BigEndian.Uint32(loca[4*i:]))
+		}
+		if 2*i+2 > len(loca) {
+			return -1
+		}
+		return 2 * int(binary.BigEndian.Uint16(loca[2*i:]))
+	}
+	glyph := func(i int) []byte {
+		start, end := offset(i), offset(i+1)
+		if start < 0 || end < start || end > len(glyf) {
+			return nil
+		}
+		return glyf[start:end]
+	}
+
+	// The missing glyph is always kept, as are the components of composite glyphs
+	used := map[int]bool{0: true}
+	var pending []int
+	for _, g := range characters {
+		glyphs = append(glyphs, g)
+	}
+	for _, g := range symbols {
+		glyphs = append(glyphs, g)
+	}
+	for _, g := range glyphs {
+		if int(g) < count && !used[int(g)] {
+			used[int(g)] = true
+			pending = append(pending, int(g))
+		}
+	}
+	for len(pending) > 0 {
+		g := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		for _, c := range components(glyph(g)) {
+			if c < count && !used[c] {
+				used[c] = true
+				pending = append(pending, c)
+			}
+		}
+	}
+	last := 0
+	for g := range used {
+		if g > last {
+			last = g
+		}
+	}
+	count = last + 1
+
+	// Glyph data is kept for used glyphs, aligned to four bytes, with long offsets
+	var newGlyf bytes.Buffer
+	newLoca := make([]byte, 4*(count+1))
+	for i := 0; i < count; i++ {
+		binary.BigEndian.PutUint32(newLoca[4*i:], uint32(newGlyf.Len()))
+		if used[i] {
+			newGlyf.Write(glyph(i))
+			for newGlyf.Len()%4 != 0 {
+				newGlyf.WriteByte(0)
+			}
+		}
+	}
+	binary.BigEndian.PutUint32(newLoca[4*count:], uint32(newGlyf.Len()))
+
+	// Horizontal metrics are kept for the remaining glyphs, with the advance widths of unused glyphs
+	metrics := int(binary.BigEndian.Uint16(hhea[34:]))
+	if metrics > count {
+		metrics = count
+	}
+	newHmtx := make([]byte, 4*metrics+2*(count-metrics))
+	copy(newHmtx, hmtx)
+
+	newHead := append([]byte{}, head...)
+	binary.BigEndian.PutUint16(newHead[50:], 1)
+	// The checksum adjustment is set once the font is complete
+	binary.BigEndian.PutUint32(newHead[8:], 0)
+	newHhea := append([]byte{}, hhea...)
+	binary.BigEndian.PutUint16(newHhea[34:], uint16(metrics))
+	newMaxp := append([]byte{}, maxp...)
+	binary.BigEndian.PutUint16(newMaxp[4:], uint16(count))
+
+	result := map[string][]byte{
+		"cmap": newCmap(characters, symbols, count),
+		"glyf": newGlyf.Bytes(),
+		"head": newHead,
+		"hhea": newHhea,
+		"hmtx": newHmtx,
+		"loca": newLoca,
+		"maxp": newMaxp,
+	}
+	for t, d := range tables {
+		if _, ok := result[t]; !ok && subsetTables[t] {
+			result[t] = d
+		}
+	}
+	font, offsets := writeTables(result)
+	binary.BigEndian.PutUint32(font[offsets["head"]+8:], 0xB1B0AFBA-checksum(font))
+	return font, nil
+}
+
+// components returns the indices of the glyphs of which the given glyph is composed, if it is a composite glyph.
+func components(glyph []byte) []int {
+	if len(glyph) < 10 || int16(binary.BigEndian.Uint16(glyph)) >= 0 {
+		return nil
+	}
+	var result []int
+	for i := 10; i+4 <= len(glyph); {
+		flags := binary.BigEndian.Uint16(glyph[i:])
+		result = append(result, int(binary.BigEndian.Uint16(glyph[i+2:])))
+		i += 4
+		if flags&ARG_1_AND_2_ARE_WORDS != 0 {
+			i += 4
+		} else {
+			i += 2
+		}
+		switch {
+		case flags&WE_HAVE_A_SCALE != 0:
+			i += 2
+		case flags&WE_HAVE_AN_X_AND_Y_SCALE != 0:
+			i += 4
+		case flags&WE_HAVE_A_TWO_BY_TWO != 0:
+			i += 8
+		}
+		if flags&MORE_COMPONENTS == 0 {
+			break
+		}
+	}
+	return result
+}
+
+// cmapGroup maps a range of consecutive characters to consecutive glyphs.
+type cmapGroup struct {
+	Start, End rune
+	Glyph      uint16
+}
+
+// cmapGroups returns the groups mapping the given characters, in order, to their glyphs.
+func cmapGroups(runes []rune, characters map[rune]uint16) []*cmapGroup {
+	var groups []*cmapGroup
+	for _, r := range runes {
+		g := characters[r]
+		if n := len(groups); n > 0 {
+			last := groups[n-1]
+			if r == last.End+1 && int(g) == int(last.Glyph)+int(r-last.Start) {
+				last.End = r
+				continue
+			}
+		}
+		groups = append(groups, &cmapGroup{
+			Start: r,
+			End:   r,
+			Glyph: g,
+		})
+	}
+	return groups
+}
+
+// newCmap returns a character to glyph index mapping table for the given characters, with a subtable for the basic multilingual plane, and another for all of Unicode, and if there are any symbols, a subtable of symbols.
+// The subtable for the basic multilingual plane is omitted if it would be too long, which requires more than 8000 ranges of characters.
+func newCmap(characters, symbols map[rune]uint16, count int) []byte {
+	sorted := func(characters map[rune]uint16) []rune {
+		var runes []rune
+		for r, g := range characters {
+			if int(g) < count {
+				runes = append(runes, r)
+			}
+		}
+		sort.Slice(runes, func(i, j int) bool {
+			return runes[i] < runes[j]
+		})
+		return runes
+	}
+	runes := sorted(characters)
+	var bmp []rune
+	for _, r := range runes {
+		if r < 0xFFFF {
+			bmp = append(bmp, r)
+		}
+	}
+	format4 := newCmapFormat4(cmapGroups(bmp, characters))
+	symbol := newCmapFormat4(cmapGroups(sorted(symbols), symbols))
+	write := func(b *bytes.Buffer, values ...interface{}) {
+		for _, v := range values {
+			binary.Write(b, binary.BigEndian, v)
+		}
+	}
+
+	// Format 12 maps each range of characters in a group
+	groups := cmapGroups(runes, characters)
+	var format12 bytes.Buffer
+	write(&format12, uint16(12), uint16(0), uint32(16+12*len(groups)), uint32(0), uint32(len(groups)))
+	for _, g := range groups {
+		write(&format12, uint32(g.Start), uint32(g.End), uint32(g.Glyph))
+	}
+
+	// Subtables are in order of platform and encoding, and those which are too long are omitted
+	var subtables []*cmapSubtable
+	if symbol != nil && len(symbols) > 0 {
+		subtables = append(subtables, &cmapSubtable{3, 0, symbol})
+	}
+	if format4 != nil {
+		subtables = append(subtables, &cmapSubtable{3, 1, format4})
+	}
+	subtables = append(subtables, &cmapSubtable{3, 10, format12.Bytes()})
+	var cmap bytes.Buffer
+	write(&cmap, uint16(0), uint16(len(subtables)))
+	offset := 4 + 8*len(subtables)
+	for _, t := range subtables {
+		write(&cmap, t.Platform, t.Encoding, uint32(offset))
+		offset += len(t.Data)
+	}
+	for _, t := range subtables {
+		cmap.Write(t.Data)
+	}
+	return cmap.Bytes()
+}
+
+// cmapSubtable is a subtable of a character to glyph index mapping table for a platform and encoding.
+type cmapSubtable struct {
+	Platform, Encoding uint16
+	Data               []byte
+}
+
+// newCmapFormat4 returns a format 4 subtable mapping each of the given groups of the basic multilingual plane in a segment, and ending with the segment of 0xFFFF, or nil if it would be too long.
+func newCmapFormat4(groups []*cmapGroup) []byte {
+	segments := len(groups) + 1
+	length := 16 + 8*segments
+	if length > 0xFFFF {
+		return nil
+	}
+	searchRange, entrySelector := 1, 0
+	for searchRange*2 <= segments {
+		searchRange *= 2
+		entrySelector++
+	}
+	var b bytes.Buffer
+	write := func(values ...interface{}) {
+		for _, v := range values {
+			binary.Write(&b, binary.BigEndian, v)
+		}
+	}
+	write(uint16(4), uint16(length), uint16(0), uint16(2*segments), uint16(2*searchRange), uint16(entrySelector), uint16(2*segments-2*searchRange))
+	for _, g := range groups {
+		write(uint16(g.End))
+	}
+	write(uint16(0xFFFF), uint16(0))
+	for _, g := range groups {
+		write(uint16(g.Start))
+	}
+	write(uint16(0xFFFF))
+	for _, g := range groups {
+		write(g.Glyph - uint16(g.Start))
+	}
+	write(uint16(1))
+	for i := 0; i < segments; i++ {
+		write(uint16(0))
+	}
+	return b.Bytes()
+}
+
+// readTables returns the tables of the given TrueType font, by their tags.
+func readTables(data []byte) (map[string][]byte, error) {
+	if len(data) < 12 {
+		return nil, errors.New("Invalid TrueType Font")
+	}
+	tables := make(map[string][]byte)
+	count := int(binary.BigEndian.Uint16(data[4:]))
+	for i := 0; i < count; i++ {
+		record := 12 + 16*i
+		if record+16 > len(data) {
+			return nil, errors.New("Invalid TrueType Font")
+		}
+		offset := int(binary.BigEndian.Uint32(data[record+8:]))
+		length := int(binary.BigEndian.Uint32(data[record+12:]))
+		if offset < 0 || length < 0 || offset+length > len(data) {
+			return nil, errors.New("Invalid TrueType Font")
+		}
+		tables[string(data[record:record+4])] = data[offset : offset+length]
+	}
+	return tables, nil
+}
+
+// writeTables returns a TrueType font holding the given tables, in order of their tags, and the offset of each table in the font.
+func writeTables(tables map[string][]byte) ([]byte, map[string]int) {
+	var tags []string
+	for t := range tables {
+		tags = append(tags, t)
+	}
+	sort.Strings(tags)
+	searchRange, entrySelector := 1, 0
+	for searchRange*2 <= len(tags) {
+		searchRange *= 2
+		entrySelector++
+	}
+	var b bytes.Buffer
+	binary.Write(&b, binary.BigEndian, []uint16{1, 0, uint16(len(tags)), uint16(16 * searchRange), uint16(entrySelector), uint16(16*len(tags) - 16*searchRange)})
+	offsets := make(map[string]int)
+	offset := 12 + 16*len(tags)
+	for _, t := range tags {
+		offsets[t] = offset
+		b.WriteString(t)
+		binary.Write(&b, binary.BigEndian, []uint32{checksum(tables[t]), uint32(offset), uint32(len(tables[t]))})
+		offset += (len(tables[t]) + 3) &^ 3
+	}
+	for _, t := range tags {
+		b.Write(tables[t])
+		for b.Len()%4 != 0 {
+			b.WriteByte(0)
+		}
+	}
+	return b.Bytes(), offsets
+}
+
+// checksum returns the sum of the given data as big endian 32 bit integers, padded with zeros.
+func checksum(data []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(data); i += 4 {
+		var word [4]byte
+		copy(word[:], data[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
@@ -0,0 +1,170 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package font_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/AletheiaWareLLC/pdfgo/font"
+	"github.com/golang/freetype/truetype"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/math/fixed"
+	"regexp"
+	"testing"
+)
+
+// embedded returns the name and data of the font file embedded by the given font descriptor.
+func embedded(t *testing.T, descriptor *pdfgo.DictionaryObject) (string, []byte) {
+	t.Helper()
+	file := pdfgo.Resolve(descriptor.GetEntry("FontFile2")).(*pdfgo.StreamObject)
+	return descriptor.GetEntry("FontName").(*pdfgo.NameObject).Name, file.Data
+}
+
+func TestTrueTypeFont_Subset(t *testing.T) {
+	p := pdfgo.NewPDF()
+	f, err := font.NewTrueTypeFont(p, goRegular(t))
+	assert.Nil(t, err)
+	f.Subset = true
+	f.MeasureText([]rune("Hello"), 12)
+	f.Use([]rune("é"))
+	var buffer bytes.Buffer
+	assert.Nil(t, p.Write(&buffer))
+
+	d := f.GetReference().Object.(*pdfgo.DictionaryObject)
+	descriptor := pdfgo.Resolve(d.GetEntry("FontDescriptor")).(*pdfgo.DictionaryObject)
+	name, data := embedded(t, descriptor)
+	assert.Regexp(t, regexp.MustCompile(`^[A-Z]{6}\+GoRegular$`), name)
+	assert.Equal(t, name, d.GetEntry("BaseFont").(*pdfgo.NameObject).Name)
+	assert.Equal(t, f.Tag()+"+GoRegular", name)
+	assert.Less(t, len(data), len(goregular.TTF)/4)
+
+	// Used characters keep their glyphs, unused characters are not mapped
+	original, err := truetype.Parse(goregular.TTF)
+	assert.Nil(t, err)
+	subset, err := truetype.Parse(data)
+	assert.Nil(t, err)
+	scale := fixed.Int26_6(original.FUnitsPerEm())
+	for _, c := range "Helloé" {
+		assert.Equal(t, original.Index(c), subset.Index(c), string(c))
+		assert.Equal(t, original.HMetric(scale, original.Index(c)), subset.HMetric(scale, subset.Index(c)), string(c))
+		var expected, actual truetype.GlyphBuf
+		assert.Nil(t, expected.Load(original, scale, original.Index(c), 0))
+		assert.Nil(t, actual.Load(subset, scale, subset.Index(c), 0))
+		assert.Equal(t, expected.Points, actual.Points, string(c))
+	}
+	assert.Equal(t, truetype.Index(0), subset.Index('Z'))
+
+	// The checksum adjustment of the head table, found through the table directory, makes the font sum to the magic number
+	var sum uint32
+	for i := 0; i+4 <= len(data); i += 4 {
+		sum += binary.BigEndian.Uint32(data[i:])
+	}
+	assert.Equal(t, 0, len(data)%4)
+	assert.Equal(t, uint32(0xB1B0AFBA), sum)
+	var adjustment uint32
+	for i := 0; i < int(binary.BigEndian.Uint16(data[4:])); i++ {
+		entry := data[12+16*i:]
+		if string(entry[:4]) == "head" {
+			adjustment = binary.BigEndian.Uint32(data[binary.BigEndian.Uint32(entry[8:])+8:])
+		}
+	}
+	assert.NotZero(t, adjustment)
+
+	// Different characters get a different tag
+	f.Use([]rune("Z"))
+	assert.NotEqual(t, name[:6], f.Tag())
+}
+
+func TestTrueTypeFont_NotSubset(t *testing.T) {
+	p := pdfgo.NewPDF()
+	f, err := font.NewTrueTypeFont(p, goRegular(t))
+	assert.Nil(t, err)
+	f.MeasureText([]rune("Hello"), 12)
+	var buffer bytes.Buffer
+	assert.Nil(t, p.Write(&buffer))
+	d := f.GetReference().Object.(*pdfgo.DictionaryObject)
+	name, data := embedded(t, pdfgo.Resolve(d.GetEntry("FontDescriptor")).(*pdfgo.DictionaryObject))
+	assert.Equal(t, "GoRegular", name)
+	assert.Equal(t, goregular.TTF, data)
+}
+
+func TestType0Font_Subset(t *testing.T) {
+	text := "Здравствуй, мир"
+	var subset *font.Type0Font
+	img, _ := drawText(t, func(p *pdfgo.PDF, file string) (font.Font, error) {
+		f, err := font.NewType0Font(p, file)
+		if err == nil {
+			f.Subset = true
+			subset = f
+		}
+		return f, err
+	}, text)
+	expected, _ := drawText(t, newType0Font, text)
+	assert.Equal(t, expected.Pix, img.Pix)
+
+	d := subset.GetReference().Object.(*pdfgo.DictionaryObject)
+	descendant := pdfgo.Resolve(d.GetEntry("DescendantFonts").(*pdfgo.ArrayObject).Array[0]).(*pdfgo.DictionaryObject)
+	name, data := embedded(t, pdfgo.Resolve(descendant.GetEntry("FontDescriptor")).(*pdfgo.DictionaryObject))
+	assert.Regexp(t, regexp.MustCompile(`^[A-Z]{6}\+GoRegular$`), name)
+	assert.Equal(t, name, d.GetEntry("BaseFont").(*pdfgo.NameObject).Name)
+	assert.Equal(t, name, descendant.GetEntry("BaseFont").(*pdfgo.NameObject).Name)
+	assert.Less(t, len(data), len(goregular.TTF)/4)
+
+	// Widths are only given for used glyphs
+	decoded := font.NewDecoder(d).Decode(subset.Encode([]rune("мир")))
+	assert.Equal(t, 3, len(decoded))
+	for _, c := range decoded {
+		assert.Greater(t, c.Width, 0.0)
+	}
+	w := pdfgo.Resolve(descendant.GetEntry("W")).(*pdfgo.ArrayObject)
+	count := 0
+	for i := 1; i < len(w.Array); i += 2 {
+		count += len(w.Array[i].(*pdfgo.ArrayObject).Array)
+	}
+	assert.GreaterOrEqual(t, count, 13)
+	assert.Less(t, count, 100)
+}
+
+func TestTrueTypeFont_SubsetManyCharacters(t *testing.T) {
+	p := pdfgo.NewPDF()
+	f, err := font.NewType0Font(p, goRegular(t))
+	assert.Nil(t, err)
+	f.Subset = true
+	// More separate ranges of characters than fit in a format 4 subtable
+	var text []rune
+	for r := rune(0x4E00); r < 0x4E00+20000; r += 2 {
+		text = append(text, r)
+	}
+	f.Use(text)
+	f.Use([]rune("ABCDEFGHIJKLMNOPQRSTUVWXYZ"))
+	var buffer bytes.Buffer
+	assert.Nil(t, p.Write(&buffer))
+
+	d := f.GetReference().Object.(*pdfgo.DictionaryObject)
+	descendant := pdfgo.Resolve(d.GetEntry("DescendantFonts").(*pdfgo.ArrayObject).Array[0]).(*pdfgo.DictionaryObject)
+	_, data := embedded(t, pdfgo.Resolve(descendant.GetEntry("FontDescriptor")).(*pdfgo.DictionaryObject))
+	original, err := truetype.Parse(goregular.TTF)
+	assert.Nil(t, err)
+	subset, err := truetype.Parse(data)
+	assert.Nil(t, err)
+	for _, c := range "AMZ" {
+		assert.Equal(t, original.Index(c), subset.Index(c), string(c))
+	}
+	assert.Equal(t, truetype.Index(0), subset.Index('a'))
+}
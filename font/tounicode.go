@@ -0,0 +1,75 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package font
+
+import (
+	"errors"
+	"github.com/AletheiaWareLLC/pdfgo"
+)
+
+// Ligatures maps the characters of ligatures to the characters they join, which are the text copied from them.
+var Ligatures = map[rune][]rune{
+	0xFB00: []rune("ff"),
+	0xFB01: []rune("fi"),
+	0xFB02: []rune("fl"),
+	0xFB03: []rune("ffi"),
+	0xFB04: []rune("ffl"),
+	0xFB05: []rune("ſt"),
+	0xFB06: []rune("st"),
+}
+
+// UnicodeText returns the characters represented by the given character, which are those joined by a ligature, or the character itself.
+func UnicodeText(r rune) []rune {
+	if l, ok := Ligatures[r]; ok {
+		return l
+	}
+	return []rune{r}
+}
+
+// EncodingToUnicode returns a ToUnicode CMap for a simple font with the given encoding, mapping each code to the characters of its glyph name, so ligatures such as "f_i" map to several characters.
+func EncodingToUnicode(e *Encoding) *CMap {
+	c := NewCMap("")
+	c.AddCodeSpace([]byte{0x00}, []byte{0xFF})
+	for code, name := range e {
+		if name == "" {
+			continue
+		}
+		var runes []rune
+		for _, r := range GlyphRunes(name) {
+			runes = append(runes, UnicodeText(r)...)
+		}
+		if len(runes) > 0 {
+			c.AddUnicode([]byte{byte(code)}, runes)
+		}
+	}
+	return c
+}
+
+// AddToUnicode adds a ToUnicode CMap to the given simple font, derived from its encoding including any differences, so text shown with a custom encoding can be copied and searched.
+func AddToUnicode(p *pdfgo.PDF, font *pdfgo.DictionaryObject) error {
+	d := NewDecoder(font)
+	if d.Composite {
+		return errors.New("Composite font requires glyph to character mapping")
+	}
+	if d.encoding == nil {
+		return errors.New("Font has no encoding: " + d.Name)
+	}
+	stream := p.NewStreamObject()
+	stream.Data = EncodingToUnicode(d.encoding).Bytes()
+	font.SetNameObjectEntry("ToUnicode", pdfgo.NewObjectReference(stream))
+	return nil
+}
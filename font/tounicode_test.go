@@ -0,0 +1,146 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package font_test
+
+import (
+	"fmt"
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/AletheiaWareLLC/pdfgo/font"
+	"github.com/AletheiaWareLLC/pdfgo/text"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"testing"
+)
+
+func TestCMap_Bytes(t *testing.T) {
+	c := font.NewCMap("")
+	c.AddCodeSpace([]byte{0x00, 0x00}, []byte{0xFF, 0xFF})
+	c.AddUnicode([]byte{0x00, 0x01}, []rune("A"))
+	c.AddUnicode([]byte{0x00, 0x02}, []rune("ffi"))
+	c.AddUnicode([]byte{0x00, 0x03}, []rune("😀"))
+	// Entries are split into blocks of at most one hundred
+	for i := 0; i < 150; i++ {
+		c.AddUnicode([]byte{0x01, byte(i)}, []rune{rune(0x400 + i)})
+	}
+	data := c.Bytes()
+	assert.Contains(t, string(data), "100 beginbfchar")
+	parsed, err := font.ParseCMap(data)
+	assert.Nil(t, err)
+	assert.Equal(t, "Adobe-Identity-UCS", parsed.Name)
+	assert.Equal(t, 2, parsed.CodeLength([]byte{0x00, 0x01}))
+	for code, expected := range map[string]string{
+		"\x00\x01": "A",
+		"\x00\x02": "ffi",
+		"\x00\x03": "😀",
+		"\x01\x00": "Ѐ",
+		"\x01\x95": string(rune(0x400 + 0x95)),
+	} {
+		runes, ok := parsed.Unicode([]byte(code))
+		assert.True(t, ok, code)
+		assert.Equal(t, expected, string(runes))
+	}
+	_, ok := parsed.Unicode([]byte{0x00, 0x04})
+	assert.False(t, ok)
+}
+
+func TestEncodingToUnicode(t *testing.T) {
+	p := pdfgo.NewPDF()
+	f := p.NewDictionaryObject()
+	f.AddNameNameEntry("Type", "Font")
+	f.AddNameNameEntry("Subtype", "Type1")
+	f.AddNameNameEntry("BaseFont", "Custom")
+	encoding := p.NewDictionaryObject()
+	encoding.AddNameNameEntry("BaseEncoding", "WinAnsiEncoding")
+	encoding.AddNameObjectEntry("Differences", &pdfgo.ArrayObject{
+		Array: []pdfgo.Object{
+			&pdfgo.NumberObject{Number: 1},
+			&pdfgo.NameObject{Name: "f_i"},
+			&pdfgo.NameObject{Name: "fl"},
+			&pdfgo.NameObject{Name: "Aring"},
+		},
+	})
+	f.AddNameObjectEntry("Encoding", encoding)
+	assert.Nil(t, font.AddToUnicode(p, f))
+	assert.True(t, f.HasEntry("ToUnicode"))
+
+	var text string
+	for _, c := range font.NewDecoder(f).Decode([]byte("\x01\x02\x03A")) {
+		text += string(c.Text)
+	}
+	assert.Equal(t, "fiflÅA", text)
+
+	composite := p.NewDictionaryObject()
+	composite.AddNameNameEntry("Subtype", "Type0")
+	assert.NotNil(t, font.AddToUnicode(p, composite))
+}
+
+func TestTrueTypeFont_ToUnicode(t *testing.T) {
+	newTrueTypeFont := func(p *pdfgo.PDF, file string) (font.Font, error) {
+		return font.NewTrueTypeFont(p, file)
+	}
+	for _, subset := range []bool{false, true} {
+		p, box := showText(t, func(p *pdfgo.PDF, file string) (font.Font, error) {
+			f, err := font.NewTrueTypeFont(p, file)
+			if err == nil {
+				f.Subset = subset
+			}
+			return f, err
+		}, "Crème brûlée, €5")
+		assert.Equal(t, 1, len(box.Lines))
+		extracted, err := text.ExtractText(p.GetPages()[0])
+		assert.Nil(t, err)
+		assert.Equal(t, "Crème brûlée, €5", extracted, fmt.Sprint(subset))
+	}
+	img, _ := drawText(t, newTrueTypeFont, "Crème brûlée")
+	assert.Greater(t, ink(img), 100)
+}
+
+func TestType0Font_ToUnicode(t *testing.T) {
+	p, _ := showText(t, newType0Font, "ﬁnd Здравствуй")
+	extracted, err := text.ExtractText(p.GetPages()[0])
+	assert.Nil(t, err)
+	// Ligatures are copied as the characters they join
+	assert.Equal(t, "find Здравствуй", extracted)
+
+	// Glyphs chosen by shaping map to the characters they represent
+	p = pdfgo.NewPDF()
+	f, err := font.NewType0Font(p, goRegular(t))
+	assert.Nil(t, err)
+	codes := append(f.Encode([]rune("a")), f.EncodeGlyph(f.Font.Index('ﬄ'), []rune("ffl"))...)
+	assert.Nil(t, p.Write(ioutil.Discard))
+	var decoded string
+	for _, c := range font.NewDecoder(f.GetReference().Object.(*pdfgo.DictionaryObject)).Decode(codes) {
+		decoded += string(c.Text)
+	}
+	assert.Equal(t, "affl", decoded)
+}
+
+func TestCMap_Code(t *testing.T) {
+	c := font.NewCMap("")
+	c.AddCodeSpace([]byte{0x00}, []byte{0xFF})
+	// Several codes map to the same character, and the lowest is always chosen
+	for _, code := range []byte{0x41, 0x61, 0x21, 0x91, 0x11} {
+		c.AddUnicode([]byte{code}, []rune("A"))
+	}
+	for i := 0; i < 20; i++ {
+		code, ok := c.Code('A')
+		assert.True(t, ok)
+		assert.Equal(t, []byte{0x11}, code)
+	}
+	_, ok := c.Code('B')
+	assert.False(t, ok)
+}
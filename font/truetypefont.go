@@ -17,27 +17,115 @@
 package font
 
 import (
+	"crypto/sha256"
 	"github.com/AletheiaWareLLC/pdfgo"
 	"github.com/golang/freetype/truetype"
 	"golang.org/x/image/math/fixed"
 	"io/ioutil"
 	"log"
+	"math"
+	"sort"
 )
 
 type TrueTypeFont struct {
 	Reference *pdfgo.ObjectReference
 	Font      *truetype.Font
+	// Subset embeds only the glyphs of the characters which were measured, encoded, or used, when the document is written
+	Subset bool
+	// data is the original font file
+	data []byte
+	// file is the stream embedding the font file
+	file *pdfgo.StreamObject
+	// basename is the PostScript name of the font
+	basename string
+	// names are the dictionaries naming the font, which are tagged when the font is subset
+	names []*pdfgo.DictionaryObject
+	// used maps the characters shown by the font to their glyphs
+	used map[rune]uint16
+	// glyphs maps glyphs shown by the font, such as ligatures, to the characters they represent
+	glyphs map[uint16][]rune
+	// toUnicode is the stream mapping codes to characters, which is updated when the document is written
+	toUnicode *pdfgo.StreamObject
+	// symbolic is true if the font has no encoding, so codes are mapped to glyphs by its symbol character to glyph index mapping
+	symbolic bool
 }
 
 func NewTrueTypeFont(p *pdfgo.PDF, file string) (*TrueTypeFont, error) {
+	b, f, err := readTrueType(file)
+	if err != nil {
+		return nil, err
+	}
+	basename := postscriptName(f)
+	font := p.NewDictionaryObject()
+	font.AddNameNameEntry("Type", "Font")
+	font.AddNameNameEntry("Subtype", "TrueType")
+	font.AddNameNameEntry("BaseFont", basename)
+	d := ReadTrueTypeDescriptor(b, f)
+	d.FontName = basename
+	// Symbolic fonts map codes to glyphs with their own character to glyph index mapping, so have no encoding
+	symbolic := d.Flags&FLAG_SYMBOLIC != 0
+	if !symbolic {
+		font.AddNameNameEntry("Encoding", "WinAnsiEncoding")
+	}
+	// Widths are given in glyph space, of 1000 units per em, for each code
+	var widths []pdfgo.Object
+	units := float64(f.FUnitsPerEm())
+	scale := fixed.Int26_6(f.FUnitsPerEm())
+	for code := 32; code < 256; code++ {
+		var index truetype.Index
+		if symbolic {
+			index = symbolGlyph(f, code)
+		} else if runes := GlyphRunes(WinAnsiEncoding[code]); len(runes) == 1 {
+			index = f.Index(runes[0])
+		}
+		var width float64
+		if index != 0 {
+			width = float64(f.HMetric(scale, index).AdvanceWidth)
+		}
+		widths = append(widths, &pdfgo.NumberObject{
+			Number: math.Round(width * 1000 / units),
+		})
+	}
+	font.AddNameObjectEntry("FirstChar", &pdfgo.NumberObject{
+		Number: 32,
+	})
+	font.AddNameObjectEntry("LastChar", &pdfgo.NumberObject{
+		Number: 255,
+	})
+	width := p.NewArrayObject(widths)
+	font.AddNameObjectEntry("Widths", pdfgo.NewObjectReference(width))
+	descriptor, stream := newTrueTypeDescriptor(p, d, b)
+	font.AddNameObjectEntry("FontDescriptor", pdfgo.NewObjectReference(descriptor))
+	toUnicode := p.NewStreamObject()
+	font.AddNameObjectEntry("ToUnicode", pdfgo.NewObjectReference(toUnicode))
+	t := &TrueTypeFont{
+		Reference: pdfgo.NewObjectReference(font),
+		Font:      f,
+		data:      b,
+		file:      stream,
+		basename:  basename,
+		names:     []*pdfgo.DictionaryObject{font, descriptor},
+		used:      make(map[rune]uint16),
+		toUnicode: toUnicode,
+		symbolic:  symbolic,
+	}
+	if err := t.embed(); err != nil {
+		return nil, err
+	}
+	p.OnWrite(t.embed)
+	return t, nil
+}
+
+// readTrueType reads and parses the TrueType font in the given file.
+func readTrueType(file string) ([]byte, *truetype.Font, error) {
 	log.Println("Loading Font:", file)
 	b, err := ioutil.ReadFile(file)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	f, err := truetype.Parse(b)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	/**/
 	for i, n := range []truetype.NameID{
@@ -67,84 +155,33 @@ func NewTrueTypeFont(p *pdfgo.PDF, file string) (*TrueTypeFont, error) {
 		}
 	}
 	/**/
-	basename := f.Name(truetype.NameIDPostscriptName)
-	if basename == "" {
-		basename = f.Name(truetype.NameIDFontFamily)
-	}
-	font := p.NewDictionaryObject()
-	font.AddNameNameEntry("Type", "Font")
-	font.AddNameNameEntry("Subtype", "TrueType")
-	font.AddNameNameEntry("BaseFont", basename)
-	// TODO font.AddNameNameEntry("Encoding", "") // "MacRomanEncoding" or "WinAnsiEncoding"
-	var (
-		end      int
-		widths   []pdfgo.Object
-		sum, max float64
-	)
-	scale := fixed.Int26_6(f.FUnitsPerEm())
-	for i := 32; i < 65536; i++ {
-		index := f.Index(rune(i))
-		if index == 0 {
-			continue
-		}
-		end = i
-		width := float64(f.HMetric(scale, index).AdvanceWidth)
-		sum += width
-		if width > max {
-			max = width
-		}
-		//log.Println(i, string(rune(i)), index, width)
-		widths = append(widths, &pdfgo.NumberObject{
-			Number: width,
-		})
+	return b, f, nil
+}
+
+// postscriptName returns the PostScript name of the given font, or its family name if it has none.
+func postscriptName(f *truetype.Font) string {
+	name := f.Name(truetype.NameIDPostscriptName)
+	if name == "" {
+		name = f.Name(truetype.NameIDFontFamily)
 	}
-	font.AddNameObjectEntry("FirstChar", &pdfgo.NumberObject{
-		Number: 32,
-	})
-	font.AddNameObjectEntry("LastChar", &pdfgo.NumberObject{
-		Number: float64(end),
-	})
-	width := p.NewArrayObject(widths)
-	font.AddNameObjectEntry("Widths", pdfgo.NewObjectReference(width))
-	descriptor := p.NewDictionaryObject()
-	descriptor.AddNameNameEntry("Type", "FontDescriptor")
-	descriptor.AddNameNameEntry("FontName", basename)
-
-	// TODO descriptor.AddNameNameEntry("Ascent",)
-	// TODO descriptor.AddNameNameEntry("Descent",)
-	// TODO descriptor.AddNameNameEntry("CapHeight",)
-	// TODO descriptor.AddNameNameEntry("Flags", integer)
-	bounds := f.Bounds(scale)
-	descriptor.AddNameObjectEntry("FontBBox", &pdfgo.ArrayObject{
-		Array: []pdfgo.Object{
-			&pdfgo.NumberObject{Number: float64(bounds.Min.X)},
-			&pdfgo.NumberObject{Number: float64(bounds.Min.Y)},
-			&pdfgo.NumberObject{Number: float64(bounds.Max.X)},
-			&pdfgo.NumberObject{Number: float64(bounds.Max.Y)},
-		},
-	})
-	// TODO descriptor.AddNameNameEntry("ItalicAngle", integer)
-	// TODO descriptor.AddNameNameEntry("Leading",)
-	// TODO descriptor.AddNameNameEntry("StemV",)
-	// TODO descriptor.AddNameNameEntry("StemH",)
-	average := sum / (224)
-	descriptor.AddNameObjectEntry("AvgWidth", &pdfgo.NumberObject{
-		Number: average,
-	})
-	descriptor.AddNameObjectEntry("MaxWidth", &pdfgo.NumberObject{
-		Number: max,
-	})
-	descriptor.AddNameObjectEntry("MissingWidth", &pdfgo.NumberObject{
-		Number: average,
-	})
+	return name
+}
+
+// newTrueTypeDescriptor returns the descriptor of a font with the given metrics, and the stream embedding its data.
+func newTrueTypeDescriptor(p *pdfgo.PDF, d *Descriptor, data []byte) (*pdfgo.DictionaryObject, *pdfgo.StreamObject) {
+	descriptor := d.Add(p)
 	stream := p.NewStreamObject()
-	stream.Data = b
+	stream.Data = data
 	descriptor.AddNameObjectEntry("FontFile2", pdfgo.NewObjectReference(stream))
-	font.AddNameObjectEntry("FontDescriptor", pdfgo.NewObjectReference(descriptor))
-	return &TrueTypeFont{
-		Reference: pdfgo.NewObjectReference(font),
-		Font:      f,
-	}, nil
+	return descriptor, stream
+}
+
+// symbolGlyph returns the glyph of the given code in a symbolic font, which maps codes into the private use area from 0xF000, or else directly.
+func symbolGlyph(f *truetype.Font, code int) truetype.Index {
+	if index := f.Index(rune(0xF000 + code)); index != 0 {
+		return index
+	}
+	return f.Index(rune(code))
 }
 
 func (f *TrueTypeFont) GetReference() *pdfgo.ObjectReference {
@@ -156,7 +193,8 @@ func (f *TrueTypeFont) MeasureText(text []rune, fontSize float64) float64 {
 	var previous truetype.Index
 	var width fixed.Int26_6
 	for i, c := range text {
-		index := f.Font.Index(c)
+		index := f.index(c)
+		f.use(c, index)
 		if i > 0 {
 			kern := f.Font.Kern(scale, previous, index)
 			width += kern
@@ -167,3 +205,134 @@ func (f *TrueTypeFont) MeasureText(text []rune, fontSize float64) float64 {
 	}
 	return float64(width) * fontSize / float64(f.Font.FUnitsPerEm())
 }
+
+// Use records that the given text is shown by the font, so its glyphs are embedded when the font is subset.
+// Text which is measured or encoded by the font is recorded automatically.
+func (f *TrueTypeFont) Use(text []rune) {
+	for _, c := range text {
+		f.use(c, f.index(c))
+	}
+}
+
+// index returns the glyph which shows the given character, which in symbolic fonts is the glyph of its code.
+func (f *TrueTypeFont) index(c rune) truetype.Index {
+	if f.symbolic {
+		if code, ok := f.code(c); ok {
+			return symbolGlyph(f.Font, int(code))
+		}
+	}
+	return f.Font.Index(c)
+}
+
+// code returns the code which shows the given character, which in symbolic fonts is the low byte of characters from 0 to 0xFF or from 0xF000 to 0xF0FF, and otherwise is its code in WinAnsiEncoding.
+func (f *TrueTypeFont) code(c rune) (byte, bool) {
+	if !f.symbolic {
+		return WinAnsiEncoding.Encode(c)
+	}
+	switch {
+	case c >= 0 && c <= 0xFF:
+		return byte(c), true
+	case c >= 0xF000 && c <= 0xF0FF:
+		return byte(c - 0xF000), true
+	}
+	return 0, false
+}
+
+func (f *TrueTypeFont) use(c rune, index truetype.Index) {
+	if f.used == nil {
+		f.used = make(map[rune]uint16)
+	}
+	f.used[c] = uint16(index)
+}
+
+// Encode returns the codes which show the given text, replacing characters which can't be encoded with question marks.
+func (f *TrueTypeFont) Encode(text []rune) []byte {
+	codes := make([]byte, 0, len(text))
+	for _, c := range text {
+		code, ok := f.code(c)
+		if !ok {
+			c, code = '?', '?'
+		}
+		f.use(c, f.index(c))
+		codes = append(codes, code)
+	}
+	return codes
+}
+
+// ToUnicode returns a CMap which maps the codes of the characters shown by the font to those characters.
+func (f *TrueTypeFont) ToUnicode() *CMap {
+	c := NewCMap("")
+	c.AddCodeSpace([]byte{0x00}, []byte{0xFF})
+	for r := range f.used {
+		if code, ok := f.code(r); ok {
+			c.AddUnicode([]byte{code}, UnicodeText(r))
+		}
+	}
+	return c
+}
+
+// embed sets the data of the embedded font file, which is either the whole font, or the subset of used glyphs with the name of the font tagged, and the mapping of codes to characters.
+func (f *TrueTypeFont) embed() error {
+	if f.file == nil {
+		return nil
+	}
+	if f.toUnicode != nil {
+		f.toUnicode.Data = f.ToUnicode().Bytes()
+	}
+	data, name := f.data, f.basename
+	if f.Subset {
+		var glyphs []uint16
+		for g := range f.glyphs {
+			glyphs = append(glyphs, g)
+		}
+		// Symbolic fonts map codes through the private use area
+		symbols := make(map[rune]uint16)
+		if f.symbolic {
+			for r, g := range f.used {
+				if code, ok := f.code(r); ok {
+					symbols[0xF000+rune(code)] = g
+				}
+			}
+		}
+		d, err := subsetTrueType(f.data, f.used, symbols, glyphs)
+		if err != nil {
+			return err
+		}
+		data, name = d, f.Tag()+"+"+f.basename
+	}
+	f.file.Data = data
+	for _, d := range f.names {
+		if d.HasEntry("FontName") {
+			d.SetNameNameEntry("FontName", name)
+		} else {
+			d.SetNameNameEntry("BaseFont", name)
+		}
+	}
+	return nil
+}
+
+// Tag returns the six uppercase letters which prefix the name of the font when it is subset, derived from the characters and glyphs used so that different subsets get different tags.
+func (f *TrueTypeFont) Tag() string {
+	var runes []rune
+	for c := range f.used {
+		runes = append(runes, c)
+	}
+	for g := range f.glyphs {
+		// Glyphs are distinguished from characters by being outside of Unicode
+		runes = append(runes, 0x110000+rune(g))
+	}
+	sort.Slice(runes, func(i, j int) bool {
+		return runes[i] < runes[j]
+	})
+	hash := sha256.New()
+	hash.Write([]byte(f.basename))
+	for _, r := range runes {
+		hash.Write([]byte{byte(r >> 16), byte(r >> 8), byte(r)})
+	}
+	sum := hash.Sum(nil)
+	tag := make([]byte, 6)
+	for i := range tag {
+		tag[i] = 'A' + sum[i]%26
+	}
+	return string(tag)
+}
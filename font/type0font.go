@@ -0,0 +1,232 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package font
+
+import (
+	"encoding/binary"
+	"errors"
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/math/fixed"
+	"math"
+	"sort"
+)
+
+// Type0Font is a TrueType font shown through a composite font with Identity-H encoding, where each character is shown by the two byte index of its glyph, so any character in the font can be shown.
+type Type0Font struct {
+	TrueTypeFont
+	// widths holds the width of each glyph, in order of its index
+	widths []pdfgo.Object
+	// w is the array of glyph widths in the descendant font, which only holds the used glyphs when the font is subset
+	w *pdfgo.ArrayObject
+}
+
+func NewType0Font(p *pdfgo.PDF, file string) (*Type0Font, error) {
+	b, f, err := readTrueType(file)
+	if err != nil {
+		return nil, err
+	}
+	count, err := glyphCount(b)
+	if err != nil {
+		return nil, err
+	}
+	basename := postscriptName(f)
+
+	// Widths are given in glyph space, of 1000 units per em, for each glyph in order of its index
+	var widths []pdfgo.Object
+	units := float64(f.FUnitsPerEm())
+	scale := fixed.Int26_6(f.FUnitsPerEm())
+	for i := 0; i < count; i++ {
+		width := float64(f.HMetric(scale, truetype.Index(i)).AdvanceWidth)
+		widths = append(widths, &pdfgo.NumberObject{
+			Number: math.Round(width * 1000 / units),
+		})
+	}
+
+	font := p.NewDictionaryObject()
+	font.AddNameNameEntry("Type", "Font")
+	font.AddNameNameEntry("Subtype", "Type0")
+	font.AddNameNameEntry("BaseFont", basename)
+	font.AddNameNameEntry("Encoding", "Identity-H")
+	descendant := p.NewDictionaryObject()
+	descendant.AddNameNameEntry("Type", "Font")
+	descendant.AddNameNameEntry("Subtype", "CIDFontType2")
+	descendant.AddNameNameEntry("BaseFont", basename)
+	info := &pdfgo.DictionaryObject{
+		Dictionary: make(map[*pdfgo.NameObject]pdfgo.Object),
+	}
+	info.AddNameObjectEntry("Registry", &pdfgo.StringObject{
+		String: "Adobe",
+	})
+	info.AddNameObjectEntry("Ordering", &pdfgo.StringObject{
+		String: "Identity",
+	})
+	info.AddNameObjectEntry("Supplement", &pdfgo.NumberObject{
+		Number: 0,
+	})
+	descendant.AddNameObjectEntry("CIDSystemInfo", info)
+	w := p.NewArrayObject(nil)
+	descendant.AddNameObjectEntry("W", pdfgo.NewObjectReference(w))
+	// Codes are glyph indices, so each CID maps to the glyph of the same index
+	descendant.AddNameNameEntry("CIDToGIDMap", "Identity")
+	d := ReadTrueTypeDescriptor(b, f)
+	d.FontName = basename
+	descriptor, stream := newTrueTypeDescriptor(p, d, b)
+	descendant.AddNameObjectEntry("FontDescriptor", pdfgo.NewObjectReference(descriptor))
+	font.AddNameObjectEntry("DescendantFonts", &pdfgo.ArrayObject{
+		Array: []pdfgo.Object{
+			pdfgo.NewObjectReference(descendant),
+		},
+	})
+	toUnicode := p.NewStreamObject()
+	font.AddNameObjectEntry("ToUnicode", pdfgo.NewObjectReference(toUnicode))
+	t := &Type0Font{
+		TrueTypeFont: TrueTypeFont{
+			Reference: pdfgo.NewObjectReference(font),
+			Font:      f,
+			data:      b,
+			file:      stream,
+			basename:  basename,
+			names:     []*pdfgo.DictionaryObject{font, descendant, descriptor},
+			used:      make(map[rune]uint16),
+			glyphs:    make(map[uint16][]rune),
+			toUnicode: toUnicode,
+		},
+		widths: widths,
+		w:      w,
+	}
+	// Until the document is written, the whole font is embedded
+	if err := t.embed(); err != nil {
+		return nil, err
+	}
+	p.OnWrite(t.embed)
+	return t, nil
+}
+
+// embed sets the data of the embedded font file, the mapping of codes to characters, and the glyph widths, which are those of all glyphs, or only of the used glyphs when the font is subset.
+func (f *Type0Font) embed() error {
+	if err := f.TrueTypeFont.embed(); err != nil {
+		return err
+	}
+	f.toUnicode.Data = f.ToUnicode().Bytes()
+	if !f.Subset {
+		f.w.Array = []pdfgo.Object{
+			&pdfgo.NumberObject{
+				Number: 0,
+			},
+			&pdfgo.ArrayObject{
+				Array: f.widths,
+			},
+		}
+		return nil
+	}
+	var glyphs []int
+	for _, g := range f.used {
+		glyphs = append(glyphs, int(g))
+	}
+	for g := range f.glyphs {
+		glyphs = append(glyphs, int(g))
+	}
+	sort.Ints(glyphs)
+	// Consecutive glyphs share a range
+	f.w.Array = nil
+	var widths *pdfgo.ArrayObject
+	for i, g := range glyphs {
+		if g >= len(f.widths) || (i > 0 && g == glyphs[i-1]) {
+			continue
+		}
+		if widths == nil || g != glyphs[i-1]+1 {
+			widths = &pdfgo.ArrayObject{}
+			f.w.Array = append(f.w.Array, &pdfgo.NumberObject{
+				Number: float64(g),
+			}, widths)
+		}
+		widths.Array = append(widths.Array, f.widths[g])
+	}
+	return nil
+}
+
+// MeasureText returns the width of the given text, which is shown without kerning.
+func (f *Type0Font) MeasureText(text []rune, fontSize float64) float64 {
+	scale := fixed.Int26_6(f.Font.FUnitsPerEm())
+	var width fixed.Int26_6
+	for _, c := range text {
+		index := f.Font.Index(c)
+		f.use(c, index)
+		width += f.Font.HMetric(scale, index).AdvanceWidth
+	}
+	return float64(width) * fontSize / float64(f.Font.FUnitsPerEm())
+}
+
+// Encode returns the two byte glyph indices which show the given text.
+func (f *Type0Font) Encode(text []rune) []byte {
+	codes := make([]byte, 0, 2*len(text))
+	for _, c := range text {
+		index := f.Font.Index(c)
+		f.use(c, index)
+		codes = append(codes, byte(index>>8), byte(index))
+	}
+	return codes
+}
+
+// EncodeGlyph returns the two byte code which shows the glyph of the given index, such as a ligature chosen by shaping, recording that it represents the given text.
+func (f *Type0Font) EncodeGlyph(index truetype.Index, text []rune) []byte {
+	if f.glyphs == nil {
+		f.glyphs = make(map[uint16][]rune)
+	}
+	f.glyphs[uint16(index)] = text
+	return []byte{byte(index >> 8), byte(index)}
+}
+
+// ToUnicode returns a CMap which maps the codes of the glyphs shown by the font to the characters they represent.
+// Where several characters share a glyph, the glyph maps to the first of them.
+func (f *Type0Font) ToUnicode() *CMap {
+	c := NewCMap("")
+	c.AddCodeSpace([]byte{0x00, 0x00}, []byte{0xFF, 0xFF})
+	var runes []rune
+	for r := range f.used {
+		runes = append(runes, r)
+	}
+	sort.Slice(runes, func(i, j int) bool {
+		return runes[i] > runes[j]
+	})
+	for _, r := range runes {
+		g := f.used[r]
+		if g == 0 {
+			// The missing glyph represents no character
+			continue
+		}
+		c.AddUnicode([]byte{byte(g >> 8), byte(g)}, UnicodeText(r))
+	}
+	for g, text := range f.glyphs {
+		c.AddUnicode([]byte{byte(g >> 8), byte(g)}, text)
+	}
+	return c
+}
+
+// glyphCount returns the number of glyphs in the given TrueType font, from its maximum profile table.
+func glyphCount(data []byte) (int, error) {
+	tables, err := readTables(data)
+	if err != nil {
+		return 0, err
+	}
+	maxp, ok := tables["maxp"]
+	if !ok || len(maxp) < 6 {
+		return 0, errors.New("TrueType Font has no maximum profile")
+	}
+	return int(binary.BigEndian.Uint16(maxp[4:])), nil
+}
@@ -0,0 +1,148 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package font_test
+
+import (
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/AletheiaWareLLC/pdfgo/font"
+	"github.com/AletheiaWareLLC/pdfgo/graphics"
+	"github.com/AletheiaWareLLC/pdfgo/render"
+	"github.com/golang/freetype/truetype"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/image/font/gofont/goregular"
+	"image"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// goRegular writes the Go Regular font to a temporary file, returning its path.
+func goRegular(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "font")
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	file := filepath.Join(dir, "Go-Regular.ttf")
+	assert.Nil(t, ioutil.WriteFile(file, goregular.TTF, 0600))
+	return file
+}
+
+// showText writes a page showing the text in a text box in the given font, returning the document and the box.
+func showText(t *testing.T, newFont func(*pdfgo.PDF, string) (font.Font, error), text string) (*pdfgo.PDF, *graphics.TextBox) {
+	t.Helper()
+	p := pdfgo.NewPDF()
+	f, err := newFont(p, goRegular(t))
+	assert.Nil(t, err)
+	fonts := p.NewDictionaryObject()
+	fonts.AddNameObjectEntry("F1", f.GetReference())
+	resources := p.NewDictionaryObject()
+	resources.AddNameObjectEntry("Font", fonts)
+	box := &graphics.TextBox{
+		Text:       []rune(text),
+		FontID:     "F1",
+		Font:       f,
+		FontSize:   24,
+		FontColour: []float64{0},
+	}
+	_, err = box.SetBounds(&graphics.Rectangle{Left: 10, Bottom: 10, Right: 390, Top: 90})
+	assert.Nil(t, err)
+	writer := graphics.NewContentWriter()
+	assert.Nil(t, box.Write(p, writer))
+	data, err := writer.Bytes()
+	assert.Nil(t, err)
+	contents := p.NewStreamObject()
+	contents.Data = data
+	p.AddPage(400, 100, pdfgo.NewObjectReference(resources), pdfgo.NewObjectReference(contents))
+	assert.Nil(t, p.Write(ioutil.Discard))
+	return p, box
+}
+
+// drawText writes and renders the text shown by a text box in the given font, returning the image and the box.
+func drawText(t *testing.T, newFont func(*pdfgo.PDF, string) (font.Font, error), text string) (*image.RGBA, *graphics.TextBox) {
+	t.Helper()
+	p, box := showText(t, newFont, text)
+	img, err := render.Render(p, 0, 72)
+	assert.Nil(t, err)
+	return img, box
+}
+
+// ink returns the number of dark pixels in the image.
+func ink(img *image.RGBA) int {
+	count := 0
+	for i := 0; i < len(img.Pix); i += 4 {
+		if img.Pix[i] < 128 {
+			count++
+		}
+	}
+	return count
+}
+
+func newType0Font(p *pdfgo.PDF, file string) (font.Font, error) {
+	return font.NewType0Font(p, file)
+}
+
+func TestType0Font(t *testing.T) {
+	p := pdfgo.NewPDF()
+	f, err := font.NewType0Font(p, goRegular(t))
+	assert.Nil(t, err)
+	d := f.GetReference().Object.(*pdfgo.DictionaryObject)
+	assert.Equal(t, "Type0", d.GetEntry("Subtype").(*pdfgo.NameObject).Name)
+	assert.Equal(t, "Identity-H", d.GetEntry("Encoding").(*pdfgo.NameObject).Name)
+	descendant := pdfgo.Resolve(d.GetEntry("DescendantFonts").(*pdfgo.ArrayObject).Array[0]).(*pdfgo.DictionaryObject)
+	assert.Equal(t, "CIDFontType2", descendant.GetEntry("Subtype").(*pdfgo.NameObject).Name)
+	assert.Equal(t, "Identity", descendant.GetEntry("CIDToGIDMap").(*pdfgo.NameObject).Name)
+
+	// Codes are the glyph indices of the characters, whose widths are in glyph space
+	parsed, err := truetype.Parse(goregular.TTF)
+	assert.Nil(t, err)
+	codes := f.Encode([]rune("Aж"))
+	assert.Equal(t, 4, len(codes))
+	a := parsed.Index('A')
+	assert.Equal(t, []byte{byte(a >> 8), byte(a)}, codes[:2])
+	decoder := font.NewDecoder(d)
+	decoded := decoder.Decode(codes)
+	assert.Equal(t, 2, len(decoded))
+	assert.Equal(t, int(a), decoded[0].CID)
+	assert.InDelta(t, f.MeasureText([]rune("A"), 1), decoded[0].Width, 0.001)
+	// Widths are rounded to the nearest unit of glyph space
+	text := []rune("The quick brown fox jumps over the lazy dog")
+	for i, c := range decoder.Decode(f.Encode(text)) {
+		assert.InDelta(t, f.MeasureText(text[i:i+1], 1), c.Width, 0.0005, string(text[i]))
+	}
+
+	_, err = font.NewType0Font(p, "missing.ttf")
+	assert.NotNil(t, err)
+}
+
+func TestType0Font_TextBox(t *testing.T) {
+	for _, text := range []string{
+		"Καλημέρα κόσμε",
+		"Здравствуй, мир",
+		"∑ ∞ ≤ ≥ → €",
+	} {
+		img, box := drawText(t, newType0Font, text)
+		assert.Equal(t, 1, len(box.Lines), text)
+		assert.Greater(t, ink(img), 100, text)
+	}
+	// Similar characters of different scripts are shown with their own glyphs
+	greek, _ := drawText(t, newType0Font, "αβγ")
+	cyrillic, _ := drawText(t, newType0Font, "абв")
+	assert.NotEqual(t, greek.Pix, cyrillic.Pix)
+}
@@ -17,7 +17,6 @@
 package graphics
 
 import (
-	"bytes"
 	"github.com/AletheiaWareLLC/pdfgo"
 )
 
@@ -25,5 +24,6 @@ type Box interface {
 	// Tells the Box to resize within the given bounds.
 	// Returns the actual bounds occupied.
 	SetBounds(bounds *Rectangle) (*Rectangle, error)
-	Write(p *pdfgo.PDF, buffer *bytes.Buffer) error
+	// Writes the content of the Box.
+	Write(p *pdfgo.PDF, writer *ContentWriter) error
 }
@@ -17,8 +17,6 @@
 package graphics
 
 import (
-	"bytes"
-	"fmt"
 	"github.com/AletheiaWareLLC/pdfgo"
 )
 
@@ -36,18 +34,20 @@ func (b *ColourBox) SetBounds(bounds *Rectangle) (*Rectangle, error) {
 	return bounds, nil
 }
 
-func (b *ColourBox) Write(p *pdfgo.PDF, buffer *bytes.Buffer) error {
-	buffer.WriteString("q\n")
+func (b *ColourBox) Write(p *pdfgo.PDF, writer *ContentWriter) error {
+	writer.SaveState()
 	// Fill
 	if b.FillColour != nil {
-		buffer.WriteString(fmt.Sprintf("%s %s %s rg\n", FloatToString(b.FillColour[0]), FloatToString(b.FillColour[1]), FloatToString(b.FillColour[2])))
-		buffer.WriteString(fmt.Sprintf("%s %s %s %s re f\n", FloatToString(b.Left), FloatToString(b.Bottom), FloatToString(b.DX()), FloatToString(b.DY())))
+		writer.SetFillColour(b.FillColour)
+		writer.Rectangle(b.Left, b.Bottom, b.DX(), b.DY())
+		writer.Fill()
 	}
 	// Border
 	if b.BorderColour != nil {
-		buffer.WriteString(fmt.Sprintf("%s %s %s RG\n", FloatToString(b.BorderColour[0]), FloatToString(b.BorderColour[1]), FloatToString(b.BorderColour[2])))
-		buffer.WriteString(fmt.Sprintf("%s %s %s %s re S\n", FloatToString(b.Left), FloatToString(b.Bottom), FloatToString(b.DX()), FloatToString(b.DY())))
+		writer.SetStrokeColour(b.BorderColour)
+		writer.Rectangle(b.Left, b.Bottom, b.DX(), b.DY())
+		writer.Stroke()
 	}
-	buffer.WriteString("Q")
-	return nil
+	writer.RestoreState()
+	return writer.Err()
 }
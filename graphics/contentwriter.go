@@ -0,0 +1,511 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package graphics
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/AletheiaWareLLC/pdfgo/content"
+	"strconv"
+	"strings"
+)
+
+// KAPPA is the distance of the control points of the Bézier curves approximating a quarter circle of unit radius
+const KAPPA = 0.5522847498
+
+type LineCap int
+
+const (
+	ButtCap LineCap = iota
+	RoundCap
+	ProjectingSquareCap
+)
+
+type LineJoin int
+
+const (
+	MiterJoin LineJoin = iota
+	RoundJoin
+	BevelJoin
+)
+
+// ContentWriter writes the operators of a content stream, one per line, checking that
+// graphics states and text and marked content sections are correctly nested.
+// The first error encountered is kept and all later operators are ignored.
+type ContentWriter struct {
+	// Precision is the maximum number of decimal places written, or -1 for as many as needed
+	Precision int
+	buffer    bytes.Buffer
+	states    int
+	marked    int
+	text      bool
+	path      bool
+	err       error
+}
+
+func NewContentWriter() *ContentWriter {
+	return &ContentWriter{
+		Precision: -1,
+	}
+}
+
+// Err returns the first error encountered.
+func (w *ContentWriter) Err() error {
+	return w.err
+}
+
+// Bytes returns the content written, or an error if the content is invalid or incomplete.
+func (w *ContentWriter) Bytes() ([]byte, error) {
+	if w.err != nil {
+		return nil, w.err
+	}
+	if w.text {
+		return nil, fmt.Errorf("Unterminated text object")
+	}
+	if w.states != 0 {
+		return nil, fmt.Errorf("Unbalanced graphics state: %d unrestored", w.states)
+	}
+	if w.marked != 0 {
+		return nil, fmt.Errorf("Unbalanced marked content: %d unterminated", w.marked)
+	}
+	return w.buffer.Bytes(), nil
+}
+
+// Number formats the given number with the writer's precision.
+func (w *ContentWriter) Number(f float64) string {
+	if w.Precision < 0 {
+		return FloatToString(f)
+	}
+	s := strconv.FormatFloat(f, 'f', w.Precision, 64)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	if s == "-0" {
+		s = "0"
+	}
+	return s
+}
+
+func (w *ContentWriter) fail(format string, args ...interface{}) {
+	if w.err == nil {
+		w.err = fmt.Errorf(format, args...)
+	}
+}
+
+func (w *ContentWriter) write(operator string, operands ...string) {
+	if w.err != nil {
+		return
+	}
+	if w.path && !isPathOperator(operator) {
+		w.fail("Path not ended before %s", operator)
+		return
+	}
+	if w.buffer.Len() > 0 {
+		w.buffer.WriteByte('\n')
+	}
+	for _, o := range operands {
+		w.buffer.WriteString(o)
+		w.buffer.WriteByte(' ')
+	}
+	w.buffer.WriteString(operator)
+}
+
+func (w *ContentWriter) numbers(operator string, numbers ...float64) {
+	var operands []string
+	for _, n := range numbers {
+		operands = append(operands, w.Number(n))
+	}
+	w.write(operator, operands...)
+}
+
+func (w *ContentWriter) outsideText(operator string) bool {
+	if w.text {
+		w.fail("%s not allowed in text object", operator)
+		return false
+	}
+	return true
+}
+
+func (w *ContentWriter) insideText(operator string) bool {
+	if !w.text {
+		w.fail("%s not allowed outside text object", operator)
+		return false
+	}
+	return true
+}
+
+func isPathOperator(operator string) bool {
+	switch operator {
+	case "m", "l", "c", "v", "y", "h", "re", "S", "s", "f", "F", "f*", "B", "B*", "b", "b*", "n", "W", "W*":
+		return true
+	}
+	return false
+}
+
+// WriteOperation writes an operation parsed from an existing content stream.
+func (w *ContentWriter) WriteOperation(o *content.Operation) {
+	switch o.Operator {
+	case "q":
+		w.SaveState()
+		return
+	case "Q":
+		w.RestoreState()
+		return
+	case "BT":
+		w.BeginText()
+		return
+	case "ET":
+		w.EndText()
+		return
+	case "BMC", "BDC":
+		w.marked++
+	case "EMC":
+		if w.marked == 0 {
+			w.fail("EMC without BMC or BDC")
+			return
+		}
+		w.marked--
+	}
+	if o.Operator == "BI" {
+		// Inline images are written whole
+		var buffer bytes.Buffer
+		if _, err := o.Write(&buffer); err != nil {
+			w.fail("%s", err)
+			return
+		}
+		w.write(buffer.String())
+		return
+	}
+	var operands []string
+	for _, operand := range o.Operands {
+		var buffer bytes.Buffer
+		if _, err := operand.Write(&buffer); err != nil {
+			w.fail("%s", err)
+			return
+		}
+		operands = append(operands, buffer.String())
+	}
+	w.write(o.Operator, operands...)
+	w.updatePath(o.Operator)
+}
+
+func (w *ContentWriter) updatePath(operator string) {
+	switch operator {
+	case "m", "l", "c", "v", "y", "h", "re", "W", "W*":
+		w.path = true
+	case "S", "s", "f", "F", "f*", "B", "B*", "b", "b*", "n":
+		w.path = false
+	}
+}
+
+func (w *ContentWriter) pathConstruction(operator string, numbers ...float64) {
+	if w.outsideText(operator) {
+		w.numbers(operator, numbers...)
+		w.path = true
+	}
+}
+
+func (w *ContentWriter) pathPainting(operator string) {
+	if !w.path {
+		w.fail("%s without path", operator)
+		return
+	}
+	w.write(operator)
+	w.path = false
+}
+
+// Graphics State
+
+func (w *ContentWriter) SaveState() {
+	if w.outsideText("q") {
+		w.write("q")
+		w.states++
+	}
+}
+
+func (w *ContentWriter) RestoreState() {
+	if !w.outsideText("Q") {
+		return
+	}
+	if w.states == 0 {
+		w.fail("Q without q")
+		return
+	}
+	w.write("Q")
+	w.states--
+}
+
+func (w *ContentWriter) Transform(a, b, c, d, e, f float64) {
+	if w.outsideText("cm") {
+		w.numbers("cm", a, b, c, d, e, f)
+	}
+}
+
+func (w *ContentWriter) SetLineWidth(width float64) {
+	w.numbers("w", width)
+}
+
+func (w *ContentWriter) SetLineCap(cap LineCap) {
+	w.write("J", strconv.Itoa(int(cap)))
+}
+
+func (w *ContentWriter) SetLineJoin(join LineJoin) {
+	w.write("j", strconv.Itoa(int(join)))
+}
+
+func (w *ContentWriter) SetMiterLimit(limit float64) {
+	w.numbers("M", limit)
+}
+
+func (w *ContentWriter) SetDash(dashes []float64, phase float64) {
+	var array []string
+	for _, d := range dashes {
+		array = append(array, w.Number(d))
+	}
+	w.write("d", "["+strings.Join(array, " ")+"]", w.Number(phase))
+}
+
+// SetGraphicsState applies the named graphics state parameter dictionary from the resources.
+func (w *ContentWriter) SetGraphicsState(name string) {
+	w.write("gs", "/"+pdfgo.EscapeName(name))
+}
+
+// Path Construction
+
+func (w *ContentWriter) MoveTo(x, y float64) {
+	w.pathConstruction("m", x, y)
+}
+
+func (w *ContentWriter) LineTo(x, y float64) {
+	w.pathConstruction("l", x, y)
+}
+
+func (w *ContentWriter) CurveTo(x1, y1, x2, y2, x3, y3 float64) {
+	w.pathConstruction("c", x1, y1, x2, y2, x3, y3)
+}
+
+func (w *ContentWriter) ClosePath() {
+	w.pathConstruction("h")
+}
+
+func (w *ContentWriter) Rectangle(x, y, width, height float64) {
+	w.pathConstruction("re", x, y, width, height)
+}
+
+// Clip intersects the clipping path with the current path, using the nonzero winding rule, and must be followed by a path painting operator.
+func (w *ContentWriter) Clip() {
+	w.pathConstruction("W")
+}
+
+func (w *ContentWriter) ClipEvenOdd() {
+	w.pathConstruction("W*")
+}
+
+// Path Painting
+
+func (w *ContentWriter) Stroke() {
+	w.pathPainting("S")
+}
+
+func (w *ContentWriter) CloseStroke() {
+	w.pathPainting("s")
+}
+
+func (w *ContentWriter) Fill() {
+	w.pathPainting("f")
+}
+
+func (w *ContentWriter) FillEvenOdd() {
+	w.pathPainting("f*")
+}
+
+func (w *ContentWriter) FillStroke() {
+	w.pathPainting("B")
+}
+
+func (w *ContentWriter) CloseFillStroke() {
+	w.pathPainting("b")
+}
+
+// EndPath ends the path without painting it, as used after Clip.
+func (w *ContentWriter) EndPath() {
+	w.pathPainting("n")
+}
+
+// Colour
+
+func (w *ContentWriter) SetFillGray(gray float64) {
+	w.numbers("g", gray)
+}
+
+func (w *ContentWriter) SetStrokeGray(gray float64) {
+	w.numbers("G", gray)
+}
+
+func (w *ContentWriter) SetFillRGB(r, g, b float64) {
+	w.numbers("rg", r, g, b)
+}
+
+func (w *ContentWriter) SetStrokeRGB(r, g, b float64) {
+	w.numbers("RG", r, g, b)
+}
+
+func (w *ContentWriter) SetFillCMYK(c, m, y, k float64) {
+	w.numbers("k", c, m, y, k)
+}
+
+func (w *ContentWriter) SetStrokeCMYK(c, m, y, k float64) {
+	w.numbers("K", c, m, y, k)
+}
+
+// SetFillColour sets a gray, RGB or CMYK fill colour depending on the number of components.
+func (w *ContentWriter) SetFillColour(colour []float64) {
+	switch len(colour) {
+	case 1:
+		w.SetFillGray(colour[0])
+	case 3:
+		w.SetFillRGB(colour[0], colour[1], colour[2])
+	case 4:
+		w.SetFillCMYK(colour[0], colour[1], colour[2], colour[3])
+	default:
+		w.fail("Unsupported colour: %v", colour)
+	}
+}
+
+// SetStrokeColour sets a gray, RGB or CMYK stroke colour depending on the number of components.
+func (w *ContentWriter) SetStrokeColour(colour []float64) {
+	switch len(colour) {
+	case 1:
+		w.SetStrokeGray(colour[0])
+	case 3:
+		w.SetStrokeRGB(colour[0], colour[1], colour[2])
+	case 4:
+		w.SetStrokeCMYK(colour[0], colour[1], colour[2], colour[3])
+	default:
+		w.fail("Unsupported colour: %v", colour)
+	}
+}
+
+// Text
+
+func (w *ContentWriter) BeginText() {
+	if w.text {
+		w.fail("BT not allowed in text object")
+		return
+	}
+	w.write("BT")
+	w.text = true
+}
+
+func (w *ContentWriter) EndText() {
+	if w.insideText("ET") {
+		w.write("ET")
+		w.text = false
+	}
+}
+
+func (w *ContentWriter) SetFont(name string, size float64) {
+	w.write("Tf", "/"+pdfgo.EscapeName(name), w.Number(size))
+}
+
+func (w *ContentWriter) SetCharacterSpacing(spacing float64) {
+	w.numbers("Tc", spacing)
+}
+
+func (w *ContentWriter) SetWordSpacing(spacing float64) {
+	w.numbers("Tw", spacing)
+}
+
+// SetHorizontalScaling sets the horizontal scaling as a percentage.
+func (w *ContentWriter) SetHorizontalScaling(scaling float64) {
+	w.numbers("Tz", scaling)
+}
+
+func (w *ContentWriter) SetLeading(leading float64) {
+	w.numbers("TL", leading)
+}
+
+func (w *ContentWriter) SetTextRise(rise float64) {
+	w.numbers("Ts", rise)
+}
+
+func (w *ContentWriter) SetTextRender(mode int) {
+	w.write("Tr", strconv.Itoa(mode))
+}
+
+func (w *ContentWriter) SetTextMatrix(a, b, c, d, e, f float64) {
+	if w.insideText("Tm") {
+		w.numbers("Tm", a, b, c, d, e, f)
+	}
+}
+
+func (w *ContentWriter) MoveText(x, y float64) {
+	if w.insideText("Td") {
+		w.numbers("Td", x, y)
+	}
+}
+
+func (w *ContentWriter) NextLine() {
+	if w.insideText("T*") {
+		w.write("T*")
+	}
+}
+
+// ShowText shows a string, given with its escape sequences as in a StringObject.
+func (w *ContentWriter) ShowText(text string) {
+	if w.insideText("Tj") {
+		w.write("Tj", "("+text+")")
+	}
+}
+
+// NextLineShowText moves to the next line and shows a string, given with its escape sequences as in a StringObject.
+func (w *ContentWriter) NextLineShowText(text string) {
+	if w.insideText("'") {
+		w.write("'", "("+text+")")
+	}
+}
+
+// XObjects
+
+// DrawXObject paints the named image or form XObject from the resources.
+func (w *ContentWriter) DrawXObject(name string) {
+	if w.outsideText("Do") {
+		w.write("Do", "/"+pdfgo.EscapeName(name))
+	}
+}
+
+// Marked Content
+
+// BeginMarkedContent starts a marked content sequence, with the named property list from the resources if properties is not empty.
+func (w *ContentWriter) BeginMarkedContent(tag, properties string) {
+	if properties == "" {
+		w.write("BMC", "/"+pdfgo.EscapeName(tag))
+	} else {
+		w.write("BDC", "/"+pdfgo.EscapeName(tag), "/"+pdfgo.EscapeName(properties))
+	}
+	w.marked++
+}
+
+func (w *ContentWriter) EndMarkedContent() {
+	if w.marked == 0 {
+		w.fail("EMC without BMC or BDC")
+		return
+	}
+	w.write("EMC")
+	w.marked--
+}
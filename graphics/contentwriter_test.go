@@ -0,0 +1,95 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package graphics_test
+
+import (
+	"github.com/AletheiaWareLLC/pdfgo/graphics"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestContentWriter(t *testing.T) {
+	w := graphics.NewContentWriter()
+	w.SaveState()
+	w.SetLineWidth(2)
+	w.SetDash([]float64{3, 1}, 0)
+	w.SetStrokeColour([]float64{1, 0, 0})
+	w.MoveTo(0, 0)
+	w.LineTo(100, 100)
+	w.Stroke()
+	w.BeginText()
+	w.SetFont("F1", 12)
+	w.MoveText(10, 20)
+	w.ShowText("Hello \\(World\\)")
+	w.EndText()
+	w.RestoreState()
+	data, err := w.Bytes()
+	assert.Nil(t, err)
+	assert.Equal(t, "q\n2 w\n[3 1] 0 d\n1 0 0 RG\n0 0 m\n100 100 l\nS\nBT\n/F1 12 Tf\n10 20 Td\n(Hello \\(World\\)) Tj\nET\nQ", string(data))
+}
+
+func TestContentWriter_precision(t *testing.T) {
+	w := graphics.NewContentWriter()
+	w.Precision = 2
+	w.Transform(1.0/3, 0, 0, 2.499, -0.001, 10)
+	data, err := w.Bytes()
+	assert.Nil(t, err)
+	assert.Equal(t, "0.33 0 0 2.5 0 10 cm", string(data))
+}
+
+func TestContentWriter_unbalancedState(t *testing.T) {
+	w := graphics.NewContentWriter()
+	w.SaveState()
+	_, err := w.Bytes()
+	assert.NotNil(t, err)
+
+	w = graphics.NewContentWriter()
+	w.RestoreState()
+	assert.NotNil(t, w.Err())
+}
+
+func TestContentWriter_textNesting(t *testing.T) {
+	w := graphics.NewContentWriter()
+	w.ShowText("Outside")
+	assert.NotNil(t, w.Err())
+
+	w = graphics.NewContentWriter()
+	w.BeginText()
+	w.BeginText()
+	assert.NotNil(t, w.Err())
+
+	w = graphics.NewContentWriter()
+	w.BeginText()
+	w.SaveState()
+	assert.NotNil(t, w.Err())
+
+	w = graphics.NewContentWriter()
+	w.BeginText()
+	_, err := w.Bytes()
+	assert.NotNil(t, err)
+}
+
+func TestContentWriter_unpaintedPath(t *testing.T) {
+	w := graphics.NewContentWriter()
+	w.Rectangle(0, 0, 10, 10)
+	w.SetFillRGB(1, 0, 0)
+	assert.NotNil(t, w.Err())
+
+	w = graphics.NewContentWriter()
+	w.Fill()
+	assert.NotNil(t, w.Err())
+}
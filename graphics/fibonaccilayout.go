@@ -17,7 +17,6 @@
 package graphics
 
 import (
-	"bytes"
 	"github.com/AletheiaWareLLC/pdfgo"
 )
 
@@ -59,9 +58,9 @@ func (l *FibonacciLayout) SetBounds(bounds *Rectangle) (*Rectangle, error) {
 	return used, nil
 }
 
-func (l *FibonacciLayout) Write(p *pdfgo.PDF, buffer *bytes.Buffer) error {
+func (l *FibonacciLayout) Write(p *pdfgo.PDF, writer *ContentWriter) error {
 	for _, b := range l.Boxes {
-		if err := b.Write(p, buffer); err != nil {
+		if err := b.Write(p, writer); err != nil {
 			return err
 		}
 	}
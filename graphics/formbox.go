@@ -0,0 +1,95 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package graphics
+
+import (
+	"github.com/AletheiaWareLLC/pdfgo"
+	"math"
+)
+
+// FormBox places a form XObject at its natural size in the top left of its bounds.
+type FormBox struct {
+	Rectangle
+	FormID string
+	// FormBounds is the bounding box of the form in form space
+	FormBounds Rectangle
+	// FormMatrix is the matrix of the form, mapping form space to user space, or nil for the identity
+	FormMatrix []float64
+}
+
+func (b *FormBox) SetBounds(bounds *Rectangle) (*Rectangle, error) {
+	t := b.transformedBounds()
+	b.Left = bounds.Left
+	b.Top = bounds.Top
+	b.Right = bounds.Left + t.DX()
+	b.Bottom = bounds.Top - t.DY()
+	return &b.Rectangle, nil
+}
+
+func (b *FormBox) Write(p *pdfgo.PDF, writer *ContentWriter) error {
+	t := b.transformedBounds()
+	writer.SaveState()
+	writer.Transform(1, 0, 0, 1, b.Left-t.Left, b.Bottom-t.Bottom)
+	writer.DrawXObject(b.FormID)
+	writer.RestoreState()
+	return writer.Err()
+}
+
+// transformedBounds returns the smallest rectangle holding the form bounds transformed by the form matrix.
+func (b *FormBox) transformedBounds() *Rectangle {
+	m := b.FormMatrix
+	if len(m) != 6 {
+		return &b.FormBounds
+	}
+	f := b.FormBounds
+	var t *Rectangle
+	for _, c := range [][2]float64{{f.Left, f.Bottom}, {f.Left, f.Top}, {f.Right, f.Bottom}, {f.Right, f.Top}} {
+		x := m[0]*c[0] + m[2]*c[1] + m[4]
+		y := m[1]*c[0] + m[3]*c[1] + m[5]
+		if t == nil {
+			t = &Rectangle{Left: x, Right: x, Bottom: y, Top: y}
+			continue
+		}
+		t.Left = math.Min(t.Left, x)
+		t.Right = math.Max(t.Right, x)
+		t.Bottom = math.Min(t.Bottom, y)
+		t.Top = math.Max(t.Top, y)
+	}
+	return t
+}
+
+// NewForm lays out the box in a form XObject of the given size, so that it can be stored once and drawn on many pages by a FormBox.
+// The resources are those named by the content the box writes, and may be nil if there are none.
+func NewForm(p *pdfgo.PDF, box Box, width, height float64, resources pdfgo.Object) (*pdfgo.ObjectReference, error) {
+	if _, err := box.SetBounds(&Rectangle{
+		Left:   0,
+		Right:  width,
+		Top:    height,
+		Bottom: 0,
+	}); err != nil {
+		return nil, err
+	}
+	writer := NewContentWriter()
+	if err := box.Write(p, writer); err != nil {
+		return nil, err
+	}
+	data, err := writer.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	return p.AddForm(0, 0, width, height, nil, resources, data), nil
+}
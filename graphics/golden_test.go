@@ -0,0 +1,238 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package graphics_test
+
+import (
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/AletheiaWareLLC/pdfgo/graphics"
+	"github.com/AletheiaWareLLC/pdfgo/render"
+	"github.com/AletheiaWareLLC/pdfgo/render/rendertest"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// newBoxPage lays out the box to fill a page of the given size, and writes it to a new document.
+func newBoxPage(t *testing.T, box graphics.Box, width, height float64) *pdfgo.PDF {
+	t.Helper()
+	p := pdfgo.NewPDF()
+	addBoxPage(t, p, box, width, height, nil)
+	return p
+}
+
+// addBoxPage lays out the box to fill a new page of the given size.
+func addBoxPage(t *testing.T, p *pdfgo.PDF, box graphics.Box, width, height float64, resources pdfgo.Object) {
+	t.Helper()
+	if _, err := box.SetBounds(&graphics.Rectangle{
+		Left:   0,
+		Right:  width,
+		Top:    height,
+		Bottom: 0,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	writer := graphics.NewContentWriter()
+	if err := box.Write(p, writer); err != nil {
+		t.Fatal(err)
+	}
+	data, err := writer.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents := p.NewStreamObject()
+	contents.Data = data
+	p.AddPage(width, height, resources, pdfgo.NewObjectReference(contents))
+}
+
+// sizedColourBox is a colour box which uses a fixed size from the top left of its bounds.
+type sizedColourBox struct {
+	graphics.ColourBox
+	width, height float64
+}
+
+func newSizedColourBox(width, height float64, fill ...float64) *sizedColourBox {
+	b := &sizedColourBox{
+		width:  width,
+		height: height,
+	}
+	b.FillColour = fill
+	b.BorderColour = []float64{0, 0, 0}
+	return b
+}
+
+func (b *sizedColourBox) SetBounds(bounds *graphics.Rectangle) (*graphics.Rectangle, error) {
+	return b.ColourBox.SetBounds(&graphics.Rectangle{
+		Left:   bounds.Left,
+		Right:  bounds.Left + b.width,
+		Top:    bounds.Top,
+		Bottom: bounds.Top - b.height,
+	})
+}
+
+func TestGolden_colourBox(t *testing.T) {
+	box := &graphics.ColourBox{
+		BorderColour: []float64{1, 0, 0},
+		FillColour:   []float64{0, 0, 1},
+	}
+	inner := newSizedColourBox(100, 150, 1, 1, 0)
+	inner.BorderColour = []float64{1, 0, 0}
+	layout := &graphics.MaxLayout{}
+	layout.Add(box)
+	layout.Add(&graphics.GravityLayout{
+		Box:     inner,
+		Gravity: graphics.Middle,
+	})
+	rendertest.AssertPage(t, newBoxPage(t, layout, 200, 300), 0, "testdata/colourbox.png")
+}
+
+func TestGolden_listLayout(t *testing.T) {
+	l := &graphics.ListLayout{
+		Direction: graphics.TopBottom,
+		Padding:   10,
+	}
+	l.Add(newSizedColourBox(150, 40, 1, 0, 0))
+	l.Add(newSizedColourBox(100, 60, 0, 1, 0))
+	l.Add(newSizedColourBox(50, 80, 0, 0, 1))
+	// Doesn't fit so isn't drawn
+	l.Add(newSizedColourBox(200, 200, 0, 0, 0))
+	rendertest.AssertPage(t, newBoxPage(t, l, 200, 300), 0, "testdata/listlayout.png")
+}
+
+func TestGolden_gravityLayout(t *testing.T) {
+	l := &graphics.MaxLayout{}
+	for i, g := range []graphics.Gravity{graphics.North, graphics.Middle, graphics.South} {
+		colour := []float64{0, 0, 0}
+		colour[i] = 1
+		l.Add(&graphics.GravityLayout{
+			Box:     newSizedColourBox(60, 60, colour...),
+			Gravity: g,
+		})
+	}
+	rendertest.AssertPage(t, newBoxPage(t, l, 200, 300), 0, "testdata/gravitylayout.png")
+}
+
+func TestGolden_fibonacciLayout(t *testing.T) {
+	l := &graphics.FibonacciLayout{
+		Sizes: []float64{89, 55, 34, 21, 13, 8},
+	}
+	for i := range l.Sizes {
+		g := float64(i) / float64(len(l.Sizes))
+		l.Add(&graphics.ColourBox{
+			BorderColour: []float64{0, 0, 0},
+			FillColour:   []float64{g, 0.5, 1 - g},
+		})
+	}
+	rendertest.AssertPage(t, newBoxPage(t, l, 100, 150), 0, "testdata/fibonaccilayout.png")
+}
+
+func TestGolden_formBox(t *testing.T) {
+	p := pdfgo.NewPDF()
+	letterhead := &graphics.ListLayout{
+		Direction: graphics.TopBottom,
+		Padding:   5,
+	}
+	letterhead.Add(newSizedColourBox(180, 20, 0, 0, 0.5))
+	letterhead.Add(newSizedColourBox(90, 10, 0.5, 0.5, 0.5))
+	form, err := graphics.NewForm(p, letterhead, 180, 35, nil)
+	assert.Nil(t, err)
+	xobjects := p.NewDictionaryObject()
+	xobjects.AddNameObjectEntry("Letterhead", form)
+	resources := p.NewDictionaryObject()
+	resources.AddNameObjectEntry("XObject", xobjects)
+	for i := 0; i < 2; i++ {
+		l := &graphics.ListLayout{
+			Direction: graphics.TopBottom,
+			Padding:   10,
+		}
+		l.Add(&graphics.FormBox{
+			FormID: "Letterhead",
+			FormBounds: graphics.Rectangle{
+				Right: 180,
+				Top:   35,
+			},
+		})
+		l.Add(newSizedColourBox(100, 50*float64(i+1), 1, 0, 0))
+		addBoxPage(t, p, l, 200, 300, resources)
+	}
+	// The form is stored once and shared by both pages
+	streams := 0
+	for _, o := range p.Objects {
+		if s, ok := o.(*pdfgo.StreamObject); ok && s.HasEntry("BBox") {
+			streams++
+		}
+	}
+	assert.Equal(t, 1, streams)
+	rendertest.AssertPage(t, p, 0, "testdata/formbox_0.png")
+	rendertest.AssertPage(t, p, 1, "testdata/formbox_1.png")
+}
+
+func TestGolden_pageBox(t *testing.T) {
+	supplier := pdfgo.NewPDF()
+	terms := &graphics.ListLayout{
+		Direction: graphics.TopBottom,
+		Padding:   20,
+	}
+	terms.Add(newSizedColourBox(200, 100, 0, 0.5, 0))
+	terms.Add(newSizedColourBox(100, 180, 0.5, 0, 0.5))
+	addBoxPage(t, supplier, terms, 200, 300, nil)
+
+	p := pdfgo.NewPDF()
+	form, width, height, err := p.ImportPage(supplier.GetPages()[0])
+	assert.Nil(t, err)
+	xobjects := p.NewDictionaryObject()
+	xobjects.AddNameObjectEntry("Terms", form)
+	resources := p.NewDictionaryObject()
+	resources.AddNameObjectEntry("XObject", xobjects)
+	l := &graphics.MaxLayout{}
+	l.Add(&graphics.ColourBox{
+		BorderColour: []float64{1, 0, 0},
+	})
+	l.Add(&graphics.PageBox{
+		FormID: "Terms",
+		Width:  width,
+		Height: height,
+	})
+	addBoxPage(t, p, l, 100, 100, resources)
+	rendertest.AssertPage(t, p, 0, "testdata/pagebox.png")
+}
+
+func TestFormBox_matrix(t *testing.T) {
+	p := pdfgo.NewPDF()
+	// The form is rotated a quarter turn anticlockwise and doubled in size
+	form := p.AddForm(0, 0, 50, 20, []float64{0, 2, -2, 0, 0, 0}, nil, []byte("0 0 50 20 re f"))
+	xobjects := p.NewDictionaryObject()
+	xobjects.AddNameObjectEntry("Rotated", form)
+	resources := p.NewDictionaryObject()
+	resources.AddNameObjectEntry("XObject", xobjects)
+	box := &graphics.FormBox{
+		FormID: "Rotated",
+		FormBounds: graphics.Rectangle{
+			Right: 50,
+			Top:   20,
+		},
+		FormMatrix: []float64{0, 2, -2, 0, 0, 0},
+	}
+	addBoxPage(t, p, box, 200, 300, resources)
+	assert.Equal(t, graphics.Rectangle{Left: 0, Bottom: 200, Right: 40, Top: 300}, box.Rectangle)
+
+	img, err := render.Render(p, 0, 72)
+	assert.Nil(t, err)
+	// Inside the box is filled, beside and below it is not
+	assert.Less(t, img.RGBAAt(20, 50).R, uint8(128))
+	assert.Less(t, img.RGBAAt(38, 98).R, uint8(128))
+	assert.Greater(t, img.RGBAAt(60, 50).R, uint8(128))
+	assert.Greater(t, img.RGBAAt(20, 110).R, uint8(128))
+}
@@ -17,7 +17,6 @@
 package graphics
 
 import (
-	"bytes"
 	"fmt"
 	"github.com/AletheiaWareLLC/pdfgo"
 )
@@ -90,6 +89,6 @@ func (l *GravityLayout) SetBounds(bounds *Rectangle) (*Rectangle, error) {
 	return bounds, nil
 }
 
-func (l *GravityLayout) Write(p *pdfgo.PDF, buffer *bytes.Buffer) error {
-	return l.Box.Write(p, buffer)
+func (l *GravityLayout) Write(p *pdfgo.PDF, writer *ContentWriter) error {
+	return l.Box.Write(p, writer)
 }
@@ -17,8 +17,6 @@
 package graphics
 
 import (
-	"bytes"
-	"fmt"
 	"github.com/AletheiaWareLLC/pdfgo"
 	"math"
 )
@@ -33,7 +31,7 @@ type ImageBox struct {
 func (b *ImageBox) SetBounds(bounds *Rectangle) (*Rectangle, error) {
 	dx := bounds.Right - bounds.Left
 	dy := bounds.Top - bounds.Bottom
-	scaledWidth, scaledHeight := b.scales(dx, dy)
+	scaledWidth, scaledHeight := scaleToFit(b.Width, b.Height, b.MinimumWidth, b.MinimumHeight, dx, dy)
 	b.Left = bounds.Left
 	b.Top = bounds.Top
 	b.Right = bounds.Left + scaledWidth
@@ -41,23 +39,27 @@ func (b *ImageBox) SetBounds(bounds *Rectangle) (*Rectangle, error) {
 	return &b.Rectangle, nil
 }
 
-func (b *ImageBox) Write(p *pdfgo.PDF, buffer *bytes.Buffer) error {
+func (b *ImageBox) Write(p *pdfgo.PDF, writer *ContentWriter) error {
 	dx := b.DX()
 	dy := b.DY()
-	scaledWidth, scaledHeight := b.scales(dx, dy)
+	scaledWidth, scaledHeight := scaleToFit(b.Width, b.Height, b.MinimumWidth, b.MinimumHeight, dx, dy)
 	translateX := b.Left + ((dx - scaledWidth) / 2)
 	translateY := b.Bottom + ((dy - scaledHeight) / 2)
-	buffer.WriteString(fmt.Sprintf("q %f 0 0 %f %f %f cm /%s Do Q\n", scaledWidth, scaledHeight, translateX, translateY, b.ImageID))
-	return nil
+	writer.SaveState()
+	writer.Transform(scaledWidth, 0, 0, scaledHeight, translateX, translateY)
+	writer.DrawXObject(b.ImageID)
+	writer.RestoreState()
+	return writer.Err()
 }
 
-func (b *ImageBox) scales(dx, dy float64) (float64, float64) {
-	scale := math.Max(b.Width/dx, b.Height/dy)
-	maximumScale := math.Max(b.Width/b.MinimumWidth, b.Height/b.MinimumHeight)
+// scaleToFit returns the size of content of the given width and height, scaled to fit within dx and dy while preserving its aspect ratio, but no smaller than the minimum width or height.
+func scaleToFit(width, height, minimumWidth, minimumHeight, dx, dy float64) (float64, float64) {
+	scale := math.Max(width/dx, height/dy)
+	maximumScale := math.Max(width/minimumWidth, height/minimumHeight)
 	if scale > maximumScale {
 		scale = maximumScale
 	}
-	scaledWidth := b.Width / scale
-	scaledHeight := b.Height / scale
+	scaledWidth := width / scale
+	scaledHeight := height / scale
 	return scaledWidth, scaledHeight
 }
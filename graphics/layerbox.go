@@ -0,0 +1,41 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package graphics
+
+import (
+	"errors"
+	"github.com/AletheiaWareLLC/pdfgo"
+)
+
+// LayerBox writes the content of a Box as optional content, shown only when its layer is.
+type LayerBox struct {
+	Box
+	// Properties names the layer in the properties of the resources, as returned by pdfgo.AddLayerProperties
+	Properties string
+}
+
+func (b *LayerBox) Write(p *pdfgo.PDF, writer *ContentWriter) error {
+	if b.Properties == "" {
+		return errors.New("Missing optional content properties")
+	}
+	writer.BeginMarkedContent("OC", b.Properties)
+	if err := b.Box.Write(p, writer); err != nil {
+		return err
+	}
+	writer.EndMarkedContent()
+	return writer.Err()
+}
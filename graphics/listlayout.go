@@ -17,7 +17,6 @@
 package graphics
 
 import (
-	"bytes"
 	"fmt"
 	"github.com/AletheiaWareLLC/pdfgo"
 )
@@ -85,12 +84,12 @@ func (l *ListLayout) SetBounds(bounds *Rectangle) (*Rectangle, error) {
 	return used, nil
 }
 
-func (l *ListLayout) Write(p *pdfgo.PDF, buffer *bytes.Buffer) error {
+func (l *ListLayout) Write(p *pdfgo.PDF, writer *ContentWriter) error {
 	for i, b := range l.Boxes {
 		if i >= l.Visible {
 			break
 		}
-		if err := b.Write(p, buffer); err != nil {
+		if err := b.Write(p, writer); err != nil {
 			return err
 		}
 	}
@@ -1,7 +1,6 @@
 package graphics_test
 
 import (
-	"bytes"
 	"github.com/AletheiaWareLLC/pdfgo"
 	"github.com/AletheiaWareLLC/pdfgo/graphics"
 	"github.com/stretchr/testify/assert"
@@ -53,7 +52,7 @@ func (b *fixedSizeBox) SetBounds(bounds *graphics.Rectangle) (*graphics.Rectangl
 	}, nil
 }
 
-func (b *fixedSizeBox) Write(p *pdfgo.PDF, buffer *bytes.Buffer) error {
+func (b *fixedSizeBox) Write(p *pdfgo.PDF, writer *graphics.ContentWriter) error {
 	// Nothing
 	return nil
 }
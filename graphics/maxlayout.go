@@ -17,7 +17,6 @@
 package graphics
 
 import (
-	"bytes"
 	"github.com/AletheiaWareLLC/pdfgo"
 )
 
@@ -38,9 +37,9 @@ func (l *MaxLayout) SetBounds(bounds *Rectangle) (*Rectangle, error) {
 	return bounds, nil
 }
 
-func (l *MaxLayout) Write(p *pdfgo.PDF, buffer *bytes.Buffer) error {
+func (l *MaxLayout) Write(p *pdfgo.PDF, writer *ContentWriter) error {
 	for _, b := range l.Boxes {
-		if err := b.Write(p, buffer); err != nil {
+		if err := b.Write(p, writer); err != nil {
 			return err
 		}
 	}
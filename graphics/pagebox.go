@@ -0,0 +1,55 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package graphics
+
+import (
+	"github.com/AletheiaWareLLC/pdfgo"
+)
+
+// PageBox places a page imported by PDF.ImportPage, scaled to fit its bounds like an ImageBox.
+type PageBox struct {
+	Rectangle
+	FormID                      string
+	Width, Height               float64
+	MinimumWidth, MinimumHeight float64
+}
+
+func (b *PageBox) SetBounds(bounds *Rectangle) (*Rectangle, error) {
+	dx := bounds.Right - bounds.Left
+	dy := bounds.Top - bounds.Bottom
+	scaledWidth, scaledHeight := scaleToFit(b.Width, b.Height, b.MinimumWidth, b.MinimumHeight, dx, dy)
+	b.Left = bounds.Left
+	b.Top = bounds.Top
+	b.Right = bounds.Left + scaledWidth
+	b.Bottom = bounds.Top - scaledHeight
+	return &b.Rectangle, nil
+}
+
+func (b *PageBox) Write(p *pdfgo.PDF, writer *ContentWriter) error {
+	dx := b.DX()
+	dy := b.DY()
+	scaledWidth, scaledHeight := scaleToFit(b.Width, b.Height, b.MinimumWidth, b.MinimumHeight, dx, dy)
+	translateX := b.Left + ((dx - scaledWidth) / 2)
+	translateY := b.Bottom + ((dy - scaledHeight) / 2)
+	// The imported page occupies its width and height from the origin of form space
+	scale := scaledWidth / b.Width
+	writer.SaveState()
+	writer.Transform(scale, 0, 0, scale, translateX, translateY)
+	writer.DrawXObject(b.FormID)
+	writer.RestoreState()
+	return writer.Err()
+}
@@ -42,6 +42,16 @@ func (r *Rectangle) Max(o *Rectangle) *Rectangle {
 	}
 }
 
+// Intersects returns true if the rectangles overlap by more than their edges.
+func (r *Rectangle) Intersects(o *Rectangle) bool {
+	return r.Left < o.Right && o.Left < r.Right && r.Bottom < o.Top && o.Bottom < r.Top
+}
+
+// Contains returns true if the given point is inside the rectangle, or on its edge.
+func (r *Rectangle) Contains(x, y float64) bool {
+	return x >= r.Left && x <= r.Right && y >= r.Bottom && y <= r.Top
+}
+
 func NegativeRectangle() *Rectangle {
 	return &Rectangle{
 		Left:   math.MaxFloat64,
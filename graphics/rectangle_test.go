@@ -56,3 +56,26 @@ func TestRectangle_FloatToString(t *testing.T) {
 		t.Errorf("Incorrect result; expected '%s', got '%s'", expected, actual)
 	}
 }
+
+func TestRectangle_Intersects(t *testing.T) {
+	r := &graphics.Rectangle{
+		Left:   0,
+		Right:  10,
+		Top:    10,
+		Bottom: 0,
+	}
+	for _, test := range []struct {
+		o        *graphics.Rectangle
+		expected bool
+	}{
+		{&graphics.Rectangle{Left: 5, Right: 15, Top: 15, Bottom: 5}, true},
+		{&graphics.Rectangle{Left: 2, Right: 3, Top: 3, Bottom: 2}, true},
+		{&graphics.Rectangle{Left: 10, Right: 15, Top: 10, Bottom: 0}, false},
+		{&graphics.Rectangle{Left: 0, Right: 10, Top: 20, Bottom: 11}, false},
+	} {
+		actual := r.Intersects(test.o)
+		if test.expected != actual {
+			t.Errorf("Incorrect result for %v; expected '%t', got '%t'", test.o, test.expected, actual)
+		}
+	}
+}
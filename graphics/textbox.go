@@ -17,8 +17,6 @@
 package graphics
 
 import (
-	"bytes"
-	"fmt"
 	"github.com/AletheiaWareLLC/pdfgo"
 	"github.com/AletheiaWareLLC/pdfgo/font"
 	"log"
@@ -27,6 +25,12 @@ import (
 
 const TEXT_PADDING = 8
 
+// Approximate ascent and descent of glyphs, relative to the font size, used to place text when the metrics of its font are not known
+const (
+	ASCENT  = 0.8
+	DESCENT = -0.2
+)
+
 type Alignment int
 
 const (
@@ -83,6 +87,21 @@ func (b *TextBox) AddMeasuredLine(text []rune, width, delta float64) {
 	case Left:
 		line = newLeftLine(text, width, delta, indent)
 	}
+	if e, ok := b.Font.(font.Encoder); ok {
+		codes := e.Encode(text)
+		line.Text = pdfgo.EscapeString(string(codes))
+		// Word spacing applies only to single byte codes, so justification of multiple byte codes is spread between all characters
+		if line.WordSpacing != 0 && len(codes) != len(text) {
+			var wordSpaces float64
+			for _, c := range text {
+				if unicode.IsSpace(c) {
+					wordSpaces++
+				}
+			}
+			line.CharacterSpacing += line.WordSpacing * wordSpaces / float64(len(text)-1)
+			line.WordSpacing = 0
+		}
+	}
 	b.Lines = append(b.Lines, line)
 }
 
@@ -230,27 +249,29 @@ func (b *TextBox) SetBounds(bounds *Rectangle) (*Rectangle, error) {
 	}, nil
 }
 
-func (b *TextBox) Write(p *pdfgo.PDF, buffer *bytes.Buffer) error {
-	buffer.WriteString("q\nBT\n")
-	buffer.WriteString(fmt.Sprintf("/%s %s Tf\n", b.FontID, FloatToString(b.FontSize)))
-	buffer.WriteString(fmt.Sprintf("%s %s %s rg\n", FloatToString(b.FontColour[0]), FloatToString(b.FontColour[1]), FloatToString(b.FontColour[2])))
-	buffer.WriteString(fmt.Sprintf("1 0 0 1 %s %s Tm\n", FloatToString(b.OriginX), FloatToString(b.OriginY)))
-	buffer.WriteString(fmt.Sprintf("%s TL\n", FloatToString(b.FontSize)))
+func (b *TextBox) Write(p *pdfgo.PDF, writer *ContentWriter) error {
+	writer.SaveState()
+	writer.BeginText()
+	writer.SetFont(b.FontID, b.FontSize)
+	writer.SetFillColour(b.FontColour)
+	writer.SetTextMatrix(1, 0, 0, 1, b.OriginX, b.OriginY)
+	writer.SetLeading(b.FontSize)
 	for i, l := range b.Lines {
-		buffer.WriteString(fmt.Sprintf("%s Tc\n", FloatToString(l.CharacterSpacing)))
-		buffer.WriteString(fmt.Sprintf("%s Tw\n", FloatToString(l.WordSpacing)))
-		buffer.WriteString(fmt.Sprintf("%s Tz\n", FloatToString(l.HorizontalScaling)))
-		buffer.WriteString(fmt.Sprintf("%s 0 Td\n", FloatToString(l.Indent)))
-		buffer.WriteString(fmt.Sprintf("%s Ts\n", FloatToString(l.Rise)))
-		buffer.WriteString(fmt.Sprintf("%d Tr\n", l.Render))
-		if i == 0 { // TODO try with UTF-16BE
-			buffer.WriteString(fmt.Sprintf("(%s) Tj\n", l.Text))
+		writer.SetCharacterSpacing(l.CharacterSpacing)
+		writer.SetWordSpacing(l.WordSpacing)
+		writer.SetHorizontalScaling(l.HorizontalScaling)
+		writer.MoveText(l.Indent, 0)
+		writer.SetTextRise(l.Rise)
+		writer.SetTextRender(l.Render)
+		if i == 0 {
+			writer.ShowText(l.Text)
 		} else {
-			buffer.WriteString(fmt.Sprintf("(%s) '\n", l.Text))
+			writer.NextLineShowText(l.Text)
 		}
 	}
-	buffer.WriteString("ET\nQ")
-	return nil
+	writer.EndText()
+	writer.RestoreState()
+	return writer.Err()
 }
 
 func SplitLines(text []rune) [][]rune {
@@ -280,6 +301,34 @@ func SplitLines(text []rune) [][]rune {
 	return lines
 }
 
+// Wrap splits the text into lines which fit the given width, as measured by the given function, breaking at spaces where possible.
+func Wrap(text string, width float64, measure func(string) float64) []string {
+	var lines []string
+	for _, paragraph := range SplitLines([]rune(text)) {
+		var line []rune
+		space := -1
+		for _, r := range paragraph {
+			line = append(line, r)
+			if unicode.IsSpace(r) {
+				space = len(line) - 1
+				continue
+			}
+			if len(line) > 1 && measure(string(line)) > width {
+				if space >= 0 {
+					lines = append(lines, string(line[:space]))
+					line = append([]rune{}, line[space+1:]...)
+				} else {
+					lines = append(lines, string(line[:len(line)-1]))
+					line = []rune{r}
+				}
+				space = -1
+			}
+		}
+		lines = append(lines, string(line))
+	}
+	return lines
+}
+
 func PDFEscapeString(text []rune) string {
 	var output []rune
 	for i := 0; i < len(text); i++ {
@@ -49,6 +49,23 @@ func TestTextBox_SplitLines(t *testing.T) {
 	}
 }
 
+func TestTextBox_Wrap(t *testing.T) {
+	// Each character is one unit wide
+	measure := func(line string) float64 {
+		return float64(len([]rune(line)))
+	}
+	expected := []string{"This is", "a test", "abcdefg", "hij", "", "end"}
+	actual := graphics.Wrap("This is a test abcdefghij\n\nend", 7, measure)
+	if len(actual) != len(expected) {
+		t.Fatalf("Incorrect line count; expected '%d', got '%d': %q", len(expected), len(actual), actual)
+	}
+	for i, e := range expected {
+		if actual[i] != e {
+			t.Errorf("Incorrect wrapping; expected '%s', got '%s'", e, actual[i])
+		}
+	}
+}
+
 func TestTextBox_PDFEscapeString(t *testing.T) {
 	given := []rune("ATTACK) Tj\n\nET")
 	expected := "ATTACK\\) Tj\n\nET"
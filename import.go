@@ -0,0 +1,183 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pdfgo
+
+import (
+	"errors"
+	"math"
+)
+
+// ImportPage copies the given page, which may belong to another document, into a form XObject.
+// The page's resources are copied with it, and objects shared between them are copied once.
+// The form is translated, and rotated according to the page's Rotate entry, so that it occupies the returned width and height from the origin of form space.
+func (p *PDF) ImportPage(page *DictionaryObject) (*ObjectReference, float64, float64, error) {
+	left, bottom, right, top := PageMediaBox(page)
+	if a, ok := GetInheritedEntry(page, "CropBox").(*ArrayObject); ok && len(a.Array) == 4 {
+		var box [4]float64
+		for i, o := range a.Array {
+			if n, ok := Resolve(o).(*NumberObject); ok {
+				box[i] = n.Number
+			}
+		}
+		// The crop box is clipped to the media box
+		left, bottom = math.Max(left, math.Min(box[0], box[2])), math.Max(bottom, math.Min(box[1], box[3]))
+		right, top = math.Min(right, math.Max(box[0], box[2])), math.Min(top, math.Max(box[1], box[3]))
+	}
+	if right <= left || top <= bottom {
+		return nil, 0, 0, errors.New("Invalid Page Bounds")
+	}
+	rotate := 0
+	if n, ok := GetInheritedEntry(page, "Rotate").(*NumberObject); ok {
+		rotate = ((int(n.Number)%360 + 360) % 360) / 90 * 90
+	}
+	width, height := right-left, top-bottom
+	var matrix []float64
+	switch rotate {
+	case 90:
+		matrix = []float64{0, -1, 1, 0, -bottom, right}
+		width, height = height, width
+	case 180:
+		matrix = []float64{-1, 0, 0, -1, right, top}
+	case 270:
+		matrix = []float64{0, 1, -1, 0, top, -left}
+		width, height = height, width
+	default:
+		matrix = []float64{1, 0, 0, 1, -left, -bottom}
+	}
+
+	copies := make(map[Object]Object)
+	var resources Object
+	if r := GetInheritedEntry(page, "Resources"); r != nil {
+		resources = p.ImportObject(r, copies)
+	}
+	s := p.NewStreamObject()
+	switch c := Resolve(page.GetEntry("Contents")).(type) {
+	case *StreamObject:
+		// A single stream keeps its encoding
+		s.Data = c.Data
+		for _, key := range []string{"Filter", "DecodeParms"} {
+			if v := c.GetEntry(key); v != nil {
+				s.AddNameObjectEntry(key, p.ImportObject(v, copies))
+			}
+		}
+	default:
+		data, err := PageContents(page)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		s.Data = data
+	}
+	s.AddNameNameEntry("Type", "XObject")
+	s.AddNameNameEntry("Subtype", "Form")
+	s.AddNameObjectEntry("BBox", NewRectangleArray(left, bottom, right, top))
+	m := &ArrayObject{}
+	for _, v := range matrix {
+		m.Array = append(m.Array, &NumberObject{
+			Number: v,
+		})
+	}
+	s.AddNameObjectEntry("Matrix", m)
+	if resources != nil {
+		s.AddNameObjectEntry("Resources", resources)
+	}
+	if g := page.GetEntry("Group"); g != nil {
+		s.AddNameObjectEntry("Group", p.ImportObject(g, copies))
+	}
+	return NewObjectReference(s), width, height, nil
+}
+
+// ImportObject deep copies the given object, which may belong to another document, into this document.
+// Indirect objects are added to the document, and the copies map records them so that each is only copied once.
+// Parent entries are not followed so the page tree of the other document isn't copied.
+func (p *PDF) ImportObject(object Object, copies map[Object]Object) Object {
+	if r, ok := object.(*ObjectReference); ok {
+		target := r.Object
+		if target == nil {
+			return &NullObject{}
+		}
+		if c, ok := copies[target]; ok {
+			return NewObjectReference(c)
+		}
+		switch target.(type) {
+		case *UnresolvedObject, *NullObject:
+			return &NullObject{}
+		}
+		c := p.importDirect(target, copies, true)
+		return NewObjectReference(c)
+	}
+	return p.importDirect(object, copies, false)
+}
+
+func (p *PDF) importDirect(object Object, copies map[Object]Object, indirect bool) Object {
+	var c Object
+	switch o := object.(type) {
+	case *ArrayObject:
+		a := &ArrayObject{}
+		if indirect {
+			p.add(a)
+			copies[o] = a
+		}
+		for _, e := range o.Array {
+			a.Array = append(a.Array, p.ImportObject(e, copies))
+		}
+		return a
+	case *StreamObject:
+		s := &StreamObject{}
+		s.Dictionary = make(map[*NameObject]Object)
+		s.Data = o.Data
+		if indirect {
+			p.add(s)
+			copies[o] = s
+		}
+		p.importEntries(&s.DictionaryObject, &o.DictionaryObject, copies)
+		return s
+	case *DictionaryObject:
+		d := &DictionaryObject{
+			Dictionary: make(map[*NameObject]Object),
+		}
+		if indirect {
+			p.add(d)
+			copies[o] = d
+		}
+		p.importEntries(d, o, copies)
+		return d
+	case *BooleanObject:
+		c = &BooleanObject{Boolean: o.Boolean}
+	case *NameObject:
+		c = &NameObject{Name: o.Name}
+	case *NumberObject:
+		c = &NumberObject{Number: o.Number}
+	case *StringObject:
+		c = &StringObject{String: o.String}
+	default:
+		c = &NullObject{}
+	}
+	if indirect {
+		p.add(c)
+		copies[object] = c
+	}
+	return c
+}
+
+func (p *PDF) importEntries(to, from *DictionaryObject, copies map[Object]Object) {
+	for _, k := range from.Keys {
+		if k.Name == "Parent" {
+			continue
+		}
+		to.AddNameObjectEntry(k.Name, p.ImportObject(from.Dictionary[k], copies))
+	}
+}
@@ -0,0 +1,70 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pdfgo_test
+
+import (
+	"bytes"
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestImportPage(t *testing.T) {
+	source := pdfgo.NewPDF()
+	font := source.NewDictionaryObject()
+	font.AddNameNameEntry("Type", "Font")
+	font.AddNameNameEntry("BaseFont", "Helvetica")
+	fonts := source.NewDictionaryObject()
+	fonts.AddNameObjectEntry("F1", pdfgo.NewObjectReference(font))
+	fonts.AddNameObjectEntry("F2", pdfgo.NewObjectReference(font))
+	resources := source.NewDictionaryObject()
+	resources.AddNameObjectEntry("Font", pdfgo.NewObjectReference(fonts))
+	contents := source.NewStreamObject()
+	contents.Data = []byte("BT /F1 12 Tf (Terms) Tj ET")
+	source.AddPage(200, 300, pdfgo.NewObjectReference(resources), pdfgo.NewObjectReference(contents))
+	var buffer bytes.Buffer
+	assert.Nil(t, source.Write(&buffer))
+	parsed, err := pdfgo.ReadPDF(buffer.Bytes())
+	assert.Nil(t, err)
+	page := parsed.GetPages()[0]
+	page.SetNameObjectEntry("Rotate", &pdfgo.NumberObject{Number: 90})
+
+	p := pdfgo.NewPDF()
+	count := len(p.Objects)
+	form, width, height, err := p.ImportPage(page)
+	assert.Nil(t, err)
+	assert.Equal(t, 300., width)
+	assert.Equal(t, 200., height)
+	s := form.Object.(*pdfgo.StreamObject)
+	assert.Equal(t, "BT /F1 12 Tf (Terms) Tj ET", string(s.Data))
+	assert.Equal(t, "Form", s.GetEntry("Subtype").(*pdfgo.NameObject).Name)
+	matrix := s.GetEntry("Matrix").(*pdfgo.ArrayObject)
+	assert.Equal(t, 0., matrix.Array[0].(*pdfgo.NumberObject).Number)
+	assert.Equal(t, 200., matrix.Array[5].(*pdfgo.NumberObject).Number)
+
+	// The font dictionary and the shared font are copied once each, along with the form
+	assert.Equal(t, count+3, len(p.Objects))
+	copied := pdfgo.Resolve(s.GetEntry("Resources")).(*pdfgo.DictionaryObject)
+	copiedFonts := pdfgo.Resolve(copied.GetEntry("Font")).(*pdfgo.DictionaryObject)
+	f1 := pdfgo.Resolve(copiedFonts.GetEntry("F1")).(*pdfgo.DictionaryObject)
+	assert.Equal(t, f1, pdfgo.Resolve(copiedFonts.GetEntry("F2")))
+	assert.Equal(t, "Helvetica", f1.GetEntry("BaseFont").(*pdfgo.NameObject).Name)
+	assert.NotEqual(t, font, f1)
+
+	buffer.Reset()
+	assert.Nil(t, p.Write(&buffer))
+}
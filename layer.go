@@ -0,0 +1,252 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pdfgo
+
+import (
+	"errors"
+)
+
+// Layer is an optional content group, whose content the viewer can show or hide.
+type Layer struct {
+	Name string
+	// Hidden is true if the layer is off when the document is opened
+	Hidden bool
+	// Locked is true if the viewer doesn't allow the layer to be shown or hidden
+	Locked bool
+	// Parent nests the layer under another in the viewer's list of layers, if not nil
+	Parent *Layer
+	// Group is the optional content group dictionary, set when the layer is added
+	Group *DictionaryObject
+}
+
+// AddLayer adds the given layer to the optional content properties of the document, after any existing layers.
+// The objects which were changed are returned so they can be written as an incremental update.
+func (p *PDF) AddLayer(l *Layer) ([]Object, error) {
+	if l.Name == "" {
+		return nil, errors.New("Layer requires a name")
+	}
+	if l.Group != nil {
+		return nil, errors.New("Layer already added: " + l.Name)
+	}
+	if l.Parent != nil && l.Parent.Group == nil {
+		return nil, errors.New("Parent layer not added: " + l.Parent.Name)
+	}
+	changed := []Object{p.Catalog}
+	properties, ok := Resolve(p.Catalog.GetEntry("OCProperties")).(*DictionaryObject)
+	if !ok {
+		properties = &DictionaryObject{
+			Dictionary: make(map[*NameObject]Object),
+		}
+		p.Catalog.SetNameObjectEntry("OCProperties", properties)
+	}
+	changed = append(changed, properties)
+	config, ok := Resolve(properties.GetEntry("D")).(*DictionaryObject)
+	if !ok {
+		config = &DictionaryObject{
+			Dictionary: make(map[*NameObject]Object),
+		}
+		properties.SetNameObjectEntry("D", config)
+	}
+	changed = append(changed, config)
+
+	group := p.NewDictionaryObject()
+	group.AddNameNameEntry("Type", "OCG")
+	group.AddNameObjectEntry("Name", NewTextString(l.Name))
+	reference := NewObjectReference(group)
+
+	add := func(d *DictionaryObject, key string) {
+		a, ok := Resolve(d.GetEntry(key)).(*ArrayObject)
+		if !ok {
+			a = &ArrayObject{}
+			d.SetNameObjectEntry(key, a)
+		}
+		changed = append(changed, a)
+		a.Array = append(a.Array, reference)
+	}
+	add(properties, "OCGs")
+	if l.Hidden {
+		add(config, "OFF")
+	} else {
+		add(config, "ON")
+	}
+	if l.Locked {
+		add(config, "Locked")
+	}
+	order, ok := Resolve(config.GetEntry("Order")).(*ArrayObject)
+	if !ok {
+		order = &ArrayObject{}
+		config.SetNameObjectEntry("Order", order)
+	}
+	changed = append(changed, order)
+	if l.Parent == nil || !nest(order, l.Parent.Group, reference) {
+		order.Array = append(order.Array, reference)
+	}
+	l.Group = group
+
+	var result []Object
+	for _, o := range changed {
+		if o.GetName() > 0 {
+			result = append(result, o)
+		}
+	}
+	return result, nil
+}
+
+// nest adds the given child to the array of children following the parent in the given order, returning false if the parent isn't found.
+func nest(order *ArrayObject, parent *DictionaryObject, child Object) bool {
+	for i, o := range order.Array {
+		if a, ok := Resolve(o).(*ArrayObject); ok {
+			if nest(a, parent, child) {
+				return true
+			}
+			continue
+		}
+		if Resolve(o) != parent {
+			continue
+		}
+		if i+1 < len(order.Array) {
+			if children, ok := Resolve(order.Array[i+1]).(*ArrayObject); ok {
+				children.Array = append(children.Array, child)
+				return true
+			}
+		}
+		children := &ArrayObject{
+			Array: []Object{child},
+		}
+		order.Array = append(order.Array[:i+1], append([]Object{children}, order.Array[i+1:]...)...)
+		return true
+	}
+	return false
+}
+
+// GetLayers returns the layers of the document, in the order the viewer lists them.
+func (p *PDF) GetLayers() []*Layer {
+	properties, ok := Resolve(p.Catalog.GetEntry("OCProperties")).(*DictionaryObject)
+	if !ok {
+		return nil
+	}
+	config, ok := Resolve(properties.GetEntry("D")).(*DictionaryObject)
+	if !ok {
+		config = &DictionaryObject{
+			Dictionary: make(map[*NameObject]Object),
+		}
+	}
+	contains := func(key string, group *DictionaryObject) bool {
+		if a, ok := Resolve(config.GetEntry(key)).(*ArrayObject); ok {
+			for _, o := range a.Array {
+				if Resolve(o) == group {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	hidden := false
+	if n, ok := Resolve(config.GetEntry("BaseState")).(*NameObject); ok && n.Name == "OFF" {
+		hidden = true
+	}
+	layers := make(map[*DictionaryObject]*Layer)
+	var result []*Layer
+	layer := func(o Object, parent *Layer) *Layer {
+		group, ok := Resolve(o).(*DictionaryObject)
+		if !ok {
+			return nil
+		}
+		if l, ok := layers[group]; ok {
+			return l
+		}
+		l := &Layer{
+			Hidden: (hidden && !contains("ON", group)) || contains("OFF", group),
+			Locked: contains("Locked", group),
+			Parent: parent,
+			Group:  group,
+		}
+		if s, ok := Resolve(group.GetEntry("Name")).(*StringObject); ok {
+			l.Name = s.Text()
+		}
+		layers[group] = l
+		result = append(result, l)
+		return l
+	}
+	var walk func(order *ArrayObject, parent *Layer)
+	walk = func(order *ArrayObject, parent *Layer) {
+		var previous *Layer
+		for _, o := range order.Array {
+			if a, ok := Resolve(o).(*ArrayObject); ok {
+				walk(a, previous)
+				continue
+			}
+			previous = layer(o, parent)
+		}
+	}
+	if order, ok := Resolve(config.GetEntry("Order")).(*ArrayObject); ok {
+		walk(order, nil)
+	}
+	// Layers which the viewer doesn't list
+	if groups, ok := Resolve(properties.GetEntry("OCGs")).(*ArrayObject); ok {
+		for _, o := range groups.Array {
+			layer(o, nil)
+		}
+	}
+	return result
+}
+
+// SetLayer makes the given image or form XObject, or annotation, a member of the given layer, so it's shown only when the layer is.
+func SetLayer(object Object, l *Layer) error {
+	if l.Group == nil {
+		return errors.New("Layer not added: " + l.Name)
+	}
+	var d *DictionaryObject
+	switch o := Resolve(object).(type) {
+	case *DictionaryObject:
+		d = o
+	case *StreamObject:
+		d = &o.DictionaryObject
+	default:
+		return errors.New("Layer requires a dictionary or stream")
+	}
+	d.SetNameObjectEntry("OC", NewObjectReference(l.Group))
+	return nil
+}
+
+// AddLayerProperties names the given layer in the properties of the given resources, returning the name by which marked content refers to it.
+func AddLayerProperties(resources *DictionaryObject, l *Layer) (string, error) {
+	if l.Group == nil {
+		return "", errors.New("Layer not added: " + l.Name)
+	}
+	properties, ok := Resolve(resources.GetEntry("Properties")).(*DictionaryObject)
+	if !ok {
+		properties = &DictionaryObject{
+			Dictionary: make(map[*NameObject]Object),
+		}
+		resources.SetNameObjectEntry("Properties", properties)
+	}
+	return propertiesName(properties, l.Group), nil
+}
+
+// propertiesName returns the name of the given property list in the given properties, adding it if it isn't there.
+func propertiesName(properties *DictionaryObject, list Object) string {
+	list = Resolve(list)
+	for _, k := range properties.Keys {
+		if Resolve(properties.Dictionary[k]) == list {
+			return k.Name
+		}
+	}
+	name := UniqueName(properties, "OC")
+	properties.AddNameObjectEntry(name, NewObjectReference(list))
+	return name
+}
@@ -0,0 +1,131 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pdfgo_test
+
+import (
+	"bytes"
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/AletheiaWareLLC/pdfgo/annotation"
+	"github.com/AletheiaWareLLC/pdfgo/graphics"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestAddLayer(t *testing.T) {
+	p := pdfgo.NewPDF()
+	p.AddPage(200, 200, nil, nil)
+	page := p.GetPages()[0]
+
+	dimensions := &pdfgo.Layer{Name: "Dimensions"}
+	metric := &pdfgo.Layer{Name: "Metric", Parent: dimensions}
+	imperial := &pdfgo.Layer{Name: "Imperial", Parent: dimensions, Hidden: true}
+	notes := &pdfgo.Layer{Name: "Notes", Locked: true}
+	_, err := p.AddLayer(metric)
+	assert.Equal(t, "Parent layer not added: Dimensions", err.Error())
+	for _, l := range []*pdfgo.Layer{dimensions, metric, imperial, notes} {
+		_, err := p.AddLayer(l)
+		assert.Nil(t, err)
+	}
+	_, err = p.AddLayer(notes)
+	assert.Equal(t, "Layer already added: Notes", err.Error())
+
+	// Draw a box in the metric layer
+	resources := p.NewDictionaryObject()
+	name, err := pdfgo.AddLayerProperties(resources, metric)
+	assert.Nil(t, err)
+	assert.Equal(t, "OC1", name)
+	name, err = pdfgo.AddLayerProperties(resources, metric)
+	assert.Nil(t, err)
+	assert.Equal(t, "OC1", name)
+	box := &graphics.LayerBox{
+		Box: &graphics.ColourBox{
+			FillColour: []float64{1, 0, 0},
+		},
+		Properties: name,
+	}
+	_, err = box.SetBounds(&graphics.Rectangle{Left: 10, Bottom: 10, Right: 50, Top: 50})
+	assert.Nil(t, err)
+	writer := graphics.NewContentWriter()
+	assert.Nil(t, box.Write(p, writer))
+	data, err := writer.Bytes()
+	assert.Nil(t, err)
+	assert.Equal(t, "/OC /OC1 BDC\nq\n1 0 0 rg\n10 10 40 40 re\nf\nQ\nEMC", string(data))
+	// Optional content requires the properties naming its layer
+	unnamed := &graphics.LayerBox{
+		Box: box.Box,
+	}
+	assert.Equal(t, "Missing optional content properties", unnamed.Write(p, graphics.NewContentWriter()).Error())
+	page.SetNameObjectEntry("Resources", pdfgo.NewObjectReference(resources))
+	assert.Nil(t, p.AddPageContent(page, data))
+
+	// Annotations and XObjects are members of layers
+	m := annotation.NewMarkup(page, annotation.RED)
+	m.Layer = notes
+	square := &annotation.Square{
+		Markup:    m,
+		Rectangle: graphics.Rectangle{Left: 100, Bottom: 100, Right: 150, Top: 150},
+	}
+	_, err = square.Add(p)
+	assert.Nil(t, err)
+	form := p.AddForm(0, 0, 10, 10, nil, nil, []byte("0 0 10 10 re f"))
+	assert.Nil(t, pdfgo.SetLayer(form, imperial))
+	assert.NotNil(t, pdfgo.SetLayer(form, &pdfgo.Layer{Name: "Unknown"}))
+
+	var buffer bytes.Buffer
+	assert.Nil(t, p.Write(&buffer))
+	result, err := pdfgo.ReadPDF(buffer.Bytes())
+	assert.Nil(t, err)
+	layers := result.GetLayers()
+	assert.Equal(t, 4, len(layers))
+	for i, expected := range []*pdfgo.Layer{dimensions, metric, imperial, notes} {
+		assert.Equal(t, expected.Name, layers[i].Name)
+		assert.Equal(t, expected.Hidden, layers[i].Hidden, expected.Name)
+		assert.Equal(t, expected.Locked, layers[i].Locked, expected.Name)
+		if expected.Parent == nil {
+			assert.Nil(t, layers[i].Parent, expected.Name)
+		} else {
+			assert.Equal(t, expected.Parent.Name, layers[i].Parent.Name, expected.Name)
+		}
+	}
+	annots := pdfgo.Resolve(result.GetPages()[0].GetEntry("Annots")).(*pdfgo.ArrayObject)
+	oc := pdfgo.Resolve(pdfgo.Resolve(annots.Array[0]).(*pdfgo.DictionaryObject).GetEntry("OC"))
+	assert.Equal(t, layers[3].Group, oc)
+}
+
+func TestFlattenAnnotations_layer(t *testing.T) {
+	p := pdfgo.NewPDF()
+	p.AddPage(200, 200, nil, nil)
+	page := p.GetPages()[0]
+	notes := &pdfgo.Layer{Name: "Notes"}
+	_, err := p.AddLayer(notes)
+	assert.Nil(t, err)
+	m := annotation.NewMarkup(page, annotation.RED)
+	m.Layer = notes
+	_, err = (&annotation.Square{
+		Markup:    m,
+		Rectangle: graphics.Rectangle{Left: 100, Bottom: 100, Right: 150, Top: 150},
+	}).Add(p)
+	assert.Nil(t, err)
+	assert.Nil(t, p.FlattenAnnotations(page))
+
+	properties := pdfgo.Resolve(pdfgo.PageResources(page).GetEntry("Properties")).(*pdfgo.DictionaryObject)
+	assert.Equal(t, notes.Group, pdfgo.Resolve(properties.GetEntry("OC1")))
+	contents, err := pdfgo.PageContents(page)
+	assert.Nil(t, err)
+	assert.Contains(t, string(contents), "/OC /OC1 BDC q")
+	assert.Contains(t, string(contents), "Do Q EMC\n")
+}
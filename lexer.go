@@ -0,0 +1,243 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pdfgo
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type TokenKind int
+
+const (
+	TokenEOF TokenKind = iota
+	TokenNumber
+	TokenName
+	TokenString
+	TokenHexString
+	TokenArrayStart
+	TokenArrayEnd
+	TokenDictionaryStart
+	TokenDictionaryEnd
+	TokenKeyword
+)
+
+type Token struct {
+	Kind TokenKind
+	// Value holds the number, the decoded name, the escaped contents of a string, or the keyword
+	Value  string
+	Offset int
+}
+
+type Lexer struct {
+	Data   []byte
+	Offset int
+}
+
+func NewLexer(data []byte) *Lexer {
+	return &Lexer{
+		Data: data,
+	}
+}
+
+func IsWhitespace(c byte) bool {
+	switch c {
+	case 0, '\t', '\n', '\f', '\r', ' ':
+		return true
+	}
+	return false
+}
+
+func IsDelimiter(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+// SkipWhitespace advances past any whitespace and comments.
+func (l *Lexer) SkipWhitespace() {
+	for l.Offset < len(l.Data) {
+		c := l.Data[l.Offset]
+		if c == '%' {
+			for l.Offset < len(l.Data) && l.Data[l.Offset] != '\r' && l.Data[l.Offset] != '\n' {
+				l.Offset++
+			}
+		} else if IsWhitespace(c) {
+			l.Offset++
+		} else {
+			return
+		}
+	}
+}
+
+// Peek returns the next token without consuming it.
+func (l *Lexer) Peek() (*Token, error) {
+	offset := l.Offset
+	t, err := l.Next()
+	l.Offset = offset
+	return t, err
+}
+
+// Next consumes and returns the next token.
+func (l *Lexer) Next() (*Token, error) {
+	l.SkipWhitespace()
+	start := l.Offset
+	if start >= len(l.Data) {
+		return &Token{Kind: TokenEOF, Offset: start}, nil
+	}
+	c := l.Data[start]
+	switch c {
+	case '[':
+		l.Offset++
+		return &Token{Kind: TokenArrayStart, Value: "[", Offset: start}, nil
+	case ']':
+		l.Offset++
+		return &Token{Kind: TokenArrayEnd, Value: "]", Offset: start}, nil
+	case '{', '}':
+		// PostScript calculator function braces
+		l.Offset++
+		return &Token{Kind: TokenKeyword, Value: string(c), Offset: start}, nil
+	case '<':
+		if start+1 < len(l.Data) && l.Data[start+1] == '<' {
+			l.Offset += 2
+			return &Token{Kind: TokenDictionaryStart, Value: "<<", Offset: start}, nil
+		}
+		return l.nextHexString()
+	case '>':
+		if start+1 < len(l.Data) && l.Data[start+1] == '>' {
+			l.Offset += 2
+			return &Token{Kind: TokenDictionaryEnd, Value: ">>", Offset: start}, nil
+		}
+		return nil, fmt.Errorf("Unexpected '>' at %d", start)
+	case '(':
+		return l.nextString()
+	case ')':
+		return nil, fmt.Errorf("Unexpected ')' at %d", start)
+	case '/':
+		return l.nextName()
+	}
+	l.Offset++
+	for l.Offset < len(l.Data) && !IsWhitespace(l.Data[l.Offset]) && !IsDelimiter(l.Data[l.Offset]) {
+		l.Offset++
+	}
+	value := string(l.Data[start:l.Offset])
+	if IsNumber(value) {
+		return &Token{Kind: TokenNumber, Value: value, Offset: start}, nil
+	}
+	return &Token{Kind: TokenKeyword, Value: value, Offset: start}, nil
+}
+
+func (l *Lexer) nextHexString() (*Token, error) {
+	start := l.Offset
+	l.Offset++
+	var digits []byte
+	for {
+		if l.Offset >= len(l.Data) {
+			return nil, fmt.Errorf("Unterminated hex string at %d", start)
+		}
+		c := l.Data[l.Offset]
+		l.Offset++
+		if c == '>' {
+			break
+		}
+		if IsWhitespace(c) {
+			continue
+		}
+		digits = append(digits, c)
+	}
+	if len(digits)%2 == 1 {
+		// Missing final digit is assumed to be zero
+		digits = append(digits, '0')
+	}
+	decoded := make([]byte, len(digits)/2)
+	if _, err := hex.Decode(decoded, digits); err != nil {
+		return nil, fmt.Errorf("Invalid hex string at %d: %s", start, err)
+	}
+	return &Token{Kind: TokenHexString, Value: string(decoded), Offset: start}, nil
+}
+
+func (l *Lexer) nextString() (*Token, error) {
+	start := l.Offset
+	l.Offset++
+	depth := 1
+	for l.Offset < len(l.Data) {
+		c := l.Data[l.Offset]
+		switch c {
+		case '\\':
+			l.Offset++
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				value := string(l.Data[start+1 : l.Offset])
+				l.Offset++
+				return &Token{Kind: TokenString, Value: value, Offset: start}, nil
+			}
+		}
+		l.Offset++
+	}
+	return nil, fmt.Errorf("Unterminated string at %d", start)
+}
+
+func (l *Lexer) nextName() (*Token, error) {
+	start := l.Offset
+	l.Offset++
+	var sb strings.Builder
+	for l.Offset < len(l.Data) {
+		c := l.Data[l.Offset]
+		if IsWhitespace(c) || IsDelimiter(c) {
+			break
+		}
+		if c == '#' && l.Offset+2 < len(l.Data) {
+			if v, err := strconv.ParseUint(string(l.Data[l.Offset+1:l.Offset+3]), 16, 8); err == nil {
+				sb.WriteByte(byte(v))
+				l.Offset += 3
+				continue
+			}
+		}
+		sb.WriteByte(c)
+		l.Offset++
+	}
+	return &Token{Kind: TokenName, Value: sb.String(), Offset: start}, nil
+}
+
+// IsNumber returns true if the given string is an integer or real number.
+func IsNumber(s string) bool {
+	if s == "" {
+		return false
+	}
+	digits := 0
+	point := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= '0' && c <= '9':
+			digits++
+		case c == '.' && !point:
+			point = true
+		case (c == '+' || c == '-') && i == 0:
+		default:
+			return false
+		}
+	}
+	return digits > 0
+}
@@ -16,7 +16,11 @@
 
 package pdfgo
 
-import "io"
+import (
+	"fmt"
+	"io"
+	"strings"
+)
 
 type NameObject struct {
 	Metadata
@@ -24,5 +28,19 @@ type NameObject struct {
 }
 
 func (o *NameObject) Write(out io.Writer) (int, error) {
-	return WriteF(out, "/%s", o.Name)
+	return WriteF(out, "/%s", EscapeName(o.Name))
+}
+
+// EscapeName encodes delimiters, whitespace and non-printable characters in a name as #xx.
+func EscapeName(name string) string {
+	var sb strings.Builder
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c < '!' || c > '~' || c == '#' || IsDelimiter(c) {
+			sb.WriteString(fmt.Sprintf("#%02X", c))
+		} else {
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
 }
@@ -26,6 +26,31 @@ type NumberObject struct {
 	Number float64
 }
 
+// NumberValue returns the value of the given number, resolving references, or zero if it isn't a number.
+func NumberValue(o Object) float64 {
+	if n, ok := Resolve(o).(*NumberObject); ok {
+		return n.Number
+	}
+	return 0
+}
+
+// Numbers returns the values of the given array of numbers, resolving references, or false if it isn't an array of numbers.
+func Numbers(o Object) ([]float64, bool) {
+	a, ok := Resolve(o).(*ArrayObject)
+	if !ok {
+		return nil, false
+	}
+	var result []float64
+	for _, e := range a.Array {
+		n, ok := Resolve(e).(*NumberObject)
+		if !ok {
+			return nil, false
+		}
+		result = append(result, n.Number)
+	}
+	return result, true
+}
+
 func (o *NumberObject) Write(out io.Writer) (int, error) {
 	return WriteF(out, strconv.FormatFloat(o.Number, 'f', -1, 64))
 }
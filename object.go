@@ -52,3 +52,7 @@ func (m *Metadata) GetAddress() int {
 func (m *Metadata) GetGeneration() int {
 	return m.Generation
 }
+
+func (m *Metadata) SetGeneration(generation int) {
+	m.Generation = generation
+}
@@ -0,0 +1,117 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pdfgo
+
+import (
+	"errors"
+)
+
+// Styles of outline items
+const (
+	OUTLINE_ITALIC = 1 << 0
+	OUTLINE_BOLD   = 1 << 1
+)
+
+// OutlineItem is an entry in the outline, or bookmarks, of the document, which performs an action when clicked.
+type OutlineItem struct {
+	Title  string
+	Action Action
+	// Open shows the children of the item when the document is opened
+	Open bool
+	// Colour is the RGB colour of the title, if not nil
+	Colour   []float64
+	Style    int
+	Children []*OutlineItem
+}
+
+// SetOutline sets the outline of the document to the given items, replacing any outline it had.
+func (p *PDF) SetOutline(items []*OutlineItem) error {
+	outlines := p.NewDictionaryObject()
+	outlines.AddNameNameEntry("Type", "Outlines")
+	count, err := p.addOutlineItems(outlines, items)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		outlines.AddNameObjectEntry("Count", &NumberObject{
+			Number: float64(count),
+		})
+	}
+	p.Catalog.SetNameObjectEntry("Outlines", NewObjectReference(outlines))
+	return nil
+}
+
+// addOutlineItems adds the given items as children of the given parent, returning the number of items which are visible when the parent is open.
+func (p *PDF) addOutlineItems(parent *DictionaryObject, items []*OutlineItem) (int, error) {
+	var previous *DictionaryObject
+	visible := 0
+	for _, item := range items {
+		if item.Title == "" {
+			return 0, errors.New("Outline item requires a title")
+		}
+		d := p.NewDictionaryObject()
+		d.AddNameObjectEntry("Title", NewTextString(item.Title))
+		d.AddNameObjectEntry("Parent", NewObjectReference(parent))
+		if item.Action != nil {
+			action, err := NewActionDictionary(item.Action)
+			if err != nil {
+				return 0, err
+			}
+			d.AddNameObjectEntry("A", action)
+		}
+		if len(item.Colour) == 3 {
+			d.AddNameObjectEntry("C", &ArrayObject{
+				Array: []Object{
+					&NumberObject{Number: item.Colour[0]},
+					&NumberObject{Number: item.Colour[1]},
+					&NumberObject{Number: item.Colour[2]},
+				},
+			})
+		}
+		if item.Style != 0 {
+			d.AddNameObjectEntry("F", &NumberObject{
+				Number: float64(item.Style),
+			})
+		}
+		count, err := p.addOutlineItems(d, item.Children)
+		if err != nil {
+			return 0, err
+		}
+		if count > 0 {
+			// Closed items count the descendants which would be visible negatively
+			if !item.Open {
+				count = -count
+			}
+			d.AddNameObjectEntry("Count", &NumberObject{
+				Number: float64(count),
+			})
+		}
+		visible++
+		if item.Open {
+			visible += count
+		}
+		if previous == nil {
+			parent.SetNameObjectEntry("First", NewObjectReference(d))
+		} else {
+			previous.AddNameObjectEntry("Next", NewObjectReference(d))
+			d.AddNameObjectEntry("Prev", NewObjectReference(previous))
+		}
+		parent.SetNameObjectEntry("Last", NewObjectReference(d))
+		previous = d
+	}
+	return visible, nil
+}
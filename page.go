@@ -0,0 +1,164 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pdfgo
+
+import (
+	"bytes"
+	"errors"
+)
+
+// GetPages returns the page dictionaries of the document, in order.
+func (p *PDF) GetPages() []*DictionaryObject {
+	var pages []*DictionaryObject
+	visited := make(map[Object]bool)
+	var walk func(kids *ArrayObject)
+	walk = func(kids *ArrayObject) {
+		for _, k := range kids.Array {
+			d, ok := Resolve(k).(*DictionaryObject)
+			if !ok || visited[d] {
+				continue
+			}
+			visited[d] = true
+			if kids, ok := Resolve(d.GetEntry("Kids")).(*ArrayObject); ok && !isPage(d) {
+				walk(kids)
+			} else {
+				pages = append(pages, d)
+			}
+		}
+	}
+	if p.Pages != nil {
+		walk(p.Pages)
+	}
+	return pages
+}
+
+func isPage(d *DictionaryObject) bool {
+	t, ok := Resolve(d.GetEntry("Type")).(*NameObject)
+	return ok && t.Name == "Page"
+}
+
+// GetInheritedEntry returns the value of the given key in the page, or in its nearest ancestor in the page tree.
+// Resources, MediaBox, CropBox and Rotate are inheritable.
+func GetInheritedEntry(page *DictionaryObject, key string) Object {
+	visited := make(map[*DictionaryObject]bool)
+	for page != nil && !visited[page] {
+		visited[page] = true
+		if v := page.GetEntry(key); v != nil {
+			return Resolve(v)
+		}
+		page, _ = Resolve(page.GetEntry("Parent")).(*DictionaryObject)
+	}
+	return nil
+}
+
+// PageResources returns the resource dictionary of the given page, or an empty dictionary.
+func PageResources(page *DictionaryObject) *DictionaryObject {
+	if r, ok := GetInheritedEntry(page, "Resources").(*DictionaryObject); ok {
+		return r
+	}
+	return &DictionaryObject{
+		Dictionary: make(map[*NameObject]Object),
+	}
+}
+
+// PageMediaBox returns the left, bottom, right, and top of the media box of the given page.
+// Pages without a valid media box are assumed to be US Letter.
+func PageMediaBox(page *DictionaryObject) (float64, float64, float64, float64) {
+	if a, ok := GetInheritedEntry(page, "MediaBox").(*ArrayObject); ok && len(a.Array) == 4 {
+		var box [4]float64
+		valid := true
+		for i, o := range a.Array {
+			n, ok := Resolve(o).(*NumberObject)
+			if !ok {
+				valid = false
+				break
+			}
+			box[i] = n.Number
+		}
+		if valid {
+			if box[0] > box[2] {
+				box[0], box[2] = box[2], box[0]
+			}
+			if box[1] > box[3] {
+				box[1], box[3] = box[3], box[1]
+			}
+			return box[0], box[1], box[2], box[3]
+		}
+	}
+	return 0, 0, 612, 792
+}
+
+// PageContents returns the decoded content of the given page, concatenating the streams if there are several.
+func PageContents(page *DictionaryObject) ([]byte, error) {
+	var streams []*StreamObject
+	switch c := Resolve(page.GetEntry("Contents")).(type) {
+	case nil, *NullObject:
+		return nil, nil
+	case *StreamObject:
+		streams = append(streams, c)
+	case *ArrayObject:
+		for _, o := range c.Array {
+			if s, ok := Resolve(o).(*StreamObject); ok {
+				streams = append(streams, s)
+			}
+		}
+	default:
+		return nil, errors.New("Invalid Page Contents")
+	}
+	var buffer bytes.Buffer
+	for i, s := range streams {
+		data, err := s.Decode()
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			// Streams are separated by whitespace so that tokens aren't joined
+			buffer.WriteByte('\n')
+		}
+		buffer.Write(data)
+	}
+	return buffer.Bytes(), nil
+}
+
+// AddPageContent draws the given content over the existing content of the given page.
+// The existing content is isolated in its own graphics state so that any changes it makes don't affect the new content.
+func (p *PDF) AddPageContent(page *DictionaryObject, data []byte) error {
+	var contents []Object
+	switch c := page.GetEntry("Contents").(type) {
+	case nil:
+	case *ObjectReference:
+		if a, ok := Resolve(c).(*ArrayObject); ok {
+			contents = append(contents, a.Array...)
+		} else {
+			contents = append(contents, c)
+		}
+	case *ArrayObject:
+		contents = append(contents, c.Array...)
+	default:
+		return errors.New("Invalid Page Contents")
+	}
+	save := p.NewStreamObject()
+	save.Data = []byte("q\n")
+	restore := p.NewStreamObject()
+	restore.Data = append([]byte("Q\n"), data...)
+	a := &ArrayObject{}
+	a.Array = append(a.Array, NewObjectReference(save))
+	a.Array = append(a.Array, contents...)
+	a.Array = append(a.Array, NewObjectReference(restore))
+	page.SetNameObjectEntry("Contents", a)
+	return nil
+}
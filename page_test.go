@@ -0,0 +1,64 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pdfgo_test
+
+import (
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGetPages_nested(t *testing.T) {
+	p := pdfgo.NewPDF()
+	resources := p.NewDictionaryObject()
+	resources.AddNameNameEntry("ProcSet", "PDF")
+	node := p.NewDictionaryObject()
+	node.AddNameNameEntry("Type", "Pages")
+	node.AddNameObjectEntry("Resources", resources)
+	kids := &pdfgo.ArrayObject{}
+	node.AddNameObjectEntry("Kids", kids)
+	for i := 0; i < 2; i++ {
+		page := p.NewDictionaryObject()
+		page.AddNameNameEntry("Type", "Page")
+		page.AddNameObjectEntry("Parent", pdfgo.NewObjectReference(node))
+		kids.Array = append(kids.Array, pdfgo.NewObjectReference(page))
+	}
+	p.AddPage(100, 200, nil, nil)
+	p.Pages.Array = append([]pdfgo.Object{pdfgo.NewObjectReference(node)}, p.Pages.Array...)
+
+	pages := p.GetPages()
+	assert.Equal(t, 3, len(pages))
+	assert.Equal(t, resources, pdfgo.PageResources(pages[0]))
+	left, bottom, right, top := pdfgo.PageMediaBox(pages[2])
+	assert.Equal(t, []float64{0, 0, 100, 200}, []float64{left, bottom, right, top})
+	contents, err := pdfgo.PageContents(pages[2])
+	assert.Nil(t, err)
+	assert.Nil(t, contents)
+}
+
+func TestAddPageContent(t *testing.T) {
+	p := pdfgo.NewPDF()
+	contents := p.NewStreamObject()
+	contents.Data = []byte("1 0 0 RG")
+	p.AddPage(100, 200, nil, pdfgo.NewObjectReference(contents))
+	page := p.GetPages()[0]
+	assert.Nil(t, p.AddPageContent(page, []byte("0 0 10 10 re f")))
+	assert.Equal(t, 3, len(page.GetEntry("Contents").(*pdfgo.ArrayObject).Array))
+	data, err := pdfgo.PageContents(page)
+	assert.Nil(t, err)
+	assert.Equal(t, "q\n\n1 0 0 RG\nQ\n0 0 10 10 re f", string(data))
+}
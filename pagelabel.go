@@ -0,0 +1,213 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pdfgo
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LabelStyle is the numbering style of page labels.
+type LabelStyle int
+
+const (
+	// NoNumbering labels pages with the prefix only
+	NoNumbering LabelStyle = iota
+	Decimal
+	UpperRoman
+	LowerRoman
+	UpperLetters
+	LowerLetters
+)
+
+func (s LabelStyle) String() string {
+	switch s {
+	case Decimal:
+		return "D"
+	case UpperRoman:
+		return "R"
+	case LowerRoman:
+		return "r"
+	case UpperLetters:
+		return "A"
+	case LowerLetters:
+		return "a"
+	}
+	return ""
+}
+
+// PageLabel labels a range of pages, from its page to the page of the next label.
+type PageLabel struct {
+	// PageIndex is the zero-based index of the first page of the range
+	PageIndex int
+	Style     LabelStyle
+	Prefix    string
+	// Start is the number of the first page of the range, and defaults to 1
+	Start int
+}
+
+// Label returns the label of the page with the given index, which must be in the range.
+func (l *PageLabel) Label(index int) string {
+	start := l.Start
+	if start < 1 {
+		start = 1
+	}
+	number := start + index - l.PageIndex
+	switch l.Style {
+	case Decimal:
+		return l.Prefix + strconv.Itoa(number)
+	case UpperRoman:
+		return l.Prefix + strings.ToUpper(roman(number))
+	case LowerRoman:
+		return l.Prefix + roman(number)
+	case UpperLetters:
+		return l.Prefix + strings.ToUpper(letters(number))
+	case LowerLetters:
+		return l.Prefix + letters(number)
+	}
+	return l.Prefix
+}
+
+// roman returns the given number in lower case roman numerals.
+func roman(number int) string {
+	var b strings.Builder
+	for _, n := range []struct {
+		value   int
+		numeral string
+	}{
+		{1000, "m"}, {900, "cm"}, {500, "d"}, {400, "cd"},
+		{100, "c"}, {90, "xc"}, {50, "l"}, {40, "xl"},
+		{10, "x"}, {9, "ix"}, {5, "v"}, {4, "iv"}, {1, "i"},
+	} {
+		for number >= n.value {
+			b.WriteString(n.numeral)
+			number -= n.value
+		}
+	}
+	return b.String()
+}
+
+// letters returns the given number in lower case letters; a to z, then aa to zz, and so on.
+func letters(number int) string {
+	if number < 1 {
+		return ""
+	}
+	letter := string(rune('a' + (number-1)%26))
+	return strings.Repeat(letter, (number-1)/26+1)
+}
+
+// SetPageLabels sets the labels of the pages of the document, replacing any it had.
+// The first label must begin at the first page, and no two labels may begin at the same page.
+func (p *PDF) SetPageLabels(labels []*PageLabel) error {
+	if len(labels) == 0 {
+		p.Catalog.RemoveEntry("PageLabels")
+		return nil
+	}
+	sorted := append([]*PageLabel{}, labels...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].PageIndex < sorted[j].PageIndex
+	})
+	if sorted[0].PageIndex != 0 {
+		return errors.New("Page labels must begin at the first page")
+	}
+	entries := make(map[int]Object)
+	for i, l := range sorted {
+		if i > 0 && l.PageIndex == sorted[i-1].PageIndex {
+			return fmt.Errorf("Duplicate page label: %d", l.PageIndex)
+		}
+		if l.Start < 0 {
+			return fmt.Errorf("Invalid Page Label Start: %d", l.Start)
+		}
+		d := &DictionaryObject{
+			Dictionary: make(map[*NameObject]Object),
+		}
+		d.AddNameNameEntry("Type", "PageLabel")
+		if l.Style != NoNumbering {
+			d.AddNameNameEntry("S", l.Style.String())
+		}
+		if l.Prefix != "" {
+			d.AddNameObjectEntry("P", NewTextString(l.Prefix))
+		}
+		if l.Start > 1 {
+			d.AddNameObjectEntry("St", &NumberObject{
+				Number: float64(l.Start),
+			})
+		}
+		entries[l.PageIndex] = d
+	}
+	p.Catalog.SetNameObjectEntry("PageLabels", NewObjectReference(p.NewNumberTree(entries)))
+	return nil
+}
+
+// GetPageLabels returns the labels of the pages of the document, in order of their pages.
+func (p *PDF) GetPageLabels() []*PageLabel {
+	tree, ok := Resolve(p.Catalog.GetEntry("PageLabels")).(*DictionaryObject)
+	if !ok {
+		return nil
+	}
+	var labels []*PageLabel
+	for _, e := range NumberTreeEntries(tree) {
+		d, ok := Resolve(e.Value).(*DictionaryObject)
+		if !ok {
+			continue
+		}
+		l := &PageLabel{
+			PageIndex: e.Number,
+		}
+		switch name(d.GetEntry("S")) {
+		case "D":
+			l.Style = Decimal
+		case "R":
+			l.Style = UpperRoman
+		case "r":
+			l.Style = LowerRoman
+		case "A":
+			l.Style = UpperLetters
+		case "a":
+			l.Style = LowerLetters
+		}
+		if s, ok := Resolve(d.GetEntry("P")).(*StringObject); ok {
+			l.Prefix = s.Text()
+		}
+		if n, ok := Resolve(d.GetEntry("St")).(*NumberObject); ok {
+			l.Start = int(n.Number)
+		}
+		labels = append(labels, l)
+	}
+	sort.SliceStable(labels, func(i, j int) bool {
+		return labels[i].PageIndex < labels[j].PageIndex
+	})
+	return labels
+}
+
+// PageLabel returns the label of the page with the given zero-based index, or its number if the document doesn't label it.
+func (p *PDF) PageLabel(index int) string {
+	var label *PageLabel
+	for _, l := range p.GetPageLabels() {
+		if l.PageIndex > index {
+			break
+		}
+		label = l
+	}
+	if label == nil {
+		return strconv.Itoa(index + 1)
+	}
+	return label.Label(index)
+}
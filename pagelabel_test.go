@@ -0,0 +1,76 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pdfgo_test
+
+import (
+	"bytes"
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPageLabels(t *testing.T) {
+	p := pdfgo.NewPDF()
+	for i := 0; i < 10; i++ {
+		p.AddPage(200, 200, nil, nil)
+	}
+	assert.Equal(t, "3", p.PageLabel(2))
+
+	labels := []*pdfgo.PageLabel{
+		{PageIndex: 0, Style: pdfgo.LowerRoman},
+		{PageIndex: 4, Style: pdfgo.Decimal},
+		{PageIndex: 7, Style: pdfgo.Decimal, Prefix: "A-", Start: 1},
+		{PageIndex: 9, Style: pdfgo.UpperLetters, Prefix: "Index ", Start: 27},
+	}
+	assert.Nil(t, p.SetPageLabels(labels))
+
+	var buffer bytes.Buffer
+	assert.Nil(t, p.Write(&buffer))
+	result, err := pdfgo.ReadPDF(buffer.Bytes())
+	assert.Nil(t, err)
+	read := result.GetPageLabels()
+	assert.Equal(t, 4, len(read))
+	for i, l := range labels {
+		assert.Equal(t, l.PageIndex, read[i].PageIndex)
+		assert.Equal(t, l.Style, read[i].Style)
+		assert.Equal(t, l.Prefix, read[i].Prefix)
+	}
+	var actual []string
+	for i := 0; i < 10; i++ {
+		actual = append(actual, result.PageLabel(i))
+	}
+	assert.Equal(t, []string{"i", "ii", "iii", "iv", "1", "2", "3", "A-1", "A-2", "Index AA"}, actual)
+}
+
+func TestPageLabels_invalid(t *testing.T) {
+	p := pdfgo.NewPDF()
+	assert.NotNil(t, p.SetPageLabels([]*pdfgo.PageLabel{{PageIndex: 1}}))
+	assert.NotNil(t, p.SetPageLabels([]*pdfgo.PageLabel{{PageIndex: 0}, {PageIndex: 0}}))
+	assert.NotNil(t, p.SetPageLabels([]*pdfgo.PageLabel{{PageIndex: 0, Start: -1}}))
+}
+
+func TestPageLabel_Label(t *testing.T) {
+	for expected, label := range map[string]*pdfgo.PageLabel{
+		"MCMXCIV": {Style: pdfgo.UpperRoman, Start: 1994},
+		"xl":      {Style: pdfgo.LowerRoman, Start: 40},
+		"z":       {Style: pdfgo.LowerLetters, Start: 26},
+		"bbb":     {Style: pdfgo.LowerLetters, Start: 54},
+		"Cover":   {Prefix: "Cover"},
+	} {
+		assert.Equal(t, expected, label.Label(0))
+	}
+}
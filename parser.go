@@ -0,0 +1,269 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pdfgo
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// UnresolvedObject stands in for the target of an indirect reference until the referenced object has been read.
+type UnresolvedObject struct {
+	Metadata
+}
+
+func (o *UnresolvedObject) Write(out io.Writer) (int, error) {
+	return WriteS(out, "null")
+}
+
+type Parser struct {
+	*Lexer
+	// Resolve looks up an indirect object, such as the length of a stream, while parsing.
+	Resolve func(number, generation int) Object
+	// Repaired is set when the length of the last stream parsed had to be recovered by searching for endstream.
+	Repaired bool
+}
+
+func NewParser(data []byte) *Parser {
+	return &Parser{
+		Lexer: NewLexer(data),
+	}
+}
+
+// ParseObject parses the next direct object, or indirect reference.
+func (p *Parser) ParseObject() (Object, error) {
+	t, err := p.Next()
+	if err != nil {
+		return nil, err
+	}
+	return p.parseObject(t)
+}
+
+func (p *Parser) parseObject(t *Token) (Object, error) {
+	switch t.Kind {
+	case TokenEOF:
+		return nil, io.ErrUnexpectedEOF
+	case TokenNumber:
+		if r := p.parseReference(t); r != nil {
+			return r, nil
+		}
+		n, err := strconv.ParseFloat(t.Value, 64)
+		if err != nil {
+			return nil, err
+		}
+		return &NumberObject{Number: n}, nil
+	case TokenName:
+		return &NameObject{Name: t.Value}, nil
+	case TokenString:
+		return &StringObject{String: t.Value}, nil
+	case TokenHexString:
+		return &StringObject{String: EscapeString(t.Value)}, nil
+	case TokenArrayStart:
+		a := &ArrayObject{}
+		for {
+			t, err := p.Next()
+			if err != nil {
+				return nil, err
+			}
+			if t.Kind == TokenArrayEnd {
+				return a, nil
+			}
+			o, err := p.parseObject(t)
+			if err != nil {
+				return nil, err
+			}
+			a.Array = append(a.Array, o)
+		}
+	case TokenDictionaryStart:
+		d := &DictionaryObject{
+			Dictionary: make(map[*NameObject]Object),
+		}
+		for {
+			t, err := p.Next()
+			if err != nil {
+				return nil, err
+			}
+			if t.Kind == TokenDictionaryEnd {
+				return d, nil
+			}
+			if t.Kind != TokenName {
+				return nil, fmt.Errorf("Expected dictionary key at %d, got '%s'", t.Offset, t.Value)
+			}
+			v, err := p.ParseObject()
+			if err != nil {
+				return nil, err
+			}
+			// Entries with a null value are equivalent to absent entries
+			if _, ok := v.(*NullObject); ok {
+				continue
+			}
+			d.SetNameObjectEntry(t.Value, v)
+		}
+	case TokenKeyword:
+		switch t.Value {
+		case "true":
+			return &BooleanObject{Boolean: true}, nil
+		case "false":
+			return &BooleanObject{Boolean: false}, nil
+		case "null":
+			return &NullObject{}, nil
+		}
+	}
+	return nil, fmt.Errorf("Unexpected token at %d: '%s'", t.Offset, t.Value)
+}
+
+// parseReference returns an ObjectReference if the given number is followed by a generation and R, otherwise the lexer is left unchanged.
+func (p *Parser) parseReference(t *Token) *ObjectReference {
+	offset := p.Offset
+	number, err := strconv.Atoi(t.Value)
+	if err != nil || number < 0 {
+		return nil
+	}
+	g, err := p.Next()
+	if err != nil || g.Kind != TokenNumber {
+		p.Offset = offset
+		return nil
+	}
+	generation, err := strconv.Atoi(g.Value)
+	if err != nil || generation < 0 {
+		p.Offset = offset
+		return nil
+	}
+	r, err := p.Next()
+	if err != nil || r.Kind != TokenKeyword || r.Value != "R" {
+		p.Offset = offset
+		return nil
+	}
+	o := &UnresolvedObject{}
+	o.Name = number
+	o.Generation = generation
+	return NewObjectReference(o)
+}
+
+// ParseIndirectObject parses an object of the form "number generation obj ... endobj".
+func (p *Parser) ParseIndirectObject() (int, int, Object, error) {
+	p.Repaired = false
+	n, err := p.Next()
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	g, err := p.Next()
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	k, err := p.Next()
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	if n.Kind != TokenNumber || g.Kind != TokenNumber || k.Kind != TokenKeyword || k.Value != "obj" {
+		return 0, 0, nil, fmt.Errorf("Expected indirect object at %d", n.Offset)
+	}
+	number, err := strconv.Atoi(n.Value)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	generation, err := strconv.Atoi(g.Value)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	o, err := p.ParseObject()
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	if d, ok := o.(*DictionaryObject); ok {
+		t, err := p.Peek()
+		if err == nil && t.Kind == TokenKeyword && t.Value == "stream" {
+			s, err := p.parseStream(d, t)
+			if err != nil {
+				return 0, 0, nil, err
+			}
+			o = s
+		}
+	}
+	o.SetName(number)
+	if m, ok := o.(interface{ SetGeneration(int) }); ok {
+		m.SetGeneration(generation)
+	}
+	t, err := p.Peek()
+	if err == nil && t.Kind == TokenKeyword && t.Value == "endobj" {
+		p.Next()
+	}
+	return number, generation, o, nil
+}
+
+func (p *Parser) parseStream(d *DictionaryObject, t *Token) (*StreamObject, error) {
+	start := t.Offset + len("stream")
+	// Keyword is followed by CRLF or LF, tolerate a lone CR
+	if start < len(p.Data) && p.Data[start] == '\r' {
+		start++
+	}
+	if start < len(p.Data) && p.Data[start] == '\n' {
+		start++
+	}
+	s := &StreamObject{
+		DictionaryObject: *d,
+	}
+	length := -1
+	if l, ok := p.resolve(d.GetEntry("Length")).(*NumberObject); ok {
+		length = int(l.Number)
+	}
+	end := start + length
+	if length < 0 || end > len(p.Data) || !hasEndStream(p.Data[end:]) {
+		// Length is missing or wrong, search for the end of the stream instead
+		i := bytes.Index(p.Data[start:], []byte("endstream"))
+		if i < 0 {
+			return nil, fmt.Errorf("Unterminated stream at %d", t.Offset)
+		}
+		end = start + i
+		if end > start && p.Data[end-1] == '\n' {
+			end--
+		}
+		if end > start && p.Data[end-1] == '\r' {
+			end--
+		}
+		p.Repaired = true
+	}
+	s.Data = p.Data[start:end]
+	p.Offset = end
+	e, err := p.Next()
+	if err != nil {
+		return nil, err
+	}
+	if e.Kind != TokenKeyword || e.Value != "endstream" {
+		return nil, fmt.Errorf("Expected endstream at %d", e.Offset)
+	}
+	return s, nil
+}
+
+func (p *Parser) resolve(o Object) Object {
+	if r, ok := o.(*ObjectReference); ok {
+		if _, ok := r.Object.(*UnresolvedObject); ok && p.Resolve != nil {
+			return Resolve(p.Resolve(r.GetName(), r.GetGeneration()))
+		}
+	}
+	return Resolve(o)
+}
+
+func hasEndStream(data []byte) bool {
+	i := 0
+	for i < len(data) && IsWhitespace(data[i]) {
+		i++
+	}
+	return bytes.HasPrefix(data[i:], []byte("endstream"))
+}
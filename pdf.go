@@ -35,6 +35,16 @@ type PDF struct {
 	PageCount      *NumberObject
 	Annotations    *ArrayObject
 	Objects        []Object
+	// Info refers to the Document Information Dictionary, if any
+	Info *ObjectReference
+	// ID holds the file identifiers, if any
+	ID *ArrayObject
+	// Binary writes a comment of non-ASCII bytes after the header, marking the file as binary as PDF/A requires
+	Binary bool
+	// Redacted marks a document from which content has been removed, so that it is only written in full, without the original data
+	Redacted bool
+	// writers are called before the document is written
+	writers []func() error
 }
 
 func NewPDF() *PDF {
@@ -59,14 +69,7 @@ func (p *PDF) AddPage(width, height float64, resources, contents Object) {
 	page := p.NewDictionaryObject()
 	page.AddNameNameEntry("Type", "Page")
 	page.AddNameObjectEntry("Parent", p.PagesReference)
-	page.AddNameObjectEntry("MediaBox", &ArrayObject{
-		Array: []Object{
-			&NumberObject{Number: 0},
-			&NumberObject{Number: 0},
-			&NumberObject{Number: width},
-			&NumberObject{Number: height},
-		},
-	})
+	page.AddNameObjectEntry("MediaBox", NewRectangleArray(0, 0, width, height))
 	page.AddNameObjectEntry("Annots", p.Annotations)
 	if resources != nil {
 		page.AddNameObjectEntry("Resources", resources)
@@ -231,7 +234,24 @@ func (p *PDF) AddImage(mime string, data []byte) (*ObjectReference, float64, flo
 	return NewObjectReference(s), width, height, nil
 }
 
+// OnWrite registers a function to be called each time before the document is written, such as to embed the subset of a font once the text using it is known.
+func (p *PDF) OnWrite(f func() error) {
+	p.writers = append(p.writers, f)
+}
+
+func (p *PDF) beforeWrite() error {
+	for _, w := range p.writers {
+		if err := w(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (p *PDF) Write(out io.Writer) error {
+	if err := p.beforeWrite(); err != nil {
+		return err
+	}
 	// Write Header
 	var count int
 	n, err := WriteF(out, "%%PDF-%s\n", p.Version)
@@ -239,22 +259,18 @@ func (p *PDF) Write(out io.Writer) error {
 		return err
 	}
 	count += n
-	log.Println("Wrote Header", count)
-
-	// Write Body
-	for _, o := range p.Objects {
-		o.SetAddress(count)
-		n, err = WriteF(out, "%d 0 obj ", o.GetName())
+	if p.Binary {
+		n, err = WriteS(out, "%\xE2\xE3\xCF\xD3\n")
 		if err != nil {
 			return err
 		}
 		count += n
-		n, err = o.Write(out)
-		if err != nil {
-			return err
-		}
-		count += n
-		n, err = WriteS(out, " endobj\n")
+	}
+	log.Println("Wrote Header", count)
+
+	// Write Body
+	for _, o := range p.Objects {
+		n, err = writeObject(out, o, count)
 		if err != nil {
 			return err
 		}
@@ -284,23 +300,82 @@ func (p *PDF) Write(out io.Writer) error {
 	log.Println("Wrote Cross Reference", count)
 
 	// Write Trailer
-	n, err = WriteF(out, "trailer <</Size %d /Root %d 0 R>>\n", len(p.Objects)+1, p.Catalog.GetName())
+	n, err = writeTrailer(out, p.trailer(), xrefOffset)
 	if err != nil {
 		return err
 	}
 	count += n
+	log.Println("Wrote Trailer", count)
+	return nil
+}
+
+// writeObject writes the given indirect object, recording the address it is written at.
+func writeObject(out io.Writer, o Object, address int) (int, error) {
+	o.SetAddress(address)
+	var count int
+	n, err := WriteF(out, "%d %d obj ", o.GetName(), o.GetGeneration())
+	if err != nil {
+		return 0, err
+	}
+	count += n
+	n, err = o.Write(out)
+	if err != nil {
+		return 0, err
+	}
+	count += n
+	n, err = WriteS(out, " endobj\n")
+	if err != nil {
+		return 0, err
+	}
+	count += n
+	return count, nil
+}
+
+func (p *PDF) trailer() *DictionaryObject {
+	trailer := &DictionaryObject{
+		Dictionary: make(map[*NameObject]Object),
+	}
+	trailer.AddNameObjectEntry("Size", &NumberObject{
+		Number: float64(len(p.Objects) + 1),
+	})
+	trailer.AddNameObjectEntry("Root", NewObjectReference(p.Catalog))
+	if p.Info != nil {
+		trailer.AddNameObjectEntry("Info", p.Info)
+	}
+	if p.ID != nil {
+		trailer.AddNameObjectEntry("ID", p.ID)
+	}
+	return trailer
+}
+
+func writeTrailer(out io.Writer, trailer *DictionaryObject, xrefOffset int) (int, error) {
+	var count int
+	n, err := WriteS(out, "trailer ")
+	if err != nil {
+		return 0, err
+	}
+	count += n
+	n, err = trailer.Write(out)
+	if err != nil {
+		return 0, err
+	}
+	count += n
+	n, err = WriteS(out, "\n")
+	if err != nil {
+		return 0, err
+	}
+	count += n
 	n, err = WriteF(out, "startxref\n%d\n", xrefOffset)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	count += n
 	n, err = WriteS(out, "%%EOF\n")
 	if err != nil {
-		return err
+		return 0, err
 	}
 	count += n
-	log.Println("Wrote Trailer", count)
-	return nil
+	return count, nil
 }
 
 func WriteF(out io.Writer, format string, args ...interface{}) (int, error) {
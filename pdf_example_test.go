@@ -17,7 +17,6 @@
 package pdfgo_test
 
 import (
-	"bytes"
 	"github.com/AletheiaWareLLC/pdfgo"
 	"github.com/AletheiaWareLLC/pdfgo/font"
 	"github.com/AletheiaWareLLC/pdfgo/graphics"
@@ -102,12 +101,16 @@ func ExamplePDF_stream_colourbox() {
 		log.Fatal(err)
 	}
 
-	var buffer bytes.Buffer
-	if err := box.Write(p, &buffer); err != nil {
+	writer := graphics.NewContentWriter()
+	if err := box.Write(p, writer); err != nil {
+		log.Fatal(err)
+	}
+	data, err := writer.Bytes()
+	if err != nil {
 		log.Fatal(err)
 	}
 	contents := p.NewStreamObject()
-	contents.Data = buffer.Bytes()
+	contents.Data = data
 
 	// Create Page
 	width := 400.0
@@ -125,9 +128,11 @@ func ExamplePDF_stream_colourbox() {
 	// stream
 	// q
 	// 0 0 1 rg
-	// 50 50 300 500 re f
+	// 50 50 300 500 re
+	// f
 	// 1 0 0 RG
-	// 50 50 300 500 re S
+	// 50 50 300 500 re
+	// S
 	// Q
 	// endstream endobj
 	// 4 0 obj <</Type /Page /Parent 2 0 R /MediaBox [0 0 400 600] /Annots [] /Contents 3 0 R>> endobj
@@ -317,12 +322,16 @@ func ExamplePDF_stream_textbox() {
 		log.Fatal(err)
 	}
 
-	var buffer bytes.Buffer
-	if err := box.Write(p, &buffer); err != nil {
+	writer := graphics.NewContentWriter()
+	if err := box.Write(p, writer); err != nil {
+		log.Fatal(err)
+	}
+	data, err := writer.Bytes()
+	if err != nil {
 		log.Fatal(err)
 	}
 	contents := p.NewStreamObject()
-	contents.Data = buffer.Bytes()
+	contents.Data = data
 
 	// Create Page
 	width := 400.0
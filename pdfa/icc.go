@@ -0,0 +1,150 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pdfa
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+)
+
+// SRGB_PROFILE_NAME identifies the sRGB colour space in output intents
+const SRGB_PROFILE_NAME = "sRGB IEC61966-2.1"
+
+// SRGBProfile returns a version 2 ICC display profile of the sRGB colour space, suitable as the destination profile of an output intent.
+func SRGBProfile() []byte {
+	type tag struct {
+		signature string
+		data      []byte
+	}
+	// Colourants are adapted to the D50 illuminant of the profile connection space
+	curve := trc()
+	tags := []*tag{
+		{"desc", textDescription(SRGB_PROFILE_NAME)},
+		{"cprt", text("No copyright, use freely")},
+		{"wtpt", xyz(0.9642, 1, 0.8249)},
+		{"rXYZ", xyz(0.4361, 0.2225, 0.0139)},
+		{"gXYZ", xyz(0.3851, 0.7169, 0.0971)},
+		{"bXYZ", xyz(0.1431, 0.0606, 0.7141)},
+		{"rTRC", curve},
+		{"gTRC", curve},
+		{"bTRC", curve},
+	}
+
+	header := 128
+	table := 4 + 12*len(tags)
+	var data bytes.Buffer
+	var entries bytes.Buffer
+	binary.Write(&entries, binary.BigEndian, uint32(len(tags)))
+	offsets := make(map[*byte]uint32)
+	for _, t := range tags {
+		// Tags with the same data share it
+		offset, ok := offsets[&t.data[0]]
+		if !ok {
+			offset = uint32(header + table + data.Len())
+			offsets[&t.data[0]] = offset
+			data.Write(t.data)
+			for data.Len()%4 != 0 {
+				data.WriteByte(0)
+			}
+		}
+		entries.WriteString(t.signature)
+		binary.Write(&entries, binary.BigEndian, offset)
+		binary.Write(&entries, binary.BigEndian, uint32(len(t.data)))
+	}
+
+	size := header + table + data.Len()
+	var profile bytes.Buffer
+	binary.Write(&profile, binary.BigEndian, uint32(size))
+	profile.Write(make([]byte, 4))                               // Preferred CMM
+	binary.Write(&profile, binary.BigEndian, uint32(0x02100000)) // Version 2.1
+	profile.WriteString("mntr")
+	profile.WriteString("RGB ")
+	profile.WriteString("XYZ ")
+	for _, v := range []uint16{2021, 1, 1, 0, 0, 0} {
+		binary.Write(&profile, binary.BigEndian, v)
+	}
+	profile.WriteString("acsp")
+	profile.Write(make([]byte, 4)) // Platform
+	profile.Write(make([]byte, 4)) // Flags
+	profile.Write(make([]byte, 4)) // Manufacturer
+	profile.Write(make([]byte, 4)) // Model
+	profile.Write(make([]byte, 8)) // Attributes
+	profile.Write(make([]byte, 4)) // Perceptual rendering intent
+	profile.Write(xyz(0.9642, 1, 0.8249)[8:])
+	profile.Write(make([]byte, 4))  // Creator
+	profile.Write(make([]byte, 16)) // Profile ID
+	profile.Write(make([]byte, 28)) // Reserved
+	profile.Write(entries.Bytes())
+	profile.Write(data.Bytes())
+	return profile.Bytes()
+}
+
+func s15Fixed16(f float64) uint32 {
+	return uint32(int32(math.Round(f * 65536)))
+}
+
+func xyz(x, y, z float64) []byte {
+	var b bytes.Buffer
+	b.WriteString("XYZ ")
+	b.Write(make([]byte, 4))
+	for _, v := range []float64{x, y, z} {
+		binary.Write(&b, binary.BigEndian, s15Fixed16(v))
+	}
+	return b.Bytes()
+}
+
+func text(s string) []byte {
+	var b bytes.Buffer
+	b.WriteString("text")
+	b.Write(make([]byte, 4))
+	b.WriteString(s)
+	b.WriteByte(0)
+	return b.Bytes()
+}
+
+func textDescription(s string) []byte {
+	var b bytes.Buffer
+	b.WriteString("desc")
+	b.Write(make([]byte, 4))
+	binary.Write(&b, binary.BigEndian, uint32(len(s)+1))
+	b.WriteString(s)
+	b.WriteByte(0)
+	b.Write(make([]byte, 8))  // Unicode language and count
+	b.Write(make([]byte, 3))  // ScriptCode code and count
+	b.Write(make([]byte, 67)) // ScriptCode description
+	return b.Bytes()
+}
+
+// trc returns the tone reproduction curve of sRGB, sampled at 1024 points.
+func trc() []byte {
+	const count = 1024
+	var b bytes.Buffer
+	b.WriteString("curv")
+	b.Write(make([]byte, 4))
+	binary.Write(&b, binary.BigEndian, uint32(count))
+	for i := 0; i < count; i++ {
+		v := float64(i) / (count - 1)
+		if v <= 0.04045 {
+			v /= 12.92
+		} else {
+			v = math.Pow((v+0.055)/1.055, 2.4)
+		}
+		binary.Write(&b, binary.BigEndian, uint16(math.Round(v*65535)))
+	}
+	return b.Bytes()
+}
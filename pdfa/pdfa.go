@@ -0,0 +1,134 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pdfa
+
+import (
+	"crypto/md5"
+	"github.com/AletheiaWareLLC/pdfgo"
+	"time"
+)
+
+// PART and CONFORMANCE identify the PDF/A-3b standard in XMP metadata
+const (
+	PART        = 3
+	CONFORMANCE = "B"
+)
+
+// Conform prepares the given document to conform to PDF/A-3b; it writes the metadata as XMP and in the Document Information Dictionary, adds an sRGB output intent if there is none, gives the document identifiers, and marks the file as binary.
+// Fonts and content are not changed, so the written document should be checked with Validate.
+// The objects which were changed are returned so they can be written as an incremental update.
+func Conform(p *pdfgo.PDF, m *Metadata) ([]pdfgo.Object, error) {
+	changed := []pdfgo.Object{p.Catalog}
+	xmp := m.XMP(PART, CONFORMANCE)
+	s := p.NewStreamObject()
+	s.Data = xmp
+	s.AddNameNameEntry("Type", "Metadata")
+	s.AddNameNameEntry("Subtype", "XML")
+	p.Catalog.SetNameObjectEntry("Metadata", pdfgo.NewObjectReference(s))
+
+	var info *pdfgo.DictionaryObject
+	if p.Info != nil {
+		info, _ = pdfgo.Resolve(p.Info).(*pdfgo.DictionaryObject)
+	}
+	if info != nil {
+		changed = append(changed, info)
+	} else {
+		info = p.NewDictionaryObject()
+		p.Info = pdfgo.NewObjectReference(info)
+	}
+	// Entries must match the XMP metadata, so those without a value are removed
+	for key, value := range map[string]string{
+		"Title":    m.Title,
+		"Author":   m.Author,
+		"Subject":  m.Subject,
+		"Keywords": m.Keywords,
+		"Creator":  m.Creator,
+		"Producer": m.Producer,
+	} {
+		if value == "" {
+			info.RemoveEntry(key)
+		} else {
+			info.SetNameObjectEntry(key, pdfgo.NewTextString(value))
+		}
+	}
+	for key, value := range map[string]time.Time{
+		"CreationDate": m.Created,
+		"ModDate":      m.Modified,
+	} {
+		if value.IsZero() {
+			info.RemoveEntry(key)
+		} else {
+			info.SetNameObjectEntry(key, pdfgo.NewDateString(value))
+		}
+	}
+
+	if outputIntent(p.Catalog) == nil {
+		profile := p.NewStreamObject()
+		profile.Data = SRGBProfile()
+		profile.AddNameObjectEntry("N", &pdfgo.NumberObject{
+			Number: 3,
+		})
+		intent := p.NewDictionaryObject()
+		intent.AddNameNameEntry("Type", "OutputIntent")
+		intent.AddNameNameEntry("S", "GTS_PDFA1")
+		intent.AddNameObjectEntry("OutputConditionIdentifier", pdfgo.NewTextString(SRGB_PROFILE_NAME))
+		intent.AddNameObjectEntry("Info", pdfgo.NewTextString(SRGB_PROFILE_NAME))
+		intent.AddNameObjectEntry("DestOutputProfile", pdfgo.NewObjectReference(profile))
+		intents, ok := pdfgo.Resolve(p.Catalog.GetEntry("OutputIntents")).(*pdfgo.ArrayObject)
+		if !ok {
+			intents = &pdfgo.ArrayObject{}
+			p.Catalog.SetNameObjectEntry("OutputIntents", intents)
+		} else if intents.GetName() > 0 {
+			changed = append(changed, intents)
+		}
+		intents.Array = append(intents.Array, pdfgo.NewObjectReference(intent))
+	}
+
+	if p.ID == nil {
+		sum := md5.Sum(append(xmp, []byte(time.Now().String())...))
+		id := pdfgo.EscapeString(string(sum[:]))
+		p.ID = &pdfgo.ArrayObject{
+			Array: []pdfgo.Object{
+				&pdfgo.StringObject{String: id},
+				&pdfgo.StringObject{String: id},
+			},
+		}
+	}
+	p.Binary = true
+	return changed, nil
+}
+
+// outputIntent returns the PDF/A output intent of the given catalog, or nil.
+func outputIntent(catalog *pdfgo.DictionaryObject) *pdfgo.DictionaryObject {
+	intents, ok := pdfgo.Resolve(catalog.GetEntry("OutputIntents")).(*pdfgo.ArrayObject)
+	if !ok {
+		return nil
+	}
+	for _, o := range intents.Array {
+		if d, ok := pdfgo.Resolve(o).(*pdfgo.DictionaryObject); ok && name(d.GetEntry("S")) == "GTS_PDFA1" {
+			return d
+		}
+	}
+	return nil
+}
+
+func name(o pdfgo.Object) string {
+	if n, ok := pdfgo.Resolve(o).(*pdfgo.NameObject); ok {
+		return n.Name
+	}
+	return ""
+}
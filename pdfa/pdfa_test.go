@@ -0,0 +1,193 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pdfa_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/AletheiaWareLLC/pdfgo/font"
+	"github.com/AletheiaWareLLC/pdfgo/pdfa"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/image/font/gofont/goregular"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var date = time.Date(2021, 2, 3, 4, 5, 6, 0, time.UTC)
+
+func metadata() *pdfa.Metadata {
+	return &pdfa.Metadata{
+		Title:    "Test",
+		Author:   "Alice",
+		Subject:  "Testing",
+		Keywords: "a, b",
+		Creator:  "pdfgo",
+		Producer: "pdfgo",
+		Created:  date,
+		Modified: date,
+	}
+}
+
+// document returns a one page document drawing with the font created by the given function.
+func document(t *testing.T, newFont func(*pdfgo.PDF) pdfgo.Object) *pdfgo.PDF {
+	t.Helper()
+	p := pdfgo.NewPDF()
+	resources := p.NewDictionaryObject()
+	content := p.NewStreamObject()
+	content.Data = []byte("0 0 1 rg 10 10 180 180 re f\n")
+	if newFont != nil {
+		fonts := p.NewDictionaryObject()
+		fonts.AddNameObjectEntry("F1", newFont(p))
+		resources.AddNameObjectEntry("Font", fonts)
+		content.Data = append(content.Data, []byte("BT /F1 12 Tf 20 20 Td (Hello) Tj ET\n")...)
+	}
+	p.AddPage(200, 200, pdfgo.NewObjectReference(resources), pdfgo.NewObjectReference(content))
+	return p
+}
+
+func validate(t *testing.T, p *pdfgo.PDF) *pdfa.Report {
+	t.Helper()
+	var buffer bytes.Buffer
+	assert.Nil(t, p.Write(&buffer))
+	report, err := pdfa.Validate(buffer.Bytes())
+	assert.Nil(t, err)
+	return report
+}
+
+func TestSRGBProfile(t *testing.T) {
+	profile := pdfa.SRGBProfile()
+	assert.Equal(t, len(profile), int(binary.BigEndian.Uint32(profile)))
+	assert.Equal(t, "mntr", string(profile[12:16]))
+	assert.Equal(t, "RGB ", string(profile[16:20]))
+	assert.Equal(t, "acsp", string(profile[36:40]))
+}
+
+func TestXMP(t *testing.T) {
+	m := metadata()
+	m.Extensions = []*pdfa.Extension{
+		{
+			Schema:    "Example Schema",
+			Namespace: "http://example.com/ns#",
+			Prefix:    "ex",
+			Properties: []*pdfa.Property{
+				{
+					Name:        "Value",
+					ValueType:   "Text",
+					Category:    "external",
+					Description: "Example value",
+					Value:       "1 < 2",
+				},
+			},
+		},
+	}
+	properties, err := pdfa.ParseXMP(m.XMP(3, "B"))
+	assert.Nil(t, err)
+	for _, test := range []struct {
+		namespace, name, expected string
+	}{
+		{pdfa.NAMESPACE_PDFAID, "part", "3"},
+		{pdfa.NAMESPACE_PDFAID, "conformance", "B"},
+		{pdfa.NAMESPACE_DC, "title", "Test"},
+		{pdfa.NAMESPACE_DC, "creator", "Alice"},
+		{pdfa.NAMESPACE_DC, "description", "Testing"},
+		{pdfa.NAMESPACE_PDF, "Keywords", "a, b"},
+		{pdfa.NAMESPACE_PDF, "Producer", "pdfgo"},
+		{pdfa.NAMESPACE_XMP, "CreatorTool", "pdfgo"},
+		{pdfa.NAMESPACE_XMP, "CreateDate", "2021-02-03T04:05:06Z"},
+		{"http://example.com/ns#", "Value", "1 < 2"},
+		{pdfa.NAMESPACE_PDFA_SCHEMA, "namespaceURI", "http://example.com/ns#"},
+	} {
+		value, ok := properties.Get(test.namespace, test.name)
+		assert.True(t, ok, test.name)
+		assert.Equal(t, test.expected, value, test.name)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("NotConforming", func(t *testing.T) {
+		report := validate(t, document(t, nil))
+		assert.False(t, report.Valid())
+		assert.Contains(t, report.Violations, "Catalog has no XMP metadata")
+		assert.Contains(t, report.Violations, "Document has no PDF/A output intent")
+	})
+	t.Run("Conforming", func(t *testing.T) {
+		p := document(t, nil)
+		_, err := pdfa.Conform(p, metadata())
+		assert.Nil(t, err)
+		report := validate(t, p)
+		assert.Equal(t, []string(nil), report.Violations)
+		assert.True(t, report.Valid())
+	})
+	t.Run("StandardFont", func(t *testing.T) {
+		p := document(t, func(p *pdfgo.PDF) pdfgo.Object {
+			f := p.NewDictionaryObject()
+			f.AddNameNameEntry("Type", "Font")
+			f.AddNameNameEntry("Subtype", "Type1")
+			f.AddNameNameEntry("BaseFont", "Helvetica")
+			return pdfgo.NewObjectReference(f)
+		})
+		_, err := pdfa.Conform(p, metadata())
+		assert.Nil(t, err)
+		report := validate(t, p)
+		assert.Equal(t, []string{"Font Helvetica isn't embedded"}, report.Violations)
+	})
+	t.Run("EmbeddedFont", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "pdfa")
+		assert.Nil(t, err)
+		defer os.RemoveAll(dir)
+		file := filepath.Join(dir, "Go-Regular.ttf")
+		assert.Nil(t, ioutil.WriteFile(file, goregular.TTF, 0600))
+		p := document(t, func(p *pdfgo.PDF) pdfgo.Object {
+			f, err := font.NewTrueTypeFont(p, file)
+			assert.Nil(t, err)
+			return f.Reference
+		})
+		_, err = pdfa.Conform(p, metadata())
+		assert.Nil(t, err)
+		report := validate(t, p)
+		assert.Equal(t, []string(nil), report.Violations)
+	})
+	t.Run("Mismatch", func(t *testing.T) {
+		p := document(t, nil)
+		_, err := pdfa.Conform(p, metadata())
+		assert.Nil(t, err)
+		info := pdfgo.Resolve(p.Info).(*pdfgo.DictionaryObject)
+		info.SetNameObjectEntry("Title", pdfgo.NewTextString("Other"))
+		report := validate(t, p)
+		assert.Equal(t, []string{`Document information Title doesn't match XMP metadata: "Other" != "Test"`}, report.Violations)
+	})
+	t.Run("Attachment", func(t *testing.T) {
+		p := document(t, nil)
+		_, err := p.AddAttachment(&pdfgo.Attachment{
+			Name: "data.csv",
+			Data: []byte("a,b\n"),
+		})
+		assert.Nil(t, err)
+		_, err = pdfa.Conform(p, metadata())
+		assert.Nil(t, err)
+		report := validate(t, p)
+		assert.Equal(t, []string{
+			"Embedded file data.csv has no media type",
+			"Embedded file data.csv has no modification date",
+			"Embedded file data.csv has no relationship to the document",
+		}, report.Violations)
+	})
+}
@@ -0,0 +1,410 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pdfa
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/AletheiaWareLLC/pdfgo"
+	"strings"
+	"time"
+)
+
+// Report lists the ways in which a document violates PDF/A-3b.
+type Report struct {
+	// Document is the parsed document, if it could be parsed
+	Document   *pdfgo.PDF
+	Violations []string
+}
+
+// Valid returns true if no violations were found.
+func (r *Report) Valid() bool {
+	return len(r.Violations) == 0
+}
+
+// Add records a violation.
+func (r *Report) Add(format string, args ...interface{}) {
+	r.Violations = append(r.Violations, fmt.Sprintf(format, args...))
+}
+
+// Actions which are forbidden by PDF/A
+var forbiddenActions = map[string]bool{
+	"Launch":         true,
+	"Sound":          true,
+	"Movie":          true,
+	"ResetForm":      true,
+	"ImportData":     true,
+	"Hide":           true,
+	"SetOCGState":    true,
+	"Rendition":      true,
+	"Trans":          true,
+	"GoTo3DView":     true,
+	"JavaScript":     true,
+	"SetFieldValues": true,
+}
+
+// Annotations which are forbidden by PDF/A
+var forbiddenAnnotations = map[string]bool{
+	"Sound":     true,
+	"Movie":     true,
+	"Screen":    true,
+	"3D":        true,
+	"RichMedia": true,
+}
+
+// Namespaces of schemas which are predefined by XMP, and don't need describing in extension schemas
+var predefinedNamespaces = map[string]bool{
+	NAMESPACE_RDF:                                    true,
+	NAMESPACE_DC:                                     true,
+	NAMESPACE_PDF:                                    true,
+	NAMESPACE_XMP:                                    true,
+	NAMESPACE_PDFAID:                                 true,
+	NAMESPACE_PDFA_EXTENSION:                         true,
+	NAMESPACE_PDFA_SCHEMA:                            true,
+	NAMESPACE_PDFA_PROPERTY:                          true,
+	"http://www.w3.org/XML/1998/namespace":           true,
+	"adobe:ns:meta/":                                 true,
+	"http://ns.adobe.com/xap/1.0/mm/":                true,
+	"http://ns.adobe.com/xap/1.0/rights/":            true,
+	"http://ns.adobe.com/xap/1.0/sType/Event#":       true,
+	"http://ns.adobe.com/xap/1.0/sType/ResourceRef#": true,
+	"http://ns.adobe.com/photoshop/1.0/":             true,
+	"http://ns.adobe.com/tiff/1.0/":                  true,
+	"http://ns.adobe.com/exif/1.0/":                  true,
+}
+
+// Validate checks the given file against the rules of PDF/A-3b which concern the file structure, metadata, output intents, fonts, annotations, actions, and embedded files.
+// It is not a complete validator; in particular, the content of pages is not checked for device colours without an output intent, or for transparency.
+// An error is returned only if the file can't be parsed at all.
+func Validate(data []byte) (*Report, error) {
+	report := &Report{}
+	if !bytes.HasPrefix(data, []byte("%PDF-")) {
+		report.Add("File doesn't start with a header")
+	}
+	p, repairs, err := pdfgo.RepairPDF(data)
+	if err == pdfgo.ErrEncrypted {
+		report.Add("File is encrypted")
+		return report, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	report.Document = p
+	for _, m := range repairs.Messages {
+		report.Add("File structure is damaged: %s", m)
+	}
+	if !p.Binary {
+		report.Add("Header isn't followed by a comment of binary characters")
+	}
+	if p.ID == nil {
+		report.Add("Trailer has no file identifiers")
+	}
+
+	v := &validator{
+		report:  report,
+		visited: make(map[pdfgo.Object]bool),
+	}
+	v.metadata(p)
+	if intent := outputIntent(p.Catalog); intent == nil {
+		report.Add("Document has no PDF/A output intent")
+	} else if _, ok := pdfgo.Resolve(intent.GetEntry("DestOutputProfile")).(*pdfgo.StreamObject); !ok {
+		report.Add("Output intent has no destination profile")
+	}
+	if p.Catalog.HasEntry("AA") {
+		report.Add("Catalog has additional actions")
+	}
+	v.action(p.Catalog.GetEntry("OpenAction"))
+	if names, ok := pdfgo.Resolve(p.Catalog.GetEntry("Names")).(*pdfgo.DictionaryObject); ok && names.HasEntry("JavaScript") {
+		report.Add("Document has JavaScript")
+	}
+	for i, page := range p.GetPages() {
+		if page.HasEntry("AA") {
+			report.Add("Page %d has additional actions", i+1)
+		}
+		v.resources(pdfgo.PageResources(page))
+		v.annotations(i+1, page)
+	}
+	v.attachments(p)
+	return report, nil
+}
+
+type validator struct {
+	report  *Report
+	visited map[pdfgo.Object]bool
+}
+
+// metadata checks the XMP metadata identifies the document as PDF/A and agrees with the Document Information Dictionary.
+func (v *validator) metadata(p *pdfgo.PDF) {
+	s, ok := pdfgo.Resolve(p.Catalog.GetEntry("Metadata")).(*pdfgo.StreamObject)
+	if !ok {
+		v.report.Add("Catalog has no XMP metadata")
+		return
+	}
+	if name(s.GetEntry("Type")) != "Metadata" || name(s.GetEntry("Subtype")) != "XML" {
+		v.report.Add("Metadata stream isn't of type Metadata and subtype XML")
+	}
+	if s.HasEntry("Filter") {
+		v.report.Add("Metadata stream is filtered")
+	}
+	properties, err := ParseXMP(s.Data)
+	if err != nil {
+		v.report.Add("XMP metadata can't be parsed: %s", err)
+		return
+	}
+	if part, _ := properties.Get(NAMESPACE_PDFAID, "part"); part != "3" {
+		v.report.Add("XMP metadata doesn't identify PDF/A part 3: %q", part)
+	}
+	if conformance, _ := properties.Get(NAMESPACE_PDFAID, "conformance"); conformance != "A" && conformance != "B" && conformance != "U" {
+		v.report.Add("XMP metadata doesn't identify a PDF/A conformance level: %q", conformance)
+	}
+
+	// Properties of schemas which aren't predefined must be described by extension schemas
+	described := make(map[string]bool)
+	for _, n := range properties[NAMESPACE_PDFA_SCHEMA+" namespaceURI"] {
+		described[n] = true
+	}
+	for key := range properties {
+		namespace := key[:strings.LastIndex(key, " ")]
+		if !predefinedNamespaces[namespace] && !described[namespace] {
+			v.report.Add("XMP schema isn't described by an extension schema: %s", namespace)
+			described[namespace] = true
+		}
+	}
+
+	var info *pdfgo.DictionaryObject
+	if p.Info != nil {
+		info, _ = pdfgo.Resolve(p.Info).(*pdfgo.DictionaryObject)
+	}
+	if info == nil {
+		return
+	}
+	for key, property := range map[string][2]string{
+		"Title":    {NAMESPACE_DC, "title"},
+		"Author":   {NAMESPACE_DC, "creator"},
+		"Subject":  {NAMESPACE_DC, "description"},
+		"Keywords": {NAMESPACE_PDF, "Keywords"},
+		"Creator":  {NAMESPACE_XMP, "CreatorTool"},
+		"Producer": {NAMESPACE_PDF, "Producer"},
+	} {
+		s, ok := pdfgo.Resolve(info.GetEntry(key)).(*pdfgo.StringObject)
+		if !ok {
+			continue
+		}
+		if value, _ := properties.Get(property[0], property[1]); value != s.Text() {
+			v.report.Add("Document information %s doesn't match XMP metadata: %q != %q", key, s.Text(), value)
+		}
+	}
+	for key, property := range map[string]string{
+		"CreationDate": "CreateDate",
+		"ModDate":      "ModifyDate",
+	} {
+		s, ok := pdfgo.Resolve(info.GetEntry(key)).(*pdfgo.StringObject)
+		if !ok {
+			continue
+		}
+		date, err := pdfgo.ParseDate(string(s.Bytes()))
+		if err != nil {
+			v.report.Add("Document information %s is invalid: %s", key, err)
+			continue
+		}
+		value, _ := properties.Get(NAMESPACE_XMP, property)
+		xmp, err := time.Parse(time.RFC3339, value)
+		if err != nil || !xmp.Equal(date) {
+			v.report.Add("Document information %s doesn't match XMP metadata: %s != %q", key, date.Format(time.RFC3339), value)
+		}
+	}
+}
+
+// resources checks the fonts of the given resources are embedded, and the resources of any forms they contain.
+func (v *validator) resources(resources *pdfgo.DictionaryObject) {
+	if resources == nil || v.visited[resources] {
+		return
+	}
+	v.visited[resources] = true
+	if fonts, ok := pdfgo.Resolve(resources.GetEntry("Font")).(*pdfgo.DictionaryObject); ok {
+		for _, k := range fonts.Keys {
+			if f, ok := pdfgo.Resolve(fonts.Dictionary[k]).(*pdfgo.DictionaryObject); ok {
+				v.font(f)
+			}
+		}
+	}
+	if xs, ok := pdfgo.Resolve(resources.GetEntry("XObject")).(*pdfgo.DictionaryObject); ok {
+		for _, k := range xs.Keys {
+			if x, ok := pdfgo.Resolve(xs.Dictionary[k]).(*pdfgo.StreamObject); ok {
+				v.xobject(x)
+			}
+		}
+	}
+	if states, ok := pdfgo.Resolve(resources.GetEntry("ExtGState")).(*pdfgo.DictionaryObject); ok {
+		for _, k := range states.Keys {
+			if s, ok := pdfgo.Resolve(states.Dictionary[k]).(*pdfgo.DictionaryObject); ok {
+				if s.HasEntry("TR") {
+					v.report.Add("Graphics state %s has a transfer function", k.Name)
+				}
+				if tr2 := s.GetEntry("TR2"); tr2 != nil && name(tr2) != "Default" {
+					v.report.Add("Graphics state %s has a transfer function", k.Name)
+				}
+			}
+		}
+	}
+}
+
+func (v *validator) xobject(x *pdfgo.StreamObject) {
+	if v.visited[x] {
+		return
+	}
+	v.visited[x] = true
+	switch name(x.GetEntry("Subtype")) {
+	case "Form":
+		if r, ok := pdfgo.Resolve(x.GetEntry("Resources")).(*pdfgo.DictionaryObject); ok {
+			v.resources(r)
+		}
+	case "PS":
+		v.report.Add("Document has a PostScript XObject")
+	case "Image":
+		if x.HasEntry("Alternates") || x.HasEntry("OPI") {
+			v.report.Add("Image has alternates or OPI")
+		}
+		if b, ok := pdfgo.Resolve(x.GetEntry("Interpolate")).(*pdfgo.BooleanObject); ok && b.Boolean {
+			v.report.Add("Image is interpolated")
+		}
+	}
+}
+
+// font checks the given font program is embedded.
+func (v *validator) font(f *pdfgo.DictionaryObject) {
+	if v.visited[f] {
+		return
+	}
+	v.visited[f] = true
+	switch name(f.GetEntry("Subtype")) {
+	case "Type3":
+		if r, ok := pdfgo.Resolve(f.GetEntry("Resources")).(*pdfgo.DictionaryObject); ok {
+			v.resources(r)
+		}
+		return
+	case "Type0":
+		if descendants, ok := pdfgo.Resolve(f.GetEntry("DescendantFonts")).(*pdfgo.ArrayObject); ok && len(descendants.Array) > 0 {
+			if d, ok := pdfgo.Resolve(descendants.Array[0]).(*pdfgo.DictionaryObject); ok {
+				v.font(d)
+				return
+			}
+		}
+		v.report.Add("Font %s has no descendant font", name(f.GetEntry("BaseFont")))
+		return
+	}
+	descriptor, ok := pdfgo.Resolve(f.GetEntry("FontDescriptor")).(*pdfgo.DictionaryObject)
+	if !ok || (!descriptor.HasEntry("FontFile") && !descriptor.HasEntry("FontFile2") && !descriptor.HasEntry("FontFile3")) {
+		v.report.Add("Font %s isn't embedded", name(f.GetEntry("BaseFont")))
+	}
+}
+
+// annotations checks the annotations of the given page are printed, and have appearances and permitted actions.
+func (v *validator) annotations(number int, page *pdfgo.DictionaryObject) {
+	annots, ok := pdfgo.Resolve(page.GetEntry("Annots")).(*pdfgo.ArrayObject)
+	if !ok {
+		return
+	}
+	for _, o := range annots.Array {
+		a, ok := pdfgo.Resolve(o).(*pdfgo.DictionaryObject)
+		if !ok {
+			continue
+		}
+		subtype := name(a.GetEntry("Subtype"))
+		if forbiddenAnnotations[subtype] {
+			v.report.Add("Page %d has a %s annotation", number, subtype)
+			continue
+		}
+		if subtype != "Popup" {
+			flags := 0
+			if f, ok := pdfgo.Resolve(a.GetEntry("F")).(*pdfgo.NumberObject); ok {
+				flags = int(f.Number)
+			}
+			// Print is required, and Invisible, Hidden, NoView, and ToggleNoView are forbidden
+			if flags&4 == 0 || flags&(1|2|32|256) != 0 {
+				v.report.Add("Page %d has a %s annotation which isn't printed", number, subtype)
+			}
+		}
+		if ap, ok := pdfgo.Resolve(a.GetEntry("AP")).(*pdfgo.DictionaryObject); ok {
+			if len(ap.Keys) != 1 || !ap.HasEntry("N") {
+				v.report.Add("Page %d has a %s annotation with appearances other than normal", number, subtype)
+			}
+			switch n := pdfgo.Resolve(ap.GetEntry("N")).(type) {
+			case *pdfgo.StreamObject:
+				v.xobject(n)
+			case *pdfgo.DictionaryObject:
+				for _, k := range n.Keys {
+					if s, ok := pdfgo.Resolve(n.Dictionary[k]).(*pdfgo.StreamObject); ok {
+						v.xobject(s)
+					}
+				}
+			}
+		} else if subtype != "Popup" && subtype != "Link" {
+			v.report.Add("Page %d has a %s annotation without an appearance", number, subtype)
+		}
+		if a.HasEntry("AA") {
+			v.report.Add("Page %d has a %s annotation with additional actions", number, subtype)
+		}
+		v.action(a.GetEntry("A"))
+	}
+}
+
+// action checks the given action, and any actions following it, are permitted.
+func (v *validator) action(o pdfgo.Object) {
+	a, ok := pdfgo.Resolve(o).(*pdfgo.DictionaryObject)
+	if !ok || v.visited[a] {
+		return
+	}
+	v.visited[a] = true
+	if s := name(a.GetEntry("S")); forbiddenActions[s] {
+		v.report.Add("Document has a %s action", s)
+	}
+	switch next := pdfgo.Resolve(a.GetEntry("Next")).(type) {
+	case *pdfgo.DictionaryObject:
+		v.action(next)
+	case *pdfgo.ArrayObject:
+		for _, n := range next.Array {
+			v.action(n)
+		}
+	}
+}
+
+// attachments checks embedded files have the media type, modification date, and relationship to the document required by PDF/A-3.
+func (v *validator) attachments(p *pdfgo.PDF) {
+	attachments, err := p.GetAttachments()
+	if err != nil {
+		v.report.Add("Embedded file can't be read: %s", err)
+	}
+	for _, page := range p.GetPages() {
+		a, err := pdfgo.PageAttachments(page)
+		if err != nil {
+			v.report.Add("Embedded file can't be read: %s", err)
+		}
+		attachments = append(attachments, a...)
+	}
+	for _, a := range attachments {
+		if a.MimeType == "" {
+			v.report.Add("Embedded file %s has no media type", a.Name)
+		}
+		if a.Modified.IsZero() {
+			v.report.Add("Embedded file %s has no modification date", a.Name)
+		}
+		if a.Relationship == "" {
+			v.report.Add("Embedded file %s has no relationship to the document", a.Name)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pdfa
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Namespaces of the XMP properties used by PDF/A
+const (
+	NAMESPACE_RDF            = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+	NAMESPACE_DC             = "http://purl.org/dc/elements/1.1/"
+	NAMESPACE_PDF            = "http://ns.adobe.com/pdf/1.3/"
+	NAMESPACE_XMP            = "http://ns.adobe.com/xap/1.0/"
+	NAMESPACE_PDFAID         = "http://www.aiim.org/pdfa/ns/id/"
+	NAMESPACE_PDFA_EXTENSION = "http://www.aiim.org/pdfa/ns/extension/"
+	NAMESPACE_PDFA_SCHEMA    = "http://www.aiim.org/pdfa/ns/schema#"
+	NAMESPACE_PDFA_PROPERTY  = "http://www.aiim.org/pdfa/ns/property#"
+)
+
+// Metadata describes a document, and is written both as XMP metadata and in the Document Information Dictionary.
+type Metadata struct {
+	Title    string
+	Author   string
+	Subject  string
+	Keywords string
+	// Creator is the application which created the original content
+	Creator string
+	// Producer is the application which produced the PDF
+	Producer string
+	Created  time.Time
+	Modified time.Time
+	// Extensions hold properties of schemas which are not predefined by XMP, and must be described for PDF/A
+	Extensions []*Extension
+}
+
+// Extension is an XMP schema which isn't predefined, with the values of its properties.
+type Extension struct {
+	// Schema is a description of the schema
+	Schema    string
+	Namespace string
+	Prefix    string
+	// Properties are written in order
+	Properties []*Property
+}
+
+// Property is a property of an extension schema, with its value.
+type Property struct {
+	Name string
+	// ValueType is the XMP type of the value, such as Text
+	ValueType string
+	// Category is internal if the value is derived from the document, or external otherwise
+	Category    string
+	Description string
+	Value       string
+}
+
+// XMP returns an XMP packet holding the metadata and the PDF/A identification of the given part and conformance level.
+func (m *Metadata) XMP(part int, conformance string) []byte {
+	var b bytes.Buffer
+	b.WriteString("<?xpacket begin=\"\xEF\xBB\xBF\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n")
+	b.WriteString("<x:xmpmeta xmlns:x=\"adobe:ns:meta/\">\n")
+	b.WriteString("<rdf:RDF xmlns:rdf=\"" + NAMESPACE_RDF + "\">\n")
+
+	description := func(prefix, namespace string, properties func()) {
+		b.WriteString("<rdf:Description rdf:about=\"\" xmlns:" + prefix + "=\"" + namespace + "\">\n")
+		properties()
+		b.WriteString("</rdf:Description>\n")
+	}
+	element := func(name, value string) {
+		b.WriteString("<" + name + ">")
+		xml.EscapeText(&b, []byte(value))
+		b.WriteString("</" + name + ">\n")
+	}
+
+	description("pdfaid", NAMESPACE_PDFAID, func() {
+		element("pdfaid:part", strconv.Itoa(part))
+		element("pdfaid:conformance", conformance)
+	})
+	description("dc", NAMESPACE_DC, func() {
+		b.WriteString("<dc:format>application/pdf</dc:format>\n")
+		if m.Title != "" {
+			b.WriteString("<dc:title><rdf:Alt><rdf:li xml:lang=\"x-default\">")
+			xml.EscapeText(&b, []byte(m.Title))
+			b.WriteString("</rdf:li></rdf:Alt></dc:title>\n")
+		}
+		if m.Author != "" {
+			b.WriteString("<dc:creator><rdf:Seq><rdf:li>")
+			xml.EscapeText(&b, []byte(m.Author))
+			b.WriteString("</rdf:li></rdf:Seq></dc:creator>\n")
+		}
+		if m.Subject != "" {
+			b.WriteString("<dc:description><rdf:Alt><rdf:li xml:lang=\"x-default\">")
+			xml.EscapeText(&b, []byte(m.Subject))
+			b.WriteString("</rdf:li></rdf:Alt></dc:description>\n")
+		}
+	})
+	description("pdf", NAMESPACE_PDF, func() {
+		if m.Keywords != "" {
+			element("pdf:Keywords", m.Keywords)
+		}
+		if m.Producer != "" {
+			element("pdf:Producer", m.Producer)
+		}
+	})
+	description("xmp", NAMESPACE_XMP, func() {
+		if m.Creator != "" {
+			element("xmp:CreatorTool", m.Creator)
+		}
+		if !m.Created.IsZero() {
+			element("xmp:CreateDate", m.Created.Format(time.RFC3339))
+		}
+		if !m.Modified.IsZero() {
+			element("xmp:ModifyDate", m.Modified.Format(time.RFC3339))
+			element("xmp:MetadataDate", m.Modified.Format(time.RFC3339))
+		}
+	})
+	for _, e := range m.Extensions {
+		description(e.Prefix, e.Namespace, func() {
+			for _, p := range e.Properties {
+				element(e.Prefix+":"+p.Name, p.Value)
+			}
+		})
+	}
+	if len(m.Extensions) > 0 {
+		b.WriteString("<rdf:Description rdf:about=\"\" xmlns:pdfaExtension=\"" + NAMESPACE_PDFA_EXTENSION + "\" xmlns:pdfaSchema=\"" + NAMESPACE_PDFA_SCHEMA + "\" xmlns:pdfaProperty=\"" + NAMESPACE_PDFA_PROPERTY + "\">\n")
+		b.WriteString("<pdfaExtension:schemas><rdf:Bag>\n")
+		for _, e := range m.Extensions {
+			b.WriteString("<rdf:li rdf:parseType=\"Resource\">\n")
+			element("pdfaSchema:schema", e.Schema)
+			element("pdfaSchema:namespaceURI", e.Namespace)
+			element("pdfaSchema:prefix", e.Prefix)
+			b.WriteString("<pdfaSchema:property><rdf:Seq>\n")
+			for _, p := range e.Properties {
+				b.WriteString("<rdf:li rdf:parseType=\"Resource\">\n")
+				element("pdfaProperty:name", p.Name)
+				element("pdfaProperty:valueType", p.ValueType)
+				element("pdfaProperty:category", p.Category)
+				element("pdfaProperty:description", p.Description)
+				b.WriteString("</rdf:li>\n")
+			}
+			b.WriteString("</rdf:Seq></pdfaSchema:property>\n")
+			b.WriteString("</rdf:li>\n")
+		}
+		b.WriteString("</rdf:Bag></pdfaExtension:schemas>\n")
+		b.WriteString("</rdf:Description>\n")
+	}
+	b.WriteString("</rdf:RDF>\n")
+	b.WriteString("</x:xmpmeta>\n")
+	// Padding allows the packet to be edited in place
+	b.WriteString(strings.Repeat(strings.Repeat(" ", 99)+"\n", 20))
+	b.WriteString("<?xpacket end=\"w\"?>")
+	return b.Bytes()
+}
+
+// Properties maps the namespace and name of each property of an XMP packet, separated by a space, to its values.
+// Arrays, such as the creators, have a value for each item, as do properties of structures in arrays, such as the schemas of extensions.
+type Properties map[string][]string
+
+// Get returns the first value of the given property, and whether it was present.
+func (p Properties) Get(namespace, name string) (string, bool) {
+	v, ok := p[namespace+" "+name]
+	if !ok {
+		return "", false
+	}
+	return v[0], true
+}
+
+// ParseXMP reads the properties of the given XMP packet, which may be given as elements or as attributes of descriptions.
+func ParseXMP(data []byte) (Properties, error) {
+	properties := make(Properties)
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	// The stack holds the properties enclosing the current element
+	var stack []xml.Name
+	var text strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Space == NAMESPACE_RDF && t.Name.Local == "Description" {
+				for _, a := range t.Attr {
+					if a.Name.Space != NAMESPACE_RDF && a.Name.Space != "xmlns" && a.Name.Space != "" {
+						key := a.Name.Space + " " + a.Name.Local
+						properties[key] = append(properties[key], a.Value)
+					}
+				}
+			}
+			stack = append(stack, t.Name)
+			text.Reset()
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+			value := strings.TrimSpace(text.String())
+			text.Reset()
+			if value == "" {
+				continue
+			}
+			// Find the property, skipping the containers of arrays
+			name := t.Name
+			for i := len(stack); name.Space == NAMESPACE_RDF && i > 0; i-- {
+				name = stack[i-1]
+			}
+			if name.Space == NAMESPACE_RDF || name.Space == "" {
+				continue
+			}
+			key := name.Space + " " + name.Local
+			properties[key] = append(properties[key], value)
+		}
+	}
+	return properties, nil
+}
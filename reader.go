@@ -0,0 +1,644 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pdfgo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+)
+
+var ErrEncrypted = errors.New("Encrypted PDFs are not supported")
+
+// Maximum number of free entries at the end of the cross reference table that are reserved when reading
+const MAX_FREE_ENTRIES = 10000
+
+type xrefEntry struct {
+	Offset     int
+	Generation int
+	// Stream is the number of the object stream containing a compressed object, or zero
+	Stream int
+	Index  int
+}
+
+type reader struct {
+	data    []byte
+	header  int
+	version string
+	// binary is true if the header is followed by a comment of non-ASCII bytes
+	binary  bool
+	xref    map[int]*xrefEntry
+	trailer *DictionaryObject
+	objects map[int]Object
+	loading map[int]bool
+	report  *RepairReport
+	// renumbered maps corrupt object numbers to those assigned when building
+	renumbered map[int]int
+}
+
+// ReadPDF parses the given data, repairing the document if necessary, and logs any repairs made.
+func ReadPDF(data []byte) (*PDF, error) {
+	p, report, err := RepairPDF(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range report.Messages {
+		log.Println("Repaired:", m)
+	}
+	return p, nil
+}
+
+// RepairPDF parses the given data using the cross reference table, falling back to scanning the file for objects when the table is missing or broken.
+// The returned report describes what was repaired, if anything, so a clean copy can be written with PDF.Write.
+func RepairPDF(data []byte) (*PDF, *RepairReport, error) {
+	r := &reader{
+		data:    data,
+		objects: make(map[int]Object),
+		loading: make(map[int]bool),
+		report:  &RepairReport{},
+	}
+	r.readHeader()
+	if err := r.readCrossReference(); err != nil {
+		r.report.add("Rebuilt cross reference table: %s", err)
+		r.report.RebuiltCrossReference = true
+		r.scan()
+	} else if err := r.loadAll(); err != nil {
+		r.report.add("Rebuilt cross reference table: %s", err)
+		r.report.RebuiltCrossReference = true
+		r.objects = make(map[int]Object)
+		r.scan()
+	}
+	if r.trailer != nil && r.trailer.HasEntry("Encrypt") {
+		return nil, nil, ErrEncrypted
+	}
+	p, err := r.build()
+	if err != nil {
+		return nil, nil, err
+	}
+	return p, r.report, nil
+}
+
+func (r *reader) readHeader() {
+	r.version = "1.7"
+	limit := len(r.data)
+	if limit > 1024 {
+		limit = 1024
+	}
+	i := bytes.Index(r.data[:limit], []byte("%PDF-"))
+	if i < 0 {
+		r.report.add("Missing header")
+		return
+	}
+	if i > 0 {
+		r.report.add("Ignored %d bytes before header", i)
+	}
+	r.header = i
+	start := i + len("%PDF-")
+	end := start
+	for end < len(r.data) && (r.data[end] == '.' || (r.data[end] >= '0' && r.data[end] <= '9')) {
+		end++
+	}
+	if end > start {
+		r.version = string(r.data[start:end])
+	}
+	for end < len(r.data) && (r.data[end] == '\r' || r.data[end] == '\n') {
+		end++
+	}
+	if end < len(r.data) && r.data[end] == '%' {
+		count := 0
+		for end++; end < len(r.data) && r.data[end] != '\r' && r.data[end] != '\n'; end++ {
+			if r.data[end] > 127 {
+				count++
+			}
+		}
+		r.binary = count >= 4
+	}
+}
+
+// startxref returns the offset of the last cross reference section.
+func (r *reader) startxref() (int, error) {
+	i := bytes.LastIndex(r.data, []byte("startxref"))
+	if i < 0 {
+		return 0, errors.New("Missing startxref")
+	}
+	l := NewLexer(r.data)
+	l.Offset = i + len("startxref")
+	t, err := l.Next()
+	if err != nil {
+		return 0, err
+	}
+	offset, err := strconv.Atoi(t.Value)
+	if err != nil || t.Kind != TokenNumber {
+		return 0, fmt.Errorf("Invalid startxref: %s", t.Value)
+	}
+	return offset, nil
+}
+
+func (r *reader) readCrossReference() error {
+	offset, err := r.startxref()
+	if err != nil {
+		return err
+	}
+	r.xref = make(map[int]*xrefEntry)
+	visited := make(map[int]bool)
+	for {
+		if offset < 0 || offset >= len(r.data) {
+			return fmt.Errorf("Invalid cross reference offset: %d", offset)
+		}
+		if visited[offset] {
+			return fmt.Errorf("Loop in cross reference sections at %d", offset)
+		}
+		visited[offset] = true
+		trailer, err := r.readCrossReferenceSection(offset)
+		if err != nil {
+			return err
+		}
+		if r.trailer == nil {
+			r.trailer = trailer
+		}
+		// Hybrid files keep compressed objects in a stream referenced from the trailer
+		if s, ok := trailer.GetEntry("XRefStm").(*NumberObject); ok && !visited[int(s.Number)] {
+			visited[int(s.Number)] = true
+			if _, err := r.readCrossReferenceSection(int(s.Number)); err != nil {
+				return err
+			}
+		}
+		previous, ok := trailer.GetEntry("Prev").(*NumberObject)
+		if !ok {
+			break
+		}
+		offset = int(previous.Number)
+	}
+	if !r.trailer.HasEntry("Root") {
+		return errors.New("Trailer missing Root")
+	}
+	return nil
+}
+
+func (r *reader) readCrossReferenceSection(offset int) (*DictionaryObject, error) {
+	p := NewParser(r.data)
+	p.Offset = offset
+	t, err := p.Peek()
+	if err != nil {
+		return nil, err
+	}
+	if t.Kind == TokenKeyword && t.Value == "xref" {
+		p.Next()
+		return r.readCrossReferenceTable(p)
+	}
+	if t.Kind == TokenNumber {
+		return r.readCrossReferenceStream(p)
+	}
+	return nil, fmt.Errorf("Expected cross reference at %d", offset)
+}
+
+func (r *reader) readCrossReferenceTable(p *Parser) (*DictionaryObject, error) {
+	for {
+		t, err := p.Next()
+		if err != nil {
+			return nil, err
+		}
+		if t.Kind == TokenKeyword && t.Value == "trailer" {
+			break
+		}
+		c, err := p.Next()
+		if err != nil {
+			return nil, err
+		}
+		start, err1 := strconv.Atoi(t.Value)
+		count, err2 := strconv.Atoi(c.Value)
+		if err1 != nil || err2 != nil {
+			return nil, fmt.Errorf("Invalid cross reference subsection at %d", t.Offset)
+		}
+		for i := 0; i < count; i++ {
+			o, err := p.Next()
+			if err != nil {
+				return nil, err
+			}
+			g, err := p.Next()
+			if err != nil {
+				return nil, err
+			}
+			k, err := p.Next()
+			if err != nil {
+				return nil, err
+			}
+			offset, err1 := strconv.Atoi(o.Value)
+			generation, err2 := strconv.Atoi(g.Value)
+			if err1 != nil || err2 != nil || (k.Value != "n" && k.Value != "f") {
+				return nil, fmt.Errorf("Invalid cross reference entry at %d", o.Offset)
+			}
+			number := start + i
+			if _, ok := r.xref[number]; ok {
+				// Later sections take precedence
+				continue
+			}
+			if k.Value == "f" {
+				r.xref[number] = nil
+			} else {
+				r.xref[number] = &xrefEntry{
+					Offset:     offset + r.header,
+					Generation: generation,
+				}
+			}
+		}
+	}
+	o, err := p.ParseObject()
+	if err != nil {
+		return nil, err
+	}
+	trailer, ok := o.(*DictionaryObject)
+	if !ok {
+		return nil, errors.New("Invalid trailer")
+	}
+	return trailer, nil
+}
+
+func (r *reader) readCrossReferenceStream(p *Parser) (*DictionaryObject, error) {
+	p.Resolve = r.resolve
+	_, _, o, err := p.ParseIndirectObject()
+	if err != nil {
+		return nil, err
+	}
+	s, ok := o.(*StreamObject)
+	if !ok {
+		return nil, errors.New("Invalid cross reference stream")
+	}
+	data, err := s.Decode()
+	if err != nil {
+		return nil, err
+	}
+	var widths []int
+	if w, ok := s.GetEntry("W").(*ArrayObject); ok {
+		for _, e := range w.Array {
+			if n, ok := e.(*NumberObject); ok {
+				widths = append(widths, int(n.Number))
+			}
+		}
+	}
+	if len(widths) != 3 {
+		return nil, errors.New("Invalid cross reference stream widths")
+	}
+	for _, w := range widths {
+		if w < 0 || w > 8 {
+			return nil, errors.New("Invalid cross reference stream widths")
+		}
+	}
+	var index []int
+	if i, ok := s.GetEntry("Index").(*ArrayObject); ok {
+		for _, e := range i.Array {
+			if n, ok := e.(*NumberObject); ok {
+				index = append(index, int(n.Number))
+			}
+		}
+	} else if size, ok := s.GetEntry("Size").(*NumberObject); ok {
+		index = []int{0, int(size.Number)}
+	}
+	field := func(data []byte, width, fallback int) int {
+		if width == 0 {
+			return fallback
+		}
+		v := 0
+		for _, b := range data[:width] {
+			v = v<<8 | int(b)
+		}
+		return v
+	}
+	entry := widths[0] + widths[1] + widths[2]
+	if entry == 0 {
+		return nil, errors.New("Invalid cross reference stream widths")
+	}
+	// The subsections can't hold more entries than the data
+	count := 0
+	for i := 0; i+1 < len(index); i += 2 {
+		if index[i] < 0 || index[i+1] < 0 {
+			return nil, errors.New("Invalid cross reference stream index")
+		}
+		count += index[i+1]
+		if count > len(data)/entry {
+			return nil, errors.New("Truncated cross reference stream")
+		}
+	}
+	position := 0
+	for i := 0; i+1 < len(index); i += 2 {
+		for number := index[i]; number < index[i]+index[i+1]; number++ {
+			if position+entry > len(data) {
+				return nil, errors.New("Truncated cross reference stream")
+			}
+			row := data[position : position+entry]
+			position += entry
+			kind := field(row, widths[0], 1)
+			second := field(row[widths[0]:], widths[1], 0)
+			third := field(row[widths[0]+widths[1]:], widths[2], 0)
+			if _, ok := r.xref[number]; ok {
+				continue
+			}
+			switch kind {
+			case 0:
+				r.xref[number] = nil
+			case 1:
+				r.xref[number] = &xrefEntry{
+					Offset:     second + r.header,
+					Generation: third,
+				}
+			case 2:
+				r.xref[number] = &xrefEntry{
+					Stream: second,
+					Index:  third,
+				}
+			}
+		}
+	}
+	return &s.DictionaryObject, nil
+}
+
+// resolve loads the given object on demand, returning nil if it cannot be loaded.
+func (r *reader) resolve(number, generation int) Object {
+	if o, ok := r.objects[number]; ok {
+		return o
+	}
+	if r.loading[number] {
+		return nil
+	}
+	r.loading[number] = true
+	defer delete(r.loading, number)
+	o, err := r.load(number)
+	if err != nil {
+		return nil
+	}
+	return o
+}
+
+func (r *reader) load(number int) (Object, error) {
+	if o, ok := r.objects[number]; ok {
+		return o, nil
+	}
+	e := r.xref[number]
+	if e == nil {
+		return nil, nil
+	}
+	if e.Stream != 0 {
+		return r.loadCompressed(number, e)
+	}
+	if e.Offset < 0 || e.Offset >= len(r.data) {
+		return nil, fmt.Errorf("Object %d offset out of range: %d", number, e.Offset)
+	}
+	p := NewParser(r.data)
+	p.Offset = e.Offset
+	p.Resolve = r.resolve
+	n, _, o, err := p.ParseIndirectObject()
+	if err != nil {
+		return nil, fmt.Errorf("Object %d: %s", number, err)
+	}
+	if n != number {
+		return nil, fmt.Errorf("Object %d offset points to object %d", number, n)
+	}
+	if p.Repaired {
+		r.report.add("Recovered length of stream in object %d", number)
+		r.report.RecoveredStreamLengths = append(r.report.RecoveredStreamLengths, number)
+	}
+	r.objects[number] = o
+	return o, nil
+}
+
+func (r *reader) loadCompressed(number int, e *xrefEntry) (Object, error) {
+	s, ok := r.resolve(e.Stream, 0).(*StreamObject)
+	if !ok {
+		return nil, fmt.Errorf("Object %d in missing object stream %d", number, e.Stream)
+	}
+	objects, err := r.readObjectStream(s)
+	if err != nil {
+		return nil, err
+	}
+	for n, o := range objects {
+		if _, ok := r.objects[n]; ok {
+			continue
+		}
+		if x := r.xref[n]; x != nil && x.Stream == e.Stream {
+			r.objects[n] = o
+		}
+	}
+	o, ok := objects[number]
+	if !ok {
+		return nil, fmt.Errorf("Object %d missing from object stream %d", number, e.Stream)
+	}
+	r.objects[number] = o
+	return o, nil
+}
+
+func (r *reader) readObjectStream(s *StreamObject) (map[int]Object, error) {
+	data, err := s.Decode()
+	if err != nil {
+		return nil, err
+	}
+	count, ok1 := Resolve(s.GetEntry("N")).(*NumberObject)
+	first, ok2 := Resolve(s.GetEntry("First")).(*NumberObject)
+	if !ok1 || !ok2 {
+		return nil, errors.New("Invalid object stream")
+	}
+	p := NewParser(data)
+	var offsets [][2]int
+	for i := 0; i < int(count.Number); i++ {
+		n, err := p.Next()
+		if err != nil {
+			return nil, err
+		}
+		o, err := p.Next()
+		if err != nil {
+			return nil, err
+		}
+		number, err1 := strconv.Atoi(n.Value)
+		offset, err2 := strconv.Atoi(o.Value)
+		if err1 != nil || err2 != nil {
+			return nil, errors.New("Invalid object stream header")
+		}
+		offsets = append(offsets, [2]int{number, offset})
+	}
+	objects := make(map[int]Object)
+	for _, o := range offsets {
+		p.Offset = int(first.Number) + o[1]
+		object, err := p.ParseObject()
+		if err != nil {
+			return nil, fmt.Errorf("Object %d: %s", o[0], err)
+		}
+		object.SetName(o[0])
+		objects[o[0]] = object
+	}
+	return objects, nil
+}
+
+func (r *reader) loadAll() error {
+	for number, e := range r.xref {
+		if e == nil || number == 0 {
+			continue
+		}
+		if _, err := r.load(number); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *reader) build() (*PDF, error) {
+	if err := r.findCatalog(); err != nil {
+		return nil, err
+	}
+	var numbers []int
+	for n := range r.objects {
+		numbers = append(numbers, n)
+	}
+	sort.Ints(numbers)
+	// Numbers far beyond the count of objects are corrupt, so are renumbered rather than reserving space for every number below them
+	limit := len(numbers) + MAX_FREE_ENTRIES
+	max := 0
+	for _, n := range numbers {
+		if n <= limit {
+			max = n
+		}
+	}
+	r.renumbered = make(map[int]int)
+	for _, n := range numbers {
+		if n > limit && r.objects[n] != nil {
+			max++
+			r.report.add("Renumbered object %d to %d", n, max)
+			r.renumbered[n] = max
+			r.objects[n].SetName(max)
+			r.objects[max] = r.objects[n]
+		}
+	}
+	// Reserve numbers up to the trailer's Size so objects added later don't reuse free entries
+	if size, ok := Resolve(r.trailer.GetEntry("Size")).(*NumberObject); ok && int(size.Number)-1 > max && int(size.Number)-1 <= max+MAX_FREE_ENTRIES {
+		max = int(size.Number) - 1
+	}
+	p := &PDF{
+		Version:     r.version,
+		Annotations: &ArrayObject{},
+		Objects:     make([]Object, max),
+		Binary:      r.binary,
+	}
+	// Keep the original object numbers, filling any gaps with null
+	for n := 1; n <= max; n++ {
+		o, ok := r.objects[n]
+		if !ok || o == nil || isCrossReferenceObject(o) {
+			o = &NullObject{}
+			o.SetName(n)
+		}
+		p.Objects[n-1] = o
+	}
+	for _, o := range p.Objects {
+		r.link(p, o)
+	}
+	r.link(p, r.trailer)
+	catalog, ok := Resolve(r.trailer.GetEntry("Root")).(*DictionaryObject)
+	if !ok {
+		return nil, errors.New("Missing Catalog")
+	}
+	p.Catalog = catalog
+	if info, ok := r.trailer.GetEntry("Info").(*ObjectReference); ok {
+		if _, ok := info.Object.(*DictionaryObject); ok {
+			p.Info = info
+		}
+	}
+	if id, ok := Resolve(r.trailer.GetEntry("ID")).(*ArrayObject); ok {
+		p.ID = id
+	}
+	if v, ok := Resolve(catalog.GetEntry("Version")).(*NameObject); ok && v.Name > p.Version {
+		p.Version = v.Name
+	}
+	if err := r.findPages(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func isCrossReferenceObject(o Object) bool {
+	if s, ok := o.(*StreamObject); ok {
+		if t, ok := s.GetEntry("Type").(*NameObject); ok {
+			return t.Name == "XRef" || t.Name == "ObjStm"
+		}
+	}
+	return false
+}
+
+// link replaces the unresolved targets of references with the objects they refer to.
+func (r *reader) link(p *PDF, o Object) {
+	replace := func(v Object) Object {
+		ref, ok := v.(*ObjectReference)
+		if !ok {
+			r.link(p, v)
+			return v
+		}
+		u, ok := ref.Object.(*UnresolvedObject)
+		if !ok {
+			return v
+		}
+		n := u.GetName()
+		if m, ok := r.renumbered[n]; ok {
+			n = m
+		}
+		if n < 1 || n > len(p.Objects) {
+			// References to missing objects are treated as null
+			return &NullObject{}
+		}
+		ref.Object = p.Objects[n-1]
+		return ref
+	}
+	switch v := o.(type) {
+	case *ArrayObject:
+		for i, e := range v.Array {
+			v.Array[i] = replace(e)
+		}
+	case *DictionaryObject:
+		for _, k := range v.Keys {
+			v.Dictionary[k] = replace(v.Dictionary[k])
+		}
+	case *StreamObject:
+		r.link(p, &v.DictionaryObject)
+	}
+}
+
+func (r *reader) findPages(p *PDF) error {
+	ref, ok := p.Catalog.GetEntry("Pages").(*ObjectReference)
+	if !ok {
+		return errors.New("Catalog missing Pages")
+	}
+	pages, ok := ref.Object.(*DictionaryObject)
+	if !ok {
+		return errors.New("Invalid Pages")
+	}
+	p.PagesReference = ref
+	switch kids := Resolve(pages.GetEntry("Kids")).(type) {
+	case *ArrayObject:
+		p.Pages = kids
+	default:
+		p.Pages = &ArrayObject{}
+		pages.SetNameObjectEntry("Kids", p.Pages)
+		r.report.add("Added missing Kids to Pages")
+	}
+	switch count := Resolve(pages.GetEntry("Count")).(type) {
+	case *NumberObject:
+		p.PageCount = count
+	default:
+		p.PageCount = &NumberObject{Number: float64(len(p.Pages.Array))}
+		pages.SetNameObjectEntry("Count", p.PageCount)
+		r.report.add("Added missing Count to Pages")
+	}
+	return nil
+}
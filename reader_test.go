@@ -0,0 +1,147 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pdfgo_test
+
+import (
+	"bytes"
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func newTestPDF(t *testing.T) []byte {
+	t.Helper()
+	p := pdfgo.NewPDF()
+	contents := p.NewStreamObject()
+	contents.Data = []byte("0 0 m\n400 600 l h S")
+	p.AddPage(400, 600, nil, pdfgo.NewObjectReference(contents))
+	var buffer bytes.Buffer
+	assert.Nil(t, p.Write(&buffer))
+	return buffer.Bytes()
+}
+
+func TestRepairPDF_valid(t *testing.T) {
+	p, report, err := pdfgo.RepairPDF(newTestPDF(t))
+	assert.Nil(t, err)
+	assert.False(t, report.Repaired())
+	assert.Equal(t, "1.7", p.Version)
+	assert.Equal(t, 1., p.PageCount.Number)
+	page := pdfgo.Resolve(p.Pages.Array[0]).(*pdfgo.DictionaryObject)
+	contents := pdfgo.Resolve(page.GetEntry("Contents")).(*pdfgo.StreamObject)
+	assert.Equal(t, "0 0 m\n400 600 l h S", string(contents.Data))
+}
+
+func TestRepairPDF_badOffsets(t *testing.T) {
+	data := newTestPDF(t)
+	// Shift every object by inserting a comment after the header
+	data = append(append(append([]byte{}, data[:9]...), []byte("%junk\n")...), data[9:]...)
+	p, report, err := pdfgo.RepairPDF(data)
+	assert.Nil(t, err)
+	assert.True(t, report.RebuiltCrossReference)
+	assert.Equal(t, 1., p.PageCount.Number)
+}
+
+func TestRepairPDF_truncated(t *testing.T) {
+	data := newTestPDF(t)
+	data = data[:bytes.Index(data, []byte("xref"))]
+	p, report, err := pdfgo.RepairPDF(data)
+	assert.Nil(t, err)
+	assert.True(t, report.RebuiltCrossReference)
+	assert.True(t, report.RecoveredTrailer)
+	assert.Equal(t, 1., p.PageCount.Number)
+
+	// Rewrite and read back cleanly
+	var buffer bytes.Buffer
+	assert.Nil(t, p.Write(&buffer))
+	_, report, err = pdfgo.RepairPDF(buffer.Bytes())
+	assert.Nil(t, err)
+	assert.False(t, report.Repaired())
+}
+
+func TestRepairPDF_missingCatalog(t *testing.T) {
+	data := newTestPDF(t)
+	data = []byte(strings.Replace(string(data[:bytes.Index(data, []byte("xref"))]), "/Type /Catalog", "/Type /Broken ", 1))
+	p, report, err := pdfgo.RepairPDF(data)
+	assert.Nil(t, err)
+	assert.True(t, report.RecoveredCatalog)
+	assert.Equal(t, 1., p.PageCount.Number)
+}
+
+func TestRepairPDF_streamLength(t *testing.T) {
+	data := newTestPDF(t)
+	data = bytes.Replace(data, []byte("/Length 19"), []byte("/Length 99"), 1)
+	p, report, err := pdfgo.RepairPDF(data)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{3}, report.RecoveredStreamLengths)
+	contents := p.Objects[2].(*pdfgo.StreamObject)
+	assert.Equal(t, "0 0 m\n400 600 l h S", string(contents.Data))
+}
+
+func TestRepairPDF_invalidStreamWidths(t *testing.T) {
+	data := newTestPDF(t)
+	root := regexp.MustCompile(`/Root \d+ 0 R`).Find(data)
+	data = data[:bytes.Index(data, []byte("xref"))]
+	offset := len(data)
+	data = append(data, []byte("9 0 obj\n<< /Type /XRef /Size 10 /W [1 -1 2] "+string(root)+" /Length 4 >>\nstream\n\x01\x00\x0a\x00\nendstream\nendobj\nstartxref\n"+strconv.Itoa(offset)+"\n%%EOF\n")...)
+	p, report, err := pdfgo.RepairPDF(data)
+	assert.Nil(t, err)
+	assert.True(t, report.RebuiltCrossReference)
+	assert.Contains(t, report.Messages[0], "Invalid cross reference stream widths")
+	assert.Equal(t, 1., p.PageCount.Number)
+}
+
+func TestRepairPDF_zeroStreamWidths(t *testing.T) {
+	data := newTestPDF(t)
+	root := regexp.MustCompile(`/Root \d+ 0 R`).Find(data)
+	data = data[:bytes.Index(data, []byte("xref"))]
+	offset := len(data)
+	data = append(data, []byte("9 0 obj\n<< /Type /XRef /Size 2000000000 /W [0 0 0] "+string(root)+" /Length 4 >>\nstream\n\x01\x00\x0a\x00\nendstream\nendobj\nstartxref\n"+strconv.Itoa(offset)+"\n%%EOF\n")...)
+	p, report, err := pdfgo.RepairPDF(data)
+	assert.Nil(t, err)
+	assert.True(t, report.RebuiltCrossReference)
+	assert.Contains(t, report.Messages[0], "Invalid cross reference stream widths")
+	assert.Equal(t, 1., p.PageCount.Number)
+}
+
+func TestRepairPDF_oversizedStreamIndex(t *testing.T) {
+	data := newTestPDF(t)
+	root := regexp.MustCompile(`/Root \d+ 0 R`).Find(data)
+	data = data[:bytes.Index(data, []byte("xref"))]
+	offset := len(data)
+	data = append(data, []byte("9 0 obj\n<< /Type /XRef /Size 2000000000 /W [1 2 1] "+string(root)+" /Length 4 >>\nstream\n\x01\x00\x0a\x00\nendstream\nendobj\nstartxref\n"+strconv.Itoa(offset)+"\n%%EOF\n")...)
+	p, report, err := pdfgo.RepairPDF(data)
+	assert.Nil(t, err)
+	assert.True(t, report.RebuiltCrossReference)
+	assert.Contains(t, report.Messages[0], "Truncated cross reference stream")
+	assert.Equal(t, 1., p.PageCount.Number)
+}
+
+func TestRepairPDF_corruptObjectNumber(t *testing.T) {
+	data := newTestPDF(t)
+	data = data[:bytes.Index(data, []byte("xref"))]
+	data = regexp.MustCompile(`\b3 0 (obj|R)`).ReplaceAll(data, []byte("99999999 0 $1"))
+	p, report, err := pdfgo.RepairPDF(data)
+	assert.Nil(t, err)
+	assert.Less(t, len(p.Objects), 10)
+	assert.Contains(t, report.Messages, "Renumbered object 99999999 to 5")
+	page := pdfgo.Resolve(p.Pages.Array[0]).(*pdfgo.DictionaryObject)
+	contents := pdfgo.Resolve(page.GetEntry("Contents")).(*pdfgo.StreamObject)
+	assert.Equal(t, "0 0 m\n400 600 l h S", string(contents.Data))
+}
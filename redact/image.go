@@ -0,0 +1,182 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package redact
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/AletheiaWareLLC/pdfgo/content"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"log"
+)
+
+// redactImage returns a copy of the given image XObject with the pixels which overlap the areas cleared, or nil if the image cannot be decoded.
+// The image is drawn with the given transformation from image space to page space.
+func (r *redactor) redactImage(s *pdfgo.StreamObject, ctm content.Matrix, resources *pdfgo.DictionaryObject) *pdfgo.StreamObject {
+	width := int(pdfgo.NumberValue(s.GetEntry("Width")))
+	height := int(pdfgo.NumberValue(s.GetEntry("Height")))
+	if width <= 0 || height <= 0 {
+		return nil
+	}
+	samples, components, bits, colourSpace, err := decodeSamples(s, resources)
+	if err != nil {
+		log.Println("Cannot decode image:", err)
+		return nil
+	}
+	stride := (width*components*bits + 7) / 8
+	if len(samples) < stride*height {
+		log.Println("Image data too short")
+		return nil
+	}
+	size := components * bits
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			// Image space maps the first row of samples to the top of the unit square
+			pixel := bounds(content.Matrix{1 / float64(width), 0, 0, 1 / float64(height), float64(x) / float64(width), 1 - float64(y+1)/float64(height)}.Multiply(ctm))
+			if r.intersects(pixel) {
+				clearBits(samples[y*stride:(y+1)*stride], x*size, size)
+			}
+		}
+	}
+	data, err := pdfgo.FlateEncode(samples)
+	if err != nil {
+		log.Println("Cannot compress image:", err)
+		return nil
+	}
+
+	copied := r.p.NewStreamObject()
+	copyEntries(&copied.DictionaryObject, &s.DictionaryObject, "Length", "Filter", "DecodeParms", "SMask")
+	copied.Data = data
+	copied.SetNameNameEntry("Filter", "FlateDecode")
+	if colourSpace != "" {
+		copied.SetNameNameEntry("ColorSpace", colourSpace)
+		copied.SetNameObjectEntry("BitsPerComponent", &pdfgo.NumberObject{
+			Number: float64(bits),
+		})
+		copied.RemoveEntry("Decode")
+	}
+	// The soft mask is redacted too, as its shape could reveal the content
+	if m, ok := pdfgo.Resolve(s.GetEntry("SMask")).(*pdfgo.StreamObject); ok {
+		if mask := r.redactImage(m, ctm, resources); mask != nil {
+			copied.AddNameObjectEntry("SMask", pdfgo.NewObjectReference(mask))
+		}
+	}
+	return copied
+}
+
+// decodeSamples returns the packed samples of the given image, the number of components and bits per sample, and the name of the colour space of the samples if it differs from that of the image.
+func decodeSamples(s *pdfgo.StreamObject, resources *pdfgo.DictionaryObject) ([]byte, int, int, string, error) {
+	data, err := s.Decode()
+	if err != nil {
+		return nil, 0, 0, "", err
+	}
+	// Unfiltered data is the image's own, which must not be cleared
+	data = append([]byte(nil), data...)
+	if filters := s.Filters(); len(filters) > 0 {
+		switch f := filters[len(filters)-1]; f {
+		case "DCTDecode", "DCT":
+			img, err := jpeg.Decode(bytes.NewReader(data))
+			if err != nil {
+				return nil, 0, 0, "", err
+			}
+			b := img.Bounds()
+			if gray, ok := img.(*image.Gray); ok {
+				var samples []byte
+				for y := b.Min.Y; y < b.Max.Y; y++ {
+					samples = append(samples, gray.Pix[gray.PixOffset(b.Min.X, y):gray.PixOffset(b.Max.X, y)]...)
+				}
+				return samples, 1, 8, "DeviceGray", nil
+			}
+			var samples []byte
+			for y := b.Min.Y; y < b.Max.Y; y++ {
+				for x := b.Min.X; x < b.Max.X; x++ {
+					c := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+					samples = append(samples, c.R, c.G, c.B)
+				}
+			}
+			return samples, 3, 8, "DeviceRGB", nil
+		case "JPXDecode", "CCITTFaxDecode", "CCF", "JBIG2Decode":
+			return nil, 0, 0, "", fmt.Errorf("Unsupported Image Filter: %s", f)
+		}
+	}
+	if b, ok := pdfgo.Resolve(s.GetEntry("ImageMask")).(*pdfgo.BooleanObject); ok && b.Boolean {
+		return data, 1, 1, "", nil
+	}
+	bits := int(pdfgo.NumberValue(s.GetEntry("BitsPerComponent")))
+	components := colourComponents(s.GetEntry("ColorSpace"), resources)
+	if bits <= 0 || components <= 0 {
+		return nil, 0, 0, "", errors.New("Unsupported Image Format")
+	}
+	return data, components, bits, "", nil
+}
+
+// colourComponents returns the number of components in the given colour space, or zero if it is not supported.
+func colourComponents(cs pdfgo.Object, resources *pdfgo.DictionaryObject) int {
+	switch v := pdfgo.Resolve(cs).(type) {
+	case *pdfgo.NameObject:
+		switch v.Name {
+		case "DeviceGray", "G", "CalGray", "Indexed", "I", "Separation":
+			return 1
+		case "DeviceRGB", "RGB", "CalRGB", "Lab":
+			return 3
+		case "DeviceCMYK", "CMYK":
+			return 4
+		}
+		// Named colour spaces are defined in the resources
+		if resources != nil {
+			if spaces, ok := pdfgo.Resolve(resources.GetEntry("ColorSpace")).(*pdfgo.DictionaryObject); ok {
+				return colourComponents(spaces.GetEntry(v.Name), nil)
+			}
+		}
+	case *pdfgo.ArrayObject:
+		if len(v.Array) == 0 {
+			return 0
+		}
+		family, ok := pdfgo.Resolve(v.Array[0]).(*pdfgo.NameObject)
+		if !ok {
+			return 0
+		}
+		switch family.Name {
+		case "ICCBased":
+			if len(v.Array) > 1 {
+				if s, ok := pdfgo.Resolve(v.Array[1]).(*pdfgo.StreamObject); ok {
+					return int(pdfgo.NumberValue(s.GetEntry("N")))
+				}
+			}
+		case "DeviceN":
+			if len(v.Array) > 1 {
+				if names, ok := pdfgo.Resolve(v.Array[1]).(*pdfgo.ArrayObject); ok {
+					return len(names.Array)
+				}
+			}
+		default:
+			return colourComponents(family, nil)
+		}
+	}
+	return 0
+}
+
+// clearBits sets the given number of bits to zero, starting at the given bit of the data.
+func clearBits(data []byte, start, count int) {
+	for i := start; i < start+count; i++ {
+		data[i/8] &^= 0x80 >> uint(i%8)
+	}
+}
@@ -0,0 +1,490 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package redact
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/AletheiaWareLLC/pdfgo/content"
+	"github.com/AletheiaWareLLC/pdfgo/graphics"
+	"github.com/AletheiaWareLLC/pdfgo/text"
+	"log"
+)
+
+// operation identifies a text-showing operation within the page content, or within a form XObject
+type operation struct {
+	form  *pdfgo.StreamObject
+	index int
+}
+
+type redactor struct {
+	p     *pdfgo.PDF
+	areas []*graphics.Rectangle
+	// glyphs holds every glyph shown by each operation which shows a glyph to be removed
+	glyphs map[operation][]*text.Glyph
+	// removed holds the glyphs to be removed, by operation, element, and offset
+	removed map[operation]map[[2]int]bool
+	forms   map[*pdfgo.StreamObject]bool
+	// replaced holds the content streams, forms, and images which were replaced by redacted copies
+	replaced []pdfgo.Object
+}
+
+// Redact removes the text and image content inside the given areas of the page at the given index, then paints the areas with the given colour, or black if nil.
+// Glyphs which overlap an area are removed, image pixels inside an area are cleared, and inline images and images that cannot be decoded are removed if they overlap an area.
+// Form XObjects and images which are changed are copied so that other pages which use them are unaffected, and the originals are freed unless the document still uses them.
+// The document is marked as redacted so that it can only be written in full with Write, which leaves out the removed content, and the page is checked with Verify.
+func Redact(p *pdfgo.PDF, index int, areas []*graphics.Rectangle, colour []float64) error {
+	pages := p.GetPages()
+	if index < 0 || index >= len(pages) {
+		return fmt.Errorf("Page Index Out of Range: %d", index)
+	}
+	page := pages[index]
+	if page.GetName() == 0 {
+		return errors.New("Cannot redact direct page")
+	}
+	r := &redactor{
+		p:       p,
+		areas:   areas,
+		glyphs:  make(map[operation][]*text.Glyph),
+		removed: make(map[operation]map[[2]int]bool),
+		forms:   make(map[*pdfgo.StreamObject]bool),
+	}
+	runs, err := text.ExtractRuns(page)
+	if err != nil {
+		return err
+	}
+	for _, run := range runs {
+		for _, g := range run.Glyphs {
+			o := operation{g.Form, g.Operation}
+			if r.intersects(g.Box) {
+				if r.removed[o] == nil {
+					r.removed[o] = make(map[[2]int]bool)
+				}
+				r.removed[o][[2]int{g.Element, g.Offset}] = true
+			}
+		}
+	}
+	for _, run := range runs {
+		for _, g := range run.Glyphs {
+			o := operation{g.Form, g.Operation}
+			if r.removed[o] != nil {
+				r.glyphs[o] = append(r.glyphs[o], g)
+			}
+		}
+	}
+
+	data, err := pdfgo.PageContents(page)
+	if err != nil {
+		return err
+	}
+	operations, err := content.Parse(data)
+	if err != nil {
+		return err
+	}
+	original := pdfgo.PageResources(page)
+	operations, resources, _, err := r.rewrite(operations, original, nil, content.IdentityMatrix(), 0)
+	if err != nil {
+		return err
+	}
+
+	// Isolate the rewritten content so that the boxes are painted in default user space
+	var buffer bytes.Buffer
+	buffer.WriteString("q\n")
+	if _, err := content.Write(&buffer, operations); err != nil {
+		return err
+	}
+	buffer.WriteString("\nQ\n")
+	writer := graphics.NewContentWriter()
+	writer.SaveState()
+	if colour == nil {
+		writer.SetFillGray(0)
+	} else {
+		writer.SetFillColour(colour)
+	}
+	for _, a := range areas {
+		writer.Rectangle(a.Left, a.Bottom, a.DX(), a.DY())
+	}
+	writer.Fill()
+	writer.RestoreState()
+	boxes, err := writer.Bytes()
+	if err != nil {
+		return err
+	}
+	buffer.Write(boxes)
+
+	r.replaced = append(r.replaced, pdfgo.Resolve(page.GetEntry("Contents")))
+	contents := p.NewStreamObject()
+	contents.Data = buffer.Bytes()
+	page.SetNameObjectEntry("Contents", pdfgo.NewObjectReference(contents))
+	if resources != original {
+		page.SetNameObjectEntry("Resources", resources)
+	}
+	free(p, r.replaced)
+	p.Redacted = true
+	return Verify(p, index, areas)
+}
+
+// Verify returns an error if text extracted from the page at the given index overlaps any of the given areas, once the document is written and read back.
+func Verify(p *pdfgo.PDF, index int, areas []*graphics.Rectangle) error {
+	var buffer bytes.Buffer
+	if err := p.Write(&buffer); err != nil {
+		return err
+	}
+	written, err := pdfgo.ReadPDF(buffer.Bytes())
+	if err != nil {
+		return err
+	}
+	pages := written.GetPages()
+	if index < 0 || index >= len(pages) {
+		return fmt.Errorf("Page Index Out of Range: %d", index)
+	}
+	runs, err := text.ExtractRuns(pages[index])
+	if err != nil {
+		return err
+	}
+	for _, run := range runs {
+		for _, g := range run.Glyphs {
+			for _, a := range areas {
+				if a.Intersects(g.Box) {
+					return fmt.Errorf("Text remains in redacted area: %q", g.Text)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (r *redactor) intersects(box *graphics.Rectangle) bool {
+	for _, a := range r.areas {
+		if a.Intersects(box) {
+			return true
+		}
+	}
+	return false
+}
+
+// rewrite returns the operations with the content inside the areas removed, the resources they use, which are copied if any were changed, and whether anything was removed.
+func (r *redactor) rewrite(operations []*content.Operation, resources *pdfgo.DictionaryObject, form *pdfgo.StreamObject, ctm content.Matrix, depth int) ([]*content.Operation, *pdfgo.DictionaryObject, bool, error) {
+	var stack []content.Matrix
+	var result []*content.Operation
+	xobjects, _ := pdfgo.Resolve(resources.GetEntry("XObject")).(*pdfgo.DictionaryObject)
+	var copied *pdfgo.DictionaryObject
+	// copyXObjects copies the resources so that their XObjects can be changed
+	copyXObjects := func() {
+		if copied == nil {
+			copied = xobjects.Copy()
+			resources = resources.Copy()
+			resources.SetNameObjectEntry("XObject", copied)
+		}
+	}
+	// addXObject adds the given XObject to a copy of the resources, returning its name
+	addXObject := func(s *pdfgo.StreamObject) string {
+		copyXObjects()
+		name := pdfgo.UniqueName(copied, "Redacted")
+		copied.AddNameObjectEntry(name, pdfgo.NewObjectReference(s))
+		return name
+	}
+	changed := false
+	// replaced holds the names of the XObjects which were replaced or removed
+	var replaced []string
+	for i, o := range operations {
+		switch o.Operator {
+		case "q":
+			stack = append(stack, ctm)
+		case "Q":
+			if len(stack) > 0 {
+				ctm = stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+			}
+		case "cm":
+			ctm = content.MatrixOperands(o).Multiply(ctm)
+		case "Tj", "'", "\"", "TJ":
+			key := operation{form, i}
+			if r.removed[key] != nil {
+				result = append(result, r.rewriteText(o, key)...)
+				changed = true
+				continue
+			}
+		case "BI":
+			if r.intersects(bounds(ctm)) {
+				log.Println("Removed Inline Image")
+				changed = true
+				continue
+			}
+		case "Do":
+			if xobjects == nil {
+				break
+			}
+			s, ok := pdfgo.Resolve(xobjects.GetEntry(o.Name(0))).(*pdfgo.StreamObject)
+			if !ok {
+				break
+			}
+			switch subtype(s) {
+			case "Image":
+				if !r.intersects(bounds(ctm)) {
+					break
+				}
+				changed = true
+				r.replaced = append(r.replaced, s)
+				replaced = append(replaced, o.Name(0))
+				image := r.redactImage(s, ctm, resources)
+				if image == nil {
+					log.Println("Removed Image:", o.Name(0))
+					continue
+				}
+				result = append(result, content.NewOperation("Do", &pdfgo.NameObject{
+					Name: addXObject(image),
+				}))
+				continue
+			case "Form":
+				if depth >= text.MAX_FORM_DEPTH || r.forms[s] {
+					break
+				}
+				f, err := r.redactForm(s, resources, ctm, depth)
+				if err != nil {
+					return nil, nil, false, err
+				}
+				if f == nil {
+					break
+				}
+				changed = true
+				r.replaced = append(r.replaced, s)
+				replaced = append(replaced, o.Name(0))
+				result = append(result, content.NewOperation("Do", &pdfgo.NameObject{
+					Name: addXObject(f),
+				}))
+				continue
+			}
+		}
+		result = append(result, o)
+	}
+	if !changed {
+		return operations, resources, false, nil
+	}
+	// Replaced XObjects which are no longer drawn are removed from the resources, so that they can be freed
+	used := make(map[string]bool)
+	for _, o := range result {
+		if o.Operator == "Do" {
+			used[o.Name(0)] = true
+		}
+	}
+	for _, name := range replaced {
+		if !used[name] {
+			copyXObjects()
+			copied.RemoveEntry(name)
+		}
+	}
+	return result, resources, true, nil
+}
+
+// redactForm returns a copy of the given form XObject with the content inside the areas removed, or nil if nothing was removed.
+func (r *redactor) redactForm(f *pdfgo.StreamObject, resources *pdfgo.DictionaryObject, ctm content.Matrix, depth int) (*pdfgo.StreamObject, error) {
+	// Guard against forms which draw themselves
+	r.forms[f] = true
+	defer delete(r.forms, f)
+	data, err := f.Decode()
+	if err != nil {
+		return nil, err
+	}
+	operations, err := content.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	if a, ok := pdfgo.Resolve(f.GetEntry("Matrix")).(*pdfgo.ArrayObject); ok && len(a.Array) == 6 {
+		var m content.Matrix
+		for i, o := range a.Array {
+			if n, ok := pdfgo.Resolve(o).(*pdfgo.NumberObject); ok {
+				m[i] = n.Number
+			}
+		}
+		ctm = m.Multiply(ctm)
+	}
+	if res, ok := pdfgo.Resolve(f.GetEntry("Resources")).(*pdfgo.DictionaryObject); ok {
+		resources = res
+	}
+	rewritten, resources, changed, err := r.rewrite(operations, resources, f, ctm, depth+1)
+	if err != nil || !changed {
+		return nil, err
+	}
+	var buffer bytes.Buffer
+	if _, err := content.Write(&buffer, rewritten); err != nil {
+		return nil, err
+	}
+	s := r.p.NewStreamObject()
+	copyEntries(&s.DictionaryObject, &f.DictionaryObject, "Length", "Filter", "DecodeParms")
+	s.SetNameObjectEntry("Resources", resources)
+	s.Data = buffer.Bytes()
+	return s, nil
+}
+
+// rewriteText returns operations equivalent to the given text-showing operation, with the removed glyphs replaced by adjustments of the same width.
+func (r *redactor) rewriteText(o *content.Operation, key operation) []*content.Operation {
+	var result []*content.Operation
+	var elements []pdfgo.Object
+	switch o.Operator {
+	case "TJ":
+		if a, ok := o.Operands[0].(*pdfgo.ArrayObject); ok {
+			elements = a.Array
+		}
+	case "Tj", "'":
+		elements = o.Operands[:1]
+	case "\"":
+		result = append(result, content.NewOperation("Tw", o.Operands[0]), content.NewOperation("Tc", o.Operands[1]))
+		elements = o.Operands[2:3]
+	}
+	if o.Operator != "Tj" && o.Operator != "TJ" {
+		result = append(result, content.NewOperation("T*"))
+	}
+
+	// Glyphs of a form drawn more than once are listed for each time it is drawn
+	glyphs := make(map[[2]int]*text.Glyph)
+	for _, g := range r.glyphs[key] {
+		glyphs[[2]int{g.Element, g.Offset}] = g
+	}
+	array := &pdfgo.ArrayObject{}
+	adjust := func(n float64) {
+		if last := len(array.Array) - 1; last >= 0 {
+			if v, ok := array.Array[last].(*pdfgo.NumberObject); ok {
+				v.Number += n
+				return
+			}
+		}
+		array.Array = append(array.Array, &pdfgo.NumberObject{
+			Number: n,
+		})
+	}
+	for i, e := range elements {
+		switch v := e.(type) {
+		case *pdfgo.NumberObject:
+			adjust(v.Number)
+		case *pdfgo.StringObject:
+			data := v.Bytes()
+			var kept []byte
+			for offset := 0; offset < len(data); {
+				g, ok := glyphs[[2]int{i, offset}]
+				if !ok || g.Length == 0 {
+					kept = append(kept, data[offset])
+					offset++
+					continue
+				}
+				if r.removed[key][[2]int{i, offset}] {
+					if len(kept) > 0 {
+						array.Array = append(array.Array, newString(kept))
+						kept = nil
+					}
+					adjust(-g.Advance)
+				} else {
+					kept = append(kept, data[offset:offset+g.Length]...)
+				}
+				offset += g.Length
+			}
+			if len(kept) > 0 {
+				array.Array = append(array.Array, newString(kept))
+			}
+		}
+	}
+	return append(result, content.NewOperation("TJ", array))
+}
+
+func newString(data []byte) *pdfgo.StringObject {
+	return &pdfgo.StringObject{
+		String: pdfgo.EscapeString(string(data)),
+	}
+}
+
+// bounds returns the bounds in page space of the unit square in the space of the given matrix, which is where images are drawn.
+func bounds(ctm content.Matrix) *graphics.Rectangle {
+	b := graphics.NegativeRectangle()
+	for _, p := range [][2]float64{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+		x, y := ctm.Transform(p[0], p[1])
+		b = b.Max(&graphics.Rectangle{
+			Left:   x,
+			Right:  x,
+			Top:    y,
+			Bottom: y,
+		})
+	}
+	return b
+}
+
+func subtype(s *pdfgo.StreamObject) string {
+	if n, ok := pdfgo.Resolve(s.GetEntry("Subtype")).(*pdfgo.NameObject); ok {
+		return n.Name
+	}
+	return ""
+}
+
+// free replaces the given objects, and the indirect objects only they refer to, with null objects so that their content is not written.
+// Objects which are still referred to from the catalog or the information dictionary are kept.
+func free(p *pdfgo.PDF, objects []pdfgo.Object) {
+	kept := make(map[pdfgo.Object]bool)
+	referenced(pdfgo.NewObjectReference(p.Catalog), kept)
+	if p.Info != nil {
+		referenced(p.Info, kept)
+	}
+	removed := make(map[pdfgo.Object]bool)
+	for _, o := range objects {
+		referenced(pdfgo.NewObjectReference(o), removed)
+	}
+	for o := range removed {
+		n := o.GetName()
+		if kept[o] || n < 1 || n > len(p.Objects) || p.Objects[n-1] != o {
+			continue
+		}
+		null := &pdfgo.NullObject{}
+		null.SetName(n)
+		p.Objects[n-1] = null
+	}
+}
+
+// referenced adds the indirect objects which the given object refers to, directly or through other objects, to the set.
+func referenced(o pdfgo.Object, set map[pdfgo.Object]bool) {
+	if r, ok := o.(*pdfgo.ObjectReference); ok {
+		if r.Object == nil || set[r.Object] {
+			return
+		}
+		o = r.Object
+		set[o] = true
+	}
+	switch v := o.(type) {
+	case *pdfgo.DictionaryObject:
+		for _, k := range v.Keys {
+			referenced(v.Dictionary[k], set)
+		}
+	case *pdfgo.StreamObject:
+		referenced(&v.DictionaryObject, set)
+	case *pdfgo.ArrayObject:
+		for _, e := range v.Array {
+			referenced(e, set)
+		}
+	}
+}
+
+// copyEntries copies the entries of one dictionary to another, except those with the given keys.
+func copyEntries(to, from *pdfgo.DictionaryObject, except ...string) {
+	skip := make(map[string]bool)
+	for _, e := range except {
+		skip[e] = true
+	}
+	for _, k := range from.Keys {
+		if !skip[k.Name] {
+			to.AddObjectObjectEntry(k, from.Dictionary[k])
+		}
+	}
+}
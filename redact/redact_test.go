@@ -0,0 +1,155 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package redact_test
+
+import (
+	"bytes"
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/AletheiaWareLLC/pdfgo/graphics"
+	"github.com/AletheiaWareLLC/pdfgo/redact"
+	"github.com/AletheiaWareLLC/pdfgo/render"
+	"github.com/AletheiaWareLLC/pdfgo/text"
+	"github.com/stretchr/testify/assert"
+	"image/color"
+	"testing"
+)
+
+// newDocument returns a document whose pages share their content, resources, image, and form.
+func newDocument(t *testing.T, pages int) ([]byte, *pdfgo.PDF) {
+	t.Helper()
+	p := pdfgo.NewPDF()
+	font := p.NewDictionaryObject()
+	font.AddNameNameEntry("Type", "Font")
+	font.AddNameNameEntry("Subtype", "Type1")
+	font.AddNameNameEntry("BaseFont", "Courier")
+	fonts := p.NewDictionaryObject()
+	fonts.AddNameObjectEntry("F1", pdfgo.NewObjectReference(font))
+
+	image := p.NewStreamObject()
+	image.AddNameNameEntry("Type", "XObject")
+	image.AddNameNameEntry("Subtype", "Image")
+	image.AddNameObjectEntry("Width", &pdfgo.NumberObject{Number: 4})
+	image.AddNameObjectEntry("Height", &pdfgo.NumberObject{Number: 4})
+	image.AddNameNameEntry("ColorSpace", "DeviceGray")
+	image.AddNameObjectEntry("BitsPerComponent", &pdfgo.NumberObject{Number: 8})
+	image.Data = bytes.Repeat([]byte{255}, 16)
+
+	formResources := p.NewDictionaryObject()
+	formResources.AddNameObjectEntry("Font", pdfgo.NewObjectReference(fonts))
+	form := p.AddForm(0, 0, 200, 200, nil, pdfgo.NewObjectReference(formResources), []byte("BT /F1 10 Tf 10 150 Td (Form Secret) Tj ET"))
+
+	xs := p.NewDictionaryObject()
+	xs.AddNameObjectEntry("Im1", pdfgo.NewObjectReference(image))
+	xs.AddNameObjectEntry("Fm1", form)
+	resources := p.NewDictionaryObject()
+	resources.AddNameObjectEntry("Font", pdfgo.NewObjectReference(fonts))
+	resources.AddNameObjectEntry("XObject", pdfgo.NewObjectReference(xs))
+	contents := p.NewStreamObject()
+	contents.Data = []byte("BT /F1 10 Tf 10 100 Td (Public Secret Public) Tj ET q 40 0 0 40 100 20 cm /Im1 Do Q /Fm1 Do")
+	for i := 0; i < pages; i++ {
+		p.AddPage(200, 200, pdfgo.NewObjectReference(resources), pdfgo.NewObjectReference(contents))
+	}
+
+	var buffer bytes.Buffer
+	assert.Nil(t, p.Write(&buffer))
+	parsed, err := pdfgo.ReadPDF(buffer.Bytes())
+	assert.Nil(t, err)
+	return buffer.Bytes(), parsed
+}
+
+var areas = []*graphics.Rectangle{
+	// Secret in the page content
+	{Left: 50, Right: 90, Bottom: 95, Top: 110},
+	// Top left quarter of the image
+	{Left: 100, Right: 120, Bottom: 40, Top: 60},
+	// Secret in the form
+	{Left: 40, Right: 80, Bottom: 145, Top: 160},
+}
+
+func TestRedact(t *testing.T) {
+	_, p := newDocument(t, 2)
+	pages := p.GetPages()
+	assert.NotNil(t, redact.Verify(p, 0, areas))
+
+	assert.Nil(t, redact.Redact(p, 0, areas, nil))
+	assert.Nil(t, redact.Verify(p, 0, areas))
+
+	runs, err := text.ExtractRuns(pages[0])
+	assert.Nil(t, err)
+	var texts []string
+	for _, r := range runs {
+		texts = append(texts, r.Text)
+	}
+	assert.Equal(t, []string{"PublicPublic", "Form "}, texts)
+	// Removed glyphs are replaced by adjustments so the remaining text doesn't move
+	assert.InDelta(t, 10+14*6., runs[0].Glyphs[6].X, 0.0001)
+
+	// The other page, which shares the resources and form, is unchanged
+	s, err := text.ExtractText(pages[1])
+	assert.Nil(t, err)
+	assert.Contains(t, s, "Public Secret Public")
+	assert.Contains(t, s, "Form Secret")
+
+	// The pixels under the area are cleared, and the area is painted
+	xs := pdfgo.Resolve(pdfgo.PageResources(pages[0]).GetEntry("XObject")).(*pdfgo.DictionaryObject)
+	image := pdfgo.Resolve(xs.GetEntry("Redacted1")).(*pdfgo.StreamObject)
+	data, err := image.Decode()
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{
+		0, 0, 255, 255,
+		0, 0, 255, 255,
+		255, 255, 255, 255,
+		255, 255, 255, 255,
+	}, data)
+	img, err := render.Render(p, 0, 72)
+	assert.Nil(t, err)
+	assert.Equal(t, color.RGBA{0, 0, 0, 255}, img.RGBAAt(70, 100))
+	assert.Equal(t, color.RGBA{255, 255, 255, 255}, img.RGBAAt(130, 170))
+}
+
+func TestRedact_write(t *testing.T) {
+	original, p := newDocument(t, 1)
+	assert.True(t, bytes.Contains(original, []byte("Secret")))
+	assert.Nil(t, redact.Redact(p, 0, areas, nil))
+
+	// The replaced content, form, and image are not written
+	var buffer bytes.Buffer
+	assert.Nil(t, p.Write(&buffer))
+	assert.False(t, bytes.Contains(buffer.Bytes(), []byte("Secret")))
+	assert.False(t, bytes.Contains(buffer.Bytes(), bytes.Repeat([]byte{255}, 16)))
+	written, err := pdfgo.ReadPDF(buffer.Bytes())
+	assert.Nil(t, err)
+	s, err := text.ExtractText(written.GetPages()[0])
+	assert.Nil(t, err)
+	assert.Contains(t, s, "Public")
+	assert.Contains(t, s, "Form")
+
+	// The original data holds the removed content, so the document cannot be written as an incremental update
+	buffer.Reset()
+	assert.NotNil(t, p.WriteUpdate(&buffer, original, []pdfgo.Object{p.GetPages()[0]}))
+}
+
+func TestRedact_inlineImage(t *testing.T) {
+	p := pdfgo.NewPDF()
+	contents := p.NewStreamObject()
+	contents.Data = []byte("q 40 0 0 40 100 20 cm BI /W 1 /H 1 /CS /G /BPC 8 ID \xff EI Q")
+	p.AddPage(200, 200, nil, pdfgo.NewObjectReference(contents))
+	assert.Nil(t, redact.Redact(p, 0, areas, []float64{1, 1, 1}))
+	data, err := pdfgo.PageContents(p.GetPages()[0])
+	assert.Nil(t, err)
+	assert.NotContains(t, string(data), "BI")
+}
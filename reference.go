@@ -43,5 +43,16 @@ func (o *ObjectReference) GetGeneration() int {
 }
 
 func (o *ObjectReference) Write(out io.Writer) (int, error) {
-	return WriteF(out, "%d 0 R", o.Object.GetName())
+	return WriteF(out, "%d %d R", o.Object.GetName(), o.Object.GetGeneration())
+}
+
+// Resolve follows object references until it reaches a direct object.
+func Resolve(object Object) Object {
+	for {
+		r, ok := object.(*ObjectReference)
+		if !ok || r.Object == nil {
+			return object
+		}
+		object = r.Object
+	}
 }
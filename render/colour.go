@@ -0,0 +1,193 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package render
+
+import (
+	"github.com/AletheiaWareLLC/pdfgo"
+	"image/color"
+	"math"
+)
+
+// colourSpace converts colour components to RGB.
+type colourSpace struct {
+	name       string
+	components int
+	// Indexed colour spaces look up colours of the base space
+	base   *colourSpace
+	lookup []byte
+	// Pattern colour spaces aren't painted
+	pattern bool
+}
+
+var (
+	deviceGray = &colourSpace{
+		name:       "DeviceGray",
+		components: 1,
+	}
+	deviceRGB = &colourSpace{
+		name:       "DeviceRGB",
+		components: 3,
+	}
+	deviceCMYK = &colourSpace{
+		name:       "DeviceCMYK",
+		components: 4,
+	}
+)
+
+// newColourSpace returns the colour space described by the given object, looking up names in the resources.
+func newColourSpace(object pdfgo.Object, resources *pdfgo.DictionaryObject) *colourSpace {
+	switch o := pdfgo.Resolve(object).(type) {
+	case *pdfgo.NameObject:
+		switch o.Name {
+		case "DeviceGray", "G", "CalGray":
+			return deviceGray
+		case "DeviceRGB", "RGB", "CalRGB":
+			return deviceRGB
+		case "DeviceCMYK", "CMYK":
+			return deviceCMYK
+		case "Pattern":
+			return &colourSpace{
+				name:    "Pattern",
+				pattern: true,
+			}
+		}
+		if resources != nil {
+			if spaces, ok := pdfgo.Resolve(resources.GetEntry("ColorSpace")).(*pdfgo.DictionaryObject); ok {
+				if s := spaces.GetEntry(o.Name); s != nil {
+					return newColourSpace(s, nil)
+				}
+			}
+		}
+	case *pdfgo.ArrayObject:
+		if len(o.Array) == 0 {
+			break
+		}
+		family, _ := pdfgo.Resolve(o.Array[0]).(*pdfgo.NameObject)
+		if family == nil {
+			break
+		}
+		switch family.Name {
+		case "ICCBased":
+			if len(o.Array) > 1 {
+				if s, ok := pdfgo.Resolve(o.Array[1]).(*pdfgo.StreamObject); ok {
+					if n, ok := pdfgo.Resolve(s.GetEntry("N")).(*pdfgo.NumberObject); ok {
+						switch int(n.Number) {
+						case 1:
+							return deviceGray
+						case 4:
+							return deviceCMYK
+						}
+					}
+				}
+			}
+			return deviceRGB
+		case "CalGray":
+			return deviceGray
+		case "CalRGB", "Lab":
+			return deviceRGB
+		case "Indexed", "I":
+			if len(o.Array) < 4 {
+				break
+			}
+			cs := &colourSpace{
+				name:       "Indexed",
+				components: 1,
+				base:       newColourSpace(o.Array[1], resources),
+			}
+			switch l := pdfgo.Resolve(o.Array[3]).(type) {
+			case *pdfgo.StringObject:
+				cs.lookup = l.Bytes()
+			case *pdfgo.StreamObject:
+				cs.lookup, _ = l.Decode()
+			}
+			return cs
+		case "Separation", "DeviceN":
+			components := 1
+			if family.Name == "DeviceN" && len(o.Array) > 1 {
+				if names, ok := pdfgo.Resolve(o.Array[1]).(*pdfgo.ArrayObject); ok {
+					components = len(names.Array)
+				}
+			}
+			// Tints are approximated as shades of gray
+			return &colourSpace{
+				name:       family.Name,
+				components: components,
+			}
+		case "Pattern":
+			return &colourSpace{
+				name:    "Pattern",
+				pattern: true,
+			}
+		}
+	}
+	return deviceGray
+}
+
+// colour converts the given components to RGB, with the given opacity from 0 to 1.
+func (cs *colourSpace) colour(components []float64, alpha float64) color.NRGBA {
+	c := color.NRGBA{
+		A: channel(alpha),
+	}
+	get := func(i int) float64 {
+		if i < len(components) {
+			return components[i]
+		}
+		return 0
+	}
+	switch {
+	case cs.base != nil:
+		n := cs.base.components
+		i := int(get(0)) * n
+		values := make([]float64, n)
+		for j := range values {
+			if i+j < len(cs.lookup) {
+				values[j] = float64(cs.lookup[i+j]) / 255
+			}
+		}
+		return cs.base.colour(values, alpha)
+	case cs.components == 3:
+		c.R, c.G, c.B = channel(get(0)), channel(get(1)), channel(get(2))
+	case cs.components == 4:
+		k := get(3)
+		c.R = channel((1 - get(0)) * (1 - k))
+		c.G = channel((1 - get(1)) * (1 - k))
+		c.B = channel((1 - get(2)) * (1 - k))
+	case cs.name == "Separation" || cs.name == "DeviceN":
+		g := channel(1 - get(0))
+		c.R, c.G, c.B = g, g, g
+	default:
+		g := channel(get(0))
+		c.R, c.G, c.B = g, g, g
+	}
+	return c
+}
+
+// initial returns the initial colour components of the colour space.
+func (cs *colourSpace) initial() []float64 {
+	if cs.components == 4 {
+		// Black in CMYK
+		return []float64{0, 0, 0, 1}
+	}
+	if cs.name == "Separation" || cs.name == "DeviceN" {
+		return []float64{1}
+	}
+	return make([]float64, cs.components)
+}
+
+func channel(f float64) uint8 {
+	return uint8(math.Round(math.Max(0, math.Min(1, f)) * 255))
+}
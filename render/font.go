@@ -0,0 +1,230 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package render
+
+import (
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/AletheiaWareLLC/pdfgo/content"
+	"github.com/AletheiaWareLLC/pdfgo/font"
+	"github.com/golang/freetype/truetype"
+	xfont "golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/gobolditalic"
+	"golang.org/x/image/font/gofont/goitalic"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/gomonobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/math/fixed"
+	"log"
+	"strings"
+)
+
+// Glyph outlines are loaded with 1000 units per em, matching PDF glyph space
+const GLYPH_UNITS = 1000
+
+var fallbackFonts = make(map[string]*truetype.Font)
+
+// renderFont draws the glyphs of a font dictionary.
+type renderFont struct {
+	decoder *font.Decoder
+	ttf     *truetype.Font
+	// Embedded fonts are drawn as is, fallback fonts are stretched to the widths in the PDF
+	embedded bool
+	// CIDToGIDMap of embedded composite fonts, nil for the identity mapping
+	cidToGID []byte
+	buffer   truetype.GlyphBuf
+}
+
+func newRenderFont(dictionary *pdfgo.DictionaryObject) *renderFont {
+	f := &renderFont{
+		decoder: font.NewDecoder(dictionary),
+	}
+	descriptor, _ := pdfgo.Resolve(dictionary.GetEntry("FontDescriptor")).(*pdfgo.DictionaryObject)
+	if f.decoder.Composite {
+		if descendants, ok := pdfgo.Resolve(dictionary.GetEntry("DescendantFonts")).(*pdfgo.ArrayObject); ok && len(descendants.Array) > 0 {
+			if descendant, ok := pdfgo.Resolve(descendants.Array[0]).(*pdfgo.DictionaryObject); ok {
+				descriptor, _ = pdfgo.Resolve(descendant.GetEntry("FontDescriptor")).(*pdfgo.DictionaryObject)
+				if s, ok := pdfgo.Resolve(descendant.GetEntry("CIDToGIDMap")).(*pdfgo.StreamObject); ok {
+					f.cidToGID, _ = s.Decode()
+				}
+			}
+		}
+	}
+	if descriptor != nil {
+		for _, key := range []string{"FontFile2", "FontFile3"} {
+			s, ok := pdfgo.Resolve(descriptor.GetEntry(key)).(*pdfgo.StreamObject)
+			if !ok {
+				continue
+			}
+			data, err := s.Decode()
+			if err != nil {
+				log.Println("Font", f.decoder.Name, key+":", err)
+				continue
+			}
+			ttf, err := truetype.Parse(data)
+			if err != nil {
+				// Compact and Type 1 font programs aren't supported
+				continue
+			}
+			f.ttf = ttf
+			f.embedded = true
+		}
+	}
+	if f.ttf == nil {
+		f.ttf = fallbackFont(f.decoder.Name)
+	}
+	return f
+}
+
+// fallbackFont returns the Go font which most closely resembles the named font.
+func fallbackFont(name string) *truetype.Font {
+	bold := strings.Contains(name, "Bold") || strings.Contains(name, "Black") || strings.Contains(name, "Heavy")
+	italic := strings.Contains(name, "Italic") || strings.Contains(name, "Oblique")
+	mono := font.StandardFontFamily(name) == "Courier" || strings.Contains(name, "Mono")
+	var key string
+	var data []byte
+	switch {
+	case mono && bold:
+		key, data = "gomonobold", gomonobold.TTF
+	case mono:
+		key, data = "gomono", gomono.TTF
+	case bold && italic:
+		key, data = "gobolditalic", gobolditalic.TTF
+	case bold:
+		key, data = "gobold", gobold.TTF
+	case italic:
+		key, data = "goitalic", goitalic.TTF
+	default:
+		key, data = "goregular", goregular.TTF
+	}
+	if f, ok := fallbackFonts[key]; ok {
+		return f
+	}
+	f, err := truetype.Parse(data)
+	if err != nil {
+		log.Println("Font", key+":", err)
+		return nil
+	}
+	fallbackFonts[key] = f
+	return f
+}
+
+func (f *renderFont) index(c *font.Code) truetype.Index {
+	if f.embedded && f.decoder.Composite {
+		if f.cidToGID != nil {
+			if i := 2 * c.CID; i+1 < len(f.cidToGID) {
+				return truetype.Index(f.cidToGID[i])<<8 | truetype.Index(f.cidToGID[i+1])
+			}
+			return 0
+		}
+		return truetype.Index(c.CID)
+	}
+	text := c.Text
+	if runes := font.GlyphRunes(c.Name); len(text) != 1 && len(runes) == 1 {
+		// Text of several characters, such as that of a ligature, is shown by the glyph named in the encoding
+		text = runes
+	}
+	if len(text) > 0 {
+		if i := f.ttf.Index(text[0]); i != 0 {
+			return i
+		}
+	}
+	if f.embedded {
+		// Symbolic fonts map codes directly, or into the private use area
+		if i := f.ttf.Index(rune(c.Code)); i != 0 {
+			return i
+		}
+		return f.ttf.Index(rune(0xF000 + c.Code))
+	}
+	return 0
+}
+
+// outline adds the outline of the glyph for the given code to the path, transformed by the matrix from glyph space.
+func (f *renderFont) outline(p *path, c *font.Code, m content.Matrix) {
+	if f.ttf == nil {
+		return
+	}
+	i := f.index(c)
+	if err := f.buffer.Load(f.ttf, fixed.I(GLYPH_UNITS), i, xfont.HintingNone); err != nil {
+		return
+	}
+	if !f.embedded && f.buffer.AdvanceWidth > 0 {
+		// Stretch the fallback glyph to the width the document expects
+		advance := float64(f.buffer.AdvanceWidth) / 64 / GLYPH_UNITS
+		if c.Width > 0 {
+			m = content.Matrix{c.Width / advance, 0, 0, 1, 0, 0}.Multiply(m)
+		}
+	}
+	m = content.Matrix{1. / GLYPH_UNITS, 0, 0, 1. / GLYPH_UNITS, 0, 0}.Multiply(m)
+	start := 0
+	for _, end := range f.buffer.Ends {
+		contour(p, f.buffer.Points[start:end], m)
+		start = end
+	}
+}
+
+// contour adds a closed TrueType contour of on and off curve points to the path.
+func contour(p *path, points []truetype.Point, m content.Matrix) {
+	n := len(points)
+	if n == 0 {
+		return
+	}
+	get := func(i int) (point, bool) {
+		a := points[i]
+		return transform(m, float64(a.X)/64, float64(a.Y)/64), a.Flags&1 != 0
+	}
+	mid := func(a, b point) point {
+		return point{(a.x + b.x) / 2, (a.y + b.y) / 2}
+	}
+	// Start at an on curve point, or between two off curve points
+	start, on := get(0)
+	from, to := 1, n
+	if !on {
+		last, lastOn := get(n - 1)
+		if lastOn {
+			start = last
+			from, to = 0, n-1
+		} else {
+			start = mid(last, start)
+			from, to = 0, n
+		}
+	}
+	p.moveTo(start)
+	var control *point
+	for i := from; i < to; i++ {
+		a, on := get(i)
+		if on {
+			if control != nil {
+				p.quadTo(*control, a)
+				control = nil
+			} else {
+				p.lineTo(a)
+			}
+			continue
+		}
+		if control != nil {
+			p.quadTo(*control, mid(*control, a))
+		}
+		control = &a
+	}
+	if control != nil {
+		p.quadTo(*control, start)
+	} else {
+		p.lineTo(start)
+	}
+	p.close()
+}
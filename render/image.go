@@ -0,0 +1,276 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package render
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/AletheiaWareLLC/pdfgo"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math"
+)
+
+// Abbreviations used in inline image dictionaries
+var inlineKeys = map[string]string{
+	"BPC": "BitsPerComponent",
+	"CS":  "ColorSpace",
+	"D":   "Decode",
+	"DP":  "DecodeParms",
+	"F":   "Filter",
+	"H":   "Height",
+	"IM":  "ImageMask",
+	"W":   "Width",
+}
+
+var inlineNames = map[string]string{
+	"G":    "DeviceGray",
+	"RGB":  "DeviceRGB",
+	"CMYK": "DeviceCMYK",
+	"AHx":  "ASCIIHexDecode",
+	"A85":  "ASCII85Decode",
+	"LZW":  "LZWDecode",
+	"Fl":   "FlateDecode",
+	"RL":   "RunLengthDecode",
+	"DCT":  "DCTDecode",
+	"CCF":  "CCITTFaxDecode",
+}
+
+// inlineStream converts an inline image into an equivalent image XObject.
+func inlineStream(dictionary *pdfgo.DictionaryObject, data []byte) *pdfgo.StreamObject {
+	s := &pdfgo.StreamObject{}
+	s.Dictionary = make(map[*pdfgo.NameObject]pdfgo.Object)
+	s.Data = data
+	if dictionary == nil {
+		return s
+	}
+	for _, k := range dictionary.Keys {
+		key := k.Name
+		if full, ok := inlineKeys[key]; ok {
+			key = full
+		}
+		value := dictionary.Dictionary[k]
+		switch v := value.(type) {
+		case *pdfgo.NameObject:
+			if full, ok := inlineNames[v.Name]; ok {
+				value = &pdfgo.NameObject{Name: full}
+			}
+		case *pdfgo.ArrayObject:
+			array := &pdfgo.ArrayObject{}
+			for _, e := range v.Array {
+				if n, ok := e.(*pdfgo.NameObject); ok {
+					if full, ok := inlineNames[n.Name]; ok {
+						e = &pdfgo.NameObject{Name: full}
+					}
+				}
+				array.Array = append(array.Array, e)
+			}
+			value = array
+		}
+		s.SetNameObjectEntry(key, value)
+	}
+	return s
+}
+
+// decodeImage returns the samples of an image XObject.
+// Stencil masks are returned as an alpha image to be painted with the fill colour.
+func decodeImage(s *pdfgo.StreamObject, resources *pdfgo.DictionaryObject) (image.Image, error) {
+	data, err := s.Decode()
+	if err != nil {
+		return nil, err
+	}
+	filters := s.Filters()
+	if len(filters) > 0 {
+		switch filters[len(filters)-1] {
+		case "DCTDecode", "DCT":
+			img, err := jpeg.Decode(bytes.NewReader(data))
+			if err != nil {
+				return nil, err
+			}
+			return applySoftMask(img, s, resources), nil
+		case "JPXDecode", "CCITTFaxDecode", "CCF", "JBIG2Decode":
+			return nil, fmt.Errorf("Unsupported Image Filter: %s", filters[len(filters)-1])
+		}
+	}
+	width := int(pdfgo.NumberValue(s.GetEntry("Width")))
+	height := int(pdfgo.NumberValue(s.GetEntry("Height")))
+	if width <= 0 || height <= 0 {
+		return nil, errors.New("Invalid Image Size")
+	}
+	mask := false
+	if b, ok := pdfgo.Resolve(s.GetEntry("ImageMask")).(*pdfgo.BooleanObject); ok {
+		mask = b.Boolean
+	}
+	bits := int(pdfgo.NumberValue(s.GetEntry("BitsPerComponent")))
+	if mask || bits == 0 {
+		bits = 1
+	}
+	var decode []float64
+	if a, ok := pdfgo.Resolve(s.GetEntry("Decode")).(*pdfgo.ArrayObject); ok {
+		for _, o := range a.Array {
+			decode = append(decode, pdfgo.NumberValue(o))
+		}
+	}
+	if mask {
+		img := image.NewAlpha(image.Rect(0, 0, width, height))
+		// Samples of 0 are painted, unless the decode array is inverted
+		paint := uint32(0)
+		if len(decode) >= 2 && decode[0] > decode[1] {
+			paint = 1
+		}
+		reader := &sampleReader{data: data, bits: 1}
+		for y := 0; y < height; y++ {
+			reader.align()
+			for x := 0; x < width; x++ {
+				if reader.read() == paint {
+					img.Pix[y*img.Stride+x] = 255
+				}
+			}
+		}
+		return img, nil
+	}
+	cs := newColourSpace(s.GetEntry("ColorSpace"), resources)
+	n := cs.components
+	if n == 0 {
+		return nil, errors.New("Unsupported Image Colour Space")
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	reader := &sampleReader{data: data, bits: bits}
+	max := float64(uint32(1)<<uint(bits) - 1)
+	components := make([]float64, n)
+	for y := 0; y < height; y++ {
+		reader.align()
+		for x := 0; x < width; x++ {
+			for i := range components {
+				v := float64(reader.read())
+				if cs.base != nil {
+					// Indexed samples are not normalised
+					components[i] = v
+				} else if len(decode) >= 2*(i+1) {
+					components[i] = decode[2*i] + v*(decode[2*i+1]-decode[2*i])/max
+				} else {
+					components[i] = v / max
+				}
+			}
+			img.SetNRGBA(x, y, cs.colour(components, 1))
+		}
+	}
+	return applySoftMask(img, s, resources), nil
+}
+
+// applySoftMask returns the image with the alpha channel taken from its SMask, if any.
+func applySoftMask(img image.Image, s *pdfgo.StreamObject, resources *pdfgo.DictionaryObject) image.Image {
+	m, ok := pdfgo.Resolve(s.GetEntry("SMask")).(*pdfgo.StreamObject)
+	if !ok {
+		return img
+	}
+	alpha, err := decodeImage(m, resources)
+	if err != nil {
+		return img
+	}
+	bounds := img.Bounds()
+	ab := alpha.Bounds()
+	result := image.NewNRGBA(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			// Scale the mask to the size of the image
+			ax := ab.Min.X + (x-bounds.Min.X)*ab.Dx()/bounds.Dx()
+			ay := ab.Min.Y + (y-bounds.Min.Y)*ab.Dy()/bounds.Dy()
+			g := color.GrayModel.Convert(alpha.At(ax, ay)).(color.Gray)
+			c.A = uint8(uint32(c.A) * uint32(g.Y) / 255)
+			result.SetNRGBA(x, y, c)
+		}
+	}
+	return result
+}
+
+// sampleReader reads samples of up to 16 bits from rows of packed data.
+type sampleReader struct {
+	data     []byte
+	bits     int
+	position int
+}
+
+func (r *sampleReader) align() {
+	r.position = (r.position + 7) / 8 * 8
+}
+
+func (r *sampleReader) read() uint32 {
+	var v uint32
+	for i := 0; i < r.bits; i++ {
+		index := r.position / 8
+		var bit uint32
+		if index < len(r.data) {
+			bit = uint32(r.data[index]>>(7-uint(r.position%8))) & 1
+		}
+		v = v<<1 | bit
+		r.position++
+	}
+	return v
+}
+
+// drawImage paints the image into the unit square of user space, sampling the nearest pixel.
+func (r *renderer) drawImage(img image.Image, s *state) {
+	m := s.ctm.Multiply(r.base)
+	unit := &path{}
+	unit.moveTo(transform(m, 0, 0))
+	unit.lineTo(transform(m, 1, 0))
+	unit.lineTo(transform(m, 1, 1))
+	unit.lineTo(transform(m, 0, 1))
+	bounds := unit.bounds().Intersect(r.image.Bounds())
+	det := m[0]*m[3] - m[1]*m[2]
+	if bounds.Empty() || math.Abs(det) < 1e-12 {
+		return
+	}
+	// Invert the matrix to map device pixels back into the image
+	inverse := [6]float64{
+		m[3] / det, -m[1] / det, -m[2] / det, m[0] / det,
+		(m[2]*m[5] - m[3]*m[4]) / det, (m[1]*m[4] - m[0]*m[5]) / det,
+	}
+	ib := img.Bounds()
+	alpha, stencil := img.(*image.Alpha)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			dx, dy := float64(x)+0.5, float64(y)+0.5
+			u := inverse[0]*dx + inverse[2]*dy + inverse[4]
+			v := inverse[1]*dx + inverse[3]*dy + inverse[5]
+			if u < 0 || u >= 1 || v < 0 || v >= 1 {
+				continue
+			}
+			// Image rows run from the top of the unit square
+			ix := ib.Min.X + int(u*float64(ib.Dx()))
+			iy := ib.Min.Y + int((1-v)*float64(ib.Dy()))
+			if iy >= ib.Max.Y {
+				iy = ib.Max.Y - 1
+			}
+			coverage := uint32(255)
+			if s.clip != nil {
+				coverage = uint32(s.clip.AlphaAt(x, y).A)
+			}
+			if stencil {
+				r.blend(x, y, s.fillColour, coverage*uint32(alpha.AlphaAt(ix, iy).A)/255)
+				continue
+			}
+			c := color.NRGBAModel.Convert(img.At(ix, iy)).(color.NRGBA)
+			c.A = uint8(uint32(c.A) * uint32(s.fillColour.A) / 255)
+			r.blend(x, y, c, coverage)
+		}
+	}
+}
@@ -0,0 +1,390 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package render
+
+import (
+	"github.com/AletheiaWareLLC/pdfgo/content"
+	"github.com/golang/freetype/raster"
+	"golang.org/x/image/math/fixed"
+	"image"
+	"image/color"
+	"math"
+)
+
+type point struct {
+	x, y float64
+}
+
+type subpath struct {
+	points []point
+	closed bool
+}
+
+// path holds subpaths in device space, with curves flattened into line segments.
+type path struct {
+	subpaths []*subpath
+}
+
+func (p *path) empty() bool {
+	return len(p.subpaths) == 0
+}
+
+func (p *path) current() *subpath {
+	if len(p.subpaths) == 0 {
+		return nil
+	}
+	return p.subpaths[len(p.subpaths)-1]
+}
+
+func (p *path) last() point {
+	if s := p.current(); s != nil && len(s.points) > 0 {
+		return s.points[len(s.points)-1]
+	}
+	return point{}
+}
+
+func (p *path) moveTo(a point) {
+	if s := p.current(); s != nil && len(s.points) == 1 {
+		// Replace a lone move
+		s.points[0] = a
+		return
+	}
+	p.subpaths = append(p.subpaths, &subpath{
+		points: []point{a},
+	})
+}
+
+func (p *path) lineTo(a point) {
+	s := p.current()
+	if s == nil || s.closed {
+		p.moveTo(p.last())
+		s = p.current()
+	}
+	s.points = append(s.points, a)
+}
+
+// quadTo adds a quadratic Bézier curve, flattened according to its size.
+func (p *path) quadTo(b, c point) {
+	a := p.last()
+	n := segments(distance(a, b) + distance(b, c))
+	for i := 1; i <= n; i++ {
+		t := float64(i) / float64(n)
+		u := 1 - t
+		p.lineTo(point{
+			x: u*u*a.x + 2*u*t*b.x + t*t*c.x,
+			y: u*u*a.y + 2*u*t*b.y + t*t*c.y,
+		})
+	}
+}
+
+// cubicTo adds a cubic Bézier curve, flattened according to its size.
+func (p *path) cubicTo(b, c, d point) {
+	a := p.last()
+	n := segments(distance(a, b) + distance(b, c) + distance(c, d))
+	for i := 1; i <= n; i++ {
+		t := float64(i) / float64(n)
+		u := 1 - t
+		p.lineTo(point{
+			x: u*u*u*a.x + 3*u*u*t*b.x + 3*u*t*t*c.x + t*t*t*d.x,
+			y: u*u*u*a.y + 3*u*u*t*b.y + 3*u*t*t*c.y + t*t*t*d.y,
+		})
+	}
+}
+
+func (p *path) close() {
+	if s := p.current(); s != nil && len(s.points) > 0 {
+		s.closed = true
+		// Subsequent segments start a new subpath at the same point
+		p.subpaths = append(p.subpaths, &subpath{
+			points: []point{s.points[0]},
+		})
+	}
+}
+
+func (p *path) bounds() image.Rectangle {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, s := range p.subpaths {
+		for _, a := range s.points {
+			minX = math.Min(minX, a.x)
+			minY = math.Min(minY, a.y)
+			maxX = math.Max(maxX, a.x)
+			maxY = math.Max(maxY, a.y)
+		}
+	}
+	if minX > maxX {
+		return image.Rectangle{}
+	}
+	return image.Rect(int(math.Floor(minX)), int(math.Floor(minY)), int(math.Ceil(maxX))+1, int(math.Ceil(maxY))+1)
+}
+
+func distance(a, b point) float64 {
+	return math.Hypot(b.x-a.x, b.y-a.y)
+}
+
+func segments(length float64) int {
+	n := int(length / 4)
+	if n < 2 {
+		return 2
+	}
+	if n > 64 {
+		return 64
+	}
+	return n
+}
+
+func transform(m content.Matrix, x, y float64) point {
+	x, y = m.Transform(x, y)
+	return point{x, y}
+}
+
+// scale returns the average scale factor of the given matrix, used to convert line widths and dash lengths.
+func scale(m content.Matrix) float64 {
+	return math.Sqrt(math.Abs(m[0]*m[3] - m[1]*m[2]))
+}
+
+func toFixed(a point, offset image.Point) fixed.Point26_6 {
+	return fixed.Point26_6{
+		X: fixed.Int26_6((a.x - float64(offset.X)) * 64),
+		Y: fixed.Int26_6((a.y - float64(offset.Y)) * 64),
+	}
+}
+
+// coverage rasterizes the given path within the bounds, returning the coverage of each pixel.
+func (r *renderer) coverage(bounds image.Rectangle, nonZero bool, add func(*raster.Rasterizer, image.Point)) []uint8 {
+	w, h := bounds.Dx(), bounds.Dy()
+	r.rasterizer.SetBounds(w, h)
+	r.rasterizer.Clear()
+	r.rasterizer.UseNonZeroWinding = nonZero
+	add(r.rasterizer, bounds.Min)
+	cover := make([]uint8, w*h)
+	r.rasterizer.Rasterize(raster.PainterFunc(func(spans []raster.Span, done bool) {
+		for _, s := range spans {
+			if s.Y < 0 || s.Y >= h {
+				continue
+			}
+			a := uint8(s.Alpha >> 8)
+			for x := s.X0; x < s.X1 && x < w; x++ {
+				if x >= 0 {
+					cover[s.Y*w+x] = a
+				}
+			}
+		}
+	}))
+	return cover
+}
+
+func addFill(p *path) func(*raster.Rasterizer, image.Point) {
+	return func(rz *raster.Rasterizer, offset image.Point) {
+		for _, s := range p.subpaths {
+			if len(s.points) < 2 {
+				continue
+			}
+			rz.Start(toFixed(s.points[0], offset))
+			for _, a := range s.points[1:] {
+				rz.Add1(toFixed(a, offset))
+			}
+			// Fills implicitly close each subpath
+			rz.Add1(toFixed(s.points[0], offset))
+		}
+	}
+}
+
+func addStroke(p *path, width float64, capper raster.Capper, joiner raster.Joiner) func(*raster.Rasterizer, image.Point) {
+	return func(rz *raster.Rasterizer, offset image.Point) {
+		for _, s := range p.subpaths {
+			if len(s.points) < 2 {
+				continue
+			}
+			var q raster.Path
+			q.Start(toFixed(s.points[0], offset))
+			for _, a := range s.points[1:] {
+				q.Add1(toFixed(a, offset))
+			}
+			if s.closed {
+				// Revisit the first segment so the start is joined rather than capped
+				q.Add1(toFixed(s.points[0], offset))
+				q.Add1(toFixed(s.points[1], offset))
+			}
+			rz.AddStroke(q, fixed.Int26_6(width*64), capper, joiner)
+		}
+	}
+}
+
+// fill paints the path with the given colour and rule.
+func (r *renderer) fill(p *path, evenOdd bool, c color.NRGBA, s *state) {
+	bounds := p.bounds().Intersect(r.image.Bounds())
+	if bounds.Empty() {
+		return
+	}
+	r.composite(bounds, r.coverage(bounds, !evenOdd, addFill(p)), c, s.clip)
+}
+
+// stroke paints the outline of the path with the line style of the given state.
+func (r *renderer) stroke(p *path, s *state) {
+	k := scale(s.ctm.Multiply(r.base))
+	width := s.lineWidth * k
+	if width < 1 {
+		// Thin lines are drawn one pixel wide
+		width = 1
+	}
+	if len(s.dash) > 0 {
+		p = dash(p, s.dash, s.dashPhase, k)
+	}
+	margin := int(math.Ceil(width*math.Max(s.miterLimit, 1))) + 1
+	bounds := p.bounds().Inset(-margin).Intersect(r.image.Bounds())
+	if bounds.Empty() {
+		return
+	}
+	var capper raster.Capper
+	switch s.lineCap {
+	case 1:
+		capper = raster.RoundCapper
+	case 2:
+		capper = raster.SquareCapper
+	default:
+		capper = raster.ButtCapper
+	}
+	var joiner raster.Joiner
+	switch s.lineJoin {
+	case 2:
+		joiner = raster.BevelJoiner
+	default:
+		// Miter joins are approximated as round
+		joiner = raster.RoundJoiner
+	}
+	r.composite(bounds, r.coverage(bounds, true, addStroke(p, width, capper, joiner)), s.strokeColour, s.clip)
+}
+
+// clip intersects the clipping mask of the state with the path.
+func (r *renderer) clip(p *path, evenOdd bool, s *state) {
+	clip := image.NewAlpha(r.image.Bounds())
+	bounds := p.bounds().Intersect(r.image.Bounds())
+	if !bounds.Empty() {
+		cover := r.coverage(bounds, !evenOdd, addFill(p))
+		w := bounds.Dx()
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				a := cover[(y-bounds.Min.Y)*w+x-bounds.Min.X]
+				if s.clip != nil {
+					a = uint8(uint32(a) * uint32(s.clip.AlphaAt(x, y).A) / 255)
+				}
+				clip.SetAlpha(x, y, color.Alpha{A: a})
+			}
+		}
+	}
+	s.clip = clip
+}
+
+// composite blends the colour onto the image, weighted by the coverage and clipping mask.
+func (r *renderer) composite(bounds image.Rectangle, cover []uint8, c color.NRGBA, clip *image.Alpha) {
+	w := bounds.Dx()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			a := uint32(cover[(y-bounds.Min.Y)*w+x-bounds.Min.X])
+			if clip != nil {
+				a = a * uint32(clip.AlphaAt(x, y).A) / 255
+			}
+			r.blend(x, y, c, a)
+		}
+	}
+}
+
+// blend mixes the non-premultiplied colour into the pixel with the given coverage, from 0 to 255.
+func (r *renderer) blend(x, y int, c color.NRGBA, coverage uint32) {
+	a := coverage * uint32(c.A) / 255
+	if a == 0 {
+		return
+	}
+	i := r.image.PixOffset(x, y)
+	pix := r.image.Pix[i : i+4 : i+4]
+	pix[0] = uint8((uint32(c.R)*a + uint32(pix[0])*(255-a)) / 255)
+	pix[1] = uint8((uint32(c.G)*a + uint32(pix[1])*(255-a)) / 255)
+	pix[2] = uint8((uint32(c.B)*a + uint32(pix[2])*(255-a)) / 255)
+	pix[3] = uint8(a + uint32(pix[3])*(255-a)/255)
+}
+
+// dash splits the subpaths of the path into dashes, whose lengths are scaled by the given factor.
+func dash(p *path, pattern []float64, phase, k float64) *path {
+	total := 0.
+	for _, d := range pattern {
+		total += d
+	}
+	if total <= 0 {
+		return p
+	}
+	result := &path{}
+	for _, s := range p.subpaths {
+		points := s.points
+		if s.closed && len(points) > 0 {
+			points = append(append([]point{}, points...), points[0])
+		}
+		if len(points) < 2 {
+			continue
+		}
+		// Find the position in the pattern at the start of the subpath
+		index := 0
+		remaining := pattern[0] * k
+		offset := math.Mod(phase, total) * k
+		for offset > 0 {
+			if offset < remaining {
+				remaining -= offset
+				break
+			}
+			offset -= remaining
+			index = (index + 1) % len(pattern)
+			remaining = pattern[index] * k
+		}
+		on := index%2 == 0
+		if on {
+			result.subpaths = append(result.subpaths, &subpath{
+				points: []point{points[0]},
+			})
+		}
+		for i := 1; i < len(points); i++ {
+			a, b := points[i-1], points[i]
+			length := distance(a, b)
+			position := 0.
+			for length-position > remaining {
+				position += remaining
+				t := position / length
+				m := point{a.x + (b.x-a.x)*t, a.y + (b.y-a.y)*t}
+				if on {
+					d := result.subpaths[len(result.subpaths)-1]
+					d.points = append(d.points, m)
+				} else {
+					result.subpaths = append(result.subpaths, &subpath{
+						points: []point{m},
+					})
+				}
+				on = !on
+				index = (index + 1) % len(pattern)
+				remaining = pattern[index] * k
+				if remaining <= 0 {
+					// Zero length dashes still alternate
+					remaining = 1e-9
+				}
+			}
+			remaining -= length - position
+			if on {
+				d := result.subpaths[len(result.subpaths)-1]
+				d.points = append(d.points, b)
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,541 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package render
+
+import (
+	"errors"
+	"fmt"
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/AletheiaWareLLC/pdfgo/content"
+	"github.com/golang/freetype/raster"
+	"image"
+	"image/color"
+	"log"
+	"math"
+)
+
+// Maximum depth of nested form XObjects
+const MAX_FORM_DEPTH = 16
+
+type state struct {
+	ctm          content.Matrix
+	clip         *image.Alpha
+	fillSpace    *colourSpace
+	strokeSpace  *colourSpace
+	fill         []float64
+	stroke       []float64
+	fillAlpha    float64
+	strokeAlpha  float64
+	fillColour   color.NRGBA
+	strokeColour color.NRGBA
+	lineWidth    float64
+	lineCap      int
+	lineJoin     int
+	miterLimit   float64
+	dash         []float64
+	dashPhase    float64
+	// Text state
+	characterSpacing float64
+	wordSpacing      float64
+	scale            float64
+	leading          float64
+	font             *renderFont
+	size             float64
+	rise             float64
+	render           int
+}
+
+func (s *state) updateColours() {
+	s.fillColour = s.fillSpace.colour(s.fill, s.fillAlpha)
+	s.strokeColour = s.strokeSpace.colour(s.stroke, s.strokeAlpha)
+}
+
+type renderer struct {
+	image      *image.RGBA
+	base       content.Matrix
+	rasterizer *raster.Rasterizer
+	fonts      map[*pdfgo.DictionaryObject]*renderFont
+	forms      map[*pdfgo.StreamObject]bool
+}
+
+// Render draws the page at the given index of the document at the given resolution in dots per inch.
+func Render(p *pdfgo.PDF, index int, dpi float64) (*image.RGBA, error) {
+	pages := p.GetPages()
+	if index < 0 || index >= len(pages) {
+		return nil, fmt.Errorf("Page Index Out of Range: %d", index)
+	}
+	return RenderPage(pages[index], dpi)
+}
+
+// RenderPage draws the given page at the given resolution in dots per inch, on a white background.
+// Paths, clipping, text, and images are drawn; shadings, patterns, blend modes, and Type3 fonts are not.
+// Fonts which aren't embedded as TrueType are drawn with a similar Go font, stretched to the widths in the document.
+func RenderPage(page *pdfgo.DictionaryObject, dpi float64) (*image.RGBA, error) {
+	if dpi <= 0 {
+		return nil, errors.New("Invalid Resolution")
+	}
+	left, bottom, right, top := pdfgo.PageMediaBox(page)
+	k := dpi / 72
+	width, height := right-left, top-bottom
+	rotate := 0
+	if n, ok := pdfgo.GetInheritedEntry(page, "Rotate").(*pdfgo.NumberObject); ok {
+		rotate = ((int(n.Number)%360 + 360) % 360) / 90 * 90
+	}
+	// The base matrix maps default user space to device space, with the origin at the top left
+	var base content.Matrix
+	switch rotate {
+	case 90:
+		width, height = height, width
+		base = content.Matrix{0, k, k, 0, -bottom * k, -left * k}
+	case 180:
+		base = content.Matrix{-k, 0, 0, k, right * k, -bottom * k}
+	case 270:
+		width, height = height, width
+		base = content.Matrix{0, -k, -k, 0, top * k, right * k}
+	default:
+		base = content.Matrix{k, 0, 0, -k, -left * k, top * k}
+	}
+	w := int(math.Ceil(width*k - 0.001))
+	h := int(math.Ceil(height*k - 0.001))
+	if w <= 0 || h <= 0 {
+		return nil, errors.New("Invalid Page Size")
+	}
+	r := &renderer{
+		image:      image.NewRGBA(image.Rect(0, 0, w, h)),
+		base:       base,
+		rasterizer: raster.NewRasterizer(w, h),
+		fonts:      make(map[*pdfgo.DictionaryObject]*renderFont),
+		forms:      make(map[*pdfgo.StreamObject]bool),
+	}
+	for i := range r.image.Pix {
+		r.image.Pix[i] = 255
+	}
+	data, err := pdfgo.PageContents(page)
+	if err != nil {
+		return nil, err
+	}
+	operations, err := content.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	s := &state{
+		ctm:         content.IdentityMatrix(),
+		fillSpace:   deviceGray,
+		strokeSpace: deviceGray,
+		fill:        []float64{0},
+		stroke:      []float64{0},
+		fillAlpha:   1,
+		strokeAlpha: 1,
+		lineWidth:   1,
+		miterLimit:  10,
+		scale:       1,
+	}
+	s.updateColours()
+	if err := r.execute(operations, pdfgo.PageResources(page), s, 0); err != nil {
+		return nil, err
+	}
+	return r.image, nil
+}
+
+func (r *renderer) execute(operations []*content.Operation, resources *pdfgo.DictionaryObject, s *state, depth int) error {
+	var stack []state
+	p := &path{}
+	// Pending clip set by W or W*, applied when the path is painted
+	clip, clipEvenOdd := false, false
+	var tm, tlm content.Matrix
+	endPath := func() {
+		if clip {
+			r.clip(p, clipEvenOdd, s)
+			clip = false
+		}
+		p = &path{}
+	}
+	for _, o := range operations {
+		m := s.ctm.Multiply(r.base)
+		switch o.Operator {
+		// Graphics state
+		case "q":
+			saved := *s
+			stack = append(stack, saved)
+		case "Q":
+			if len(stack) > 0 {
+				*s = stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+			}
+		case "cm":
+			s.ctm = content.MatrixOperands(o).Multiply(s.ctm)
+		case "w":
+			s.lineWidth = o.Number(0)
+		case "J":
+			s.lineCap = int(o.Number(0))
+		case "j":
+			s.lineJoin = int(o.Number(0))
+		case "M":
+			s.miterLimit = o.Number(0)
+		case "d":
+			s.dash, s.dashPhase = dashOperands(o.Operands)
+		case "gs":
+			r.setGraphicsState(resources, o.Name(0), s)
+		// Path construction
+		case "m":
+			p.moveTo(transform(m, o.Number(0), o.Number(1)))
+		case "l":
+			p.lineTo(transform(m, o.Number(0), o.Number(1)))
+		case "c":
+			p.cubicTo(transform(m, o.Number(0), o.Number(1)), transform(m, o.Number(2), o.Number(3)), transform(m, o.Number(4), o.Number(5)))
+		case "v":
+			p.cubicTo(p.last(), transform(m, o.Number(0), o.Number(1)), transform(m, o.Number(2), o.Number(3)))
+		case "y":
+			end := transform(m, o.Number(2), o.Number(3))
+			p.cubicTo(transform(m, o.Number(0), o.Number(1)), end, end)
+		case "h":
+			p.close()
+		case "re":
+			x, y, w, h := o.Number(0), o.Number(1), o.Number(2), o.Number(3)
+			p.moveTo(transform(m, x, y))
+			p.lineTo(transform(m, x+w, y))
+			p.lineTo(transform(m, x+w, y+h))
+			p.lineTo(transform(m, x, y+h))
+			p.close()
+		// Path painting
+		case "S":
+			r.stroke(p, s)
+			endPath()
+		case "s":
+			p.close()
+			r.stroke(p, s)
+			endPath()
+		case "f", "F", "f*":
+			if !s.fillSpace.pattern {
+				r.fill(p, o.Operator == "f*", s.fillColour, s)
+			}
+			endPath()
+		case "B", "B*", "b", "b*":
+			if o.Operator == "b" || o.Operator == "b*" {
+				p.close()
+			}
+			if !s.fillSpace.pattern {
+				r.fill(p, o.Operator == "B*" || o.Operator == "b*", s.fillColour, s)
+			}
+			r.stroke(p, s)
+			endPath()
+		case "n":
+			endPath()
+		case "W":
+			clip, clipEvenOdd = true, false
+		case "W*":
+			clip, clipEvenOdd = true, true
+		// Colour
+		case "CS":
+			s.strokeSpace = newColourSpace(o.Operands[0], resources)
+			s.stroke = s.strokeSpace.initial()
+			s.updateColours()
+		case "cs":
+			s.fillSpace = newColourSpace(o.Operands[0], resources)
+			s.fill = s.fillSpace.initial()
+			s.updateColours()
+		case "SC", "SCN":
+			s.stroke = numbers(o.Operands)
+			s.updateColours()
+		case "sc", "scn":
+			s.fill = numbers(o.Operands)
+			s.updateColours()
+		case "G":
+			s.strokeSpace, s.stroke = deviceGray, numbers(o.Operands)
+			s.updateColours()
+		case "g":
+			s.fillSpace, s.fill = deviceGray, numbers(o.Operands)
+			s.updateColours()
+		case "RG":
+			s.strokeSpace, s.stroke = deviceRGB, numbers(o.Operands)
+			s.updateColours()
+		case "rg":
+			s.fillSpace, s.fill = deviceRGB, numbers(o.Operands)
+			s.updateColours()
+		case "K":
+			s.strokeSpace, s.stroke = deviceCMYK, numbers(o.Operands)
+			s.updateColours()
+		case "k":
+			s.fillSpace, s.fill = deviceCMYK, numbers(o.Operands)
+			s.updateColours()
+		// Text
+		case "BT":
+			tm = content.IdentityMatrix()
+			tlm = tm
+		case "Tc":
+			s.characterSpacing = o.Number(0)
+		case "Tw":
+			s.wordSpacing = o.Number(0)
+		case "Tz":
+			s.scale = o.Number(0) / 100
+		case "TL":
+			s.leading = o.Number(0)
+		case "Ts":
+			s.rise = o.Number(0)
+		case "Tr":
+			s.render = int(o.Number(0))
+		case "Tf":
+			s.font = r.font(resources, o.Name(0))
+			s.size = o.Number(1)
+		case "Td":
+			tlm = content.TranslationMatrix(o.Number(0), o.Number(1)).Multiply(tlm)
+			tm = tlm
+		case "TD":
+			s.leading = -o.Number(1)
+			tlm = content.TranslationMatrix(o.Number(0), o.Number(1)).Multiply(tlm)
+			tm = tlm
+		case "Tm":
+			tlm = content.MatrixOperands(o)
+			tm = tlm
+		case "T*":
+			tlm = content.TranslationMatrix(0, -s.leading).Multiply(tlm)
+			tm = tlm
+		case "Tj", "'", "\"":
+			if o.Operator != "Tj" {
+				if o.Operator == "\"" {
+					s.wordSpacing = o.Number(0)
+					s.characterSpacing = o.Number(1)
+				}
+				tlm = content.TranslationMatrix(0, -s.leading).Multiply(tlm)
+				tm = tlm
+			}
+			if len(o.Operands) > 0 {
+				if str, ok := o.Operands[len(o.Operands)-1].(*pdfgo.StringObject); ok {
+					r.showText(str, s, &tm)
+				}
+			}
+		case "TJ":
+			if len(o.Operands) == 0 {
+				continue
+			}
+			a, ok := o.Operands[0].(*pdfgo.ArrayObject)
+			if !ok {
+				continue
+			}
+			for _, element := range a.Array {
+				switch v := element.(type) {
+				case *pdfgo.NumberObject:
+					tx := -v.Number / 1000 * s.size * s.scale
+					tm = content.TranslationMatrix(tx, 0).Multiply(tm)
+				case *pdfgo.StringObject:
+					r.showText(v, s, &tm)
+				}
+			}
+		// External objects
+		case "Do":
+			if err := r.drawXObject(resources, o.Name(0), s, depth); err != nil {
+				log.Println("XObject", o.Name(0)+":", err)
+			}
+		case "BI":
+			img, err := decodeImage(inlineStream(o.ImageDictionary, o.ImageData), resources)
+			if err != nil {
+				log.Println("Inline Image:", err)
+				continue
+			}
+			r.drawImage(img, s)
+		}
+	}
+	return nil
+}
+
+func numbers(operands []pdfgo.Object) []float64 {
+	var values []float64
+	for _, o := range operands {
+		if n, ok := o.(*pdfgo.NumberObject); ok {
+			values = append(values, n.Number)
+		}
+	}
+	return values
+}
+
+func dashOperands(operands []pdfgo.Object) ([]float64, float64) {
+	if len(operands) < 2 {
+		return nil, 0
+	}
+	a, ok := pdfgo.Resolve(operands[0]).(*pdfgo.ArrayObject)
+	if !ok {
+		return nil, 0
+	}
+	var phase float64
+	if n, ok := pdfgo.Resolve(operands[1]).(*pdfgo.NumberObject); ok {
+		phase = n.Number
+	}
+	var array []pdfgo.Object
+	for _, e := range a.Array {
+		array = append(array, pdfgo.Resolve(e))
+	}
+	return numbers(array), phase
+}
+
+func (r *renderer) setGraphicsState(resources *pdfgo.DictionaryObject, name string, s *state) {
+	states, ok := pdfgo.Resolve(resources.GetEntry("ExtGState")).(*pdfgo.DictionaryObject)
+	if !ok {
+		return
+	}
+	g, ok := pdfgo.Resolve(states.GetEntry(name)).(*pdfgo.DictionaryObject)
+	if !ok {
+		return
+	}
+	for _, k := range g.Keys {
+		v := pdfgo.Resolve(g.Dictionary[k])
+		n, _ := v.(*pdfgo.NumberObject)
+		switch k.Name {
+		case "LW":
+			if n != nil {
+				s.lineWidth = n.Number
+			}
+		case "LC":
+			if n != nil {
+				s.lineCap = int(n.Number)
+			}
+		case "LJ":
+			if n != nil {
+				s.lineJoin = int(n.Number)
+			}
+		case "ML":
+			if n != nil {
+				s.miterLimit = n.Number
+			}
+		case "D":
+			if a, ok := v.(*pdfgo.ArrayObject); ok {
+				s.dash, s.dashPhase = dashOperands(a.Array)
+			}
+		case "CA":
+			if n != nil {
+				s.strokeAlpha = n.Number
+			}
+		case "ca":
+			if n != nil {
+				s.fillAlpha = n.Number
+			}
+		}
+	}
+	s.updateColours()
+}
+
+func (r *renderer) font(resources *pdfgo.DictionaryObject, name string) *renderFont {
+	var dictionary *pdfgo.DictionaryObject
+	if fonts, ok := pdfgo.Resolve(resources.GetEntry("Font")).(*pdfgo.DictionaryObject); ok {
+		dictionary, _ = pdfgo.Resolve(fonts.GetEntry(name)).(*pdfgo.DictionaryObject)
+	}
+	if dictionary == nil {
+		log.Println("Missing Font:", name)
+		dictionary = &pdfgo.DictionaryObject{
+			Dictionary: make(map[*pdfgo.NameObject]pdfgo.Object),
+		}
+	}
+	if f, ok := r.fonts[dictionary]; ok {
+		return f
+	}
+	f := newRenderFont(dictionary)
+	r.fonts[dictionary] = f
+	return f
+}
+
+func (r *renderer) showText(str *pdfgo.StringObject, s *state, tm *content.Matrix) {
+	if s.font == nil {
+		log.Println("Text shown without a font")
+		s.font = r.font(&pdfgo.DictionaryObject{}, "")
+	}
+	fill := s.render == 0 || s.render == 2 || s.render == 4 || s.render == 6
+	stroke := s.render == 1 || s.render == 2 || s.render == 5 || s.render == 6
+	for _, code := range s.font.decoder.Decode(str.Bytes()) {
+		if fill || stroke {
+			trm := content.Matrix{s.size * s.scale, 0, 0, s.size, 0, s.rise}.Multiply(*tm).Multiply(s.ctm)
+			p := &path{}
+			s.font.outline(p, code, trm.Multiply(r.base))
+			if fill && !s.fillSpace.pattern {
+				r.fill(p, false, s.fillColour, s)
+			}
+			if stroke {
+				r.stroke(p, s)
+			}
+		}
+		tx := code.Width*s.size + s.characterSpacing
+		if code.Space {
+			tx += s.wordSpacing
+		}
+		*tm = content.TranslationMatrix(tx*s.scale, 0).Multiply(*tm)
+	}
+}
+
+func (r *renderer) drawXObject(resources *pdfgo.DictionaryObject, name string, s *state, depth int) error {
+	xobjects, ok := pdfgo.Resolve(resources.GetEntry("XObject")).(*pdfgo.DictionaryObject)
+	if !ok {
+		return errors.New("Missing XObject Resources")
+	}
+	x, ok := pdfgo.Resolve(xobjects.GetEntry(name)).(*pdfgo.StreamObject)
+	if !ok {
+		return errors.New("Missing XObject")
+	}
+	subtype, _ := pdfgo.Resolve(x.GetEntry("Subtype")).(*pdfgo.NameObject)
+	if subtype == nil {
+		return errors.New("Missing XObject Subtype")
+	}
+	switch subtype.Name {
+	case "Image":
+		img, err := decodeImage(x, resources)
+		if err != nil {
+			return err
+		}
+		r.drawImage(img, s)
+	case "Form":
+		if depth >= MAX_FORM_DEPTH || r.forms[x] {
+			return errors.New("Recursive Form")
+		}
+		r.forms[x] = true
+		defer delete(r.forms, x)
+		data, err := x.Decode()
+		if err != nil {
+			return err
+		}
+		operations, err := content.Parse(data)
+		if err != nil {
+			return err
+		}
+		form := *s
+		if a, ok := pdfgo.Resolve(x.GetEntry("Matrix")).(*pdfgo.ArrayObject); ok && len(a.Array) == 6 {
+			var m content.Matrix
+			for i, o := range a.Array {
+				if n, ok := pdfgo.Resolve(o).(*pdfgo.NumberObject); ok {
+					m[i] = n.Number
+				}
+			}
+			form.ctm = m.Multiply(form.ctm)
+		}
+		if b, ok := pdfgo.Resolve(x.GetEntry("BBox")).(*pdfgo.ArrayObject); ok && len(b.Array) == 4 {
+			// Forms are clipped to their bounding box
+			m := form.ctm.Multiply(r.base)
+			x0, y0, x1, y1 := pdfgo.NumberValue(b.Array[0]), pdfgo.NumberValue(b.Array[1]), pdfgo.NumberValue(b.Array[2]), pdfgo.NumberValue(b.Array[3])
+			bbox := &path{}
+			bbox.moveTo(transform(m, x0, y0))
+			bbox.lineTo(transform(m, x1, y0))
+			bbox.lineTo(transform(m, x1, y1))
+			bbox.lineTo(transform(m, x0, y1))
+			bbox.close()
+			r.clip(bbox, false, &form)
+		}
+		formResources := resources
+		if fr, ok := pdfgo.Resolve(x.GetEntry("Resources")).(*pdfgo.DictionaryObject); ok {
+			formResources = fr
+		}
+		return r.execute(operations, formResources, &form, depth+1)
+	default:
+		return fmt.Errorf("Unsupported XObject Subtype: %s", subtype.Name)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package render_test
+
+import (
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/AletheiaWareLLC/pdfgo/render"
+	"github.com/stretchr/testify/assert"
+	"image/color"
+	"testing"
+)
+
+func newPage(p *pdfgo.PDF, data string, resources pdfgo.Object) {
+	contents := p.NewStreamObject()
+	contents.Data = []byte(data)
+	p.AddPage(100, 200, resources, pdfgo.NewObjectReference(contents))
+}
+
+func TestRender(t *testing.T) {
+	p := pdfgo.NewPDF()
+	newPage(p, "1 0 0 rg 10 10 30 20 re f 0 0 1 RG 10 w 50 150 m 90 150 l S", nil)
+	img, err := render.Render(p, 0, 72)
+	assert.Nil(t, err)
+	assert.Equal(t, 100, img.Bounds().Dx())
+	assert.Equal(t, 200, img.Bounds().Dy())
+	// Device space has its origin at the top left
+	assert.Equal(t, color.RGBA{255, 0, 0, 255}, img.RGBAAt(20, 180))
+	assert.Equal(t, color.RGBA{255, 255, 255, 255}, img.RGBAAt(20, 20))
+	assert.Equal(t, color.RGBA{0, 0, 255, 255}, img.RGBAAt(70, 50))
+	assert.Equal(t, color.RGBA{255, 255, 255, 255}, img.RGBAAt(70, 40))
+}
+
+func TestRender_resolution(t *testing.T) {
+	p := pdfgo.NewPDF()
+	newPage(p, "0 g 0 0 50 50 re f", nil)
+	img, err := render.Render(p, 0, 144)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, img.Bounds().Dx())
+	assert.Equal(t, 400, img.Bounds().Dy())
+	assert.Equal(t, color.RGBA{0, 0, 0, 255}, img.RGBAAt(90, 310))
+	assert.Equal(t, color.RGBA{255, 255, 255, 255}, img.RGBAAt(110, 310))
+}
+
+func TestRender_clip(t *testing.T) {
+	p := pdfgo.NewPDF()
+	newPage(p, "q 0 0 50 200 re W n 0 g 0 0 100 200 re f Q 0 1 0 rg 60 0 40 10 re f", nil)
+	img, err := render.Render(p, 0, 72)
+	assert.Nil(t, err)
+	assert.Equal(t, color.RGBA{0, 0, 0, 255}, img.RGBAAt(25, 100))
+	assert.Equal(t, color.RGBA{255, 255, 255, 255}, img.RGBAAt(75, 100))
+	// Clip is restored with the graphics state
+	assert.Equal(t, color.RGBA{0, 255, 0, 255}, img.RGBAAt(80, 195))
+}
+
+func TestRender_image(t *testing.T) {
+	p := pdfgo.NewPDF()
+	image := p.NewStreamObject()
+	image.AddNameNameEntry("Type", "XObject")
+	image.AddNameNameEntry("Subtype", "Image")
+	image.AddNameObjectEntry("Width", &pdfgo.NumberObject{Number: 2})
+	image.AddNameObjectEntry("Height", &pdfgo.NumberObject{Number: 1})
+	image.AddNameNameEntry("ColorSpace", "DeviceRGB")
+	image.AddNameObjectEntry("BitsPerComponent", &pdfgo.NumberObject{Number: 8})
+	image.Data = []byte{255, 0, 0, 0, 0, 255}
+	xobjects := p.NewDictionaryObject()
+	xobjects.AddNameObjectEntry("Im1", pdfgo.NewObjectReference(image))
+	resources := p.NewDictionaryObject()
+	resources.AddNameObjectEntry("XObject", xobjects)
+	newPage(p, "q 100 0 0 200 0 0 cm /Im1 Do Q", resources)
+	img, err := render.Render(p, 0, 72)
+	assert.Nil(t, err)
+	assert.Equal(t, color.RGBA{255, 0, 0, 255}, img.RGBAAt(25, 100))
+	assert.Equal(t, color.RGBA{0, 0, 255, 255}, img.RGBAAt(75, 100))
+}
+
+func TestRender_text(t *testing.T) {
+	p := pdfgo.NewPDF()
+	f := p.NewDictionaryObject()
+	f.AddNameNameEntry("Type", "Font")
+	f.AddNameNameEntry("Subtype", "Type1")
+	f.AddNameNameEntry("BaseFont", "Helvetica")
+	fonts := p.NewDictionaryObject()
+	fonts.AddNameObjectEntry("F1", pdfgo.NewObjectReference(f))
+	resources := p.NewDictionaryObject()
+	resources.AddNameObjectEntry("Font", fonts)
+	newPage(p, "BT /F1 100 Tf 10 50 Td (I) Tj ET", resources)
+	img, err := render.Render(p, 0, 72)
+	assert.Nil(t, err)
+	// The stem of the I is filled, the space beside it is not
+	assert.Equal(t, color.RGBA{0, 0, 0, 255}, img.RGBAAt(24, 110))
+	assert.Equal(t, color.RGBA{255, 255, 255, 255}, img.RGBAAt(60, 110))
+}
+
+func TestRender_pageIndex(t *testing.T) {
+	_, err := render.Render(pdfgo.NewPDF(), 0, 72)
+	assert.NotNil(t, err)
+}
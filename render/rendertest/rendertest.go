@@ -0,0 +1,211 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package rendertest compares rendered pages against golden images.
+//
+// Golden images live in the testdata directory of the package under test.
+// Run the tests with -update to regenerate them after an intended change.
+// When an image doesn't match, the rendered image and an image highlighting the differing pixels
+// are written beside the golden image with the suffixes .actual.png and .diff.png.
+package rendertest
+
+import (
+	"flag"
+	"fmt"
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/AletheiaWareLLC/pdfgo/render"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Update regenerates golden images instead of comparing against them.
+var Update = flag.Bool("update", false, "Regenerate golden images")
+
+// DPI is the resolution pages are rendered at.
+const DPI = 72
+
+// Largest possible YIQ difference between two colours
+const MAX_DELTA = 35215
+
+// Tolerance controls how different an image can be from its golden image.
+type Tolerance struct {
+	// Threshold is the perceptual colour difference, from 0 to 1, above which a pixel is counted as different
+	Threshold float64
+	// MaxDifferent is the fraction of pixels which may differ
+	MaxDifferent float64
+}
+
+// DefaultTolerance ignores slight anti-aliasing differences while catching moved or recoloured content.
+var DefaultTolerance = Tolerance{
+	Threshold:    0.1,
+	MaxDifferent: 0.001,
+}
+
+// Result describes the differences between two images.
+type Result struct {
+	Different, Total int
+	// Diff shows the expected image faded, with differing pixels in red
+	Diff *image.RGBA
+}
+
+// Fraction returns the fraction of pixels which differ.
+func (r *Result) Fraction() float64 {
+	if r.Total == 0 {
+		return 0
+	}
+	return float64(r.Different) / float64(r.Total)
+}
+
+// Compare returns the pixels which differ perceptually between the expected and actual images.
+func Compare(expected, actual image.Image, threshold float64) (*Result, error) {
+	eb, ab := expected.Bounds(), actual.Bounds()
+	if eb.Dx() != ab.Dx() || eb.Dy() != ab.Dy() {
+		return nil, fmt.Errorf("Image Size Mismatch: Expected %dx%d, Actual %dx%d", eb.Dx(), eb.Dy(), ab.Dx(), ab.Dy())
+	}
+	result := &Result{
+		Total: eb.Dx() * eb.Dy(),
+		Diff:  image.NewRGBA(image.Rect(0, 0, eb.Dx(), eb.Dy())),
+	}
+	for y := 0; y < eb.Dy(); y++ {
+		for x := 0; x < eb.Dx(); x++ {
+			e := color.NRGBAModel.Convert(expected.At(eb.Min.X+x, eb.Min.Y+y)).(color.NRGBA)
+			a := color.NRGBAModel.Convert(actual.At(ab.Min.X+x, ab.Min.Y+y)).(color.NRGBA)
+			if Difference(e, a) > threshold {
+				result.Different++
+				result.Diff.SetRGBA(x, y, color.RGBA{255, 0, 0, 255})
+				continue
+			}
+			// Fade unchanged pixels so the differences stand out
+			l := uint8(255 - (255-luma(e))/4)
+			result.Diff.SetRGBA(x, y, color.RGBA{l, l, l, 255})
+		}
+	}
+	return result, nil
+}
+
+// Difference returns the perceptual difference between two colours, from 0 to 1, measured in YIQ space after blending onto white.
+func Difference(a, b color.NRGBA) float64 {
+	ar, ag, ab := blend(a)
+	br, bg, bb := blend(b)
+	dy := yiqY(ar, ag, ab) - yiqY(br, bg, bb)
+	di := yiqI(ar, ag, ab) - yiqI(br, bg, bb)
+	dq := yiqQ(ar, ag, ab) - yiqQ(br, bg, bb)
+	delta := 0.5053*dy*dy + 0.299*di*di + 0.1957*dq*dq
+	return math.Sqrt(delta / MAX_DELTA)
+}
+
+func blend(c color.NRGBA) (float64, float64, float64) {
+	a := float64(c.A) / 255
+	return 255 + (float64(c.R)-255)*a, 255 + (float64(c.G)-255)*a, 255 + (float64(c.B)-255)*a
+}
+
+func yiqY(r, g, b float64) float64 {
+	return r*0.29889531 + g*0.58662247 + b*0.11448223
+}
+
+func yiqI(r, g, b float64) float64 {
+	return r*0.59597799 - g*0.27417610 - b*0.32180189
+}
+
+func yiqQ(r, g, b float64) float64 {
+	return r*0.21147017 - g*0.52261711 + b*0.31114694
+}
+
+func luma(c color.NRGBA) uint8 {
+	r, g, b := blend(c)
+	return uint8(math.Round(yiqY(r, g, b)))
+}
+
+// AssertPage renders the page at the given index and compares it to the golden image.
+func AssertPage(t testing.TB, p *pdfgo.PDF, index int, golden string) {
+	t.Helper()
+	img, err := render.Render(p, index, DPI)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	AssertImage(t, img, golden, DefaultTolerance)
+}
+
+// AssertImage compares the image to the golden image within the tolerance, or replaces the golden image if Update is set.
+func AssertImage(t testing.TB, img image.Image, golden string, tolerance Tolerance) {
+	t.Helper()
+	base := strings.TrimSuffix(golden, ".png")
+	actualPath := base + ".actual.png"
+	diffPath := base + ".diff.png"
+	if *Update {
+		if err := writePNG(golden, img); err != nil {
+			t.Fatalf("Update Golden: %v", err)
+		}
+		t.Logf("Updated %s", golden)
+		os.Remove(actualPath)
+		os.Remove(diffPath)
+		return
+	}
+	expected, err := readPNG(golden)
+	if err != nil {
+		t.Fatalf("Read Golden: %v (run with -update to create it)", err)
+	}
+	result, err := Compare(expected, img, tolerance.Threshold)
+	if err != nil {
+		if err := writePNG(actualPath, img); err != nil {
+			t.Logf("Write Actual: %v", err)
+		}
+		t.Fatalf("%s: %v", golden, err)
+	}
+	if result.Fraction() <= tolerance.MaxDifferent {
+		// Remove outputs of earlier failures
+		os.Remove(actualPath)
+		os.Remove(diffPath)
+		return
+	}
+	if err := writePNG(actualPath, img); err != nil {
+		t.Logf("Write Actual: %v", err)
+	}
+	if err := writePNG(diffPath, result.Diff); err != nil {
+		t.Logf("Write Diff: %v", err)
+	}
+	t.Errorf("%s: %d of %d pixels differ (%.3f%%), see %s and %s", golden, result.Different, result.Total, result.Fraction()*100, actualPath, diffPath)
+}
+
+func readPNG(name string) (image.Image, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return png.Decode(file)
+}
+
+func writePNG(name string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(name), os.ModePerm); err != nil {
+		return err
+	}
+	file, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(file, img); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,101 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rendertest_test
+
+import (
+	"github.com/AletheiaWareLLC/pdfgo/render/rendertest"
+	"github.com/stretchr/testify/assert"
+	"image"
+	"image/color"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newImage(c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 10; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestDifference(t *testing.T) {
+	white := color.NRGBA{255, 255, 255, 255}
+	black := color.NRGBA{0, 0, 0, 255}
+	assert.Equal(t, 0., rendertest.Difference(white, white))
+	assert.True(t, rendertest.Difference(white, black) > 0.9)
+	// Transparent pixels are treated as white
+	assert.Equal(t, 0., rendertest.Difference(white, color.NRGBA{0, 0, 0, 0}))
+	assert.True(t, rendertest.Difference(white, color.NRGBA{250, 250, 250, 255}) < rendertest.DefaultTolerance.Threshold)
+}
+
+func TestCompare(t *testing.T) {
+	expected := newImage(color.RGBA{255, 255, 255, 255})
+	actual := newImage(color.RGBA{255, 255, 255, 255})
+	actual.SetRGBA(1, 2, color.RGBA{0, 0, 0, 255})
+	actual.SetRGBA(3, 4, color.RGBA{252, 252, 252, 255})
+	result, err := rendertest.Compare(expected, actual, rendertest.DefaultTolerance.Threshold)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, result.Different)
+	assert.Equal(t, 100, result.Total)
+	assert.Equal(t, color.RGBA{255, 0, 0, 255}, result.Diff.RGBAAt(1, 2))
+
+	_, err = rendertest.Compare(expected, image.NewRGBA(image.Rect(0, 0, 5, 5)), 0)
+	assert.NotNil(t, err)
+}
+
+func TestAssertImage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rendertest")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	golden := filepath.Join(dir, "testdata", "white.png")
+
+	*rendertest.Update = true
+	rendertest.AssertImage(t, newImage(color.RGBA{255, 255, 255, 255}), golden, rendertest.DefaultTolerance)
+	*rendertest.Update = false
+	_, err = os.Stat(golden)
+	assert.Nil(t, err)
+
+	rendertest.AssertImage(t, newImage(color.RGBA{254, 254, 254, 255}), golden, rendertest.DefaultTolerance)
+
+	// A failing comparison writes the actual and diff images
+	tb := &recorder{TB: t}
+	rendertest.AssertImage(tb, newImage(color.RGBA{0, 0, 0, 255}), golden, rendertest.DefaultTolerance)
+	assert.True(t, tb.failed)
+	_, err = os.Stat(filepath.Join(dir, "testdata", "white.actual.png"))
+	assert.Nil(t, err)
+	_, err = os.Stat(filepath.Join(dir, "testdata", "white.diff.png"))
+	assert.Nil(t, err)
+}
+
+// recorder captures failures instead of failing the test.
+type recorder struct {
+	testing.TB
+	failed bool
+}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.failed = true
+}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.failed = true
+}
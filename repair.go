@@ -0,0 +1,250 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pdfgo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+type RepairReport struct {
+	RebuiltCrossReference  bool
+	RecoveredTrailer       bool
+	RecoveredCatalog       bool
+	RecoveredStreamLengths []int
+	// Objects that could not be parsed and were dropped
+	DroppedObjects []int
+	Messages       []string
+}
+
+// Repaired returns true if any part of the document had to be repaired.
+func (r *RepairReport) Repaired() bool {
+	return len(r.Messages) > 0
+}
+
+func (r *RepairReport) add(format string, args ...interface{}) {
+	r.Messages = append(r.Messages, fmt.Sprintf(format, args...))
+}
+
+var objectPattern = regexp.MustCompile(`(\d+)[\x00\t\n\f\r ]+(\d+)[\x00\t\n\f\r ]+obj\b`)
+
+// scan rebuilds the cross reference table by searching the file for "number generation obj" markers.
+// When an object number occurs more than once the last occurrence wins, as it would in an incremental update.
+func (r *reader) scan() {
+	r.xref = make(map[int]*xrefEntry)
+	for _, m := range objectPattern.FindAllSubmatchIndex(r.data, -1) {
+		start := m[0]
+		if start > 0 && !IsWhitespace(r.data[start-1]) && !IsDelimiter(r.data[start-1]) {
+			continue
+		}
+		r.xref[atoi(r.data[m[2]:m[3]])] = &xrefEntry{
+			Offset:     start,
+			Generation: atoi(r.data[m[4]:m[5]]),
+		}
+	}
+	var streams []int
+	for _, number := range r.sortedEntries() {
+		if _, err := r.load(number); err != nil {
+			r.report.add("Dropped %s", err)
+			r.report.DroppedObjects = append(r.report.DroppedObjects, number)
+			delete(r.xref, number)
+			continue
+		}
+		if s, ok := r.objects[number].(*StreamObject); ok {
+			if t, ok := s.GetEntry("Type").(*NameObject); ok {
+				switch t.Name {
+				case "ObjStm":
+					streams = append(streams, number)
+				case "XRef":
+					// Cross reference streams also serve as the trailer
+					if s.HasEntry("Root") {
+						r.trailer = &s.DictionaryObject
+					}
+				}
+			}
+		}
+	}
+	// Objects inside object streams, unless also found uncompressed
+	for _, n := range streams {
+		s := r.objects[n].(*StreamObject)
+		objects, err := r.readObjectStream(s)
+		if err != nil {
+			r.report.add("Could not read object stream %d: %s", n, err)
+			continue
+		}
+		for number, o := range objects {
+			if _, ok := r.objects[number]; !ok {
+				r.objects[number] = o
+			}
+		}
+	}
+	r.findTrailer()
+}
+
+// findTrailer merges the trailer dictionaries found in the file, with later ones taking precedence.
+func (r *reader) findTrailer() {
+	var trailers []*DictionaryObject
+	data := r.data
+	offset := 0
+	for {
+		i := bytes.Index(data[offset:], []byte("trailer"))
+		if i < 0 {
+			break
+		}
+		p := NewParser(r.data)
+		p.Offset = offset + i + len("trailer")
+		if o, err := p.ParseObject(); err == nil {
+			if d, ok := o.(*DictionaryObject); ok {
+				trailers = append(trailers, d)
+			}
+		}
+		offset += i + len("trailer")
+	}
+	if len(trailers) == 0 {
+		if r.trailer == nil {
+			r.report.add("Missing trailer")
+			r.trailer = &DictionaryObject{
+				Dictionary: make(map[*NameObject]Object),
+			}
+		}
+		return
+	}
+	trailer := &DictionaryObject{
+		Dictionary: make(map[*NameObject]Object),
+	}
+	if r.trailer != nil {
+		trailers = append([]*DictionaryObject{r.trailer}, trailers...)
+	}
+	for _, t := range trailers {
+		for _, k := range []string{"Root", "Info", "ID", "Encrypt"} {
+			if v := t.GetEntry(k); v != nil {
+				trailer.SetNameObjectEntry(k, v)
+			}
+		}
+	}
+	r.trailer = trailer
+}
+
+// findCatalog ensures the trailer refers to a valid Catalog, searching the objects for one if not, and creating one if none exists.
+func (r *reader) findCatalog() error {
+	if r.trailer == nil {
+		r.trailer = &DictionaryObject{
+			Dictionary: make(map[*NameObject]Object),
+		}
+	}
+	if ref, ok := r.trailer.GetEntry("Root").(*ObjectReference); ok {
+		if d, ok := r.objects[ref.GetName()].(*DictionaryObject); ok && isType(d, "Catalog") && d.HasEntry("Pages") {
+			return nil
+		}
+		r.report.add("Trailer Root %d is not a valid Catalog", ref.GetName())
+	} else {
+		r.report.add("Trailer missing Root")
+	}
+	r.report.RecoveredTrailer = true
+	numbers := r.sortedNumbers()
+	// Use the last Catalog in the file, as it is likely from the latest update
+	for i := len(numbers) - 1; i >= 0; i-- {
+		if d, ok := r.objects[numbers[i]].(*DictionaryObject); ok && isType(d, "Catalog") && d.HasEntry("Pages") {
+			r.trailer.SetNameObjectEntry("Root", NewObjectReference(d))
+			r.report.add("Found Catalog in object %d", numbers[i])
+			return nil
+		}
+	}
+	// Otherwise build a Catalog around the root of the page tree
+	r.report.RecoveredCatalog = true
+	var root *DictionaryObject
+	for i := len(numbers) - 1; i >= 0; i-- {
+		if d, ok := r.objects[numbers[i]].(*DictionaryObject); ok && isType(d, "Pages") && !d.HasEntry("Parent") {
+			root = d
+			r.report.add("Found Pages in object %d", numbers[i])
+			break
+		}
+	}
+	if root == nil {
+		// Collect the orphaned pages into a new page tree
+		root = r.newDictionary()
+		root.AddNameNameEntry("Type", "Pages")
+		kids := &ArrayObject{}
+		for _, n := range numbers {
+			if d, ok := r.objects[n].(*DictionaryObject); ok && isType(d, "Page") {
+				d.SetNameObjectEntry("Parent", NewObjectReference(root))
+				kids.Array = append(kids.Array, NewObjectReference(d))
+			}
+		}
+		if len(kids.Array) == 0 {
+			return errors.New("Could not find any pages")
+		}
+		root.AddNameObjectEntry("Kids", kids)
+		root.AddNameObjectEntry("Count", &NumberObject{Number: float64(len(kids.Array))})
+		r.report.add("Created Pages for %d orphaned pages", len(kids.Array))
+	}
+	catalog := r.newDictionary()
+	catalog.AddNameNameEntry("Type", "Catalog")
+	catalog.AddNameObjectEntry("Pages", NewObjectReference(root))
+	r.trailer.SetNameObjectEntry("Root", NewObjectReference(catalog))
+	r.report.add("Created Catalog")
+	return nil
+}
+
+// newDictionary creates an indirect dictionary numbered after all the existing objects.
+func (r *reader) newDictionary() *DictionaryObject {
+	d := &DictionaryObject{
+		Dictionary: make(map[*NameObject]Object),
+	}
+	numbers := r.sortedNumbers()
+	n := 1
+	if len(numbers) > 0 {
+		n = numbers[len(numbers)-1] + 1
+	}
+	d.SetName(n)
+	r.objects[n] = d
+	return d
+}
+
+func (r *reader) sortedEntries() []int {
+	var numbers []int
+	for n := range r.xref {
+		numbers = append(numbers, n)
+	}
+	sort.Ints(numbers)
+	return numbers
+}
+
+func (r *reader) sortedNumbers() []int {
+	var numbers []int
+	for n := range r.objects {
+		numbers = append(numbers, n)
+	}
+	sort.Ints(numbers)
+	return numbers
+}
+
+func isType(d *DictionaryObject, name string) bool {
+	t, ok := Resolve(d.GetEntry("Type")).(*NameObject)
+	return ok && t.Name == name
+}
+
+func atoi(b []byte) int {
+	n := 0
+	for _, c := range b {
+		n = n*10 + int(c-'0')
+	}
+	return n
+}
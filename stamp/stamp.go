@@ -0,0 +1,304 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package stamp
+
+import (
+	"errors"
+	"fmt"
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/AletheiaWareLLC/pdfgo/content"
+	"github.com/AletheiaWareLLC/pdfgo/font"
+	"github.com/AletheiaWareLLC/pdfgo/graphics"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Layer determines whether a stamp is drawn over or under the existing page content.
+type Layer int
+
+const (
+	Overlay Layer = iota
+	Underlay
+)
+
+// Stamp is a form XObject placed on existing pages.
+type Stamp struct {
+	// Form draws the stamp in the box from the origin to Width and Height
+	Form          *pdfgo.ObjectReference
+	Width, Height float64
+	Layer         Layer
+	// X and Y locate the centre of the stamp as a fraction of the width and height of the page as it is displayed, from the bottom left
+	X, Y float64
+	// Rotation is the angle of the stamp in degrees, anticlockwise
+	Rotation float64
+	// Opacity ranges from 0, invisible, to 1, opaque
+	Opacity float64
+	// Scale multiplies the size of the stamp
+	Scale float64
+}
+
+// NewStamp returns a stamp of the given form, centred on the page and fully opaque.
+func NewStamp(form *pdfgo.ObjectReference, width, height float64) *Stamp {
+	return &Stamp{
+		Form:    form,
+		Width:   width,
+		Height:  height,
+		X:       0.5,
+		Y:       0.5,
+		Opacity: 1,
+		Scale:   1,
+	}
+}
+
+// NewTextStamp returns a stamp of a single line of text in the named standard font.
+func NewTextStamp(p *pdfgo.PDF, text, fontName string, size float64, colour []float64) (*Stamp, error) {
+	if !font.IsStandardFont(fontName) {
+		return nil, fmt.Errorf("Unsupported Stamp Font: %s", fontName)
+	}
+	encoding := font.GetEncoding("WinAnsiEncoding")
+	var codes []byte
+	var width float64
+	for _, r := range text {
+		c, ok := encoding.Encode(r)
+		if !ok {
+			return nil, fmt.Errorf("Unsupported Stamp Character: %q", r)
+		}
+		codes = append(codes, c)
+		width += font.StandardFontWidth(fontName, r) * size / 1000
+	}
+	height := size * (graphics.ASCENT - graphics.DESCENT)
+
+	f := p.NewDictionaryObject()
+	f.AddNameNameEntry("Type", "Font")
+	f.AddNameNameEntry("Subtype", "Type1")
+	f.AddNameNameEntry("BaseFont", fontName)
+	f.AddNameNameEntry("Encoding", "WinAnsiEncoding")
+	fonts := &pdfgo.DictionaryObject{
+		Dictionary: make(map[*pdfgo.NameObject]pdfgo.Object),
+	}
+	fonts.AddNameObjectEntry("F1", pdfgo.NewObjectReference(f))
+	resources := &pdfgo.DictionaryObject{
+		Dictionary: make(map[*pdfgo.NameObject]pdfgo.Object),
+	}
+	resources.AddNameObjectEntry("Font", fonts)
+
+	writer := graphics.NewContentWriter()
+	if colour != nil {
+		writer.SetFillColour(colour)
+	}
+	writer.BeginText()
+	writer.SetFont("F1", size)
+	writer.MoveText(0, -graphics.DESCENT*size)
+	writer.ShowText(pdfgo.EscapeString(string(codes)))
+	writer.EndText()
+	data, err := writer.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	return NewStamp(p.AddForm(0, 0, width, height, nil, resources, data), width, height), nil
+}
+
+// NewImageStamp returns a stamp of the given image, drawn at the given size, or one point per pixel if the size is zero.
+func NewImageStamp(p *pdfgo.PDF, mime string, data []byte, width, height float64) (*Stamp, error) {
+	image, w, h, err := p.AddImage(mime, data)
+	if err != nil {
+		return nil, err
+	}
+	if width <= 0 || height <= 0 {
+		width, height = w, h
+	}
+	xs := &pdfgo.DictionaryObject{
+		Dictionary: make(map[*pdfgo.NameObject]pdfgo.Object),
+	}
+	xs.AddNameObjectEntry("Im1", image)
+	resources := &pdfgo.DictionaryObject{
+		Dictionary: make(map[*pdfgo.NameObject]pdfgo.Object),
+	}
+	resources.AddNameObjectEntry("XObject", xs)
+
+	writer := graphics.NewContentWriter()
+	writer.SaveState()
+	writer.Transform(width, 0, 0, height, 0, 0)
+	writer.DrawXObject("Im1")
+	writer.RestoreState()
+	content, err := writer.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	return NewStamp(p.AddForm(0, 0, width, height, nil, resources, content), width, height), nil
+}
+
+// NewPageStamp returns a stamp of the given page, which may belong to another document.
+func NewPageStamp(p *pdfgo.PDF, page *pdfgo.DictionaryObject) (*Stamp, error) {
+	form, width, height, err := p.ImportPage(page)
+	if err != nil {
+		return nil, err
+	}
+	return NewStamp(form, width, height), nil
+}
+
+// Apply places the stamp on the pages at the given indices, or on every page if none are given.
+// It returns the page dictionaries that were changed, so that they can be written as an incremental update.
+func (s *Stamp) Apply(p *pdfgo.PDF, indices ...int) ([]pdfgo.Object, error) {
+	pages := p.GetPages()
+	if len(indices) == 0 {
+		for i := range pages {
+			indices = append(indices, i)
+		}
+	}
+	var changed []pdfgo.Object
+	for _, i := range indices {
+		if i < 0 || i >= len(pages) {
+			return nil, fmt.Errorf("Page Index Out of Range: %d", i)
+		}
+		if err := s.apply(p, pages[i]); err != nil {
+			return nil, err
+		}
+		changed = append(changed, pages[i])
+	}
+	return changed, nil
+}
+
+func (s *Stamp) apply(p *pdfgo.PDF, page *pdfgo.DictionaryObject) error {
+	if page.GetName() == 0 {
+		return errors.New("Cannot stamp direct page")
+	}
+	if s.Layer != Overlay && s.Layer != Underlay {
+		return fmt.Errorf("Unrecognized Layer: %d", s.Layer)
+	}
+	// Copy the resources so that pages sharing them are not affected
+	resources := pdfgo.PageResources(page).Copy()
+	xs, _ := pdfgo.Resolve(resources.GetEntry("XObject")).(*pdfgo.DictionaryObject)
+	xs = xs.Copy()
+	gs, _ := pdfgo.Resolve(resources.GetEntry("ExtGState")).(*pdfgo.DictionaryObject)
+	gs = gs.Copy()
+	resources.SetNameObjectEntry("XObject", xs)
+	resources.SetNameObjectEntry("ExtGState", gs)
+	formName := pdfgo.UniqueName(xs, "Stamp")
+	xs.AddNameObjectEntry(formName, s.Form)
+	stateName := pdfgo.UniqueName(gs, "StampGS")
+	state := &pdfgo.DictionaryObject{
+		Dictionary: make(map[*pdfgo.NameObject]pdfgo.Object),
+	}
+	state.AddNameNameEntry("Type", "ExtGState")
+	state.AddNameObjectEntry("CA", &pdfgo.NumberObject{
+		Number: s.Opacity,
+	})
+	state.AddNameObjectEntry("ca", &pdfgo.NumberObject{
+		Number: s.Opacity,
+	})
+	gs.AddNameObjectEntry(stateName, state)
+
+	// Centre the stamp on its position, then rotate and scale it about its centre, in the orientation the page is displayed
+	left, bottom, right, top := pdfgo.PageMediaBox(page)
+	width, height := right-left, top-bottom
+	rotate := 0
+	if n, ok := pdfgo.GetInheritedEntry(page, "Rotate").(*pdfgo.NumberObject); ok {
+		rotate = ((int(n.Number)%360 + 360) % 360) / 90 * 90
+	}
+	// The displayed page is turned clockwise, so map its space back to the user space of the page
+	var displayed content.Matrix
+	switch rotate {
+	case 90:
+		displayed = content.Matrix{0, 1, -1, 0, right, bottom}
+		width, height = height, width
+	case 180:
+		displayed = content.Matrix{-1, 0, 0, -1, right, top}
+	case 270:
+		displayed = content.Matrix{0, -1, 1, 0, left, top}
+		width, height = height, width
+	default:
+		displayed = content.Matrix{1, 0, 0, 1, left, bottom}
+	}
+	x, y := s.X*width, s.Y*height
+	sin, cos := math.Sincos(s.Rotation * math.Pi / 180)
+	a, b := s.Scale*cos, s.Scale*sin
+	dx, dy := s.Width/2, s.Height/2
+	m := content.Matrix{a, b, -b, a, x - a*dx + b*dy, y - b*dx - a*dy}.Multiply(displayed)
+
+	writer := graphics.NewContentWriter()
+	writer.SaveState()
+	writer.SetGraphicsState(stateName)
+	writer.Transform(m[0], m[1], m[2], m[3], m[4], m[5])
+	writer.DrawXObject(formName)
+	writer.RestoreState()
+	data, err := writer.Bytes()
+	if err != nil {
+		return err
+	}
+
+	page.SetNameObjectEntry("Resources", resources)
+	if s.Layer == Overlay {
+		return p.AddPageContent(page, data)
+	}
+	var original []pdfgo.Object
+	switch c := page.GetEntry("Contents").(type) {
+	case nil:
+	case *pdfgo.ObjectReference:
+		if a, ok := pdfgo.Resolve(c).(*pdfgo.ArrayObject); ok {
+			original = append(original, a.Array...)
+		} else {
+			original = append(original, c)
+		}
+	case *pdfgo.ArrayObject:
+		original = append(original, c.Array...)
+	default:
+		return errors.New("Invalid Page Contents")
+	}
+	stream := p.NewStreamObject()
+	stream.Data = data
+	contents := &pdfgo.ArrayObject{}
+	contents.Array = append(contents.Array, pdfgo.NewObjectReference(stream))
+	contents.Array = append(contents.Array, original...)
+	page.SetNameObjectEntry("Contents", contents)
+	return nil
+}
+
+// ParsePageRanges parses a comma separated list of page numbers and ranges, such as "1,3-5", counting from one, and returns the page indices, counting from zero.
+// An empty list selects every page.
+func ParsePageRanges(ranges string, count int) ([]int, error) {
+	var indices []int
+	if ranges == "" {
+		for i := 0; i < count; i++ {
+			indices = append(indices, i)
+		}
+		return indices, nil
+	}
+	for _, r := range strings.Split(ranges, ",") {
+		r = strings.TrimSpace(r)
+		first, last := r, r
+		if i := strings.Index(r, "-"); i >= 0 {
+			first, last = r[:i], r[i+1:]
+		}
+		f, err := strconv.Atoi(first)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid Page Range: %s", r)
+		}
+		l, err := strconv.Atoi(last)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid Page Range: %s", r)
+		}
+		if f < 1 || l > count || f > l {
+			return nil, fmt.Errorf("Page Range Out of Bounds: %s", r)
+		}
+		for i := f; i <= l; i++ {
+			indices = append(indices, i-1)
+		}
+	}
+	return indices, nil
+}
@@ -0,0 +1,180 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package stamp_test
+
+import (
+	"bytes"
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/AletheiaWareLLC/pdfgo/render"
+	"github.com/AletheiaWareLLC/pdfgo/stamp"
+	"github.com/stretchr/testify/assert"
+	"image/color"
+	"testing"
+)
+
+func newDocument(t *testing.T, pages int) *pdfgo.PDF {
+	t.Helper()
+	source := pdfgo.NewPDF()
+	for i := 0; i < pages; i++ {
+		contents := source.NewStreamObject()
+		contents.Data = []byte("0 0 1 rg 0 0 100 100 re f 1 0 0 1 50 0 cm")
+		source.AddPage(100, 100, nil, pdfgo.NewObjectReference(contents))
+	}
+	var buffer bytes.Buffer
+	assert.Nil(t, source.Write(&buffer))
+	p, err := pdfgo.ReadPDF(buffer.Bytes())
+	assert.Nil(t, err)
+	return p
+}
+
+func newSquareStamp(p *pdfgo.PDF) *stamp.Stamp {
+	form := p.AddForm(0, 0, 20, 20, nil, nil, []byte("1 0 0 rg 0 0 20 20 re f"))
+	return stamp.NewStamp(form, 20, 20)
+}
+
+func TestStamp_Overlay(t *testing.T) {
+	p := newDocument(t, 2)
+	s := newSquareStamp(p)
+	changed, err := s.Apply(p, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []pdfgo.Object{p.GetPages()[1]}, changed)
+
+	// The stamp is centred, unaffected by the transformation left by the original content
+	img, err := render.Render(p, 1, 72)
+	assert.Nil(t, err)
+	assert.Equal(t, color.RGBA{255, 0, 0, 255}, img.RGBAAt(50, 50))
+	assert.Equal(t, color.RGBA{0, 0, 255, 255}, img.RGBAAt(30, 50))
+
+	// Other pages are unchanged
+	img, err = render.Render(p, 0, 72)
+	assert.Nil(t, err)
+	assert.Equal(t, color.RGBA{0, 0, 255, 255}, img.RGBAAt(50, 50))
+}
+
+func TestStamp_Underlay(t *testing.T) {
+	p := newDocument(t, 1)
+	s := newSquareStamp(p)
+	s.Layer = stamp.Underlay
+	_, err := s.Apply(p)
+	assert.Nil(t, err)
+	img, err := render.Render(p, 0, 72)
+	assert.Nil(t, err)
+	assert.Equal(t, color.RGBA{0, 0, 255, 255}, img.RGBAAt(50, 50))
+}
+
+func TestStamp_Position(t *testing.T) {
+	p := newDocument(t, 1)
+	s := newSquareStamp(p)
+	s.X = 0.2
+	s.Y = 0.8
+	s.Rotation = 45
+	s.Opacity = 0.5
+	_, err := s.Apply(p)
+	assert.Nil(t, err)
+	img, err := render.Render(p, 0, 72)
+	assert.Nil(t, err)
+	// Centre of the stamp, 20 from the left and 20 from the top, is half red over blue
+	c := img.RGBAAt(20, 20)
+	assert.InDelta(t, 128, int(c.R), 2)
+	assert.InDelta(t, 128, int(c.B), 2)
+	// The corners of the rotated square are outside the stamp
+	assert.Equal(t, color.RGBA{0, 0, 255, 255}, img.RGBAAt(11, 11))
+}
+
+func TestStamp_RotatedPage(t *testing.T) {
+	for _, rotate := range []float64{90, 180, 270} {
+		p := pdfgo.NewPDF()
+		p.AddPage(100, 200, nil, nil)
+		page := p.GetPages()[0]
+		page.AddNameObjectEntry("Rotate", &pdfgo.NumberObject{
+			Number: rotate,
+		})
+		// A stamp twice as wide as it is tall
+		form := p.AddForm(0, 0, 40, 20, nil, nil, []byte("1 0 0 rg 0 0 40 20 re f"))
+		s := stamp.NewStamp(form, 40, 20)
+		s.X = 0.2
+		s.Y = 0.8
+		_, err := s.Apply(p)
+		assert.Nil(t, err)
+		img, err := render.Render(p, 0, 72)
+		assert.Nil(t, err)
+		width, height := 200, 100
+		if rotate == 180 {
+			width, height = 100, 200
+		}
+		assert.Equal(t, width, img.Bounds().Dx())
+		// The stamp is upright and positioned on the page as it is displayed
+		x, y := width/5, height/5
+		assert.Equal(t, color.RGBA{255, 0, 0, 255}, img.RGBAAt(x, y), rotate)
+		assert.Equal(t, color.RGBA{255, 0, 0, 255}, img.RGBAAt(x-18, y), rotate)
+		assert.Equal(t, color.RGBA{255, 0, 0, 255}, img.RGBAAt(x+18, y), rotate)
+		assert.Equal(t, color.RGBA{255, 255, 255, 255}, img.RGBAAt(x, y-12), rotate)
+		assert.Equal(t, color.RGBA{255, 255, 255, 255}, img.RGBAAt(x, y+12), rotate)
+	}
+}
+
+func TestNewTextStamp(t *testing.T) {
+	p := newDocument(t, 1)
+	s, err := stamp.NewTextStamp(p, "COPY", "Helvetica", 10, []float64{1, 0, 0})
+	assert.Nil(t, err)
+	// C 722, O 778, P 667, Y 667
+	assert.InDelta(t, 28.34, s.Width, 0.001)
+	assert.Equal(t, 10., s.Height)
+	_, err = stamp.NewTextStamp(p, "COPY", "Unknown", 10, nil)
+	assert.NotNil(t, err)
+	_, err = stamp.NewTextStamp(p, "中", "Helvetica", 10, nil)
+	assert.NotNil(t, err)
+}
+
+func TestParsePageRanges(t *testing.T) {
+	indices, err := stamp.ParsePageRanges("", 3)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{0, 1, 2}, indices)
+	indices, err = stamp.ParsePageRanges("1, 3-5", 5)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{0, 2, 3, 4}, indices)
+	_, err = stamp.ParsePageRanges("0", 5)
+	assert.NotNil(t, err)
+	_, err = stamp.ParsePageRanges("4-6", 5)
+	assert.NotNil(t, err)
+	_, err = stamp.ParsePageRanges("a", 5)
+	assert.NotNil(t, err)
+}
+
+func TestStamp_Resources(t *testing.T) {
+	source := pdfgo.NewPDF()
+	form := source.AddForm(0, 0, 10, 10, nil, nil, []byte("0 0 10 10 re f"))
+	xs := source.NewDictionaryObject()
+	xs.AddNameObjectEntry("Stamp1", form)
+	resources := source.NewDictionaryObject()
+	resources.AddNameObjectEntry("XObject", pdfgo.NewObjectReference(xs))
+	contents := source.NewStreamObject()
+	contents.Data = []byte("/Stamp1 Do")
+	source.AddPage(100, 100, pdfgo.NewObjectReference(resources), pdfgo.NewObjectReference(contents))
+	source.AddPage(100, 100, pdfgo.NewObjectReference(resources), pdfgo.NewObjectReference(contents))
+
+	s := newSquareStamp(source)
+	_, err := s.Apply(source, 0)
+	assert.Nil(t, err)
+	pages := source.GetPages()
+	stamped := pdfgo.Resolve(pdfgo.PageResources(pages[0]).GetEntry("XObject")).(*pdfgo.DictionaryObject)
+	assert.Equal(t, form, stamped.GetEntry("Stamp1"))
+	assert.Equal(t, s.Form, stamped.GetEntry("Stamp2"))
+	// The shared resources are unchanged
+	assert.Equal(t, resources, pdfgo.PageResources(pages[1]))
+	assert.False(t, xs.HasEntry("Stamp2"))
+}
@@ -25,22 +25,9 @@ type StreamObject struct {
 }
 
 func (o *StreamObject) Write(out io.Writer) (int, error) {
-	addKey := true
-	for k := range o.Dictionary {
-		if k.Name == "Length" {
-			addKey = false
-			break
-		}
-	}
-	key := &NameObject{
-		Name: "Length",
-	}
-	if addKey {
-		o.Keys = append(o.Keys, key)
-	}
-	o.Dictionary[key] = &NumberObject{
+	o.SetNameObjectEntry("Length", &NumberObject{
 		Number: float64(len(o.Data)),
-	}
+	})
 	var count int
 	n, err := o.DictionaryObject.Write(out)
 	if err != nil {
@@ -16,13 +16,147 @@
 
 package pdfgo
 
-import "io"
+import (
+	"io"
+	"strings"
+	"unicode/utf16"
+)
 
 type StringObject struct {
 	Metadata
+	// String holds the contents as written between the parentheses, with any escape sequences
 	String string
 }
 
 func (o *StringObject) Write(out io.Writer) (int, error) {
 	return WriteF(out, "(%s)", o.String)
 }
+
+// Bytes returns the contents of the string with escape sequences decoded.
+func (o *StringObject) Bytes() []byte {
+	return []byte(UnescapeString(o.String))
+}
+
+// NewTextString returns a direct string holding the given text, encoded as UTF-16BE with a byte order mark unless the text is ASCII.
+func NewTextString(text string) *StringObject {
+	ascii := true
+	for i := 0; i < len(text); i++ {
+		if text[i] >= 0x80 {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return &StringObject{
+			String: EscapeString(text),
+		}
+	}
+	data := []byte{0xFE, 0xFF}
+	for _, u := range utf16.Encode([]rune(text)) {
+		data = append(data, byte(u>>8), byte(u))
+	}
+	return &StringObject{
+		String: EscapeString(string(data)),
+	}
+}
+
+// Text returns the contents of a text string.
+func (o *StringObject) Text() string {
+	return DecodeText(o.Bytes())
+}
+
+// DecodeText decodes the bytes of a text string as UTF-16BE or UTF-8 if they start with a byte order mark, and otherwise as PDFDocEncoding, which is approximated by Latin-1.
+func DecodeText(data []byte) string {
+	switch {
+	case len(data) >= 2 && data[0] == 0xFE && data[1] == 0xFF:
+		units := make([]uint16, (len(data)-2)/2)
+		for i := range units {
+			units[i] = uint16(data[2+2*i])<<8 | uint16(data[3+2*i])
+		}
+		return string(utf16.Decode(units))
+	case len(data) >= 3 && data[0] == 0xEF && data[1] == 0xBB && data[2] == 0xBF:
+		return string(data[3:])
+	}
+	var sb strings.Builder
+	for _, b := range data {
+		sb.WriteRune(rune(b))
+	}
+	return sb.String()
+}
+
+// EscapeString escapes the backslashes, parentheses and carriage returns in the given string so it can be written as a literal string.
+func EscapeString(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '\\', '(', ')':
+			sb.WriteByte('\\')
+			sb.WriteByte(c)
+		case '\r':
+			sb.WriteString("\\r")
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+// UnescapeString decodes the escape sequences in the contents of a literal string.
+func UnescapeString(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '\r':
+			// End of line markers are normalized to a single line feed
+			if i+1 < len(s) && s[i+1] == '\n' {
+				i++
+			}
+			sb.WriteByte('\n')
+			continue
+		case '\\':
+		default:
+			sb.WriteByte(c)
+			continue
+		}
+		i++
+		if i >= len(s) {
+			break
+		}
+		c = s[i]
+		switch c {
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 't':
+			sb.WriteByte('\t')
+		case 'b':
+			sb.WriteByte('\b')
+		case 'f':
+			sb.WriteByte('\f')
+		case '\r':
+			// Line continuation
+			if i+1 < len(s) && s[i+1] == '\n' {
+				i++
+			}
+		case '\n':
+			// Line continuation
+		default:
+			if c >= '0' && c <= '7' {
+				var v byte
+				for j := 0; j < 3 && i < len(s) && s[i] >= '0' && s[i] <= '7'; j++ {
+					v = v*8 + (s[i] - '0')
+					i++
+				}
+				i--
+				sb.WriteByte(v)
+			} else {
+				// Unknown escapes are ignored
+				sb.WriteByte(c)
+			}
+		}
+	}
+	return sb.String()
+}
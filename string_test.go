@@ -0,0 +1,37 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pdfgo_test
+
+import (
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewTextString(t *testing.T) {
+	for _, text := range []string{"", "Name (Optional)", "Café", "名前", "😀"} {
+		s := pdfgo.NewTextString(text)
+		assert.Equal(t, text, s.Text())
+	}
+	assert.Equal(t, "Name \\(Optional\\)", pdfgo.NewTextString("Name (Optional)").String)
+	assert.Equal(t, []byte{0xFE, 0xFF, 0x54, 0x0D}, pdfgo.NewTextString("名").Bytes())
+}
+
+func TestStringObject_Text(t *testing.T) {
+	assert.Equal(t, "Café", (&pdfgo.StringObject{String: "Caf\\351"}).Text())
+	assert.Equal(t, "Café", (&pdfgo.StringObject{String: "\xEF\xBB\xBFCaf\xC3\xA9"}).Text())
+}
@@ -0,0 +1,344 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package text
+
+import (
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/AletheiaWareLLC/pdfgo/content"
+	"github.com/AletheiaWareLLC/pdfgo/font"
+	"github.com/AletheiaWareLLC/pdfgo/graphics"
+	"log"
+	"math"
+)
+
+// Maximum depth of nested form XObjects
+const MAX_FORM_DEPTH = 16
+
+// Glyph is a single character code shown on a page.
+type Glyph struct {
+	Text string
+	// X and Y are the origin of the glyph on the baseline, in page space
+	X, Y float64
+	// Box bounds the glyph in page space
+	Box *graphics.Rectangle
+	// Font is the name of the font resource, FontName is the BaseFont of the font
+	Font     string
+	FontName string
+	// FontSize is the effective font size in page space
+	FontSize float64
+	// Form is the form XObject containing the glyph, or nil if it is in the page content
+	Form *pdfgo.StreamObject
+	// Operation is the index of the text-showing operation within the page or form content
+	Operation int
+	// Element is the index of the string within the operands of a TJ operation
+	Element int
+	// Offset and Length locate the character code within the string
+	Offset, Length int
+	// Advance is the horizontal displacement after the glyph, including spacing, in thousandths of text space units
+	Advance float64
+}
+
+// Run is the text shown by a single text-showing operation.
+type Run struct {
+	Text     string
+	X, Y     float64
+	Box      *graphics.Rectangle
+	Font     string
+	FontName string
+	FontSize float64
+	Glyphs   []*Glyph
+}
+
+type state struct {
+	ctm              content.Matrix
+	characterSpacing float64
+	wordSpacing      float64
+	scale            float64
+	leading          float64
+	font             string
+	decoder          *font.Decoder
+	size             float64
+	rise             float64
+}
+
+type extractor struct {
+	decoders map[*pdfgo.DictionaryObject]*font.Decoder
+	forms    map[*pdfgo.StreamObject]bool
+	runs     []*Run
+}
+
+// ExtractRuns returns the text runs shown on the given page, in content order.
+func ExtractRuns(page *pdfgo.DictionaryObject) ([]*Run, error) {
+	data, err := pdfgo.PageContents(page)
+	if err != nil {
+		return nil, err
+	}
+	operations, err := content.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	return ExtractContentRuns(operations, pdfgo.PageResources(page), content.IdentityMatrix())
+}
+
+// ExtractContentRuns returns the text runs shown by the given operations, using the given resources and initial transformation.
+func ExtractContentRuns(operations []*content.Operation, resources *pdfgo.DictionaryObject, ctm content.Matrix) ([]*Run, error) {
+	e := &extractor{
+		decoders: make(map[*pdfgo.DictionaryObject]*font.Decoder),
+		forms:    make(map[*pdfgo.StreamObject]bool),
+	}
+	if err := e.extract(operations, resources, nil, &state{
+		ctm:   ctm,
+		scale: 1,
+	}, 0); err != nil {
+		return nil, err
+	}
+	return e.runs, nil
+}
+
+func (e *extractor) extract(operations []*content.Operation, resources *pdfgo.DictionaryObject, form *pdfgo.StreamObject, s *state, depth int) error {
+	var stack []state
+	// Text matrix and text line matrix
+	var tm, tlm content.Matrix
+	for i, o := range operations {
+		switch o.Operator {
+		case "q":
+			stack = append(stack, *s)
+		case "Q":
+			if len(stack) > 0 {
+				*s = stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+			}
+		case "cm":
+			s.ctm = content.MatrixOperands(o).Multiply(s.ctm)
+		case "BT":
+			tm = content.IdentityMatrix()
+			tlm = tm
+		case "Tc":
+			s.characterSpacing = o.Number(0)
+		case "Tw":
+			s.wordSpacing = o.Number(0)
+		case "Tz":
+			s.scale = o.Number(0) / 100
+		case "TL":
+			s.leading = o.Number(0)
+		case "Ts":
+			s.rise = o.Number(0)
+		case "Tf":
+			s.font = o.Name(0)
+			s.size = o.Number(1)
+			s.decoder = e.decoder(resources, s.font)
+		case "Td":
+			tlm = content.TranslationMatrix(o.Number(0), o.Number(1)).Multiply(tlm)
+			tm = tlm
+		case "TD":
+			s.leading = -o.Number(1)
+			tlm = content.TranslationMatrix(o.Number(0), o.Number(1)).Multiply(tlm)
+			tm = tlm
+		case "Tm":
+			tlm = content.MatrixOperands(o)
+			tm = tlm
+		case "T*":
+			tlm = content.TranslationMatrix(0, -s.leading).Multiply(tlm)
+			tm = tlm
+		case "Tj":
+			run := e.newRun(s)
+			e.show(run, s, &tm, o, 0, form, i)
+			e.addRun(run)
+		case "'":
+			tlm = content.TranslationMatrix(0, -s.leading).Multiply(tlm)
+			tm = tlm
+			run := e.newRun(s)
+			e.show(run, s, &tm, o, 0, form, i)
+			e.addRun(run)
+		case "\"":
+			s.wordSpacing = o.Number(0)
+			s.characterSpacing = o.Number(1)
+			tlm = content.TranslationMatrix(0, -s.leading).Multiply(tlm)
+			tm = tlm
+			run := e.newRun(s)
+			e.show(run, s, &tm, o, 2, form, i)
+			e.addRun(run)
+		case "TJ":
+			if len(o.Operands) == 0 {
+				continue
+			}
+			a, ok := o.Operands[0].(*pdfgo.ArrayObject)
+			if !ok {
+				continue
+			}
+			run := e.newRun(s)
+			for j, element := range a.Array {
+				switch v := element.(type) {
+				case *pdfgo.NumberObject:
+					tx := -v.Number / 1000 * s.size * s.scale
+					tm = content.TranslationMatrix(tx, 0).Multiply(tm)
+				case *pdfgo.StringObject:
+					e.showString(run, s, &tm, v, form, i, j)
+				}
+			}
+			e.addRun(run)
+		case "Do":
+			if depth >= MAX_FORM_DEPTH {
+				continue
+			}
+			xobjects, ok := pdfgo.Resolve(resources.GetEntry("XObject")).(*pdfgo.DictionaryObject)
+			if !ok {
+				continue
+			}
+			f, ok := pdfgo.Resolve(xobjects.GetEntry(o.Name(0))).(*pdfgo.StreamObject)
+			if !ok || !isForm(f) || e.forms[f] {
+				continue
+			}
+			if err := e.extractForm(f, resources, *s, depth); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func isForm(s *pdfgo.StreamObject) bool {
+	n, ok := pdfgo.Resolve(s.GetEntry("Subtype")).(*pdfgo.NameObject)
+	return ok && n.Name == "Form"
+}
+
+func (e *extractor) extractForm(f *pdfgo.StreamObject, resources *pdfgo.DictionaryObject, s state, depth int) error {
+	// Guard against forms which draw themselves
+	e.forms[f] = true
+	defer delete(e.forms, f)
+	data, err := f.Decode()
+	if err != nil {
+		return err
+	}
+	operations, err := content.Parse(data)
+	if err != nil {
+		return err
+	}
+	if a, ok := pdfgo.Resolve(f.GetEntry("Matrix")).(*pdfgo.ArrayObject); ok && len(a.Array) == 6 {
+		var m content.Matrix
+		for i, o := range a.Array {
+			if n, ok := pdfgo.Resolve(o).(*pdfgo.NumberObject); ok {
+				m[i] = n.Number
+			}
+		}
+		s.ctm = m.Multiply(s.ctm)
+	}
+	if r, ok := pdfgo.Resolve(f.GetEntry("Resources")).(*pdfgo.DictionaryObject); ok {
+		resources = r
+	}
+	return e.extract(operations, resources, f, &s, depth+1)
+}
+
+func (e *extractor) decoder(resources *pdfgo.DictionaryObject, name string) *font.Decoder {
+	var dictionary *pdfgo.DictionaryObject
+	if fonts, ok := pdfgo.Resolve(resources.GetEntry("Font")).(*pdfgo.DictionaryObject); ok {
+		dictionary, _ = pdfgo.Resolve(fonts.GetEntry(name)).(*pdfgo.DictionaryObject)
+	}
+	if dictionary == nil {
+		log.Println("Missing Font:", name)
+		dictionary = &pdfgo.DictionaryObject{
+			Dictionary: make(map[*pdfgo.NameObject]pdfgo.Object),
+		}
+	}
+	if d, ok := e.decoders[dictionary]; ok {
+		return d
+	}
+	d := font.NewDecoder(dictionary)
+	e.decoders[dictionary] = d
+	return d
+}
+
+func (e *extractor) newRun(s *state) *Run {
+	r := &Run{
+		Box:  graphics.NegativeRectangle(),
+		Font: s.font,
+	}
+	if s.decoder != nil {
+		r.FontName = s.decoder.Name
+	}
+	return r
+}
+
+func (e *extractor) addRun(r *Run) {
+	if len(r.Glyphs) == 0 {
+		return
+	}
+	r.X = r.Glyphs[0].X
+	r.Y = r.Glyphs[0].Y
+	r.FontSize = r.Glyphs[0].FontSize
+	e.runs = append(e.runs, r)
+}
+
+func (e *extractor) show(r *Run, s *state, tm *content.Matrix, o *content.Operation, index int, form *pdfgo.StreamObject, operation int) {
+	if index < len(o.Operands) {
+		if str, ok := o.Operands[index].(*pdfgo.StringObject); ok {
+			e.showString(r, s, tm, str, form, operation, 0)
+		}
+	}
+}
+
+func (e *extractor) showString(r *Run, s *state, tm *content.Matrix, str *pdfgo.StringObject, form *pdfgo.StreamObject, operation, element int) {
+	if s.decoder == nil {
+		log.Println("Text shown without a font")
+		s.decoder = e.decoder(&pdfgo.DictionaryObject{}, "")
+	}
+	offset := 0
+	for _, code := range s.decoder.Decode(str.Bytes()) {
+		// Text rendering matrix maps glyph space, scaled to the font size, to page space
+		trm := content.Matrix{s.size * s.scale, 0, 0, s.size, 0, s.rise}.Multiply(*tm).Multiply(s.ctm)
+		g := &Glyph{
+			Text:      string(code.Text),
+			Box:       graphics.NegativeRectangle(),
+			Font:      s.font,
+			FontName:  s.decoder.Name,
+			Form:      form,
+			Operation: operation,
+			Element:   element,
+			Offset:    offset,
+			Length:    len(code.Bytes),
+		}
+		g.X, g.Y = trm.Transform(0, 0)
+		g.FontSize = math.Hypot(trm[2], trm[3])
+		for _, p := range [][2]float64{
+			{0, graphics.DESCENT},
+			{code.Width, graphics.DESCENT},
+			{0, graphics.ASCENT},
+			{code.Width, graphics.ASCENT},
+		} {
+			x, y := trm.Transform(p[0], p[1])
+			g.Box = g.Box.Max(&graphics.Rectangle{
+				Left:   x,
+				Right:  x,
+				Top:    y,
+				Bottom: y,
+			})
+		}
+		// Advance the text matrix
+		tx := code.Width*s.size + s.characterSpacing
+		if code.Space {
+			tx += s.wordSpacing
+		}
+		if s.size != 0 {
+			g.Advance = tx * 1000 / s.size
+		}
+		r.Glyphs = append(r.Glyphs, g)
+		r.Text += g.Text
+		r.Box = r.Box.Max(g.Box)
+		offset += len(code.Bytes)
+		*tm = content.TranslationMatrix(tx*s.scale, 0).Multiply(*tm)
+	}
+}
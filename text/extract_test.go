@@ -0,0 +1,104 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package text_test
+
+import (
+	"bytes"
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/AletheiaWareLLC/pdfgo/text"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func newTextPage(t *testing.T, data string, newFont func(*pdfgo.PDF) *pdfgo.DictionaryObject) *pdfgo.DictionaryObject {
+	t.Helper()
+	p := pdfgo.NewPDF()
+	fonts := p.NewDictionaryObject()
+	fonts.AddNameObjectEntry("F1", pdfgo.NewObjectReference(newFont(p)))
+	resources := p.NewDictionaryObject()
+	resources.AddNameObjectEntry("Font", fonts)
+	contents := p.NewStreamObject()
+	contents.Data = []byte(data)
+	p.AddPage(400, 600, resources, pdfgo.NewObjectReference(contents))
+	var buffer bytes.Buffer
+	assert.Nil(t, p.Write(&buffer))
+	// Read back to exercise the parsed object graph
+	parsed, err := pdfgo.ReadPDF(buffer.Bytes())
+	assert.Nil(t, err)
+	pages := parsed.GetPages()
+	assert.Equal(t, 1, len(pages))
+	return pages[0]
+}
+
+func newType1Font(base string) func(*pdfgo.PDF) *pdfgo.DictionaryObject {
+	return func(p *pdfgo.PDF) *pdfgo.DictionaryObject {
+		f := p.NewDictionaryObject()
+		f.AddNameNameEntry("Type", "Font")
+		f.AddNameNameEntry("Subtype", "Type1")
+		f.AddNameNameEntry("BaseFont", base)
+		return f
+	}
+}
+
+func TestExtractRuns(t *testing.T) {
+	page := newTextPage(t, "BT /F1 10 Tf 2 0 0 2 50 500 Tm [(Hello) -1000 (World)] TJ ET", newType1Font("Courier"))
+	runs, err := text.ExtractRuns(page)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(runs))
+	run := runs[0]
+	assert.Equal(t, "HelloWorld", run.Text)
+	assert.Equal(t, "F1", run.Font)
+	assert.Equal(t, "Courier", run.FontName)
+	assert.Equal(t, 20., run.FontSize)
+	assert.Equal(t, 50., run.X)
+	assert.Equal(t, 500., run.Y)
+	// Courier glyphs are 0.6em wide, the TJ adjustment moves World by a further 1em
+	w := run.Glyphs[5]
+	assert.Equal(t, "W", w.Text)
+	assert.InDelta(t, 50+5*12+20, w.X, 0.0001)
+	assert.Equal(t, 2, w.Element)
+	assert.InDelta(t, 50+10*12+20, run.Box.Right, 0.0001)
+	assert.InDelta(t, 496, run.Box.Bottom, 0.0001)
+	assert.InDelta(t, 516, run.Box.Top, 0.0001)
+}
+
+func TestExtractRuns_toUnicode(t *testing.T) {
+	page := newTextPage(t, "BT /F1 12 Tf 10 10 Td <00010002> Tj ET", func(p *pdfgo.PDF) *pdfgo.DictionaryObject {
+		f := p.NewDictionaryObject()
+		f.AddNameNameEntry("Type", "Font")
+		f.AddNameNameEntry("Subtype", "Type0")
+		f.AddNameNameEntry("BaseFont", "ABCDEF+Custom")
+		f.AddNameNameEntry("Encoding", "Identity-H")
+		cmap := p.NewStreamObject()
+		cmap.Data = []byte("/CIDInit /ProcSet findresource begin 12 dict begin begincmap\n1 begincodespacerange <0000> <FFFF> endcodespacerange\n1 beginbfchar <0001> <0048> endbfchar\n1 beginbfrange <0002> <0003> <0069> endbfrange\nendcmap CMapName currentdict /CMap defineresource pop end end")
+		f.AddNameObjectEntry("ToUnicode", pdfgo.NewObjectReference(cmap))
+		return f
+	})
+	runs, err := text.ExtractRuns(page)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(runs))
+	assert.Equal(t, "Hi", runs[0].Text)
+	assert.Equal(t, 2, len(runs[0].Glyphs))
+	assert.Equal(t, 2, runs[0].Glyphs[1].Offset)
+}
+
+func TestExtractText(t *testing.T) {
+	page := newTextPage(t, "BT /F1 10 Tf 14 TL 50 500 Td (First line,) Tj T* (second ) Tj (line.) Tj 0 -40 Td (New) Tj 25 0 Td (paragraph) Tj ET BT /F1 10 Tf 250 500 Td (Right) Tj ET", newType1Font("Helvetica"))
+	s, err := text.ExtractText(page)
+	assert.Nil(t, err)
+	assert.Equal(t, "First line, Right\nsecond line.\n\nNew paragraph", s)
+}
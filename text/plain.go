@@ -0,0 +1,119 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package text
+
+import (
+	"github.com/AletheiaWareLLC/pdfgo"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Thresholds relative to the font size
+const (
+	// Glyphs whose baselines are closer than this are on the same line
+	LINE_TOLERANCE = 0.5
+	// Gaps between glyphs wider than this are treated as spaces
+	SPACE_THRESHOLD = 0.15
+	// Gaps between lines taller than this separate paragraphs
+	PARAGRAPH_THRESHOLD = 1.6
+)
+
+type line struct {
+	y      float64
+	size   float64
+	glyphs []*Glyph
+}
+
+// ExtractText returns the plain text of the given page, with lines and paragraphs in reading order.
+func ExtractText(page *pdfgo.DictionaryObject) (string, error) {
+	runs, err := ExtractRuns(page)
+	if err != nil {
+		return "", err
+	}
+	return Text(runs), nil
+}
+
+// Text reconstructs the reading order of the given runs, placing each line on its own and separating paragraphs by a blank line.
+// Text is assumed to be horizontal; lines are read top to bottom and glyphs left to right.
+func Text(runs []*Run) string {
+	var glyphs []*Glyph
+	for _, r := range runs {
+		for _, g := range r.Glyphs {
+			if g.Text != "" {
+				glyphs = append(glyphs, g)
+			}
+		}
+	}
+	sort.SliceStable(glyphs, func(i, j int) bool {
+		return glyphs[i].Y > glyphs[j].Y
+	})
+	var lines []*line
+	var current *line
+	for _, g := range glyphs {
+		if current == nil || current.y-g.Y > LINE_TOLERANCE*math.Max(current.size, g.FontSize) {
+			current = &line{
+				y: g.Y,
+			}
+			lines = append(lines, current)
+		}
+		current.glyphs = append(current.glyphs, g)
+		current.size = math.Max(current.size, g.FontSize)
+	}
+	var sb strings.Builder
+	for i, l := range lines {
+		if i > 0 {
+			sb.WriteString("\n")
+			if lines[i-1].y-l.y > PARAGRAPH_THRESHOLD*math.Max(lines[i-1].size, l.size) {
+				sb.WriteString("\n")
+			}
+		}
+		sb.WriteString(l.text())
+	}
+	return sb.String()
+}
+
+func (l *line) text() string {
+	sort.SliceStable(l.glyphs, func(i, j int) bool {
+		return l.glyphs[i].X < l.glyphs[j].X
+	})
+	var sb strings.Builder
+	var previous *Glyph
+	space := true
+	for _, g := range l.glyphs {
+		if isSpace(g.Text) {
+			if !space {
+				sb.WriteString(" ")
+				space = true
+			}
+			previous = g
+			continue
+		}
+		if previous != nil && !space && g.Box.Left-previous.Box.Right > SPACE_THRESHOLD*g.FontSize {
+			sb.WriteString(" ")
+		}
+		sb.WriteString(g.Text)
+		space = false
+		previous = g
+	}
+	return strings.TrimRightFunc(sb.String(), unicode.IsSpace)
+}
+
+func isSpace(s string) bool {
+	return strings.TrimFunc(s, unicode.IsSpace) == ""
+}
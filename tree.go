@@ -0,0 +1,274 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pdfgo
+
+import (
+	"bytes"
+	"sort"
+)
+
+// TREE_NODE_SIZE is the greatest number of entries in a leaf of a tree, and of kids in its other nodes
+const TREE_NODE_SIZE = 64
+
+// NameTreeEntry maps a key to a value in a name tree.
+type NameTreeEntry struct {
+	Name  string
+	Value Object
+}
+
+// NumberTreeEntry maps a key to a value in a number tree.
+type NumberTreeEntry struct {
+	Number int
+	Value  Object
+}
+
+// NewNameTree returns the root of a balanced name tree holding the given entries, in order of their keys.
+func (p *PDF) NewNameTree(entries map[string]Object) *DictionaryObject {
+	root := p.NewDictionaryObject()
+	p.setNameTree(root, entries)
+	return root
+}
+
+// setNameTree replaces the content of the given root with a balanced name tree holding the given entries.
+func (p *PDF) setNameTree(root *DictionaryObject, entries map[string]Object) {
+	keys := make([]*StringObject, 0, len(entries))
+	for k := range entries {
+		keys = append(keys, NewTextString(k))
+	}
+	// Keys are sorted by the bytes of their encoded strings
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(keys[i].Bytes(), keys[j].Bytes()) < 0
+	})
+	var pairs []Object
+	for _, k := range keys {
+		pairs = append(pairs, k, entries[k.Text()])
+	}
+	p.setTree(root, "Names", pairs)
+}
+
+// NewNumberTree returns the root of a balanced number tree holding the given entries, in order of their keys.
+func (p *PDF) NewNumberTree(entries map[int]Object) *DictionaryObject {
+	root := p.NewDictionaryObject()
+	p.setNumberTree(root, entries)
+	return root
+}
+
+// setNumberTree replaces the content of the given root with a balanced number tree holding the given entries.
+func (p *PDF) setNumberTree(root *DictionaryObject, entries map[int]Object) {
+	keys := make([]int, 0, len(entries))
+	for k := range entries {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	var pairs []Object
+	for _, k := range keys {
+		pairs = append(pairs, &NumberObject{
+			Number: float64(k),
+		}, entries[k])
+	}
+	p.setTree(root, "Nums", pairs)
+}
+
+// setTree replaces the content of the given root with a tree whose leaves hold the given sorted pairs of keys and values in the array of the given key.
+// The root holds the pairs itself if there are few enough, otherwise it has kids, and every node but the root is limited by its least and greatest keys.
+func (p *PDF) setTree(root *DictionaryObject, key string, pairs []Object) {
+	for _, k := range []string{"Names", "Nums", "Kids", "Limits"} {
+		root.RemoveEntry(k)
+	}
+	if len(pairs) <= 2*TREE_NODE_SIZE {
+		root.AddNameObjectEntry(key, &ArrayObject{
+			Array: pairs,
+		})
+		return
+	}
+	type node struct {
+		dictionary *DictionaryObject
+		first      Object
+		last       Object
+	}
+	var level []*node
+	for i := 0; i < len(pairs); i += 2 * TREE_NODE_SIZE {
+		end := i + 2*TREE_NODE_SIZE
+		if end > len(pairs) {
+			end = len(pairs)
+		}
+		leaf := p.NewDictionaryObject()
+		leaf.AddNameObjectEntry(key, &ArrayObject{
+			Array: pairs[i:end],
+		})
+		leaf.AddNameObjectEntry("Limits", &ArrayObject{
+			Array: []Object{pairs[i], pairs[end-2]},
+		})
+		level = append(level, &node{
+			dictionary: leaf,
+			first:      pairs[i],
+			last:       pairs[end-2],
+		})
+	}
+	for len(level) > TREE_NODE_SIZE {
+		var parents []*node
+		for i := 0; i < len(level); i += TREE_NODE_SIZE {
+			end := i + TREE_NODE_SIZE
+			if end > len(level) {
+				end = len(level)
+			}
+			parent := p.NewDictionaryObject()
+			kids := &ArrayObject{}
+			for _, n := range level[i:end] {
+				kids.Array = append(kids.Array, NewObjectReference(n.dictionary))
+			}
+			parent.AddNameObjectEntry("Kids", kids)
+			parent.AddNameObjectEntry("Limits", &ArrayObject{
+				Array: []Object{level[i].first, level[end-1].last},
+			})
+			parents = append(parents, &node{
+				dictionary: parent,
+				first:      level[i].first,
+				last:       level[end-1].last,
+			})
+		}
+		level = parents
+	}
+	kids := &ArrayObject{}
+	for _, n := range level {
+		kids.Array = append(kids.Array, NewObjectReference(n.dictionary))
+	}
+	root.AddNameObjectEntry("Kids", kids)
+}
+
+// NameTreeEntries returns the entries of the given name tree, in order.
+func NameTreeEntries(tree *DictionaryObject) []*NameTreeEntry {
+	var entries []*NameTreeEntry
+	walkTree(tree, "Names", func(key, value Object) bool {
+		if k, ok := Resolve(key).(*StringObject); ok {
+			entries = append(entries, &NameTreeEntry{
+				Name:  k.Text(),
+				Value: value,
+			})
+		}
+		return true
+	}, nil)
+	return entries
+}
+
+// NumberTreeEntries returns the entries of the given number tree, in order.
+func NumberTreeEntries(tree *DictionaryObject) []*NumberTreeEntry {
+	var entries []*NumberTreeEntry
+	walkTree(tree, "Nums", func(key, value Object) bool {
+		if k, ok := Resolve(key).(*NumberObject); ok {
+			entries = append(entries, &NumberTreeEntry{
+				Number: int(k.Number),
+				Value:  value,
+			})
+		}
+		return true
+	}, nil)
+	return entries
+}
+
+// LookupName returns the value of the given key in the given name tree, skipping the nodes whose limits exclude it.
+func LookupName(tree *DictionaryObject, name string) (Object, bool) {
+	key := NewTextString(name).Bytes()
+	compare := func(o Object) (int, bool) {
+		s, ok := Resolve(o).(*StringObject)
+		if !ok {
+			return 0, false
+		}
+		return bytes.Compare(key, s.Bytes()), true
+	}
+	return lookup(tree, "Names", compare)
+}
+
+// LookupNumber returns the value of the given key in the given number tree, skipping the nodes whose limits exclude it.
+func LookupNumber(tree *DictionaryObject, number int) (Object, bool) {
+	compare := func(o Object) (int, bool) {
+		n, ok := Resolve(o).(*NumberObject)
+		if !ok {
+			return 0, false
+		}
+		switch k := float64(number); {
+		case k < n.Number:
+			return -1, true
+		case k > n.Number:
+			return 1, true
+		}
+		return 0, true
+	}
+	return lookup(tree, "Nums", compare)
+}
+
+// lookup returns the value whose key compares equal to the key sought, in the tree with pairs in the array of the given key.
+func lookup(tree *DictionaryObject, key string, compare func(Object) (int, bool)) (Object, bool) {
+	var result Object
+	found := false
+	walkTree(tree, key, func(k, value Object) bool {
+		if c, ok := compare(k); ok && c == 0 {
+			result = value
+			found = true
+			return false
+		}
+		return true
+	}, func(node *DictionaryObject) bool {
+		limits, ok := Resolve(node.GetEntry("Limits")).(*ArrayObject)
+		if !ok || len(limits.Array) != 2 {
+			return true
+		}
+		// Nodes with unreadable limits are searched anyway
+		if c, ok := compare(limits.Array[0]); ok && c < 0 {
+			return false
+		}
+		if c, ok := compare(limits.Array[1]); ok && c > 0 {
+			return false
+		}
+		return true
+	})
+	return result, found
+}
+
+// walkTree calls the visit function for each pair in the tree, in order, until it returns false.
+// Nodes for which the search function returns false are skipped, if it isn't nil.
+func walkTree(tree *DictionaryObject, key string, visit func(key, value Object) bool, search func(node *DictionaryObject) bool) {
+	visited := make(map[*DictionaryObject]bool)
+	var walk func(node *DictionaryObject) bool
+	walk = func(node *DictionaryObject) bool {
+		if visited[node] {
+			return true
+		}
+		visited[node] = true
+		if search != nil && !search(node) {
+			return true
+		}
+		if pairs, ok := Resolve(node.GetEntry(key)).(*ArrayObject); ok {
+			for i := 0; i+1 < len(pairs.Array); i += 2 {
+				if !visit(pairs.Array[i], pairs.Array[i+1]) {
+					return false
+				}
+			}
+		}
+		if kids, ok := Resolve(node.GetEntry("Kids")).(*ArrayObject); ok {
+			for _, k := range kids.Array {
+				if kid, ok := Resolve(k).(*DictionaryObject); ok {
+					if !walk(kid) {
+						return false
+					}
+				}
+			}
+		}
+		return true
+	}
+	walk(tree)
+}
@@ -0,0 +1,110 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pdfgo_test
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// depth returns the number of levels of the given tree, checking every node below the root has limits.
+func depth(t *testing.T, node *pdfgo.DictionaryObject, root bool) int {
+	t.Helper()
+	assert.Equal(t, !root, node.HasEntry("Limits"))
+	kids, ok := pdfgo.Resolve(node.GetEntry("Kids")).(*pdfgo.ArrayObject)
+	if !ok {
+		return 1
+	}
+	assert.LessOrEqual(t, len(kids.Array), pdfgo.TREE_NODE_SIZE)
+	return 1 + depth(t, pdfgo.Resolve(kids.Array[0]).(*pdfgo.DictionaryObject), false)
+}
+
+func TestNameTree(t *testing.T) {
+	p := pdfgo.NewPDF()
+	small := p.NewNameTree(map[string]pdfgo.Object{
+		"b": &pdfgo.NumberObject{Number: 2},
+		"a": &pdfgo.NumberObject{Number: 1},
+	})
+	assert.Equal(t, "<</Names [(a) 1 (b) 2]>>", write(t, small))
+
+	entries := make(map[string]pdfgo.Object)
+	for i := 0; i < 5000; i++ {
+		entries[fmt.Sprintf("key%05d", i)] = &pdfgo.NumberObject{Number: float64(i)}
+	}
+	tree := p.NewNameTree(entries)
+	p.Catalog.SetNameObjectEntry("Tree", pdfgo.NewObjectReference(tree))
+	// 5000 entries fill 79 leaves, under 2 nodes, under the root
+	assert.Equal(t, 3, depth(t, tree, true))
+
+	var buffer bytes.Buffer
+	assert.Nil(t, p.Write(&buffer))
+	result, err := pdfgo.ReadPDF(buffer.Bytes())
+	assert.Nil(t, err)
+	tree = pdfgo.Resolve(result.Catalog.GetEntry("Tree")).(*pdfgo.DictionaryObject)
+	read := pdfgo.NameTreeEntries(tree)
+	assert.Equal(t, 5000, len(read))
+	for i, e := range read {
+		assert.Equal(t, fmt.Sprintf("key%05d", i), e.Name)
+	}
+	for _, i := range []int{0, 63, 64, 4095, 4096, 4999} {
+		value, ok := pdfgo.LookupName(tree, fmt.Sprintf("key%05d", i))
+		assert.True(t, ok)
+		assert.Equal(t, float64(i), pdfgo.Resolve(value).(*pdfgo.NumberObject).Number)
+	}
+	_, ok := pdfgo.LookupName(tree, "key")
+	assert.False(t, ok)
+	_, ok = pdfgo.LookupName(tree, "key99999")
+	assert.False(t, ok)
+}
+
+func TestNumberTree(t *testing.T) {
+	p := pdfgo.NewPDF()
+	entries := make(map[int]pdfgo.Object)
+	for i := 0; i < 1000; i++ {
+		entries[i*2] = pdfgo.NewTextString(fmt.Sprint(i))
+	}
+	tree := p.NewNumberTree(entries)
+	assert.Equal(t, 2, depth(t, tree, true))
+	read := pdfgo.NumberTreeEntries(tree)
+	assert.Equal(t, 1000, len(read))
+	assert.Equal(t, 1998, read[999].Number)
+	value, ok := pdfgo.LookupNumber(tree, 1000)
+	assert.True(t, ok)
+	assert.Equal(t, "500", value.(*pdfgo.StringObject).Text())
+	_, ok = pdfgo.LookupNumber(tree, 1001)
+	assert.False(t, ok)
+}
+
+func TestSetNamedDestinations(t *testing.T) {
+	p := pdfgo.NewPDF()
+	p.AddPage(200, 200, nil, nil)
+	page := p.GetPages()[0]
+	assert.NotNil(t, p.SetNamedDestinations(map[string]*pdfgo.Destination{
+		"loop": {Name: "loop"},
+	}))
+	assert.Nil(t, p.SetNamedDestinations(map[string]*pdfgo.Destination{
+		"top": {Page: page, Fit: pdfgo.FIT_WIDTH, Top: 200},
+	}))
+	names := pdfgo.Resolve(p.Catalog.GetEntry("Names")).(*pdfgo.DictionaryObject)
+	dests := pdfgo.Resolve(names.GetEntry("Dests")).(*pdfgo.DictionaryObject)
+	value, ok := pdfgo.LookupName(dests, "top")
+	assert.True(t, ok)
+	assert.Equal(t, page, pdfgo.Resolve(value.(*pdfgo.ArrayObject).Array[0]))
+}
@@ -0,0 +1,138 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pdfgo
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+)
+
+// WriteUpdate writes the original data the document was read from, followed by an incremental update containing the changed objects and any objects added since the document was read.
+// The original data must have an intact cross reference table; documents which needed repair must be written in full with Write.
+// Redacted documents must also be written in full, as the original data holds the content which was removed.
+func (p *PDF) WriteUpdate(out io.Writer, original []byte, changed []Object) error {
+	if p.Redacted {
+		return errors.New("Cannot update redacted document")
+	}
+	if err := p.beforeWrite(); err != nil {
+		return err
+	}
+	r := &reader{
+		data:   original,
+		report: &RepairReport{},
+	}
+	if err := r.readCrossReference(); err != nil {
+		return fmt.Errorf("Cannot update document: %s", err)
+	}
+	if r.trailer.HasEntry("Encrypt") {
+		return ErrEncrypted
+	}
+	startxref, err := r.startxref()
+	if err != nil {
+		return err
+	}
+	size := 0
+	if s, ok := Resolve(r.trailer.GetEntry("Size")).(*NumberObject); ok {
+		size = int(s.Number)
+	}
+
+	// Collect the objects to write, once each, in order of object number
+	objects := make(map[int]Object)
+	for _, o := range changed {
+		if o.GetName() < 1 || o.GetName() > len(p.Objects) || p.Objects[o.GetName()-1] != o {
+			return errors.New("Changed object is not in the document")
+		}
+		objects[o.GetName()] = o
+	}
+	for _, o := range p.Objects {
+		if o.GetName() >= size {
+			objects[o.GetName()] = o
+		}
+	}
+	var numbers []int
+	for n := range objects {
+		numbers = append(numbers, n)
+	}
+	sort.Ints(numbers)
+
+	count, err := out.Write(original)
+	if err != nil {
+		return err
+	}
+	if len(original) > 0 && original[len(original)-1] != '\n' && original[len(original)-1] != '\r' {
+		n, err := WriteS(out, "\n")
+		if err != nil {
+			return err
+		}
+		count += n
+	}
+	for _, number := range numbers {
+		n, err := writeObject(out, objects[number], count)
+		if err != nil {
+			return err
+		}
+		count += n
+	}
+	log.Println("Wrote Update Body", count)
+
+	// Write a cross reference subsection for each run of consecutive object numbers
+	xrefOffset := count
+	n, err := WriteS(out, "xref\n")
+	if err != nil {
+		return err
+	}
+	count += n
+	for i := 0; i < len(numbers); {
+		j := i + 1
+		for j < len(numbers) && numbers[j] == numbers[j-1]+1 {
+			j++
+		}
+		n, err = WriteF(out, "%d %d\n", numbers[i], j-i)
+		if err != nil {
+			return err
+		}
+		count += n
+		for _, number := range numbers[i:j] {
+			o := objects[number]
+			n, err = WriteF(out, "%010d %05d n\n", o.GetAddress(), o.GetGeneration())
+			if err != nil {
+				return err
+			}
+			count += n
+		}
+		i = j
+	}
+	trailer := p.trailer()
+	if len(p.Objects)+1 < size {
+		trailer.SetNameObjectEntry("Size", &NumberObject{
+			Number: float64(size),
+		})
+	}
+	trailer.AddNameObjectEntry("Prev", &NumberObject{
+		Number: float64(startxref),
+	})
+	n, err = writeTrailer(out, trailer, xrefOffset)
+	if err != nil {
+		return err
+	}
+	count += n
+	log.Println("Wrote Update Trailer", count)
+	return nil
+}
@@ -0,0 +1,70 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pdfgo_test
+
+import (
+	"bytes"
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestWriteUpdate(t *testing.T) {
+	source := pdfgo.NewPDF()
+	contents := source.NewStreamObject()
+	contents.Data = []byte("0 0 10 10 re f")
+	source.AddPage(200, 300, nil, pdfgo.NewObjectReference(contents))
+	var buffer bytes.Buffer
+	assert.Nil(t, source.Write(&buffer))
+	original := append([]byte{}, buffer.Bytes()...)
+
+	p, err := pdfgo.ReadPDF(original)
+	assert.Nil(t, err)
+	page := p.GetPages()[0]
+	added := p.NewStreamObject()
+	added.Data = []byte("20 20 10 10 re f")
+	page.SetNameObjectEntry("Contents", &pdfgo.ArrayObject{
+		Array: []pdfgo.Object{
+			page.GetEntry("Contents"),
+			pdfgo.NewObjectReference(added),
+		},
+	})
+
+	buffer.Reset()
+	assert.Nil(t, p.WriteUpdate(&buffer, original, []pdfgo.Object{page}))
+	updated := buffer.Bytes()
+	assert.Equal(t, original, updated[:len(original)])
+	assert.Contains(t, string(updated[len(original):]), "/Prev")
+
+	result, err := pdfgo.ReadPDF(updated)
+	assert.Nil(t, err)
+	pages := result.GetPages()
+	assert.Equal(t, 1, len(pages))
+	data, err := pdfgo.PageContents(pages[0])
+	assert.Nil(t, err)
+	assert.Equal(t, "0 0 10 10 re f\n20 20 10 10 re f", string(data))
+}
+
+func TestWriteUpdate_unknownObject(t *testing.T) {
+	source := pdfgo.NewPDF()
+	source.AddPage(200, 300, nil, nil)
+	var buffer bytes.Buffer
+	assert.Nil(t, source.Write(&buffer))
+	p, err := pdfgo.ReadPDF(buffer.Bytes())
+	assert.Nil(t, err)
+	assert.NotNil(t, p.WriteUpdate(&bytes.Buffer{}, buffer.Bytes(), []pdfgo.Object{&pdfgo.DictionaryObject{}}))
+}
@@ -0,0 +1,303 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pdfgo
+
+import (
+	"fmt"
+)
+
+// PageLayout determines how the viewer arranges pages when the document is opened.
+type PageLayout int
+
+const (
+	SinglePage PageLayout = iota
+	OneColumn
+	TwoColumnLeft
+	TwoColumnRight
+	TwoPageLeft
+	TwoPageRight
+)
+
+func (l PageLayout) String() string {
+	switch l {
+	case OneColumn:
+		return "OneColumn"
+	case TwoColumnLeft:
+		return "TwoColumnLeft"
+	case TwoColumnRight:
+		return "TwoColumnRight"
+	case TwoPageLeft:
+		return "TwoPageLeft"
+	case TwoPageRight:
+		return "TwoPageRight"
+	}
+	return "SinglePage"
+}
+
+// PageMode determines which panel, if any, the viewer shows beside the pages when the document is opened.
+type PageMode int
+
+const (
+	UseNone PageMode = iota
+	UseOutlines
+	UseThumbs
+	FullScreen
+	UseOC
+	UseAttachments
+)
+
+func (m PageMode) String() string {
+	switch m {
+	case UseOutlines:
+		return "UseOutlines"
+	case UseThumbs:
+		return "UseThumbs"
+	case FullScreen:
+		return "FullScreen"
+	case UseOC:
+		return "UseOC"
+	case UseAttachments:
+		return "UseAttachments"
+	}
+	return "UseNone"
+}
+
+// ReadingDirection is the order in which pages are read, and shown side by side.
+type ReadingDirection int
+
+const (
+	LeftToRight ReadingDirection = iota
+	RightToLeft
+)
+
+func (d ReadingDirection) String() string {
+	if d == RightToLeft {
+		return "R2L"
+	}
+	return "L2R"
+}
+
+// PrintScaling determines whether the print dialog scales pages to the paper by default.
+type PrintScaling int
+
+const (
+	AppDefaultScaling PrintScaling = iota
+	NoScaling
+)
+
+func (s PrintScaling) String() string {
+	if s == NoScaling {
+		return "None"
+	}
+	return "AppDefault"
+}
+
+// Duplex determines whether the print dialog prints on both sides of the paper by default.
+type Duplex int
+
+const (
+	DefaultDuplex Duplex = iota
+	Simplex
+	DuplexFlipShortEdge
+	DuplexFlipLongEdge
+)
+
+func (d Duplex) String() string {
+	switch d {
+	case Simplex:
+		return "Simplex"
+	case DuplexFlipShortEdge:
+		return "DuplexFlipShortEdge"
+	case DuplexFlipLongEdge:
+		return "DuplexFlipLongEdge"
+	}
+	return ""
+}
+
+// ViewerPreferences determine how the viewer presents the document, and the defaults of its print dialog.
+type ViewerPreferences struct {
+	HideToolbar  bool
+	HideMenubar  bool
+	HideWindowUI bool
+	// FitWindow resizes the window to fit the first page
+	FitWindow    bool
+	CenterWindow bool
+	// DisplayDocTitle shows the title of the document in the title bar, instead of the file name
+	DisplayDocTitle bool
+	Direction       ReadingDirection
+	PrintScaling    PrintScaling
+	Duplex          Duplex
+	// PickTrayByPDFSize selects the paper tray by the size of the pages
+	PickTrayByPDFSize bool
+	// NumCopies is the number of copies to print, if not zero
+	NumCopies int
+}
+
+// SetPageLayout sets how the viewer arranges pages when the document is opened.
+func (p *PDF) SetPageLayout(l PageLayout) {
+	p.Catalog.SetNameNameEntry("PageLayout", l.String())
+}
+
+// GetPageLayout returns how the viewer arranges pages when the document is opened.
+func (p *PDF) GetPageLayout() PageLayout {
+	switch name(p.Catalog.GetEntry("PageLayout")) {
+	case "OneColumn":
+		return OneColumn
+	case "TwoColumnLeft":
+		return TwoColumnLeft
+	case "TwoColumnRight":
+		return TwoColumnRight
+	case "TwoPageLeft":
+		return TwoPageLeft
+	case "TwoPageRight":
+		return TwoPageRight
+	}
+	return SinglePage
+}
+
+// SetPageMode sets which panel the viewer shows when the document is opened.
+func (p *PDF) SetPageMode(m PageMode) {
+	p.Catalog.SetNameNameEntry("PageMode", m.String())
+}
+
+// GetPageMode returns which panel the viewer shows when the document is opened.
+func (p *PDF) GetPageMode() PageMode {
+	switch name(p.Catalog.GetEntry("PageMode")) {
+	case "UseOutlines":
+		return UseOutlines
+	case "UseThumbs":
+		return UseThumbs
+	case "FullScreen":
+		return FullScreen
+	case "UseOC":
+		return UseOC
+	case "UseAttachments":
+		return UseAttachments
+	}
+	return UseNone
+}
+
+// SetLanguage sets the natural language of the text of the document, as a language tag such as en-GB.
+func (p *PDF) SetLanguage(lang string) {
+	if lang == "" {
+		p.Catalog.RemoveEntry("Lang")
+		return
+	}
+	p.Catalog.SetNameObjectEntry("Lang", NewTextString(lang))
+}
+
+// GetLanguage returns the natural language of the text of the document, or an empty string if it is unknown.
+func (p *PDF) GetLanguage() string {
+	if s, ok := Resolve(p.Catalog.GetEntry("Lang")).(*StringObject); ok {
+		return s.Text()
+	}
+	return ""
+}
+
+// SetViewerPreferences sets how the viewer presents the document, writing only the preferences which differ from the defaults.
+func (p *PDF) SetViewerPreferences(v *ViewerPreferences) error {
+	if v.NumCopies < 0 {
+		return fmt.Errorf("Invalid Number of Copies: %d", v.NumCopies)
+	}
+	d := &DictionaryObject{
+		Dictionary: make(map[*NameObject]Object),
+	}
+	for _, flag := range []struct {
+		key   string
+		value bool
+	}{
+		{"HideToolbar", v.HideToolbar},
+		{"HideMenubar", v.HideMenubar},
+		{"HideWindowUI", v.HideWindowUI},
+		{"FitWindow", v.FitWindow},
+		{"CenterWindow", v.CenterWindow},
+		{"DisplayDocTitle", v.DisplayDocTitle},
+	} {
+		if flag.value {
+			d.AddNameObjectEntry(flag.key, &BooleanObject{
+				Boolean: true,
+			})
+		}
+	}
+	if v.Direction != LeftToRight {
+		d.AddNameNameEntry("Direction", v.Direction.String())
+	}
+	if v.PrintScaling != AppDefaultScaling {
+		d.AddNameNameEntry("PrintScaling", v.PrintScaling.String())
+	}
+	if v.Duplex != DefaultDuplex {
+		d.AddNameNameEntry("Duplex", v.Duplex.String())
+	}
+	if v.PickTrayByPDFSize {
+		d.AddNameObjectEntry("PickTrayByPDFSize", &BooleanObject{
+			Boolean: true,
+		})
+	}
+	if v.NumCopies > 0 {
+		d.AddNameObjectEntry("NumCopies", &NumberObject{
+			Number: float64(v.NumCopies),
+		})
+	}
+	p.Catalog.SetNameObjectEntry("ViewerPreferences", d)
+	return nil
+}
+
+// GetViewerPreferences returns how the viewer presents the document.
+func (p *PDF) GetViewerPreferences() *ViewerPreferences {
+	v := &ViewerPreferences{}
+	d, ok := Resolve(p.Catalog.GetEntry("ViewerPreferences")).(*DictionaryObject)
+	if !ok {
+		return v
+	}
+	flag := func(key string) bool {
+		b, ok := Resolve(d.GetEntry(key)).(*BooleanObject)
+		return ok && b.Boolean
+	}
+	v.HideToolbar = flag("HideToolbar")
+	v.HideMenubar = flag("HideMenubar")
+	v.HideWindowUI = flag("HideWindowUI")
+	v.FitWindow = flag("FitWindow")
+	v.CenterWindow = flag("CenterWindow")
+	v.DisplayDocTitle = flag("DisplayDocTitle")
+	v.PickTrayByPDFSize = flag("PickTrayByPDFSize")
+	if name(d.GetEntry("Direction")) == "R2L" {
+		v.Direction = RightToLeft
+	}
+	if name(d.GetEntry("PrintScaling")) == "None" {
+		v.PrintScaling = NoScaling
+	}
+	switch name(d.GetEntry("Duplex")) {
+	case "Simplex":
+		v.Duplex = Simplex
+	case "DuplexFlipShortEdge":
+		v.Duplex = DuplexFlipShortEdge
+	case "DuplexFlipLongEdge":
+		v.Duplex = DuplexFlipLongEdge
+	}
+	if n, ok := Resolve(d.GetEntry("NumCopies")).(*NumberObject); ok {
+		v.NumCopies = int(n.Number)
+	}
+	return v
+}
+
+// name returns the value of the given name object, or an empty string if it isn't one.
+func name(o Object) string {
+	if n, ok := Resolve(o).(*NameObject); ok {
+		return n.Name
+	}
+	return ""
+}
@@ -0,0 +1,61 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pdfgo_test
+
+import (
+	"bytes"
+	"github.com/AletheiaWareLLC/pdfgo"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestViewerPreferences(t *testing.T) {
+	p := pdfgo.NewPDF()
+	p.AddPage(200, 200, nil, nil)
+	assert.Equal(t, pdfgo.SinglePage, p.GetPageLayout())
+	assert.Equal(t, pdfgo.UseNone, p.GetPageMode())
+	assert.Equal(t, &pdfgo.ViewerPreferences{}, p.GetViewerPreferences())
+
+	preferences := &pdfgo.ViewerPreferences{
+		HideToolbar:       true,
+		FitWindow:         true,
+		DisplayDocTitle:   true,
+		Direction:         pdfgo.RightToLeft,
+		PrintScaling:      pdfgo.NoScaling,
+		Duplex:            pdfgo.DuplexFlipLongEdge,
+		PickTrayByPDFSize: true,
+		NumCopies:         2,
+	}
+	p.SetPageLayout(pdfgo.TwoPageRight)
+	p.SetPageMode(pdfgo.UseOutlines)
+	p.SetLanguage("en-GB")
+	assert.Nil(t, p.SetViewerPreferences(preferences))
+	assert.Equal(t, "<</HideToolbar true /FitWindow true /DisplayDocTitle true /Direction /R2L /PrintScaling /None /Duplex /DuplexFlipLongEdge /PickTrayByPDFSize true /NumCopies 2>>", write(t, p.Catalog.GetEntry("ViewerPreferences")))
+	assert.NotNil(t, p.SetViewerPreferences(&pdfgo.ViewerPreferences{NumCopies: -1}))
+
+	var buffer bytes.Buffer
+	assert.Nil(t, p.Write(&buffer))
+	result, err := pdfgo.ReadPDF(buffer.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, pdfgo.TwoPageRight, result.GetPageLayout())
+	assert.Equal(t, pdfgo.UseOutlines, result.GetPageMode())
+	assert.Equal(t, "en-GB", result.GetLanguage())
+	assert.Equal(t, preferences, result.GetViewerPreferences())
+
+	result.SetLanguage("")
+	assert.False(t, result.Catalog.HasEntry("Lang"))
+}
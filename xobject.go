@@ -0,0 +1,64 @@
+/*
+ * Copyright 2021 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pdfgo
+
+// AddForm adds a form XObject whose content is drawn in the given bounding box of form space.
+// The matrix maps form space to user space, and is omitted if nil.
+// The resources are those named by the content, and may be nil if there are none.
+func (p *PDF) AddForm(left, bottom, right, top float64, matrix []float64, resources Object, content []byte) *ObjectReference {
+	s := p.NewStreamObject()
+	s.Data = content
+	s.AddNameNameEntry("Type", "XObject")
+	s.AddNameNameEntry("Subtype", "Form")
+	s.AddNameObjectEntry("BBox", NewRectangleArray(left, bottom, right, top))
+	if matrix != nil {
+		m := &ArrayObject{}
+		for _, v := range matrix {
+			m.Array = append(m.Array, &NumberObject{
+				Number: v,
+			})
+		}
+		s.AddNameObjectEntry("Matrix", m)
+	}
+	if resources != nil {
+		s.AddNameObjectEntry("Resources", resources)
+	}
+	return NewObjectReference(s)
+}
+
+// NewNumberArray returns a direct array of the given numbers.
+func NewNumberArray(numbers []float64) *ArrayObject {
+	a := &ArrayObject{}
+	for _, n := range numbers {
+		a.Array = append(a.Array, &NumberObject{
+			Number: n,
+		})
+	}
+	return a
+}
+
+// NewRectangleArray returns a direct array of the given coordinates, as used by MediaBox, BBox and Rect entries.
+func NewRectangleArray(left, bottom, right, top float64) *ArrayObject {
+	return &ArrayObject{
+		Array: []Object{
+			&NumberObject{Number: left},
+			&NumberObject{Number: bottom},
+			&NumberObject{Number: right},
+			&NumberObject{Number: top},
+		},
+	}
+}